@@ -0,0 +1,56 @@
+// Command grpcserver serves the user permissions API over gRPC, see package grpcserver.
+//
+// It listens on the port in the GRPC_PORT env variable, 9090 by default.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/grpcserver"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/config"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/application"
+)
+
+func main() {
+	application.InitLogger()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load(ctx)
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	repo := postgres.NewPermissionsRepo(cfg)
+	srv := grpcserver.New(permissions.NewService(repo))
+
+	port := os.Getenv("GRPC_PORT")
+	if port == "" {
+		port = "9090"
+	}
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		slog.Error("failed to listen", "error", err)
+		os.Exit(1)
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	slog.Info("listening", "addr", lis.Addr().String())
+	if err := srv.Serve(lis); err != nil {
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
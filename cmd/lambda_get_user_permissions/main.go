@@ -5,89 +5,23 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/lambdaevent"
 	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
 	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/tokens"
 	"github.com/Equineregister/user-permissions-service/internal/config"
 	"github.com/Equineregister/user-permissions-service/internal/pkg/application"
-	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
-	"github.com/Equineregister/user-permissions-service/pkg/rego"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-// Request represents the input event structure
-type Request struct {
-	TenantID  string   `json:"tenantId"`
-	UserID    string   `json:"userId"`
-	Resources []string `json:"resources"`
-}
-
-// Response represents the output structure
-type Response struct {
-	TenantID           string         `json:"tenantId"`
-	UserID             string         `json:"userId"`
-	Roles              []string       `json:"roles"`
-	RevokedPermissions []string       `json:"revokedPermissions"`
-	ExtraPermissions   []string       `json:"extraPermissions"`
-	UserResources      []Resource     `json:"userResources"`
-	RoleGraph          rego.RoleGraph `json:"roleGraph"`
-}
-
-type Resource struct {
-	ResourceID   string `json:"resourceId"`
-	ResourceType string `json:"resourceType"`
-}
-
-type handler struct {
-	repo    permissions.Reader
-	service *permissions.Service
-}
-
-// Handler is the Lambda function handler
-func (h *handler) handle(ctx context.Context, request Request) (Response, error) {
-	slog.Debug("received request", "tenantId", request.TenantID, "userId", request.UserID)
-
-	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, request.TenantID)
-	ctx = context.WithValue(ctx, contextkey.CtxKeyUserID, request.UserID)
-
-	forUser, err := h.service.GetForUser(ctx, request.Resources)
-	if err != nil {
-		slog.Error("error getting permissions for user", "error", err.Error())
-		return Response{}, err
-	}
-
-	return response(request.TenantID, request.UserID, forUser), nil
-}
-
-func response(tenantID, userID string, forUser *permissions.ForUser) Response {
-	resp := Response{
-		TenantID: tenantID,
-		UserID:   userID,
-	}
-	if forUser == nil {
-		resp.Roles = []string{}
-		resp.ExtraPermissions = []string{}
-		resp.RevokedPermissions = []string{}
-		resp.UserResources = []Resource{}
-		resp.RoleGraph = rego.RoleGraph{}
-		return resp
-	}
-
-	resp.UserResources = make([]Resource, len(forUser.Resources))
-	for i, r := range forUser.Resources {
-		resp.UserResources[i] = Resource{
-			ResourceID:   r.ID,
-			ResourceType: r.Type,
-		}
-	}
-
-	resp.Roles = forUser.Roles.StringSlice()
-	resp.RevokedPermissions = forUser.RevokedPermissions.StringSlice()
-	resp.ExtraPermissions = forUser.ExtraPermissions.StringSlice()
-	resp.RoleGraph = rego.NewRoleGraph(forUser.RoleMap)
-
-	return resp
-}
-
+// A direct invoke's event is an api.Request and the result an api.Response. When
+// the event's ifNoneMatch is the ETag of the result, a small not modified result
+// is returned. When PERMTOKEN_KEYS is set, an event with issueToken gets a signed
+// permission token.
+//
+// API Gateway and Function URL events are served as HTTP requests, with the
+// Tenant and user from the authorizer's claims, see package lambdaevent.
 func main() {
 	application.InitLogger()
 
@@ -102,10 +36,22 @@ func main() {
 	repo := postgres.NewPermissionsRepo(cfg)
 	service := permissions.NewService(repo)
 
-	h := &handler{
-		repo:    repo,
-		service: service,
+	tokenCfg, err := config.LoadTokenConfig()
+	if err != nil {
+		slog.Error("failed to load token config", "error", err)
+		os.Exit(1)
+	}
+	var tokenService *tokens.Service
+	if tokenCfg != nil {
+		tokenService = tokens.NewService(repo, tokenCfg.Keys, tokenCfg.Issuer, tokenCfg.TTL)
 	}
 
-	lambda.Start(h.handle)
+	eventCfg := config.LoadEventConfig()
+	handler := lambdaevent.NewHandler(api.NewHandler(service, tokenService), lambdaevent.Config{
+		TenantClaim:    eventCfg.TenantClaim,
+		UserClaim:      eventCfg.UserClaim,
+		AllowedOrigins: eventCfg.AllowedOrigins,
+	})
+
+	lambda.Start(handler.Handle)
 }
@@ -0,0 +1,86 @@
+// Command server serves the user permissions API over HTTP, see package httpserver,
+// and SCIM provisioning, see package scimserver.
+//
+// It listens on the port in the PORT env variable, 8080 by default. Permission
+// tokens are issued when PERMTOKEN_KEYS is set, see config.LoadTokenConfig.
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/httpserver"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/scimserver"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/Equineregister/user-permissions-service/internal/app/tokens"
+	"github.com/Equineregister/user-permissions-service/internal/config"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/application"
+)
+
+func main() {
+	application.InitLogger()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load(ctx)
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	repo := postgres.NewPermissionsRepo(cfg)
+	service := permissions.NewService(repo)
+
+	tokenCfg, err := config.LoadTokenConfig()
+	if err != nil {
+		slog.Error("failed to load token config", "error", err)
+		os.Exit(1)
+	}
+	var tokenService *tokens.Service
+	if tokenCfg != nil {
+		tokenService = tokens.NewService(repo, tokenCfg.Keys, tokenCfg.Issuer, tokenCfg.TTL)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/tenants/{tenantId}/scim/v2/", scimserver.New(scim.NewService(repo)))
+	mux.Handle("/", httpserver.New(api.NewHandler(service, tokenService)))
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// ListenAndServe returns as soon as Shutdown is called, so wait for in flight requests to finish.
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to shut down server", "error", err)
+		}
+	}()
+
+	slog.Info("listening", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+	<-shutdown
+}
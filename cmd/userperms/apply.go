@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+)
+
+func runApply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms apply [flags] <plan file>\n\nApplies a plan saved by plan -out, in one transaction.\nNothing is changed if the Tenant has changed since the plan was made.\n\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a single plan file is required")
+	}
+	path := fs.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	plan, err := rbacdoc.DecodePlan(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := rbac.NewService(repo).Apply(ctx, plan); err != nil {
+		return err
+	}
+
+	slog.Info("applied", "plan", path, "tenant", db.tenantID, "changes", len(plan.Changes))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/breakglass"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+func runBreakGlass(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("break-glass", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms break-glass [flags] <action>
+
+actions:
+  settings  print the Tenant's emergency role and the longest a session may
+            last, or set them to -role and -max when -role is set
+  grant     grant the emergency role to the -user for -for, the most allowed
+            when zero, with a -justification, printing the session's ID
+  end       end the -session now, revoking the emergency role
+  list      list the Tenant's sessions, most recent first
+  report    write the report of every session as CSV to stdout
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	roleID := fs.String("role", "", "ID of the emergency role to set, for settings")
+	maxDuration := fs.Duration("max", breakglass.DefaultMaxDuration, "the longest a session may last, for settings")
+	user := fs.String("user", "", "ID of the user granted the emergency role, for grant")
+	duration := fs.Duration("for", 0, "how long the session lasts, for grant")
+	justification := fs.String("justification", "", "why the emergency role is needed, such as the incident, for grant")
+	sessionID := fs.String("session", "", "ID of the session, for end")
+	userID := fs.String("user-id", "", "ID of the user making the change, recorded as granting or ending the session")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if *userID != "" {
+		ctx = contextkey.WithUserID(ctx, *userID)
+	}
+	svc := breakglass.NewService(repo)
+
+	switch action := fs.Arg(0); action {
+	case "settings":
+		if *roleID != "" {
+			if err := svc.SetSettings(ctx, *roleID, *maxDuration); err != nil {
+				return err
+			}
+		}
+		settings, err := svc.GetSettings(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "%s\t%s\t%s\n", settings.Role.ID, settings.Role.Name, settings.MaxDuration)
+		return nil
+	case "grant":
+		session, err := svc.Grant(ctx, *user, *justification, *duration)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, session.ID)
+		fmt.Fprintf(os.Stderr, "%s holds %s until %s\n", session.UserID, session.Role.Name, session.ExpiresAt.Format(time.RFC3339))
+		return nil
+	case "end":
+		return svc.End(ctx, *sessionID)
+	case "list":
+		sessions, err := svc.GetSessions(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, s := range sessions {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.Status(now), s.UserID, s.Role.Name,
+				s.StartedAt.Format(time.RFC3339), s.ExpiresAt.Format(time.RFC3339), s.Justification)
+		}
+		return nil
+	case "report":
+		report, err := svc.Report(ctx)
+		if err != nil {
+			return err
+		}
+		return report.WriteCSV(os.Stdout)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/postgresutil"
+)
+
+// dbFlags are the flags needed to connect to a Tenant's database.
+type dbFlags struct {
+	host     string
+	port     int
+	user     string
+	tenantID string
+}
+
+func (f *dbFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.host, "db-host", "localhost", "database hostname")
+	fs.IntVar(&f.port, "db-port", 5432, "database port")
+	fs.StringVar(&f.user, "db-user", "postgres", "database username")
+	fs.StringVar(&f.tenantID, "tenant", "", "Tenant ID, this is also the database name")
+}
+
+// connect returns a repo for the Tenant's database, and a context holding the Tenant ID.
+func (f *dbFlags) connect(ctx context.Context) (*postgres.PermissionsRepo, context.Context, error) {
+	if f.tenantID == "" {
+		return nil, nil, fmt.Errorf("-tenant is required")
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%d user=%s dbname=%s", f.host, f.port, f.user, f.tenantID)
+	pool, err := postgresutil.ConnectDSN(ctx, dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect: %w", err)
+	}
+
+	tp := postgres.NewTenantPoolFromSuppliedPool(ctx, f.tenantID, pool)
+	ctx = contextkey.WithTenantID(ctx, f.tenantID)
+
+	return postgres.NewPermissionsRepoWithTenantPool(tp), ctx, nil
+}
+
+// service returns a permissions service for the Tenant's database, and a context holding the Tenant ID.
+func (f *dbFlags) service(ctx context.Context) (*permissions.Service, context.Context, error) {
+	repo, ctx, err := f.connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return permissions.NewService(repo), ctx, nil
+}
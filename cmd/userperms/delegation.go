@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+func runDelegation(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("delegation", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms delegation [flags] <action>
+
+actions:
+  create  delegate the -delegator's -roles and -permissions to the -delegate
+          from -starts for -for, printing the delegation's ID
+  list    list the delegations by or to the -user which have not ended, every
+          user's when it is empty
+  revoke  end the -delegation now
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	delegatorID := fs.String("delegator", "", "ID of the user delegating, for create")
+	delegateID := fs.String("delegate", "", "ID of the user delegated to, for create")
+	roleIDs := fs.String("roles", "", "comma separated IDs of the roles to delegate, for create")
+	permissionNames := fs.String("permissions", "", "comma separated names of the permissions to delegate, for create")
+	starts := fs.String("starts", "", "RFC 3339 time the delegation starts, for create, now when empty")
+	duration := fs.Duration("for", 14*24*time.Hour, "how long the delegation lasts, for create")
+	reason := fs.String("reason", "", "why the delegation is made, for create")
+	userID := fs.String("user", "", "ID of the user, for list")
+	delegationID := fs.String("delegation", "", "ID of the delegation, for revoke")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+
+	svc, ctx, err := db.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch action := fs.Arg(0); action {
+	case "create":
+		startsAt := time.Now()
+		if *starts != "" {
+			if startsAt, err = time.Parse(time.RFC3339, *starts); err != nil {
+				return fmt.Errorf("-starts: %w", err)
+			}
+		}
+		d := permissions.Delegation{
+			DelegatorID: *delegatorID,
+			DelegateID:  *delegateID,
+			Reason:      *reason,
+			StartsAt:    startsAt,
+			EndsAt:      startsAt.Add(*duration),
+		}
+		for _, id := range splitList(*roleIDs) {
+			d.Roles = append(d.Roles, permissions.Role{ID: id})
+		}
+		for _, name := range splitList(*permissionNames) {
+			d.Permissions = append(d.Permissions, permissions.Permission{Name: name})
+		}
+		delegation, err := svc.CreateDelegation(ctx, d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, delegation.ID)
+		return nil
+	case "list":
+		delegations, err := svc.GetDelegations(ctx, *userID)
+		if err != nil {
+			return err
+		}
+		for _, d := range delegations {
+			delegated := append(d.Roles.StringSlice(), d.Permissions.StringSlice()...)
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", d.ID, d.DelegatorID, d.DelegateID,
+				d.StartsAt.Format(time.RFC3339), d.EndsAt.Format(time.RFC3339), strings.Join(delegated, ", "), d.Reason)
+		}
+		return nil
+	case "revoke":
+		return svc.RevokeDelegation(ctx, *delegationID)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+func runExplain(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms explain [flags] <permission>\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	userID := fs.String("user-id", "", "ID of the user to explain the permission for")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 || *userID == "" {
+		fs.Usage()
+		return fmt.Errorf("a permission and -user-id are required")
+	}
+
+	svc, ctx, err := db.service(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = contextkey.WithUserID(ctx, *userID)
+
+	e, err := svc.Explain(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	printExplanation(os.Stdout, *userID, e)
+	return nil
+}
+
+func printExplanation(w io.Writer, userID string, e *permissions.Explanation) {
+	fmt.Fprintf(w, "%s for user %s: %s\n", e.Permission, userID, e.Verdict)
+	printDerivations(w, e.Derivations)
+}
+
+func printDerivations(w io.Writer, derivations permissions.Derivations) {
+	for _, d := range derivations {
+		switch d.Kind {
+		case permissions.DerivationRole:
+			path := strings.Join(d.RolePath.StringSlice(), " -> ")
+			if d.Group != "" {
+				path = "group " + d.Group + " -> " + path
+			}
+			fmt.Fprintf(w, "  role      %s grants %s\n", path, d.Grant)
+		case permissions.DerivationResource:
+			fmt.Fprintf(w, "  resource  %s %s grants %s\n", d.Resource.Type, d.Resource.ID, d.Grant)
+		default:
+			fmt.Fprintf(w, "  %-9s %s\n", d.Kind, d.Grant)
+		}
+	}
+}
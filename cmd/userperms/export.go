@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+)
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms export [flags]\n\nExports the Tenant's resource types, permissions and roles.\n\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	format := fs.String("format", string(rbacdoc.FormatYAML), "output format, yaml or json")
+	output := fs.String("o", "", "file to write to, the format is taken from its extension (default stdout)")
+	assignments := fs.Bool("assignments", false, "include the roles assigned directly to users, for plan and apply")
+	_ = fs.Parse(args)
+
+	f := rbacdoc.Format(*format)
+	if *output != "" {
+		var err error
+		if f, err = rbacdoc.FormatFromPath(*output); err != nil {
+			return err
+		}
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	model, err := rbac.NewService(repo).Export(ctx, *assignments)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		if w, err = os.Create(*output); err != nil {
+			return fmt.Errorf("create %s: %w", *output, err)
+		}
+		defer w.Close()
+	}
+	return rbacdoc.Encode(w, f, rbacdoc.FromModel(model))
+}
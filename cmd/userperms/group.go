@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runGroup(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("group", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms group [flags] <action> [ids...]
+
+actions:
+  create          create a group named -name, printing its ID
+  delete          delete the group
+  add-members     add the user IDs to the group
+  remove-members  remove the user IDs from the group
+  assign-roles    assign the role IDs to the group
+  unassign-roles  remove the role IDs from the group
+  nest            nest the group within the -parent group
+  unnest          remove the group from the -parent group
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	groupID := fs.String("group", "", "ID of the group")
+	parentID := fs.String("parent", "", "ID of the parent group, for nest and unnest")
+	name := fs.String("name", "", "name of the group, for create")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	action, ids := fs.Arg(0), fs.Args()[1:]
+	if action != "create" && *groupID == "" {
+		fs.Usage()
+		return fmt.Errorf("-group is required")
+	}
+
+	svc, ctx, err := db.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "create":
+		group, err := svc.CreateGroup(ctx, *name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, group.ID)
+		return nil
+	case "delete":
+		return svc.DeleteGroup(ctx, *groupID)
+	case "add-members":
+		return svc.AddGroupMembers(ctx, *groupID, ids)
+	case "remove-members":
+		return svc.RemoveGroupMembers(ctx, *groupID, ids)
+	case "assign-roles":
+		return svc.AssignGroupRoles(ctx, *groupID, ids)
+	case "unassign-roles":
+		return svc.UnassignGroupRoles(ctx, *groupID, ids)
+	case "nest":
+		return svc.NestGroup(ctx, *parentID, *groupID)
+	case "unnest":
+		return svc.UnnestGroup(ctx, *parentID, *groupID)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+)
+
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms import [flags] <file>\n\nImports resource types, permissions and roles into the Tenant, matching on name.\nThe format is taken from the file's extension, .yaml, .yml or .json.\n\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a single file is required")
+	}
+	path := fs.Arg(0)
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := rbac.NewService(repo).Import(ctx, doc.Model()); err != nil {
+		return err
+	}
+
+	slog.Info("imported", "file", path, "tenant", db.tenantID)
+	return nil
+}
+
+// readDocument reads an RBAC document, the format is taken from the file's extension.
+func readDocument(path string) (*rbacdoc.Document, error) {
+	format, err := rbacdoc.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	doc, err := rbacdoc.Decode(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/imports"
+)
+
+func runImportAssignments(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import-assignments", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms import-assignments [flags] <file>
+
+Imports role assignments from a CSV file whose header names its columns:
+
+  user_id        required, the user's UUID
+  role           required, the name of the role
+  resource_type  with resource_id, grants the role's permissions on the resource alone
+  resource_id
+  expires_at     when the assignment lapses, RFC 3339 or a date
+
+Every row is checked first, and the errors reported with their lines. The valid
+rows are then imported in one transaction, or in chunks of -chunk-size.
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	dryRun := fs.Bool("dry-run", false, "check every row without importing any")
+	chunkSize := fs.Int("chunk-size", 0, "rows imported in each transaction, all of them in one when 0")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a single file is required")
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("open %s: %w", fs.Arg(0), err)
+	}
+	defer f.Close()
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	report, err := imports.NewService(repo).Import(ctx, f, imports.Options{
+		DryRun:    *dryRun,
+		ChunkSize: *chunkSize,
+		Progress: func(p imports.Progress) {
+			fmt.Fprintf(os.Stderr, "imported %d of %d rows, %d failed\n", p.Imported, p.Total, p.Failed)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printImportReport(os.Stdout, db.tenantID, report)
+	if len(report.Errors) > 0 || len(report.Failed) > 0 {
+		return fmt.Errorf("%d rows have errors and %d chunks failed", len(report.Errors), len(report.Failed))
+	}
+	return nil
+}
+
+func printImportReport(w io.Writer, tenantID string, report *imports.Report) {
+	fmt.Fprintf(w, "tenant %s: %d rows, %d valid, %d imported, %d already held\n", tenantID, report.Rows, report.Valid, report.Imported, len(report.Held))
+	for _, e := range report.Errors {
+		fmt.Fprintf(w, "  %s\n", e)
+	}
+	for _, line := range report.Held {
+		fmt.Fprintf(w, "  line %d: already held, left unchanged\n", line)
+	}
+	for _, e := range report.Failed {
+		fmt.Fprintf(w, "  %s\n", e)
+	}
+	if report.DryRun {
+		fmt.Fprintf(w, "dry run, nothing imported\n")
+	}
+}
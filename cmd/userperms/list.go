@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/listing"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+func runList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms list [flags] <action> <arg>
+
+actions:
+  role-holders <role-id>            users holding the role, with how they hold it
+  role-grants <permission>          roles granting the permission, with the grant
+  permissions <resource-type>       the Tenant's permissions on the resource type
+  resource-grantees <resource-id>   users with grants on the resource, with the grant
+
+Items are printed a line each, tab separated. When there are more, the
+-cursor for the next page is printed to stderr.
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	source := fs.String("source", "", "only role holders holding the role from the source: direct, group or inherited")
+	directOnly := fs.Bool("direct", false, "leave out inherited role grants and resource grants")
+	resourceType := fs.String("resource-type", "", "resource type of the resource, for resource-grantees")
+	cursor := fs.String("cursor", "", "cursor of the page to list, printed with the previous page")
+	limit := fs.Int("limit", listing.DefaultLimit, fmt.Sprintf("most items to list, at most %d", listing.MaxLimit))
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("an action and its argument are required")
+	}
+	action, arg := fs.Arg(0), fs.Arg(1)
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	svc := listing.NewService(repo)
+	page := listing.PageRequest{Cursor: *cursor, Limit: *limit}
+
+	var next string
+	switch action {
+	case "role-holders":
+		holders, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: arg, Source: permissions.RoleSource(*source)}, page)
+		if err != nil {
+			return err
+		}
+		for _, h := range holders.Items {
+			fmt.Printf("%s\t%s\t%s\n", h.UserID, h.Source, h.Via)
+		}
+		next = holders.NextCursor
+	case "role-grants":
+		grants, err := svc.ListRoleGrants(ctx, listing.RoleGrantsFilter{Permission: arg, DirectOnly: *directOnly}, page)
+		if err != nil {
+			return err
+		}
+		for _, g := range grants.Items {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", g.Role.ID, g.Role.Name, g.Permission, g.Via, g.Condition)
+		}
+		next = grants.NextCursor
+	case "permissions":
+		perms, err := svc.ListResourceTypePermissions(ctx, arg, page)
+		if err != nil {
+			return err
+		}
+		for _, p := range perms.Items {
+			fmt.Printf("%s\t%s\n", p.ID, p.Name)
+		}
+		next = perms.NextCursor
+	case "resource-grantees":
+		grantees, err := svc.ListResourceGrantees(ctx, listing.ResourceGranteesFilter{ResourceID: arg, ResourceType: *resourceType, DirectOnly: *directOnly}, page)
+		if err != nil {
+			return err
+		}
+		for _, g := range grantees.Items {
+			inheritedFrom := ""
+			if g.InheritedFrom != nil {
+				inheritedFrom = g.InheritedFrom.Type + "/" + g.InheritedFrom.ID
+			}
+			fmt.Printf("%s\t%s\t%s/%s\t%s\t%s\n", g.UserID, g.Permission, g.Resource.Type, g.Resource.ID, inheritedFrom, g.Condition)
+		}
+		next = grantees.NextCursor
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	if next != "" {
+		fmt.Fprintf(os.Stderr, "more: -cursor %s\n", next)
+	}
+	return nil
+}
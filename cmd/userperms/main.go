@@ -0,0 +1,74 @@
+// Command userperms is an operator tool for working with a Tenant's user permissions database.
+//
+// The Tenant's database is connected to with the -db-host, -db-port and -db-user flags,
+// the database name is the Tenant ID. PGPASSWORD is expected to be set as an env variable.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/pkg/application"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "explain", usage: "explain why a user has, or lacks, a permission", run: runExplain},
+	{name: "apply", usage: "apply a plan made by the plan command", run: runApply},
+	{name: "break-glass", usage: "grant the emergency role for a short time, with a justification, and report on it", run: runBreakGlass},
+	{name: "delegation", usage: "delegate roles and permissions between users for a time window", run: runDelegation},
+	{name: "export", usage: "export a Tenant's RBAC model as YAML or JSON", run: runExport},
+	{name: "group", usage: "manage groups, their members and roles", run: runGroup},
+	{name: "import", usage: "import an RBAC model into a Tenant from YAML or JSON", run: runImport},
+	{name: "import-assignments", usage: "import role assignments from CSV, checking every row first", run: runImportAssignments},
+	{name: "list", usage: "list role holders, role grants, permissions and resource grantees a page at a time", run: runList},
+	{name: "plan", usage: "plan the changes to make a Tenant match an RBAC model", run: runPlan},
+	{name: "register", usage: "register a service's manifest of resource types and actions with Tenants", run: runRegister},
+	{name: "relation", usage: "write, read, check and expand relationship tuples", run: runRelation},
+	{name: "relay", usage: "publish permission change events from a Tenant's outbox", run: runRelay},
+	{name: "resource", usage: "manage the hierarchy of resource types and resources", run: runResource},
+	{name: "review", usage: "run access review campaigns of who holds which role", run: runReview},
+	{name: "scim", usage: "set what SCIM groups are mapped to, and list the users and groups provisioned", run: runSCIM},
+	{name: "sod", usage: "manage separation of duties rules and scan for their violations", run: runSoD},
+	{name: "template", usage: "adopt, extend, detach and sync roles from the role template catalogue", run: runTemplate},
+	{name: "token", usage: "manage token signing keys, and issue and verify permission tokens", run: runToken},
+	{name: "validate", usage: "validate the integrity of Tenants' role graphs", run: runValidate},
+	{name: "who-can", usage: "list the users who can perform an action on a resource, and why", run: runWhoCan},
+}
+
+func main() {
+	application.InitLogger()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+		if err := cmd.run(context.Background(), os.Args[2:]); err != nil {
+			slog.Error(cmd.name+" failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: userperms <command> [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", cmd.name, cmd.usage)
+	}
+}
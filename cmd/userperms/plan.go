@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+)
+
+func runPlan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms plan [flags] <file>\n\nPlans the changes that make the Tenant match the model in the file, removing anything not in it.\nAssignments are only planned if the file has them.\n\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	out := fs.String("out", "", "file to save the plan to, for the apply command")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a single file is required")
+	}
+	doc, err := readDocument(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	plan, err := rbac.NewService(repo).Plan(ctx, doc.Model())
+	if err != nil {
+		return err
+	}
+
+	printPlan(os.Stdout, db.tenantID, plan)
+
+	if *out == "" {
+		return nil
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", *out, err)
+	}
+	defer f.Close()
+	return rbacdoc.EncodePlan(f, plan)
+}
+
+func printPlan(w io.Writer, tenantID string, plan *rbac.Plan) {
+	fmt.Fprintf(w, "tenant %s: %d changes\n", tenantID, len(plan.Changes))
+	symbols := map[rbac.Action]string{rbac.ActionAdd: "+", rbac.ActionChange: "~", rbac.ActionRemove: "-"}
+	for _, c := range plan.Changes {
+		switch {
+		case c.Kind == rbac.ChangeKindRolePermission && c.Condition != "":
+			fmt.Fprintf(w, "  %s %-15s %s -> %s (condition: %s)\n", symbols[c.Action], c.Kind, c.Subject, c.Object, c.Condition)
+		case c.Kind == rbac.ChangeKindRolePermission && c.Action == rbac.ActionChange:
+			fmt.Fprintf(w, "  %s %-15s %s -> %s (unconditional)\n", symbols[c.Action], c.Kind, c.Subject, c.Object)
+		case c.Object != "":
+			fmt.Fprintf(w, "  %s %-15s %s -> %s\n", symbols[c.Action], c.Kind, c.Subject, c.Object)
+		case c.Kind == rbac.ChangeKindPermission && c.Action != rbac.ActionRemove:
+			fmt.Fprintf(w, "  %s %-15s %s (enabled: %t)\n", symbols[c.Action], c.Kind, c.Subject, c.Enabled)
+		default:
+			fmt.Fprintf(w, "  %s %-15s %s\n", symbols[c.Action], c.Kind, c.Subject)
+		}
+	}
+
+	if len(plan.Impacts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "effective permissions:\n")
+	for _, i := range plan.Impacts {
+		fmt.Fprintf(w, "  %-40s +%d users -%d users\n", i.Permission, i.Gained, i.Lost)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/manifests"
+	"github.com/Equineregister/user-permissions-service/pkg/manifest"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+)
+
+func runRegister(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("register", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms register [flags] <file>\n\nRegisters the service's manifest of resource types and actions with each Tenant, -tenant may be\na comma separated list. New permissions are granted to their default roles, and the service's\npermissions missing from the manifest are deprecated.\n\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	enableNew := fs.Bool("enable-new", false, "make new permissions active for the Tenant")
+	dryRun := fs.Bool("dry-run", false, "print the changes without making them")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("a single file is required")
+	}
+	if db.tenantID == "" {
+		fs.Usage()
+		return fmt.Errorf("-tenant is required")
+	}
+	doc, err := readManifest(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range strings.Split(db.tenantID, ",") {
+		tenantDB := db
+		tenantDB.tenantID = strings.TrimSpace(tenantID)
+
+		repo, ctx, err := tenantDB.connect(ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantDB.tenantID, err)
+		}
+		registration, err := manifests.NewService(repo).Register(ctx, doc.Manifest(), *enableNew, *dryRun)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantDB.tenantID, err)
+		}
+		printRegistration(os.Stdout, tenantDB.tenantID, registration, *dryRun)
+	}
+	return nil
+}
+
+func readManifest(path string) (*manifest.Document, error) {
+	format, err := rbacdoc.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	doc, err := manifest.Decode(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+func printRegistration(w io.Writer, tenantID string, r *manifests.Registration, dryRun bool) {
+	fmt.Fprintf(w, "tenant %s: service %s, %d resource types and %d permissions changed\n", tenantID, r.Service, len(r.ResourceTypes), len(r.Permissions))
+	for _, e := range r.ResourceTypes {
+		fmt.Fprintf(w, "  %-10s resource_type %s\n", e.Change, e.Name)
+	}
+	for _, e := range r.Permissions {
+		fmt.Fprintf(w, "  %-10s permission    %s\n", e.Change, e.Name)
+	}
+	for _, name := range r.Enabled {
+		fmt.Fprintf(w, "  %-10s permission    %s\n", "enabled", name)
+	}
+	for _, g := range r.Grants {
+		fmt.Fprintf(w, "  %-10s role          %s -> %s\n", "granted", g.Role, g.Permission)
+	}
+	for _, g := range r.MissingRoles {
+		fmt.Fprintf(w, "  %-10s role          %s -> %s, the Tenant has no such role\n", "skipped", g.Role, g.Permission)
+	}
+	if dryRun {
+		fmt.Fprintf(w, "dry run, nothing changed\n")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/relations"
+)
+
+func runRelation(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("relation", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms relation [flags] <action> [args...]
+
+actions:
+  write <tuple>...            store the tuples, e.g. document:readme#viewer@user:<id>
+  delete <tuple>...           delete the stored tuples
+  read                        list the tuples matching -namespace, -object, -relation and -subject
+  check <userset> <subject>   report whether the subject is in the userset, e.g. document:readme#viewer user:<id>
+  expand <userset>            print the tree of subjects in the userset
+
+Role and group membership, and grants on single resources, are projected as
+tuples in the role, group and resource type namespaces, which cannot be
+written. A grant of every action on a resource projects the relation "*".
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	namespace := fs.String("namespace", "", "namespace of the objects, for read")
+	object := fs.String("object", "", "ID of the object, for read")
+	relation := fs.String("relation", "", "relation, for read")
+	subject := fs.String("subject", "", "subject, e.g. user:<id> or folder:reports#viewer, for read")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	action, rest := fs.Arg(0), fs.Args()[1:]
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	svc := relations.NewService(repo)
+
+	switch action {
+	case "write", "delete":
+		tuples := make(relations.Tuples, len(rest))
+		for i, s := range rest {
+			if tuples[i], err = relations.ParseTuple(s); err != nil {
+				return err
+			}
+		}
+		if action == "write" {
+			return svc.Write(ctx, tuples)
+		}
+		return svc.Delete(ctx, tuples)
+
+	case "read":
+		filter := relations.Filter{Namespace: *namespace, ObjectID: *object, Relation: *relation}
+		if *subject != "" {
+			s, err := relations.ParseSubject(*subject)
+			if err != nil {
+				return err
+			}
+			filter.Subject = &s
+		}
+		tuples, err := svc.Read(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, t := range tuples {
+			fmt.Println(t)
+		}
+		return nil
+
+	case "check":
+		if len(rest) != 2 {
+			fs.Usage()
+			return fmt.Errorf("check needs a userset and a subject")
+		}
+		u, err := relations.ParseUserset(rest[0])
+		if err != nil {
+			return err
+		}
+		s, err := relations.ParseSubject(rest[1])
+		if err != nil {
+			return err
+		}
+		ok, err := svc.Check(ctx, u, s)
+		if err != nil {
+			return err
+		}
+		fmt.Println(ok)
+		return nil
+
+	case "expand":
+		if len(rest) != 1 {
+			fs.Usage()
+			return fmt.Errorf("expand needs a userset")
+		}
+		u, err := relations.ParseUserset(rest[0])
+		if err != nil {
+			return err
+		}
+		tree, err := svc.Expand(ctx, u)
+		if err != nil {
+			return err
+		}
+		printTree(os.Stdout, tree, 0)
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func printTree(w io.Writer, tree *relations.Tree, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if tree.Cycle {
+		fmt.Fprintf(w, "%s%s (cycle)\n", indent, tree.Userset)
+		return
+	}
+	if tree.Repeat {
+		fmt.Fprintf(w, "%s%s (see above)\n", indent, tree.Userset)
+		return
+	}
+	fmt.Fprintf(w, "%s%s\n", indent, tree.Userset)
+	for _, s := range tree.Subjects {
+		fmt.Fprintf(w, "%s  %s\n", indent, s)
+	}
+	for _, child := range tree.Children {
+		printTree(w, child, depth+1)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/eventsink"
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+func runRelay(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("relay", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms relay [flags] -sink <sink>
+
+Publishes the permission change events in a Tenant's outbox, until interrupted,
+or once with -once. Events which fail to publish are retried with backoff.
+
+sinks:
+  sns:<topic ARN>             an SNS topic
+  eventbridge:<bus name>      an EventBridge bus
+  file:<path>                 a file, one JSON event per line
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	sinkFlag := fs.String("sink", "", "where to publish events, see above")
+	once := fs.Bool("once", false, "publish one batch of due events and exit")
+	batchSize := fs.Int("batch-size", 0, "most events to publish at once, 0 for the default")
+	_ = fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sink, err := newSink(ctx, *sinkFlag)
+	if err != nil {
+		return err
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	relay := outbox.NewRelay(repo, sink, outbox.RelayConfig{BatchSize: *batchSize})
+
+	if *once {
+		published, failed, err := relay.RelayOnce(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Info("relayed events", "published", published, "failed", failed)
+		return nil
+	}
+	return relay.Run(ctx)
+}
+
+func newSink(ctx context.Context, s string) (outbox.Sink, error) {
+	kind, target, ok := strings.Cut(s, ":")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("-sink %q must be sns:<topic ARN>, eventbridge:<bus name> or file:<path>", s)
+	}
+
+	switch kind {
+	case "file":
+		return eventsink.NewFileSink(target), nil
+	case "sns", "eventbridge":
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load aws config: %w", err)
+		}
+		if kind == "sns" {
+			return eventsink.NewSNSSink(sns.NewFromConfig(cfg), target), nil
+		}
+		return eventsink.NewEventBridgeSink(eventbridge.NewFromConfig(cfg), target), nil
+	default:
+		return nil, fmt.Errorf("unknown sink %q", kind)
+	}
+}
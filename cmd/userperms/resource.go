@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+func runResource(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("resource", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms resource [flags] <action> [ids...]
+
+actions:
+  add-type-child     make the -child-type belong to the -parent-type
+  remove-type-child  remove the -child-type from the -parent-type
+  add-children       make the resource IDs, of -child-type, belong to -parent-id
+  remove-children    remove the resource IDs, of -child-type, from -parent-id
+
+Grants on a resource apply to every resource belonging to it, at any depth.
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	parentType := fs.String("parent-type", "", "name of the parent resource type")
+	childType := fs.String("child-type", "", "name of the child resource type")
+	parentID := fs.String("parent-id", "", "ID of the parent resource, for add-children and remove-children")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	action, ids := fs.Arg(0), fs.Args()[1:]
+	if *parentType == "" || *childType == "" {
+		fs.Usage()
+		return fmt.Errorf("-parent-type and -child-type are required")
+	}
+
+	svc, ctx, err := db.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	parent := permissions.Resource{ID: *parentID, Type: *parentType}
+	children := make(permissions.Resources, len(ids))
+	for i, id := range ids {
+		children[i] = permissions.Resource{ID: id, Type: *childType}
+	}
+
+	switch action {
+	case "add-type-child":
+		return svc.AddResourceTypeChild(ctx, *parentType, *childType)
+	case "remove-type-child":
+		return svc.RemoveResourceTypeChild(ctx, *parentType, *childType)
+	case "add-children":
+		return svc.AddResourceChildren(ctx, parent, children)
+	case "remove-children":
+		return svc.RemoveResourceChildren(ctx, parent, children)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/reviews"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+func runReview(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms review [flags] <action>
+
+actions:
+  create  open a campaign named -name reviewing the assignments of -role, or of
+          every role, by the -reviewers, printing its ID
+  list    list the Tenant's campaigns
+  items   list the items of the -campaign, those of -reviewer if set
+  decide  record the -decision of the -user-id reviewer on the -item of the -campaign
+  close   apply the revoke decisions of the -campaign and close it
+  report  write the evidence report of the -campaign as CSV to stdout
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	name := fs.String("name", "", "name of the campaign, for create")
+	roleID := fs.String("role", "", "ID of the role to review, for create, every role when empty")
+	reviewerIDs := fs.String("reviewers", "", "comma separated user IDs of the reviewers, for create")
+	campaignID := fs.String("campaign", "", "ID of the campaign")
+	reviewerID := fs.String("reviewer", "", "user ID of a reviewer, for items")
+	itemID := fs.String("item", "", "ID of the item, for decide")
+	decision := fs.String("decision", "", "keep or revoke, for decide")
+	comment := fs.String("comment", "", "comment on the decision, for decide")
+	userID := fs.String("user-id", "", "ID of the user making the change, the reviewer for decide")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	action := fs.Arg(0)
+	if action != "create" && action != "list" && *campaignID == "" {
+		fs.Usage()
+		return fmt.Errorf("-campaign is required")
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if *userID != "" {
+		ctx = contextkey.WithUserID(ctx, *userID)
+	}
+	svc := reviews.NewService(repo, permissions.NewService(repo))
+
+	switch action {
+	case "create":
+		var reviewers []string
+		if *reviewerIDs != "" {
+			reviewers = strings.Split(*reviewerIDs, ",")
+		}
+		campaign, err := svc.CreateCampaign(ctx, *name, *roleID, reviewers)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, campaign.ID)
+		return nil
+	case "list":
+		campaigns, err := svc.GetCampaigns(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range campaigns {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", c.ID, c.Status, c.CreatedAt.Format("2006-01-02"), c.Name)
+		}
+		return nil
+	case "items":
+		items, err := svc.GetItems(ctx, *campaignID, *reviewerID)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			via := ""
+			if item.Group != nil {
+				via = "group " + item.Group.Name
+			}
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t%s\t%s\n", item.ID, item.UserID, item.Role.Name, via, item.ReviewerID, item.Decision)
+		}
+		return nil
+	case "decide":
+		return svc.Decide(ctx, *campaignID, *itemID, reviews.Decision(*decision), *comment)
+	case "close":
+		report, err := svc.CloseCampaign(ctx, *campaignID)
+		if err != nil {
+			return err
+		}
+		s := report.Summary()
+		fmt.Fprintf(os.Stdout, "closed: %d items, %d kept, %d revoked (%d applied), %d pending and kept\n", s.Total, s.Kept, s.Revoked, s.Applied, s.Pending)
+		return nil
+	case "report":
+		report, err := svc.Report(ctx, *campaignID)
+		if err != nil {
+			return err
+		}
+		return report.WriteCSV(os.Stdout)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
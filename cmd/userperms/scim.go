@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+)
+
+func runSCIM(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("scim", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms scim [flags] <action>
+
+actions:
+  target  print what the Tenant's new SCIM groups are mapped to, or set it to -set:
+          "role", the role of the same name, or "group", the group of the same name
+  users   list the users provisioned over SCIM
+  groups  list the groups provisioned over SCIM, and the role or group each is mapped to
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	set := fs.String("set", "", `the group target to set, "role" or "group", for target`)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	service := scim.NewService(repo)
+
+	switch fs.Arg(0) {
+	case "target":
+		if *set != "" {
+			target, err := scim.ParseTarget(*set)
+			if err != nil {
+				return err
+			}
+			if err := service.SetGroupTarget(ctx, target); err != nil {
+				return err
+			}
+		}
+		target, err := service.GetGroupTarget(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, target)
+	case "users":
+		list, err := service.ListUsers(ctx, scim.Query{Count: scim.MaxResults})
+		if err != nil {
+			return err
+		}
+		for start := 1; ; {
+			for _, u := range list.Resources {
+				fmt.Fprintf(os.Stdout, "%s\t%s\tactive=%t\t%s\n", u.ID, u.UserName, u.Active, u.ExternalID)
+			}
+			start += len(list.Resources)
+			if len(list.Resources) == 0 || start > list.TotalResults {
+				break
+			}
+			if list, err = service.ListUsers(ctx, scim.Query{StartIndex: start, Count: scim.MaxResults}); err != nil {
+				return err
+			}
+		}
+	case "groups":
+		list, err := service.ListGroups(ctx, scim.Query{Count: scim.MaxResults})
+		if err != nil {
+			return err
+		}
+		for start := 1; ; {
+			for _, g := range list.Resources {
+				fmt.Fprintf(os.Stdout, "%s\t%s\t%s %s\t%d members\n", g.ID, g.DisplayName, g.Target, g.TargetName, len(g.Members))
+			}
+			start += len(list.Resources)
+			if len(list.Resources) == 0 || start > list.TotalResults {
+				break
+			}
+			if list, err = service.ListGroups(ctx, scim.Query{StartIndex: start, Count: scim.MaxResults}); err != nil {
+				return err
+			}
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+func runSoD(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sod", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms sod [flags] <action>
+
+actions:
+  create  create a rule named -name separating the -roles, or the -permissions,
+          printing its ID
+  list    list the Tenant's rules
+  delete  delete the -rule
+  scan    list the users holding more than one member of a static rule, exits
+          non-zero when there are any
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	name := fs.String("name", "", "name of the rule, for create")
+	enforcement := fs.String("enforcement", string(permissions.SoDStatic), "static, or dynamic for roles which may be held but not active in the same request, for create")
+	roleIDs := fs.String("roles", "", "comma separated IDs of the roles to separate, for create")
+	permissionNames := fs.String("permissions", "", "comma separated names of the permissions to separate, for create")
+	ruleID := fs.String("rule", "", "ID of the rule, for delete")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+
+	svc, ctx, err := db.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch action := fs.Arg(0); action {
+	case "create":
+		rule, err := svc.CreateSoDRule(ctx, *name, permissions.SoDEnforcement(*enforcement), splitList(*roleIDs), splitList(*permissionNames))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, rule.ID)
+		return nil
+	case "list":
+		rules, err := svc.GetSoDRules(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range rules {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", r.ID, r.Enforcement, r.Name, strings.Join(r.Members(), ", "))
+		}
+		return nil
+	case "delete":
+		return svc.DeleteSoDRule(ctx, *ruleID)
+	case "scan":
+		violations, err := svc.ScanSoDViolations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, v := range violations {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\n", v.RuleName, v.UserID, strings.Join(v.Held, ", "))
+		}
+		if len(violations) > 0 {
+			return fmt.Errorf("%d separation of duties violations", len(violations))
+		}
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// splitList splits the comma separated list, an empty list has no items.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
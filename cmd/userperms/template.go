@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/internal/app/templates"
+	"github.com/Equineregister/user-permissions-service/pkg/roletemplates"
+)
+
+func runTemplate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("template", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms template [flags] <action>
+
+actions:
+  catalogue  list the templates of the catalogue
+  list       list the Tenant's roles adopting a template, and whether they are in step with it
+  adopt      make the -role, or a new role named after it, adopt the -template
+  extend     grant the adopting -role the -permissions beyond its template's
+  detach     end the -role's adoption, leaving its permissions as they are
+  sync       grant every adopting role its template's permissions and extensions,
+             -tenant may be a comma separated list
+
+adopt, extend and sync print the difference they make to each role, and the plan making it.
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	cataloguePath := fs.String("catalogue", "", "directory of template .yaml files, the standard catalogue when empty")
+	template := fs.String("template", "", "name of the template, for adopt")
+	role := fs.String("role", "", "name of the role")
+	permissionNames := fs.String("permissions", "", "comma separated permission names, for extend")
+	dryRun := fs.Bool("dry-run", false, "print the difference without making it, for adopt, extend and sync")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	action := fs.Arg(0)
+
+	catalogue, err := loadCatalogue(*cataloguePath)
+	if err != nil {
+		return err
+	}
+	if action == "catalogue" {
+		for _, t := range catalogue {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", t.Name, t.Version(), strings.Join(t.Permissions, ","), t.Description)
+		}
+		return nil
+	}
+	if db.tenantID == "" {
+		fs.Usage()
+		return fmt.Errorf("-tenant is required")
+	}
+
+	if action == "sync" {
+		for _, tenantID := range strings.Split(db.tenantID, ",") {
+			tenantDB := db
+			tenantDB.tenantID = strings.TrimSpace(tenantID)
+
+			repo, ctx, err := tenantDB.connect(ctx)
+			if err != nil {
+				return fmt.Errorf("tenant %s: %w", tenantDB.tenantID, err)
+			}
+			report, err := templates.NewService(repo, rbac.NewService(repo), catalogue).Sync(ctx, *dryRun)
+			if err != nil {
+				return fmt.Errorf("tenant %s: %w", tenantDB.tenantID, err)
+			}
+			printSyncReport(os.Stdout, tenantDB.tenantID, report)
+		}
+		return nil
+	}
+
+	repo, ctx, err := db.connect(ctx)
+	if err != nil {
+		return err
+	}
+	svc := templates.NewService(repo, rbac.NewService(repo), catalogue)
+
+	switch action {
+	case "list":
+		adoptions, err := svc.GetAdoptions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, a := range adoptions {
+			status := "missing from the catalogue"
+			if t, ok := catalogue.Get(a.Template); ok {
+				status = "in step"
+				if t.Version() != a.Version {
+					status = "out of step, " + a.Version + " -> " + t.Version()
+				}
+			}
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t%s\n", a.Role, a.Template, strings.Join(a.Extensions, ","), a.SyncedAt.Format("2006-01-02"), status)
+		}
+		return nil
+	case "adopt":
+		report, err := svc.Adopt(ctx, *template, *role, *dryRun)
+		if err != nil {
+			return err
+		}
+		printSyncReport(os.Stdout, db.tenantID, report)
+		return nil
+	case "extend":
+		report, err := svc.Extend(ctx, *role, splitList(*permissionNames), *dryRun)
+		if err != nil {
+			return err
+		}
+		printSyncReport(os.Stdout, db.tenantID, report)
+		return nil
+	case "detach":
+		return svc.Detach(ctx, *role)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// loadCatalogue reads the catalogue in the directory, or the standard catalogue when dir is empty.
+func loadCatalogue(dir string) (templates.Catalogue, error) {
+	if dir == "" {
+		return roletemplates.Standard()
+	}
+	return roletemplates.Load(os.DirFS(dir))
+}
+
+func printSyncReport(w io.Writer, tenantID string, report *templates.SyncReport) {
+	for _, d := range report.Roles {
+		if !d.Changed() {
+			continue
+		}
+		from := d.FromVersion
+		if from == "" {
+			from = "new"
+		}
+		fmt.Fprintf(w, "role %q, template %q %s -> %s\n", d.Role, d.Template, from, d.ToVersion)
+		for _, p := range d.Added {
+			fmt.Fprintf(w, "  + %s\n", p)
+		}
+		for _, p := range d.Removed {
+			fmt.Fprintf(w, "  - %s\n", p)
+		}
+	}
+	for _, a := range report.Missing {
+		fmt.Fprintf(w, "role %q, template %q is no longer in the catalogue, the role is left as it is\n", a.Role, a.Template)
+	}
+	printPlan(w, tenantID, report.Plan)
+	if !report.Applied {
+		fmt.Fprintf(w, "dry run, nothing changed\n")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/tokens"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+)
+
+func runToken(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: userperms token [flags] <action> [args...]
+
+actions:
+  keygen              print the -keys key set with a new key added after the signing key,
+                      or a new key set when -keys is not given
+  promote <kid>       print the -keys key set with the key moved to the front, to sign new tokens
+  jwks                print the JWKS of the -keys key set
+  issue               issue a token for -user in -tenant, signed with the -keys key set
+  verify <token>      verify the token against the -keys key set and print its claims
+
+To rotate keys: keygen and deploy, so the new key is published; promote it
+once verifiers have refreshed the JWKS; then remove the old key from the key
+set once the tokens it signed have expired.
+
+flags:
+`)
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	keysFile := fs.String("keys", "", "file holding the key set, the JSON of PERMTOKEN_KEYS")
+	userID := fs.String("user", "", "user ID, for issue")
+	issuer := fs.String("issuer", tokens.DefaultIssuer, "issuer of tokens")
+	ttl := fs.Duration("ttl", tokens.DefaultTTL, "how long issued tokens last")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("an action is required")
+	}
+	action, rest := fs.Arg(0), fs.Args()[1:]
+
+	var keys permtoken.KeySet
+	if *keysFile != "" {
+		b, err := os.ReadFile(*keysFile)
+		if err != nil {
+			return err
+		}
+		if keys, err = permtoken.ParseKeySet(b); err != nil {
+			return err
+		}
+	} else if action != "keygen" {
+		return fmt.Errorf("-keys is required")
+	}
+
+	switch action {
+	case "keygen":
+		key, err := permtoken.GenerateKey()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return printJSON(permtoken.KeySet{key})
+		}
+		return printJSON(append(permtoken.KeySet{keys[0], key}, keys[1:]...))
+
+	case "promote":
+		if len(rest) != 1 {
+			fs.Usage()
+			return fmt.Errorf("promote needs a key ID")
+		}
+		for i, k := range keys {
+			if k.ID == rest[0] {
+				promoted := append(permtoken.KeySet{k}, keys[:i]...)
+				return printJSON(append(promoted, keys[i+1:]...))
+			}
+		}
+		return fmt.Errorf("key %q not in the key set", rest[0])
+
+	case "jwks":
+		return printJSON(keys.JWKS())
+
+	case "issue":
+		if *userID == "" {
+			return fmt.Errorf("-user is required")
+		}
+		repo, ctx, err := db.connect(ctx)
+		if err != nil {
+			return err
+		}
+		ctx = contextkey.WithUserID(ctx, *userID)
+		token, _, err := tokens.NewService(repo, keys, *issuer, *ttl).Issue(ctx, nil, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		return nil
+
+	case "verify":
+		if len(rest) != 1 {
+			fs.Usage()
+			return fmt.Errorf("verify needs a token")
+		}
+		claims, err := permtoken.NewVerifier(keys.JWKS(), *issuer, time.Minute).Verify(rest[0])
+		if err != nil {
+			return err
+		}
+		return printJSON(claims)
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
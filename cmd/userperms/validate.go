@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/validation"
+)
+
+func runValidate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms validate [flags]\n\nValidates the role graph of each Tenant, -tenant may be a comma separated list.\n\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	failOn := fs.String("fail-on", string(validation.SeverityError), "fail if any finding is at least this severe, error or warning")
+	_ = fs.Parse(args)
+
+	severity := validation.Severity(*failOn)
+	if severity != validation.SeverityError && severity != validation.SeverityWarning {
+		fs.Usage()
+		return fmt.Errorf("unknown severity %q", *failOn)
+	}
+	if db.tenantID == "" {
+		fs.Usage()
+		return fmt.Errorf("-tenant is required")
+	}
+
+	var failed []string
+	for _, tenantID := range strings.Split(db.tenantID, ",") {
+		tenantDB := db
+		tenantDB.tenantID = strings.TrimSpace(tenantID)
+
+		repo, ctx, err := tenantDB.connect(ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantDB.tenantID, err)
+		}
+		report, err := validation.NewService(repo).Validate(ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantDB.tenantID, err)
+		}
+
+		printReport(os.Stdout, tenantDB.tenantID, report)
+		if report.Has(severity) {
+			failed = append(failed, tenantDB.tenantID)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("validation failed for tenants: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func printReport(w io.Writer, tenantID string, report *validation.Report) {
+	fmt.Fprintf(w, "tenant %s: %d findings\n", tenantID, len(report.Findings))
+	for _, f := range report.Findings {
+		fmt.Fprintf(w, "  %-7s %-19s %s\n", f.Severity, f.Check, f.Message)
+	}
+}
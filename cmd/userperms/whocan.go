@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+func runWhoCan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("who-can", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: userperms who-can [flags] <permission> <resource-id>\n")
+		fs.PrintDefaults()
+	}
+	var db dbFlags
+	db.register(fs)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("a permission and resource ID are required")
+	}
+
+	svc, ctx, err := db.service(ctx)
+	if err != nil {
+		return err
+	}
+
+	for g, err := range svc.WhoCan(ctx, fs.Arg(0), fs.Arg(1)) {
+		if err != nil {
+			return err
+		}
+		line := fmt.Sprintf("%s: %s", g.UserID, g.Decision.Effect)
+		if g.Decision.Effect == permissions.EffectConditional {
+			line += " if " + g.Decision.Condition
+		}
+		if len(g.Activate) > 0 {
+			line += " with " + strings.Join(g.Activate.StringSlice(), " or ") + " active"
+		}
+		fmt.Fprintln(os.Stdout, line)
+		printDerivations(os.Stdout, g.Derivations)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package api holds the request and response of the user permissions API, and
+// the Handler which serves them. The Lambda and the HTTP server share them, so
+// both return the same permissions in the same shape.
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	"github.com/Equineregister/user-permissions-service/pkg/rego"
+)
+
+// Request is a request for a user's permissions, the Lambda's event or the HTTP request body.
+type Request struct {
+	TenantID  string   `json:"tenantId"`
+	UserID    string   `json:"userId"`
+	Resources []string `json:"resources"`
+	// Explain is an optional permission name, when set the Response includes an Explanation for it.
+	Explain string `json:"explain,omitempty"`
+	// Attributes are the values the conditions on the user's grants are evaluated against,
+	// e.g. {"request": {"hour": 10}}, see package condition.
+	Attributes condition.Attributes `json:"attributes,omitempty"`
+	// Check are optional permission names, the Response includes a Decision for each.
+	Check []string `json:"check,omitempty"`
+	// IfNoneMatch is the ETag of a previous Response, when it still matches a
+	// not modified Response is returned in place of the full Response.
+	IfNoneMatch string `json:"ifNoneMatch,omitempty"`
+	// IssueToken requests a signed token holding the user's permissions, see package permtoken.
+	// The Response is never not modified, as each token is new.
+	IssueToken bool `json:"issueToken,omitempty"`
+	// ActiveRoles optionally names the roles active in the request, the
+	// permissions are those of these roles and the roles they inherit alone.
+	// A user holding two roles a dynamic separation of duties rule separates
+	// must name the one to use, see permissions.SoDDynamic.
+	ActiveRoles []string `json:"activeRoles,omitempty"`
+}
+
+// Validate checks the Request's fields, so a malformed Request is rejected before
+// any database work. The error is permissions.ErrInvalidInput.
+func (r Request) Validate() error {
+	if err := permissions.ValidateTenantID(r.TenantID); err != nil {
+		return err
+	}
+	if err := permissions.ValidateUserID(r.UserID); err != nil {
+		return err
+	}
+	for _, resource := range r.Resources {
+		if err := permissions.ValidateResourceType(resource); err != nil {
+			return err
+		}
+	}
+	for _, permission := range r.Check {
+		if err := permissions.ValidatePermissionName(permission); err != nil {
+			return err
+		}
+	}
+	for _, role := range r.ActiveRoles {
+		if strings.TrimSpace(role) == "" {
+			return permissions.Errorf(permissions.ErrInvalidInput, "active role name is empty")
+		}
+	}
+	if r.Explain != "" {
+		return permissions.ValidatePermissionName(r.Explain)
+	}
+	return nil
+}
+
+// Response is the user's permissions.
+type Response struct {
+	TenantID        string           `json:"tenantId"`
+	UserID          string           `json:"userId"`
+	Roles           []string         `json:"roles"`
+	RoleAssignments []RoleAssignment `json:"roleAssignments"`
+	// RevokedPermissions include conditional revocations whose condition could
+	// not be evaluated against the Request's attributes.
+	RevokedPermissions []string `json:"revokedPermissions"`
+	// ExtraPermissions are the unconditional extra permissions, and those whose
+	// condition holds against the Request's attributes.
+	ExtraPermissions []string `json:"extraPermissions"`
+	// ConditionalPermissions are the extra permissions whose condition could not
+	// be evaluated against the Request's attributes.
+	ConditionalPermissions []ConditionalPermission `json:"conditionalPermissions,omitempty"`
+	// DelegatedPermissions are the permissions delegated to the user, those
+	// whose condition does not hold against the Request's attributes left out.
+	DelegatedPermissions []DelegatedPermission `json:"delegatedPermissions,omitempty"`
+	UserResources        []Resource            `json:"userResources"`
+	ResourceGrants       []ResourceGrant       `json:"resourceGrants"`
+	RoleGraph            rego.RoleGraph        `json:"roleGraph"`
+	Explanation          *Explanation          `json:"explanation,omitempty"`
+	Decisions            []Decision            `json:"decisions,omitempty"`
+	// RoleMapVersion changes when the Tenant's role map does, see TenantRoleMap.Version.
+	RoleMapVersion string `json:"roleMapVersion,omitempty"`
+	// AssignmentVersion changes when the user's assignment set does, see ForUser.AssignmentVersion.
+	AssignmentVersion string `json:"assignmentVersion,omitempty"`
+	// ETag identifies this Response, it changes when either version or the Request does.
+	ETag string `json:"etag,omitempty"`
+	// NotModified is set when the Request's IfNoneMatch is the ETag, only the
+	// TenantID, UserID, versions and ETag are written.
+	NotModified bool `json:"notModified,omitempty"`
+	// Token is set when the Request's IssueToken is.
+	Token *Token `json:"token,omitempty"`
+}
+
+// Token is a signed permission token and when it expires.
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type notModifiedResponse struct {
+	TenantID          string `json:"tenantId"`
+	UserID            string `json:"userId"`
+	RoleMapVersion    string `json:"roleMapVersion"`
+	AssignmentVersion string `json:"assignmentVersion"`
+	ETag              string `json:"etag"`
+	NotModified       bool   `json:"notModified"`
+}
+
+// MarshalJSON writes a not modified Response without the unchanged permissions.
+func (r Response) MarshalJSON() ([]byte, error) {
+	if r.NotModified {
+		return json.Marshal(notModifiedResponse{
+			TenantID:          r.TenantID,
+			UserID:            r.UserID,
+			RoleMapVersion:    r.RoleMapVersion,
+			AssignmentVersion: r.AssignmentVersion,
+			ETag:              r.ETag,
+			NotModified:       true,
+		})
+	}
+	// response has Response's fields but not its methods, so is marshalled as usual.
+	type response Response
+	return json.Marshal(response(r))
+}
+
+// ConditionalPermission is a permission granted only if the condition holds.
+type ConditionalPermission struct {
+	Permission string `json:"permission"`
+	Condition  string `json:"condition"`
+}
+
+// Decision is the outcome of checking a permission, one of "allow", "deny" or
+// "conditional". Condition is set when the outcome is conditional.
+type Decision struct {
+	Permission string `json:"permission"`
+	Effect     string `json:"effect"`
+	Condition  string `json:"condition,omitempty"`
+}
+
+// DelegatedPermission is a permission delegated to the user by DelegatorID until
+// EndsAt, through the delegated Role when it is set. Condition is set when the
+// permission is granted only if it holds.
+type DelegatedPermission struct {
+	Permission   string    `json:"permission"`
+	Condition    string    `json:"condition,omitempty"`
+	DelegationID string    `json:"delegationId"`
+	DelegatorID  string    `json:"delegatorId"`
+	Role         string    `json:"role,omitempty"`
+	EndsAt       time.Time `json:"endsAt"`
+}
+
+// RoleAssignment is a role held by the user and its source, one of "direct",
+// "group" or "inherited". Via names the group or inheriting role.
+type RoleAssignment struct {
+	Role   string `json:"role"`
+	Source string `json:"source"`
+	Via    string `json:"via,omitempty"`
+}
+
+type Resource struct {
+	ResourceID   string `json:"resourceId"`
+	ResourceType string `json:"resourceType"`
+}
+
+// ResourceGrant is a permission the user holds on a single resource. InheritedFrom
+// is the ancestor the permission was granted on, the same action is allowed on
+// the resource, e.g. "customers:read" on a customer allows "contacts:read" on its contacts.
+type ResourceGrant struct {
+	ResourceID    string    `json:"resourceId"`
+	ResourceType  string    `json:"resourceType"`
+	Permission    string    `json:"permission"`
+	InheritedFrom *Resource `json:"inheritedFrom,omitempty"`
+	// Condition is set when the grant applies only if it holds.
+	Condition string `json:"condition,omitempty"`
+}
+
+// Explanation describes why the user has, or lacks, a permission.
+type Explanation struct {
+	Permission  string       `json:"permission"`
+	Verdict     string       `json:"verdict"`
+	Derivations []Derivation `json:"derivations"`
+}
+
+type Derivation struct {
+	Kind      string   `json:"kind"`
+	Grant     string   `json:"grant"`
+	Condition string   `json:"condition,omitempty"`
+	RolePath  []string `json:"rolePath,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	// DelegatorID and DelegatedRole are set for a delegated grant, the role
+	// only when the grant was delegated through one.
+	DelegatorID   string    `json:"delegatorId,omitempty"`
+	DelegatedRole string    `json:"delegatedRole,omitempty"`
+	Resource      *Resource `json:"resource,omitempty"`
+	// InheritedFrom is the ancestor of Resource the grant was made on.
+	InheritedFrom *Resource `json:"inheritedFrom,omitempty"`
+}
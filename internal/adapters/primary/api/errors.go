@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Error codes, one for each kind of error, see permissions.ErrNotFound and the others.
+const (
+	CodeInvalidInput     = "InvalidInput"
+	CodeNotFound         = "NotFound"
+	CodeForbidden        = "Forbidden"
+	CodeUnavailable      = "Unavailable"
+	CodeDeadlineExceeded = "DeadlineExceeded"
+	CodeNotImplemented   = "NotImplemented"
+	CodeUnauthorized     = "Unauthorized"
+	CodeInternal         = "Internal"
+)
+
+// Error is an error as callers see it, with the detail of unexpected errors left out.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Status is the HTTP status of the error.
+	Status int `json:"-"`
+}
+
+func (e Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// NewError returns the Error callers see for err, the same from every transport.
+func NewError(err error) Error {
+	message, ok := permissions.Message(err)
+	if !ok {
+		message = err.Error()
+	}
+	switch permissions.Kind(err) {
+	case permissions.ErrInvalidInput:
+		return Error{Code: CodeInvalidInput, Message: message, Status: http.StatusBadRequest}
+	case permissions.ErrNotFound:
+		return Error{Code: CodeNotFound, Message: message, Status: http.StatusNotFound}
+	case permissions.ErrForbidden:
+		return Error{Code: CodeForbidden, Message: message, Status: http.StatusForbidden}
+	case permissions.ErrUnavailable:
+		return Error{Code: CodeUnavailable, Message: message, Status: http.StatusServiceUnavailable}
+	}
+
+	switch {
+	case errors.Is(err, ErrTokensDisabled):
+		return Error{Code: CodeNotImplemented, Message: ErrTokensDisabled.Error(), Status: http.StatusNotImplemented}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Error{Code: CodeDeadlineExceeded, Message: "deadline exceeded", Status: http.StatusGatewayTimeout}
+	default:
+		return Error{Code: CodeInternal, Message: "internal error", Status: http.StatusInternalServerError}
+	}
+}
@@ -0,0 +1,69 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want api.Error
+	}{
+		{
+			err:  permissions.Errorf(permissions.ErrInvalidInput, "user ID %q is not a UUID", "u1"),
+			want: api.Error{Code: api.CodeInvalidInput, Message: `user ID "u1" is not a UUID`, Status: http.StatusBadRequest},
+		},
+		{
+			err:  fmt.Errorf("get for user: %w", permissions.Errorf(permissions.ErrNotFound, "tenant not found")),
+			want: api.Error{Code: api.CodeNotFound, Message: "tenant not found", Status: http.StatusNotFound},
+		},
+		{
+			err:  permissions.Errorf(permissions.ErrForbidden, "not allowed"),
+			want: api.Error{Code: api.CodeForbidden, Message: "not allowed", Status: http.StatusForbidden},
+		},
+		{
+			err:  permissions.WrapError(permissions.ErrUnavailable, errors.New("dial tcp: connection refused"), "database unavailable"),
+			want: api.Error{Code: api.CodeUnavailable, Message: "database unavailable", Status: http.StatusServiceUnavailable},
+		},
+		{
+			err:  api.ErrTokensDisabled,
+			want: api.Error{Code: api.CodeNotImplemented, Message: api.ErrTokensDisabled.Error(), Status: http.StatusNotImplemented},
+		},
+		{
+			err:  fmt.Errorf("query: %w", context.DeadlineExceeded),
+			want: api.Error{Code: api.CodeDeadlineExceeded, Message: "deadline exceeded", Status: http.StatusGatewayTimeout},
+		},
+		{
+			err:  errors.New("scan roles: unexpected column"),
+			want: api.Error{Code: api.CodeInternal, Message: "internal error", Status: http.StatusInternalServerError},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, api.NewError(tt.err), tt.err.Error())
+	}
+}
+
+func TestRequest_Validate(t *testing.T) {
+	valid := api.Request{TenantID: "test", UserID: "652f4d18-dd3d-40c0-874e-cbe3566abccf", Resources: []string{"invoices"}, Check: []string{"invoices:read"}}
+	assert.NoError(t, valid.Validate())
+
+	for _, change := range []func(r *api.Request){
+		func(r *api.Request) { r.TenantID = "" },
+		func(r *api.Request) { r.UserID = "u1" },
+		func(r *api.Request) { r.Resources = []string{"invoices:read"} },
+		func(r *api.Request) { r.Check = []string{"invoices"} },
+		func(r *api.Request) { r.Explain = "invoices" },
+	} {
+		r := valid
+		change(&r)
+		assert.ErrorIs(t, r.Validate(), permissions.ErrInvalidInput, "%+v", r)
+	}
+}
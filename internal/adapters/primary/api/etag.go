@@ -0,0 +1,49 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// etag identifies the Response to the Request while the versions are unchanged.
+// The Request's resources, attributes, checks, explained permission and active
+// roles shape the Response, so they are hashed along with the versions.
+func etag(request Request, roleMapVersion, assignmentVersion string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "tenant\x00%s\nuser\x00%s\n", request.TenantID, request.UserID)
+	fmt.Fprintf(h, "role_map\x00%s\nassignments\x00%s\n", roleMapVersion, assignmentVersion)
+	for _, r := range request.Resources {
+		fmt.Fprintf(h, "resource\x00%s\n", r)
+	}
+	// Maps are marshalled with sorted keys, so equal attributes hash the same.
+	attrs, _ := json.Marshal(request.Attributes)
+	fmt.Fprintf(h, "attributes\x00%s\n", attrs)
+	for _, c := range request.Check {
+		fmt.Fprintf(h, "check\x00%s\n", c)
+	}
+	fmt.Fprintf(h, "explain\x00%s\n", request.Explain)
+	for _, r := range request.ActiveRoles {
+		fmt.Fprintf(h, "active_role\x00%s\n", r)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ETagMatches reports whether ifNoneMatch, either an ETag or the value of an
+// If-None-Match header, matches the ETag. The header may list several ETags,
+// quoted and optionally weak, or be "*" which matches any.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		candidate = strings.Trim(strings.TrimPrefix(candidate, "W/"), `"`)
+		if candidate != "" && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
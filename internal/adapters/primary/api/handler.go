@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/tokens"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+)
+
+// ErrTokensDisabled is returned for a Request to issue a token when no signing keys are configured.
+var ErrTokensDisabled = errors.New("token issuing is not configured")
+
+// Handler serves Requests for users' permissions.
+type Handler struct {
+	service *permissions.Service
+	tokens  *tokens.Service
+}
+
+// NewHandler creates a Handler, tokens may be nil when tokens are not issued.
+func NewHandler(service *permissions.Service, tokens *tokens.Service) *Handler {
+	return &Handler{service: service, tokens: tokens}
+}
+
+// Handle returns the user's permissions, or a not modified Response when the
+// Request's IfNoneMatch matches the ETag of the Response it would return.
+// The Request is validated first, see NewError for what the errors mean to callers.
+func (h *Handler) Handle(ctx context.Context, request Request) (Response, error) {
+	slog.Debug("received request", "tenantId", request.TenantID, "userId", request.UserID)
+
+	if err := request.Validate(); err != nil {
+		return Response{}, err
+	}
+
+	ctx = contextkey.WithTenantID(ctx, request.TenantID)
+	ctx = contextkey.WithUserID(ctx, request.UserID)
+	ctx = contextkey.WithActiveRoles(ctx, request.ActiveRoles)
+
+	forUser, err := h.service.GetForUser(ctx, request.Resources)
+	if err != nil {
+		slog.Error("error getting permissions for user", "error", err.Error())
+		return Response{}, err
+	}
+
+	roleMapVersion := forUser.RoleMap.Version()
+	assignmentVersion := forUser.AssignmentVersion()
+	tag := etag(request, roleMapVersion, assignmentVersion)
+	if !request.IssueToken && ETagMatches(request.IfNoneMatch, tag) {
+		return Response{
+			TenantID:          request.TenantID,
+			UserID:            request.UserID,
+			RoleMapVersion:    roleMapVersion,
+			AssignmentVersion: assignmentVersion,
+			ETag:              tag,
+			NotModified:       true,
+		}, nil
+	}
+
+	resp := NewResponse(request.TenantID, request.UserID, forUser.Resolve(request.Attributes))
+	resp.RoleMapVersion = roleMapVersion
+	resp.AssignmentVersion = assignmentVersion
+	resp.ETag = tag
+
+	for _, permission := range request.Check {
+		resp.Decisions = append(resp.Decisions, decision(forUser.Decide(permission, request.Attributes)))
+	}
+
+	if request.Explain != "" {
+		e, err := h.service.Explain(ctx, request.Explain)
+		if err != nil {
+			slog.Error("error explaining permission for user", "permission", request.Explain, "error", err.Error())
+			return Response{}, err
+		}
+		resp.Explanation = explanation(e)
+	}
+
+	if request.IssueToken {
+		if resp.Token, err = h.issueToken(ctx, request); err != nil {
+			return Response{}, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (h *Handler) issueToken(ctx context.Context, request Request) (*Token, error) {
+	if h.tokens == nil {
+		return nil, ErrTokensDisabled
+	}
+	token, claims, err := h.tokens.Issue(ctx, request.Resources, request.Attributes)
+	if err != nil {
+		slog.Error("error issuing token for user", "error", err.Error())
+		return nil, err
+	}
+	return &Token{Token: token, ExpiresAt: claims.Expiry().UTC()}, nil
+}
+
+// JWKS returns the public keys of issued tokens, ok is false when tokens are not issued.
+func (h *Handler) JWKS() (jwks permtoken.JWKS, ok bool) {
+	if h.tokens == nil {
+		return permtoken.JWKS{}, false
+	}
+	return h.tokens.JWKS(), true
+}
@@ -0,0 +1,122 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo returns a fixed role map and assignments, the methods GetForUser does not use are left nil.
+type stubRepo struct {
+	permissions.ReaderWriter
+	roleMap permissions.TenantRoleMap
+	roles   permissions.RoleAssignments
+}
+
+func (r *stubRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return r.roleMap, nil
+}
+
+func (r *stubRepo) GetUserPermissionsExtraAndRevoked(context.Context, []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	return nil, nil, nil
+}
+
+func (r *stubRepo) GetUserRoleAssignments(context.Context) (permissions.RoleAssignments, error) {
+	return r.roles, nil
+}
+
+func (r *stubRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (r *stubRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return nil, nil
+}
+
+func (r *stubRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return nil, nil
+}
+
+func (r *stubRepo) GetUserDelegations(context.Context) (permissions.Delegations, error) {
+	return nil, nil
+}
+
+func newStubRepo() *stubRepo {
+	clerk := permissions.Role{Name: "clerk", ID: "1"}
+	return &stubRepo{
+		roleMap: permissions.TenantRoleMap{clerk: {Permissions: permissions.TenantPermissions{{Name: "invoices:read"}}}},
+		roles:   permissions.RoleAssignments{{Role: clerk, Source: permissions.RoleSourceDirect}},
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	repo := newStubRepo()
+	h := api.NewHandler(permissions.NewService(repo), nil)
+	request := api.Request{TenantID: "test", UserID: "652f4d18-dd3d-40c0-874e-cbe3566abccf", Check: []string{"invoices:read"}}
+
+	resp, err := h.Handle(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, resp.NotModified)
+	assert.NotEmpty(t, resp.ETag)
+	assert.Equal(t, repo.roleMap.Version(), resp.RoleMapVersion)
+
+	// The same Request with the ETag is not modified.
+	request.IfNoneMatch = resp.ETag
+	notModified, err := h.Handle(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, notModified.NotModified)
+	assert.Equal(t, resp.ETag, notModified.ETag)
+
+	b, err := json.Marshal(notModified)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"tenantId": "test",
+		"userId": "652f4d18-dd3d-40c0-874e-cbe3566abccf",
+		"roleMapVersion": "`+resp.RoleMapVersion+`",
+		"assignmentVersion": "`+resp.AssignmentVersion+`",
+		"etag": "`+resp.ETag+`",
+		"notModified": true
+	}`, string(b))
+
+	// A different Request has a different ETag.
+	request.Check = []string{"invoices:create"}
+	changed, err := h.Handle(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, changed.NotModified)
+	assert.NotEqual(t, resp.ETag, changed.ETag)
+
+	// So does a change to the role map.
+	request.Check = []string{"invoices:read"}
+	for role := range repo.roleMap {
+		repo.roleMap[role] = permissions.TenantMappedRole{Permissions: permissions.TenantPermissions{{Name: "invoices:create"}}}
+	}
+	changed, err = h.Handle(context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, changed.NotModified)
+	assert.NotEqual(t, resp.RoleMapVersion, changed.RoleMapVersion)
+	assert.Equal(t, resp.AssignmentVersion, changed.AssignmentVersion)
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{ifNoneMatch: "abc", want: true},
+		{ifNoneMatch: `"abc"`, want: true},
+		{ifNoneMatch: `W/"abc"`, want: true},
+		{ifNoneMatch: `"xyz", "abc"`, want: true},
+		{ifNoneMatch: "*", want: true},
+		{ifNoneMatch: "", want: false},
+		{ifNoneMatch: `"xyz"`, want: false},
+		{ifNoneMatch: `""`, want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, api.ETagMatches(tt.ifNoneMatch, "abc"), "If-None-Match: %s", tt.ifNoneMatch)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/rego"
+)
+
+// NewResponse builds the Response for the user's permissions, forUser may be nil.
+func NewResponse(tenantID, userID string, forUser *permissions.ForUser) Response {
+	resp := Response{
+		TenantID: tenantID,
+		UserID:   userID,
+	}
+	if forUser == nil {
+		resp.Roles = []string{}
+		resp.RoleAssignments = []RoleAssignment{}
+		resp.ExtraPermissions = []string{}
+		resp.RevokedPermissions = []string{}
+		resp.UserResources = []Resource{}
+		resp.ResourceGrants = []ResourceGrant{}
+		resp.RoleGraph = rego.RoleGraph{}
+		return resp
+	}
+
+	resp.UserResources = make([]Resource, len(forUser.Resources))
+	for i, r := range forUser.Resources {
+		resp.UserResources[i] = Resource{
+			ResourceID:   r.ID,
+			ResourceType: r.Type,
+		}
+	}
+
+	resp.ResourceGrants = make([]ResourceGrant, len(forUser.ResourceGrants))
+	for i, rg := range forUser.ResourceGrants {
+		resp.ResourceGrants[i] = ResourceGrant{
+			ResourceID:    rg.Resource.ID,
+			ResourceType:  rg.Resource.Type,
+			Permission:    rg.Permission.String(),
+			InheritedFrom: resource(rg.InheritedFrom),
+			Condition:     rg.Permission.Condition,
+		}
+	}
+
+	resp.RoleAssignments = make([]RoleAssignment, len(forUser.RoleAssignments))
+	for i, ra := range forUser.RoleAssignments {
+		resp.RoleAssignments[i] = RoleAssignment{
+			Role:   ra.Role.Name,
+			Source: string(ra.Source),
+			Via:    ra.Via,
+		}
+	}
+
+	resp.Roles = forUser.Roles.StringSlice()
+	resp.RevokedPermissions = forUser.RevokedPermissions.StringSlice()
+	resp.ExtraPermissions = make([]string, 0, len(forUser.ExtraPermissions))
+	for _, ep := range forUser.ExtraPermissions {
+		if ep.Condition != "" {
+			resp.ConditionalPermissions = append(resp.ConditionalPermissions, ConditionalPermission{
+				Permission: ep.Name,
+				Condition:  ep.Condition,
+			})
+			continue
+		}
+		resp.ExtraPermissions = append(resp.ExtraPermissions, ep.Name)
+	}
+	for _, dp := range forUser.DelegatedPermissions {
+		resp.DelegatedPermissions = append(resp.DelegatedPermissions, DelegatedPermission{
+			Permission:   dp.Permission.Name,
+			Condition:    dp.Permission.Condition,
+			DelegationID: dp.DelegationID,
+			DelegatorID:  dp.DelegatorID,
+			Role:         dp.Role,
+			EndsAt:       dp.EndsAt,
+		})
+	}
+	resp.RoleGraph = rego.NewRoleGraph(forUser.RoleMap)
+
+	return resp
+}
+
+func explanation(e *permissions.Explanation) *Explanation {
+	if e == nil {
+		return nil
+	}
+	resp := &Explanation{
+		Permission:  e.Permission,
+		Verdict:     string(e.Verdict),
+		Derivations: make([]Derivation, len(e.Derivations)),
+	}
+	for i, d := range e.Derivations {
+		resp.Derivations[i] = Derivation{
+			Kind:          string(d.Kind),
+			Grant:         d.Grant.String(),
+			Condition:     d.Grant.Condition,
+			Group:         d.Group,
+			DelegatorID:   d.DelegatorID,
+			DelegatedRole: d.DelegatedRole,
+		}
+		if len(d.RolePath) > 0 {
+			resp.Derivations[i].RolePath = d.RolePath.StringSlice()
+		}
+		resp.Derivations[i].Resource = resource(d.Resource)
+		resp.Derivations[i].InheritedFrom = resource(d.InheritedFrom)
+	}
+	return resp
+}
+
+func decision(d permissions.Decision) Decision {
+	return Decision{
+		Permission: d.Permission,
+		Effect:     string(d.Effect),
+		Condition:  d.Condition,
+	}
+}
+
+func resource(r *permissions.Resource) *Resource {
+	if r == nil {
+		return nil
+	}
+	return &Resource{
+		ResourceID:   r.ID,
+		ResourceType: r.Type,
+	}
+}
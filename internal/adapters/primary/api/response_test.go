@@ -0,0 +1,294 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/rego"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// wantJSONLogged is a flag to enable JSON logging of the expected output, useful for OPA testing.
+	wantJSONLogged = false
+)
+
+func TestNewResponse(t *testing.T) {
+	type args struct {
+		tenantID string
+		userID   string
+		forUser  *permissions.ForUser
+	}
+	tests := []struct {
+		name string
+		args args
+		want Response
+	}{
+		{
+			name: "Nil forUser",
+			args: args{
+				tenantID: "test_tenant",
+				userID:   "cba1470a-58b6-444f-a763-31b309f087e2",
+				forUser:  nil,
+			},
+			want: Response{
+				TenantID:           "test_tenant",
+				UserID:             "cba1470a-58b6-444f-a763-31b309f087e2",
+				Roles:              []string{},
+				RoleAssignments:    []RoleAssignment{},
+				ExtraPermissions:   []string{},
+				RevokedPermissions: []string{},
+				UserResources:      []Resource{},
+				ResourceGrants:     []ResourceGrant{},
+				RoleGraph:          rego.RoleGraph{},
+			},
+		},
+		{
+			name: "Empty forUser",
+			args: args{
+				tenantID: "test_tenant",
+				userID:   "4817f881-0081-4a96-a8c1-7da5b743c2ec",
+				forUser: &permissions.ForUser{
+					Roles:              nil,
+					ExtraPermissions:   nil,
+					RevokedPermissions: nil,
+					Resources:          nil,
+					RoleMap:            nil,
+				},
+			},
+			want: Response{
+				TenantID:           "test_tenant",
+				UserID:             "4817f881-0081-4a96-a8c1-7da5b743c2ec",
+				Roles:              []string{},
+				RoleAssignments:    []RoleAssignment{},
+				ExtraPermissions:   []string{},
+				RevokedPermissions: []string{},
+				UserResources:      []Resource{},
+				ResourceGrants:     []ResourceGrant{},
+				RoleGraph:          rego.RoleGraph{},
+			},
+		},
+		{
+			name: "Populated forUser",
+			args: args{
+				tenantID: "test_tenant",
+				userID:   "2cdabaf2-24fb-4c90-961f-b92f129f895e",
+				forUser: &permissions.ForUser{
+					Roles: permissions.Roles{
+						{Name: "customer service", ID: "eb1386c5-6a18-43e3-9176-b7ffa927ecc2"},
+						{Name: "discount decider", ID: "b5622eba-1c4c-42de-803d-778261c61b79"},
+					},
+					RoleAssignments: permissions.RoleAssignments{
+						{
+							Role:   permissions.Role{Name: "customer service", ID: "eb1386c5-6a18-43e3-9176-b7ffa927ecc2"},
+							Source: permissions.RoleSourceGroup,
+							Via:    "support team",
+						},
+						{
+							Role:   permissions.Role{Name: "discount decider", ID: "b5622eba-1c4c-42de-803d-778261c61b79"},
+							Source: permissions.RoleSourceInherited,
+							Via:    "customer service",
+						},
+					},
+					ExtraPermissions: permissions.UserExtraPermissions{
+						{Name: "customers:update", ID: "2c1c7083-e97e-456c-8e33-db4b1ae4ef49"},
+					},
+					RevokedPermissions: permissions.UserRevokedPermissions{
+						{Name: "discounts:delete", ID: "06597dcc-26dd-4f48-99be-95ccce65bca9"},
+					},
+					Resources: permissions.Resources{
+						{ID: "90a12308-003c-4b90-957e-59ad1f3e5b7a", Type: "customers"},
+						{ID: "6b7ef64b-8f4f-47e2-9cc6-ebeb0075904b", Type: "discounts"},
+					},
+					ResourceGrants: permissions.ResourceGrants{
+						{
+							Resource:   permissions.Resource{ID: "90a12308-003c-4b90-957e-59ad1f3e5b7a", Type: "customers"},
+							Permission: permissions.Permission{Name: "customers:read", ID: "1349adb7-052b-4879-b843-29621d96966f"},
+						},
+						{
+							Resource:      permissions.Resource{ID: "3d1f5b7a-9c2e-4a6b-8d0f-2b4d6f8a0c15", Type: "contacts"},
+							Permission:    permissions.Permission{Name: "customers:read", ID: "1349adb7-052b-4879-b843-29621d96966f"},
+							InheritedFrom: &permissions.Resource{ID: "90a12308-003c-4b90-957e-59ad1f3e5b7a", Type: "customers"},
+						},
+					},
+					RoleMap: permissions.TenantRoleMap{
+						{Name: "customer manager", ID: "e4d9424c-8249-4888-9812-35f5aefb02b2"}: {
+							Permissions: permissions.TenantPermissions{
+								{Name: "customers:delete", ID: "af02ce53-955d-4c5c-89db-9a9e0c9f11fa"},
+								{Name: "customers:update", ID: "2c1c7083-e97e-456c-8e33-db4b1ae4ef49"},
+							},
+							Inherits: []permissions.Role{
+								{Name: "customer service", ID: "eb1386c5-6a18-43e3-9176-b7ffa927ecc2"},
+							},
+						},
+						{Name: "customer service", ID: "eb1386c5-6a18-43e3-9176-b7ffa927ecc2"}: {
+							Permissions: permissions.TenantPermissions{
+								{Name: "customers:create", ID: "9bb579ce-e5a0-4917-a8c8-f9916e5ee9aa"},
+								{Name: "customers:read", ID: "1349adb7-052b-4879-b843-29621d96966f"},
+							},
+							Inherits: []permissions.Role{
+								{Name: "discount decider", ID: "b5622eba-1c4c-42de-803d-778261c61b79"},
+							},
+						},
+						{Name: "discount decider", ID: "b5622eba-1c4c-42de-803d-778261c61b79"}: {
+							Permissions: permissions.TenantPermissions{
+								{Name: "discounts:create", ID: "2a16bbbb-40cb-4bfb-8e99-fa521ab74508"},
+								{Name: "discounts:delete", ID: "06597dcc-26dd-4f48-99be-95ccce65bca9"},
+								{Name: "discounts:read", ID: "49539ec8-f619-47b4-8101-299ed590e5fb"},
+							},
+							Inherits: []permissions.Role{},
+						},
+					},
+				},
+			},
+			want: Response{
+				TenantID: "test_tenant",
+				UserID:   "2cdabaf2-24fb-4c90-961f-b92f129f895e",
+				Roles:    []string{"customer service", "discount decider"},
+				RoleAssignments: []RoleAssignment{
+					{Role: "customer service", Source: "group", Via: "support team"},
+					{Role: "discount decider", Source: "inherited", Via: "customer service"},
+				},
+				ExtraPermissions:   []string{"customers:update"},
+				RevokedPermissions: []string{"discounts:delete"},
+				UserResources: []Resource{
+					{ResourceID: "90a12308-003c-4b90-957e-59ad1f3e5b7a", ResourceType: "customers"},
+					{ResourceID: "6b7ef64b-8f4f-47e2-9cc6-ebeb0075904b", ResourceType: "discounts"},
+				},
+				ResourceGrants: []ResourceGrant{
+					{ResourceID: "90a12308-003c-4b90-957e-59ad1f3e5b7a", ResourceType: "customers", Permission: "customers:read"},
+					{
+						ResourceID:    "3d1f5b7a-9c2e-4a6b-8d0f-2b4d6f8a0c15",
+						ResourceType:  "contacts",
+						Permission:    "customers:read",
+						InheritedFrom: &Resource{ResourceID: "90a12308-003c-4b90-957e-59ad1f3e5b7a", ResourceType: "customers"},
+					},
+				},
+				RoleGraph: rego.RoleGraph{
+					"customer manager": rego.Role{Permissions: []string{"customers:delete", "customers:update"},
+						Inherits: []string{"customer service"},
+					},
+					"customer service": rego.Role{Permissions: []string{"customers:create", "customers:read"},
+						Inherits: []string{"discount decider"},
+					},
+					"discount decider": rego.Role{
+						Permissions: []string{"discounts:create", "discounts:delete", "discounts:read"},
+						Inherits:    []string{},
+					},
+				},
+			},
+		},
+		{
+			name: "Wildcard permissions",
+			args: args{
+				tenantID: "test_tenant",
+				userID:   "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42",
+				forUser: &permissions.ForUser{
+					Roles: permissions.Roles{
+						{Name: "invoice clerk", ID: "7f0e3b1a-9d6c-4c3e-8a2b-5e4f6a7b8c9d"},
+					},
+					ExtraPermissions: permissions.UserExtraPermissions{
+						{Name: "*:read", ID: "0b8f6c3e-3f0a-4c57-9d0e-4d1a2f7c9e11"},
+					},
+					RoleMap: permissions.TenantRoleMap{
+						{Name: "invoice clerk", ID: "7f0e3b1a-9d6c-4c3e-8a2b-5e4f6a7b8c9d"}: {
+							Permissions: permissions.TenantPermissions{
+								{Name: "invoices:*", ID: "5d2e9a71-8c4b-4f3e-b6a0-7e1c2d3f4a52"},
+								{Name: "products:read", ID: "62752f21-fbe2-4301-a72d-7dc8963e08e2"},
+							},
+						},
+					},
+				},
+			},
+			want: Response{
+				TenantID:           "test_tenant",
+				UserID:             "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42",
+				Roles:              []string{"invoice clerk"},
+				RoleAssignments:    []RoleAssignment{},
+				ExtraPermissions:   []string{"*:read"},
+				RevokedPermissions: []string{},
+				UserResources:      []Resource{},
+				ResourceGrants:     []ResourceGrant{},
+				RoleGraph: rego.RoleGraph{
+					"invoice clerk": rego.Role{
+						Permissions: []string{"products:read"},
+						Wildcards:   []string{"invoices:*"},
+						Inherits:    []string{},
+					},
+				},
+			},
+		},
+		{
+			name: "Conditional grants",
+			args: args{
+				tenantID: "test_tenant",
+				userID:   "c3e5a7c9-1b3d-4f5a-9c7e-1b3d5f7a9c46",
+				forUser: &permissions.ForUser{
+					Roles: permissions.Roles{
+						{Name: "invoice clerk", ID: "7f0e3b1a-9d6c-4c3e-8a2b-5e4f6a7b8c9d"},
+					},
+					ExtraPermissions: permissions.UserExtraPermissions{
+						{Name: "customers:read", ID: "7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13", Condition: "request.hour >= 9 && request.hour < 17"},
+						{Name: "products:read", ID: "62752f21-fbe2-4301-a72d-7dc8963e08e2"},
+					},
+					ResourceGrants: permissions.ResourceGrants{
+						{
+							Resource:   permissions.Resource{ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71", Type: "contacts"},
+							Permission: permissions.Permission{Name: "contacts:read", ID: "b4d6f8a0-1c3e-4a5b-9d7f-0e2a4c6e8f35", Condition: `user.region == "north"`},
+						},
+					},
+					RoleMap: permissions.TenantRoleMap{
+						{Name: "invoice clerk", ID: "7f0e3b1a-9d6c-4c3e-8a2b-5e4f6a7b8c9d"}: {
+							Permissions: permissions.TenantPermissions{
+								{Name: "invoices:approve", ID: "3f5a7c9e-1b3d-4f5a-8c7e-9b1d3f5a7c90", Condition: "invoice.amount < 10000"},
+								{Name: "invoices:read", ID: "d2c5a1a4-8b8f-4a43-8e1e-0d4f7c2b6a93"},
+							},
+						},
+					},
+				},
+			},
+			want: Response{
+				TenantID:           "test_tenant",
+				UserID:             "c3e5a7c9-1b3d-4f5a-9c7e-1b3d5f7a9c46",
+				Roles:              []string{"invoice clerk"},
+				RoleAssignments:    []RoleAssignment{},
+				ExtraPermissions:   []string{"products:read"},
+				RevokedPermissions: []string{},
+				ConditionalPermissions: []ConditionalPermission{
+					{Permission: "customers:read", Condition: "request.hour >= 9 && request.hour < 17"},
+				},
+				UserResources: []Resource{},
+				ResourceGrants: []ResourceGrant{
+					{
+						ResourceID:   "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71",
+						ResourceType: "contacts",
+						Permission:   "contacts:read",
+						Condition:    `user.region == "north"`,
+					},
+				},
+				RoleGraph: rego.RoleGraph{
+					"invoice clerk": rego.Role{
+						Permissions: []string{"invoices:read"},
+						Conditions:  map[string]string{"invoices:approve": "invoice.amount < 10000"},
+						Inherits:    []string{},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewResponse(tt.args.tenantID, tt.args.userID, tt.args.forUser)
+			assert.Equal(t, tt.want, got)
+
+			if wantJSONLogged {
+				resp, err := json.Marshal(got)
+				require.NoError(t, err)
+				t.Log(string(resp))
+			}
+
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	userpermsv1 "github.com/Equineregister/user-permissions-service/pkg/pb/userperms/v1"
+	"github.com/Equineregister/user-permissions-service/pkg/rego"
+)
+
+// getForUserResponse mirrors api.NewResponse, so gRPC and JSON clients see the same permissions.
+func getForUserResponse(ctx context.Context, forUser *permissions.ForUser, attrs condition.Attributes) *userpermsv1.GetForUserResponse {
+	tenantID, _ := contextkey.TenantID(ctx)
+	userID, _ := contextkey.UserID(ctx)
+	resp := &userpermsv1.GetForUserResponse{
+		TenantId:          tenantID,
+		UserId:            userID,
+		RoleMapVersion:    forUser.RoleMap.Version(),
+		AssignmentVersion: forUser.AssignmentVersion(),
+	}
+
+	resolved := forUser.Resolve(attrs)
+	resp.Roles = resolved.Roles.StringSlice()
+	resp.RoleAssignments = roleAssignments(resolved.RoleAssignments)
+	resp.RevokedPermissions = resolved.RevokedPermissions.StringSlice()
+	for _, ep := range resolved.ExtraPermissions {
+		if ep.Condition != "" {
+			resp.ConditionalPermissions = append(resp.ConditionalPermissions, &userpermsv1.ConditionalPermission{
+				Permission: ep.Name,
+				Condition:  ep.Condition,
+			})
+			continue
+		}
+		resp.ExtraPermissions = append(resp.ExtraPermissions, ep.Name)
+	}
+	// GetForUserResponse has no field for delegated permissions, they are granted as extra permissions are.
+	for _, dp := range resolved.DelegatedPermissions {
+		if dp.Permission.Condition != "" {
+			resp.ConditionalPermissions = append(resp.ConditionalPermissions, &userpermsv1.ConditionalPermission{
+				Permission: dp.Permission.Name,
+				Condition:  dp.Permission.Condition,
+			})
+			continue
+		}
+		resp.ExtraPermissions = append(resp.ExtraPermissions, dp.Permission.Name)
+	}
+	for _, r := range resolved.Resources {
+		resp.UserResources = append(resp.UserResources, resource(r))
+	}
+	for _, rg := range resolved.ResourceGrants {
+		grant := &userpermsv1.ResourceGrant{
+			Resource:   resource(rg.Resource),
+			Permission: rg.Permission.String(),
+			Condition:  rg.Permission.Condition,
+		}
+		if rg.InheritedFrom != nil {
+			grant.InheritedFrom = resource(*rg.InheritedFrom)
+		}
+		resp.ResourceGrants = append(resp.ResourceGrants, grant)
+	}
+
+	resp.RoleGraph = make(map[string]*userpermsv1.Role)
+	for name, role := range rego.NewRoleGraph(resolved.RoleMap) {
+		resp.RoleGraph[name] = &userpermsv1.Role{
+			Permissions: role.Permissions,
+			Wildcards:   role.Wildcards,
+			Conditions:  role.Conditions,
+			Inherits:    role.Inherits,
+		}
+	}
+	return resp
+}
+
+func roleAssignments(ras permissions.RoleAssignments) []*userpermsv1.RoleAssignment {
+	resp := make([]*userpermsv1.RoleAssignment, len(ras))
+	for i, ra := range ras {
+		resp[i] = &userpermsv1.RoleAssignment{
+			Role:   ra.Role.Name,
+			Source: string(ra.Source),
+			Via:    ra.Via,
+		}
+	}
+	return resp
+}
+
+func resource(r permissions.Resource) *userpermsv1.Resource {
+	return &userpermsv1.Resource{Type: r.Type, Id: r.ID}
+}
+
+func decision(d permissions.Decision, on *userpermsv1.Resource) *userpermsv1.Decision {
+	return &userpermsv1.Decision{
+		Permission: d.Permission,
+		Effect:     effect(d.Effect),
+		Condition:  d.Condition,
+		Resource:   on,
+	}
+}
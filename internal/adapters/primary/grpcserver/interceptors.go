@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataTenantID is the metadata key of the Tenant ID every call must carry.
+	MetadataTenantID = "x-tenant-id"
+	// MetadataUserID is the metadata key of the user ID every call must carry.
+	MetadataUserID = "x-user-id"
+)
+
+// identityInterceptor puts the Tenant and user from the call's metadata into its context.
+func identityInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tenantID := first(md.Get(MetadataTenantID))
+	if tenantID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "%s metadata is required", MetadataTenantID)
+	}
+	userID := first(md.Get(MetadataUserID))
+	if userID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "%s metadata is required", MetadataUserID)
+	}
+
+	ctx = contextkey.WithTenantID(ctx, tenantID)
+	ctx = contextkey.WithUserID(ctx, userID)
+	return handler(ctx, req)
+}
+
+// deadlineInterceptor gives calls without a deadline the default one, so that
+// a client which sets none cannot hold a database connection indefinitely.
+func deadlineInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// errorInterceptor maps the errors of calls to status codes, as api.NewError
+// maps them to HTTP statuses. Errors which are already a status are returned as
+// they are, other unexpected errors are logged and returned as Internal without
+// their detail.
+func errorInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return nil, err
+	}
+
+	message, _ := permissions.Message(err)
+	switch permissions.Kind(err) {
+	case permissions.ErrInvalidInput:
+		return nil, status.Error(codes.InvalidArgument, message)
+	case permissions.ErrNotFound:
+		return nil, status.Error(codes.NotFound, message)
+	case permissions.ErrForbidden:
+		return nil, status.Error(codes.PermissionDenied, message)
+	case permissions.ErrUnavailable:
+		return nil, status.Error(codes.Unavailable, message)
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return nil, status.Error(codes.Canceled, "canceled")
+	default:
+		slog.ErrorContext(ctx, "grpc call failed", "method", info.FullMethod, "error", err.Error())
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
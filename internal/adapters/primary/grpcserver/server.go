@@ -0,0 +1,132 @@
+// Package grpcserver serves the user permissions API over gRPC, see proto/userperms/v1.
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	userpermsv1 "github.com/Equineregister/user-permissions-service/pkg/pb/userperms/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultTimeout is the deadline given to calls which arrive without one.
+const DefaultTimeout = 5 * time.Second
+
+// New returns a gRPC server for the service, with the interceptors which set the
+// Tenant and user, apply the default deadline, and map errors to status codes.
+func New(service *permissions.Service, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		errorInterceptor,
+		deadlineInterceptor(DefaultTimeout),
+		identityInterceptor,
+	))
+	s := grpc.NewServer(opts...)
+	userpermsv1.RegisterPermissionsServiceServer(s, &server{service: service})
+	return s
+}
+
+type server struct {
+	userpermsv1.UnimplementedPermissionsServiceServer
+	service *permissions.Service
+}
+
+func (s *server) GetForUser(ctx context.Context, req *userpermsv1.GetForUserRequest) (*userpermsv1.GetForUserResponse, error) {
+	forUser, err := s.service.GetForUser(ctx, req.GetResources())
+	if err != nil {
+		return nil, err
+	}
+	return getForUserResponse(ctx, forUser, attributes(req.GetAttributes())), nil
+}
+
+func (s *server) Check(ctx context.Context, req *userpermsv1.CheckRequest) (*userpermsv1.CheckResponse, error) {
+	if req.GetPermission() == "" {
+		return nil, status.Error(codes.InvalidArgument, "permission is required")
+	}
+	forUser, err := s.service.GetForUser(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &userpermsv1.CheckResponse{
+		Decision: check(forUser, req.GetPermission(), req.GetResource(), attributes(req.GetAttributes())),
+	}, nil
+}
+
+func (s *server) BatchCheck(ctx context.Context, req *userpermsv1.BatchCheckRequest) (*userpermsv1.BatchCheckResponse, error) {
+	for i, c := range req.GetChecks() {
+		if c.GetPermission() == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "checks[%d]: permission is required", i)
+		}
+	}
+	forUser, err := s.service.GetForUser(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	attrs := attributes(req.GetAttributes())
+	resp := &userpermsv1.BatchCheckResponse{Decisions: make([]*userpermsv1.Decision, len(req.GetChecks()))}
+	for i, c := range req.GetChecks() {
+		resp.Decisions[i] = check(forUser, c.GetPermission(), c.GetResource(), attrs)
+	}
+	return resp, nil
+}
+
+func (s *server) ListPermissions(ctx context.Context, req *userpermsv1.ListPermissionsRequest) (*userpermsv1.ListPermissionsResponse, error) {
+	decisions, err := s.service.ListPermissions(ctx, req.GetResources(), attributes(req.GetAttributes()))
+	if err != nil {
+		return nil, err
+	}
+	resp := &userpermsv1.ListPermissionsResponse{}
+	for _, d := range decisions {
+		if d.Effect == permissions.EffectConditional {
+			resp.ConditionalPermissions = append(resp.ConditionalPermissions, &userpermsv1.ConditionalPermission{
+				Permission: d.Permission,
+				Condition:  d.Condition,
+			})
+			continue
+		}
+		resp.Permissions = append(resp.Permissions, d.Permission)
+	}
+	return resp, nil
+}
+
+func (s *server) ListRoles(ctx context.Context, _ *userpermsv1.ListRolesRequest) (*userpermsv1.ListRolesResponse, error) {
+	assignments, err := s.service.GetRoleAssignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &userpermsv1.ListRolesResponse{RoleAssignments: roleAssignments(assignments)}, nil
+}
+
+func check(forUser *permissions.ForUser, permission string, resource *userpermsv1.Resource, attrs condition.Attributes) *userpermsv1.Decision {
+	if resource == nil {
+		return decision(forUser.Decide(permission, attrs), nil)
+	}
+	r := permissions.Resource{ID: resource.GetId(), Type: resource.GetType()}
+	return decision(forUser.DecideOn(permission, r, attrs), resource)
+}
+
+// attributes converts the request's attributes, numbers are float64 which conditions compare as any other number.
+func attributes(s *structpb.Struct) condition.Attributes {
+	if s == nil {
+		return nil
+	}
+	return condition.Attributes(s.AsMap())
+}
+
+func effect(e permissions.Effect) userpermsv1.Effect {
+	switch e {
+	case permissions.EffectAllow:
+		return userpermsv1.Effect_EFFECT_ALLOW
+	case permissions.EffectDeny:
+		return userpermsv1.Effect_EFFECT_DENY
+	case permissions.EffectConditional:
+		return userpermsv1.Effect_EFFECT_CONDITIONAL
+	default:
+		panic(fmt.Sprintf("unknown effect %q", e))
+	}
+}
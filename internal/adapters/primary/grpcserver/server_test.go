@@ -0,0 +1,193 @@
+package grpcserver_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/grpcserver"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	userpermsv1 "github.com/Equineregister/user-permissions-service/pkg/pb/userperms/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// stubRepo returns a fixed role map and assignments, the methods GetForUser and
+// ListPermissions do not use are left nil.
+type stubRepo struct {
+	permissions.ReaderWriter
+	roleMap permissions.TenantRoleMap
+	roles   permissions.RoleAssignments
+}
+
+func (r *stubRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return r.roleMap, nil
+}
+
+func (r *stubRepo) GetTenantPermissions(context.Context, []string) (permissions.TenantPermissions, error) {
+	return permissions.TenantPermissions{
+		{Name: "invoices:read"},
+		{Name: "invoices:approve"},
+		{Name: "invoices:delete"},
+		{Name: "invoices:*"},
+	}, nil
+}
+
+func (r *stubRepo) GetUnknownResourceTypes(_ context.Context, names []string) ([]string, error) {
+	var unknown []string
+	for _, name := range names {
+		if name != "invoices" {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown, nil
+}
+
+func (r *stubRepo) GetUserPermissionsExtraAndRevoked(context.Context, []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	return nil, nil, nil
+}
+
+func (r *stubRepo) GetUserRoleAssignments(context.Context) (permissions.RoleAssignments, error) {
+	return r.roles, nil
+}
+
+func (r *stubRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (r *stubRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return nil, nil
+}
+
+func (r *stubRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return nil, nil
+}
+
+func (r *stubRepo) GetUserDelegations(context.Context) (permissions.Delegations, error) {
+	return nil, nil
+}
+
+func newClient(t *testing.T) userpermsv1.PermissionsServiceClient {
+	t.Helper()
+	clerk := permissions.Role{Name: "clerk", ID: "1"}
+	repo := &stubRepo{
+		roleMap: permissions.TenantRoleMap{clerk: {Permissions: permissions.TenantPermissions{
+			{Name: "invoices:read"},
+			{Name: "invoices:approve", Condition: "invoice.amount < 10000"},
+		}}},
+		roles: permissions.RoleAssignments{{Role: clerk, Source: permissions.RoleSourceDirect}},
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcserver.New(permissions.NewService(repo))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return userpermsv1.NewPermissionsServiceClient(conn)
+}
+
+func withIdentity(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, grpcserver.MetadataTenantID, "test", grpcserver.MetadataUserID, "652f4d18-dd3d-40c0-874e-cbe3566abccf")
+}
+
+func TestIdentityRequired(t *testing.T) {
+	client := newClient(t)
+
+	_, err := client.ListRoles(context.Background(), &userpermsv1.ListRolesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcserver.MetadataTenantID, "test")
+	_, err = client.ListRoles(ctx, &userpermsv1.ListRolesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestErrors(t *testing.T) {
+	client := newClient(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcserver.MetadataTenantID, "test", grpcserver.MetadataUserID, "u1")
+	_, err := client.GetForUser(ctx, &userpermsv1.GetForUserRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, `user ID "u1" is not a UUID`, status.Convert(err).Message())
+
+	_, err = client.GetForUser(withIdentity(context.Background()), &userpermsv1.GetForUserRequest{Resources: []string{"invoices", "unknown"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "unknown resource types: unknown", status.Convert(err).Message())
+}
+
+func TestGetForUser(t *testing.T) {
+	client := newClient(t)
+
+	resp, err := client.GetForUser(withIdentity(context.Background()), &userpermsv1.GetForUserRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "test", resp.GetTenantId())
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", resp.GetUserId())
+	assert.Equal(t, []string{"clerk"}, resp.GetRoles())
+	assert.NotEmpty(t, resp.GetRoleMapVersion())
+	assert.Contains(t, resp.GetRoleGraph(), "clerk")
+}
+
+func TestCheck(t *testing.T) {
+	client := newClient(t)
+	ctx := withIdentity(context.Background())
+
+	resp, err := client.Check(ctx, &userpermsv1.CheckRequest{Permission: "invoices:read"})
+	require.NoError(t, err)
+	assert.Equal(t, userpermsv1.Effect_EFFECT_ALLOW, resp.GetDecision().GetEffect())
+
+	_, err = client.Check(ctx, &userpermsv1.CheckRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBatchCheck(t *testing.T) {
+	client := newClient(t)
+	attrs, err := structpb.NewStruct(map[string]any{"invoice": map[string]any{"amount": 500}})
+	require.NoError(t, err)
+
+	resp, err := client.BatchCheck(withIdentity(context.Background()), &userpermsv1.BatchCheckRequest{
+		Checks: []*userpermsv1.BatchCheckRequest_Item{
+			{Permission: "invoices:read"},
+			{Permission: "invoices:approve"},
+			{Permission: "invoices:delete"},
+		},
+		Attributes: attrs,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetDecisions(), 3)
+	assert.Equal(t, userpermsv1.Effect_EFFECT_ALLOW, resp.GetDecisions()[0].GetEffect())
+	assert.Equal(t, userpermsv1.Effect_EFFECT_ALLOW, resp.GetDecisions()[1].GetEffect())
+	assert.Equal(t, userpermsv1.Effect_EFFECT_DENY, resp.GetDecisions()[2].GetEffect())
+}
+
+func TestListPermissions(t *testing.T) {
+	client := newClient(t)
+
+	resp, err := client.ListPermissions(withIdentity(context.Background()), &userpermsv1.ListPermissionsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"invoices:read"}, resp.GetPermissions())
+	require.Len(t, resp.GetConditionalPermissions(), 1)
+	assert.Equal(t, "invoices:approve", resp.GetConditionalPermissions()[0].GetPermission())
+	assert.Equal(t, "invoice.amount < 10000", resp.GetConditionalPermissions()[0].GetCondition())
+}
+
+func TestListRoles(t *testing.T) {
+	client := newClient(t)
+
+	resp, err := client.ListRoles(withIdentity(context.Background()), &userpermsv1.ListRolesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetRoleAssignments(), 1)
+	assert.Equal(t, "clerk", resp.GetRoleAssignments()[0].GetRole())
+	assert.Equal(t, string(permissions.RoleSourceDirect), resp.GetRoleAssignments()[0].GetSource())
+}
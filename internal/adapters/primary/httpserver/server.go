@@ -0,0 +1,201 @@
+// Package httpserver serves the user permissions API over HTTP.
+//
+//	GET /tenants/{tenantId}/users/{userId}/permissions
+//
+// returns the api.Response for the user. The query takes the rest of the api.Request:
+//
+//	resource   a resource to include, repeated for each
+//	check      a permission to decide, repeated for each
+//	explain    a permission to explain
+//	activeRole a role active in the request, repeated for each, see api.Request.ActiveRoles
+//	attr.<key> an attribute for conditions, e.g. attr.request.hour=10. The value
+//	           is read as JSON when it is a number, boolean or quoted string.
+//
+// The response has an ETag header, and a request whose If-None-Match header
+// matches it is answered 304 Not Modified without a body.
+//
+//	POST /tenants/{tenantId}/users/{userId}/token
+//
+// issues a signed permission token for the user, taking the resource, activeRole
+// and attr.<key> query parameters as above, and returns the api.Token.
+//
+//	GET /.well-known/jwks.json
+//
+// returns the JWKS tokens are verified with. Both answer 501 Not Implemented
+// when tokens are not issued.
+//
+// NewAuthorized serves the same API for callers already identified by an
+// authorizer in front of it, without the Tenant and user in the path:
+//
+//	GET  /permissions
+//	POST /token
+//	GET  /.well-known/jwks.json
+package httpserver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+)
+
+const attributePrefix = "attr."
+
+// New returns the HTTP handler for the API.
+func New(h *api.Handler) http.Handler {
+	s := &server{handler: h, identity: pathIdentity}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /tenants/{tenantId}/users/{userId}/permissions", s.getPermissions)
+	mux.HandleFunc("POST /tenants/{tenantId}/users/{userId}/token", s.issueToken)
+	mux.HandleFunc("GET /.well-known/jwks.json", s.jwks)
+	return mux
+}
+
+// NewAuthorized returns the HTTP handler for the API whose Tenant and user are
+// those in the request's context, see contextkey. Requests without both are
+// answered 401 Unauthorized.
+func NewAuthorized(h *api.Handler) http.Handler {
+	s := &server{handler: h, identity: contextIdentity}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /permissions", s.getPermissions)
+	mux.HandleFunc("POST /token", s.issueToken)
+	mux.HandleFunc("GET /.well-known/jwks.json", s.jwks)
+	return mux
+}
+
+type server struct {
+	handler *api.Handler
+	// identity returns the Tenant and user a request is for, ok is false when it names neither.
+	identity func(r *http.Request) (tenantID, userID string, ok bool)
+}
+
+func pathIdentity(r *http.Request) (string, string, bool) {
+	return r.PathValue("tenantId"), r.PathValue("userId"), true
+}
+
+func contextIdentity(r *http.Request) (string, string, bool) {
+	tenantID, _ := contextkey.TenantID(r.Context())
+	userID, _ := contextkey.UserID(r.Context())
+	return tenantID, userID, tenantID != "" && userID != ""
+}
+
+func (s *server) healthz(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *server) getPermissions(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := s.identity(r)
+	if !ok {
+		writeError(w, api.Error{Code: api.CodeUnauthorized, Message: "tenant and user are required", Status: http.StatusUnauthorized})
+		return
+	}
+	request := api.Request{
+		TenantID:    tenantID,
+		UserID:      userID,
+		Resources:   r.URL.Query()["resource"],
+		Check:       r.URL.Query()["check"],
+		Explain:     r.URL.Query().Get("explain"),
+		Attributes:  attributes(r.URL.Query()),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+		ActiveRoles: r.URL.Query()["activeRole"],
+	}
+
+	resp, err := s.handler.Handle(r.Context(), request)
+	if err != nil {
+		writeError(w, api.NewError(err))
+		return
+	}
+
+	w.Header().Set("ETag", `"`+resp.ETag+`"`)
+	// Clients may cache the response, but must revalidate it before each use.
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if resp.NotModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) issueToken(w http.ResponseWriter, r *http.Request) {
+	tenantID, userID, ok := s.identity(r)
+	if !ok {
+		writeError(w, api.Error{Code: api.CodeUnauthorized, Message: "tenant and user are required", Status: http.StatusUnauthorized})
+		return
+	}
+	request := api.Request{
+		TenantID:    tenantID,
+		UserID:      userID,
+		Resources:   r.URL.Query()["resource"],
+		Attributes:  attributes(r.URL.Query()),
+		IssueToken:  true,
+		ActiveRoles: r.URL.Query()["activeRole"],
+	}
+
+	resp, err := s.handler.Handle(r.Context(), request)
+	if err != nil {
+		writeError(w, api.NewError(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp.Token)
+}
+
+func (s *server) jwks(w http.ResponseWriter, _ *http.Request) {
+	jwks, ok := s.handler.JWKS()
+	if !ok {
+		writeError(w, api.NewError(api.ErrTokensDisabled))
+		return
+	}
+	// Verifiers may cache the keys briefly, a new key is published before it signs.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, jwks)
+}
+
+// attributes reads the attr.<key> query parameters, the last value is used for repeated keys.
+func attributes(query map[string][]string) condition.Attributes {
+	var attrs condition.Attributes
+	for key, values := range query {
+		name, ok := strings.CutPrefix(key, attributePrefix)
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if attrs == nil {
+			attrs = make(condition.Attributes)
+		}
+		attrs[name] = attributeValue(values[len(values)-1])
+	}
+	return attrs
+}
+
+// attributeValue reads a number, boolean or quoted string as JSON, anything else is a plain string.
+func attributeValue(s string) any {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	switch v.(type) {
+	case float64, bool, string:
+		return v
+	default:
+		return s
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error writing response", "error", err.Error())
+	}
+}
+
+// writeError writes the error as {"error": message, "code": code}.
+func writeError(w http.ResponseWriter, e api.Error) {
+	writeJSON(w, e.Status, map[string]string{"error": e.Message, "code": e.Code})
+}
@@ -0,0 +1,137 @@
+package httpserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/httpserver"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/tokens"
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo returns a clerk who may read invoices during office hours, the
+// methods GetForUser does not use are left nil.
+type stubRepo struct {
+	permissions.ReaderWriter
+}
+
+var clerk = permissions.Role{Name: "clerk", ID: "1"}
+
+func (stubRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return permissions.TenantRoleMap{clerk: {Permissions: permissions.TenantPermissions{{Name: "invoices:read", Condition: "request.hour < 17"}}}}, nil
+}
+
+func (stubRepo) GetUserPermissionsExtraAndRevoked(context.Context, []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	return nil, nil, nil
+}
+
+func (stubRepo) GetUserRoleAssignments(context.Context) (permissions.RoleAssignments, error) {
+	return permissions.RoleAssignments{{Role: clerk, Source: permissions.RoleSourceDirect}}, nil
+}
+
+func (stubRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetUserDelegations(context.Context) (permissions.Delegations, error) {
+	return nil, nil
+}
+
+func TestGetPermissions(t *testing.T) {
+	srv := httptest.NewServer(httpserver.New(api.NewHandler(permissions.NewService(stubRepo{}), nil)))
+	defer srv.Close()
+	url := srv.URL + "/tenants/test/users/652f4d18-dd3d-40c0-874e-cbe3566abccf/permissions?check=invoices:read&attr.request.hour=10"
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body api.Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", body.UserID)
+	require.Len(t, body.Decisions, 1)
+	assert.Equal(t, "allow", body.Decisions[0].Effect, "attr.request.hour is read as a number")
+	etag := resp.Header.Get("ETag")
+	assert.Equal(t, `"`+body.ETag+`"`, etag)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, etag, resp.Header.Get("ETag"))
+
+	// Other attributes are a different response.
+	req, err = http.NewRequest(http.MethodGet, srv.URL+"/tenants/test/users/652f4d18-dd3d-40c0-874e-cbe3566abccf/permissions?check=invoices:read&attr.request.hour=20", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestIssueToken(t *testing.T) {
+	key, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	repo := tokenRepo{}
+	h := api.NewHandler(permissions.NewService(repo), tokens.NewService(repo, permtoken.KeySet{key}, "", 0))
+	srv := httptest.NewServer(httpserver.New(h))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/.well-known/jwks.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var jwks permtoken.JWKS
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+
+	resp, err = http.Post(srv.URL+"/tenants/test/users/652f4d18-dd3d-40c0-874e-cbe3566abccf/token?attr.request.hour=10", "", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var token api.Token
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+
+	claims, err := permtoken.NewVerifier(jwks, tokens.DefaultIssuer, 0).Verify(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", claims.UserID())
+	assert.True(t, claims.Allows("invoices:read"))
+}
+
+func TestIssueToken_Disabled(t *testing.T) {
+	srv := httptest.NewServer(httpserver.New(api.NewHandler(permissions.NewService(stubRepo{}), nil)))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/tenants/test/users/652f4d18-dd3d-40c0-874e-cbe3566abccf/token", "", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+// tokenRepo adds the tenant's permissions to stubRepo, for issuing tokens.
+type tokenRepo struct {
+	stubRepo
+}
+
+func (tokenRepo) GetTenantPermissions(context.Context, []string) (permissions.TenantPermissions, error) {
+	return permissions.TenantPermissions{{Name: "invoices:read"}, {Name: "invoices:delete"}}, nil
+}
@@ -0,0 +1,123 @@
+// Package lambdaevent serves the Lambda's events. An event is one of
+//
+//   - an api.Request, from a direct invoke, answered with an api.Response
+//   - an API Gateway REST API proxy event, payload version 1.0
+//   - an API Gateway HTTP API event, payload version 2.0
+//   - a Function URL event, which has the 2.0 payload
+//
+// The HTTP events are served by httpserver.NewAuthorized, so the routes and
+// responses are those of the HTTP server without the Tenant and user in the
+// path. Both are taken from the claims of the authorizer in front of the Lambda,
+// a JWT or Cognito authorizer's claims or a Lambda authorizer's context, never
+// from the request. Function URLs have no such authorizer, so through them only
+// the routes which need no user are served, the others answer 401 Unauthorized.
+//
+// Errors, including unknown routes, have a JSON body {"error": "...", "code": "..."},
+// and CORS preflight requests are answered for the configured origins. The
+// errors of direct invokes are returned as the function's error, whose
+// errorType is the code, see invokeError.
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/httpserver"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+// Default claims the Tenant and user are read from.
+const (
+	DefaultTenantClaim = "custom:tenantId"
+	DefaultUserClaim   = "sub"
+)
+
+// Config configures the serving of HTTP events.
+type Config struct {
+	// TenantClaim and UserClaim name the authorizer claims holding the Tenant
+	// and user IDs, DefaultTenantClaim and DefaultUserClaim when empty.
+	TenantClaim string
+	UserClaim   string
+	// AllowedOrigins are the origins browsers may call the API from, "*" allows
+	// any. CORS headers are not sent when empty.
+	AllowedOrigins []string
+}
+
+func (c Config) withDefaults() Config {
+	if c.TenantClaim == "" {
+		c.TenantClaim = DefaultTenantClaim
+	}
+	if c.UserClaim == "" {
+		c.UserClaim = DefaultUserClaim
+	}
+	return c
+}
+
+// Handler serves the Lambda's events.
+type Handler struct {
+	api    *api.Handler
+	http   http.Handler
+	config Config
+}
+
+// NewHandler creates a Handler serving events with h.
+func NewHandler(h *api.Handler, config Config) *Handler {
+	return &Handler{api: h, http: httpserver.NewAuthorized(h), config: config.withDefaults()}
+}
+
+// Handle serves the event, see the package documentation for the kinds of event.
+func (h *Handler) Handle(ctx context.Context, event json.RawMessage) (any, error) {
+	var probe struct {
+		Version        string `json:"version"`
+		HTTPMethod     string `json:"httpMethod"`
+		RequestContext *struct {
+			HTTP struct {
+				Method string `json:"method"`
+			} `json:"http"`
+		} `json:"requestContext"`
+	}
+	if err := json.Unmarshal(event, &probe); err != nil {
+		return nil, fmt.Errorf("unmarshal event: %w", err)
+	}
+
+	switch {
+	case probe.RequestContext != nil && probe.HTTPMethod != "":
+		var e events.APIGatewayProxyRequest
+		if err := json.Unmarshal(event, &e); err != nil {
+			return nil, fmt.Errorf("unmarshal API Gateway proxy event: %w", err)
+		}
+		return h.serveV1(ctx, e)
+
+	case probe.RequestContext != nil && probe.Version == "2.0" && probe.RequestContext.HTTP.Method != "":
+		var e events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(event, &e); err != nil {
+			return nil, fmt.Errorf("unmarshal API Gateway HTTP event: %w", err)
+		}
+		return h.serveV2(ctx, e)
+
+	default:
+		var request api.Request
+		if err := json.Unmarshal(event, &request); err != nil {
+			return nil, invokeError(permissions.WrapError(permissions.ErrInvalidInput, err, "request is not valid JSON"))
+		}
+		resp, err := h.api.Handle(ctx, request)
+		if err != nil {
+			return nil, invokeError(err)
+		}
+		return resp, nil
+	}
+}
+
+// invokeError is the error of a direct invoke, its errorType is the code of the
+// api.Error and its errorMessage the message, e.g.
+//
+//	{"errorType": "InvalidInput", "errorMessage": "user ID \"u1\" is not a UUID"}
+func invokeError(err error) error {
+	e := api.NewError(err)
+	return messages.InvokeResponse_Error{Type: e.Code, Message: e.Message}
+}
@@ -0,0 +1,199 @@
+package lambdaevent_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/lambdaevent"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo returns a clerk who may read invoices, the methods GetForUser does not use are left nil.
+type stubRepo struct {
+	permissions.ReaderWriter
+}
+
+var clerk = permissions.Role{Name: "clerk", ID: "1"}
+
+func (stubRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return permissions.TenantRoleMap{clerk: {Permissions: permissions.TenantPermissions{{Name: "invoices:read"}}}}, nil
+}
+
+func (stubRepo) GetUserPermissionsExtraAndRevoked(context.Context, []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	return nil, nil, nil
+}
+
+func (stubRepo) GetUserRoleAssignments(context.Context) (permissions.RoleAssignments, error) {
+	return permissions.RoleAssignments{{Role: clerk, Source: permissions.RoleSourceDirect}}, nil
+}
+
+func (stubRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetUserDelegations(context.Context) (permissions.Delegations, error) {
+	return nil, nil
+}
+
+func newHandler() *lambdaevent.Handler {
+	return lambdaevent.NewHandler(api.NewHandler(permissions.NewService(stubRepo{}), nil), lambdaevent.Config{
+		AllowedOrigins: []string{"https://app.example.com"},
+	})
+}
+
+func handle(t *testing.T, h *lambdaevent.Handler, event any) any {
+	t.Helper()
+	b, err := json.Marshal(event)
+	require.NoError(t, err)
+	resp, err := h.Handle(context.Background(), b)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestHandle_DirectInvoke(t *testing.T) {
+	resp := handle(t, newHandler(), api.Request{TenantID: "test", UserID: "652f4d18-dd3d-40c0-874e-cbe3566abccf", Check: []string{"invoices:read"}})
+
+	require.IsType(t, api.Response{}, resp)
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", resp.(api.Response).UserID)
+	assert.Equal(t, "allow", resp.(api.Response).Decisions[0].Effect)
+}
+
+func TestHandle_DirectInvokeError(t *testing.T) {
+	b, err := json.Marshal(api.Request{TenantID: "test", UserID: "u1"})
+	require.NoError(t, err)
+
+	_, err = newHandler().Handle(context.Background(), b)
+	assert.Equal(t, messages.InvokeResponse_Error{Type: api.CodeInvalidInput, Message: `user ID "u1" is not a UUID`}, err)
+}
+
+func TestHandle_APIGatewayV1(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		HTTPMethod:                      http.MethodGet,
+		Path:                            "/permissions",
+		MultiValueQueryStringParameters: map[string][]string{"check": {"invoices:read"}},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Authorizer: map[string]any{"claims": map[string]any{"sub": "652f4d18-dd3d-40c0-874e-cbe3566abccf", "custom:tenantId": "test"}},
+		},
+	}
+	resp := handle(t, newHandler(), event)
+
+	require.IsType(t, events.APIGatewayProxyResponse{}, resp)
+	v1 := resp.(events.APIGatewayProxyResponse)
+	require.Equal(t, http.StatusOK, v1.StatusCode, v1.Body)
+	var body api.Response
+	require.NoError(t, json.Unmarshal([]byte(v1.Body), &body))
+	assert.Equal(t, "test", body.TenantID)
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", body.UserID)
+	assert.Equal(t, "allow", body.Decisions[0].Effect)
+	assert.NotEmpty(t, v1.MultiValueHeaders["Etag"])
+}
+
+func TestHandle_APIGatewayV2(t *testing.T) {
+	event := events.APIGatewayV2HTTPRequest{
+		Version:        "2.0",
+		RawPath:        "/prod/permissions",
+		RawQueryString: "check=invoices:read",
+		Headers:        map[string]string{"origin": "https://app.example.com"},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			Stage: "prod",
+			HTTP:  events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet},
+			Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: map[string]string{"sub": "652f4d18-dd3d-40c0-874e-cbe3566abccf", "custom:tenantId": "test"},
+				},
+			},
+		},
+	}
+	resp := handle(t, newHandler(), event)
+
+	require.IsType(t, events.APIGatewayV2HTTPResponse{}, resp)
+	v2 := resp.(events.APIGatewayV2HTTPResponse)
+	require.Equal(t, http.StatusOK, v2.StatusCode, v2.Body)
+	assert.Equal(t, "https://app.example.com", v2.Headers["Access-Control-Allow-Origin"])
+	var body api.Response
+	require.NoError(t, json.Unmarshal([]byte(v2.Body), &body))
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", body.UserID)
+}
+
+func TestHandle_Errors(t *testing.T) {
+	h := newHandler()
+	request := func(method, target string, claims map[string]string) events.APIGatewayV2HTTPResponse {
+		path, query, _ := strings.Cut(target, "?")
+		event := events.APIGatewayV2HTTPRequest{
+			Version:        "2.0",
+			RawPath:        path,
+			RawQueryString: query,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: method},
+			},
+		}
+		if claims != nil {
+			event.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{Claims: claims},
+			}
+		}
+		return handle(t, h, event).(events.APIGatewayV2HTTPResponse)
+	}
+
+	// A Function URL has no authorizer, nor a tenant in the claims.
+	for _, claims := range []map[string]string{nil, {"sub": "652f4d18-dd3d-40c0-874e-cbe3566abccf"}} {
+		resp := request(http.MethodGet, "/permissions", claims)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.JSONEq(t, `{"error": "tenant and user are required", "code": "Unauthorized"}`, resp.Body)
+	}
+
+	resp := request(http.MethodGet, "/unknown", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Headers["Content-Type"])
+	assert.JSONEq(t, `{"error": "Not Found", "code": "NotFound"}`, resp.Body)
+
+	resp = request(http.MethodDelete, "/permissions", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.JSONEq(t, `{"error": "Method Not Allowed", "code": "MethodNotAllowed"}`, resp.Body)
+
+	resp = request(http.MethodGet, "/permissions?resource=in%20voices", map[string]string{"sub": "652f4d18-dd3d-40c0-874e-cbe3566abccf", "custom:tenantId": "test"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.JSONEq(t, `{"error": "resource type \"in voices\" is not valid", "code": "InvalidInput"}`, resp.Body)
+
+	resp = request(http.MethodGet, "/healthz", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHandle_CORSPreflight(t *testing.T) {
+	h := newHandler()
+	preflight := func(origin string) events.APIGatewayV2HTTPResponse {
+		return handle(t, h, events.APIGatewayV2HTTPRequest{
+			Version: "2.0",
+			RawPath: "/permissions",
+			Headers: map[string]string{"origin": origin, "access-control-request-method": "GET"},
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodOptions},
+			},
+		}).(events.APIGatewayV2HTTPResponse)
+	}
+
+	resp := preflight("https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "https://app.example.com", resp.Headers["Access-Control-Allow-Origin"])
+	assert.Contains(t, resp.Headers["Access-Control-Allow-Headers"], "If-None-Match")
+
+	resp = preflight("https://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, resp.Headers["Access-Control-Allow-Origin"])
+}
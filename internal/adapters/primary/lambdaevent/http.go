@@ -0,0 +1,219 @@
+package lambdaevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *Handler) serveV1(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	query := url.Values(e.MultiValueQueryStringParameters)
+	if len(query) == 0 {
+		query = make(url.Values)
+		for k, v := range e.QueryStringParameters {
+			query.Set(k, v)
+		}
+	}
+	header := http.Header{}
+	for k, vs := range e.MultiValueHeaders {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+	if len(e.MultiValueHeaders) == 0 {
+		for k, v := range e.Headers {
+			header.Set(k, v)
+		}
+	}
+
+	ctx = withClaims(ctx, v1Claims(e.RequestContext.Authorizer), h.config)
+	r, err := newRequest(ctx, e.HTTPMethod, e.Path, query.Encode(), header, e.Body, e.IsBase64Encoded)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	rec := h.serve(r)
+	return events.APIGatewayProxyResponse{
+		StatusCode:        rec.status,
+		MultiValueHeaders: rec.header,
+		Body:              rec.body.String(),
+	}, nil
+}
+
+func (h *Handler) serveV2(ctx context.Context, e events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	header := http.Header{}
+	for k, v := range e.Headers {
+		header.Set(k, v)
+	}
+	if len(e.Cookies) > 0 {
+		header.Set("Cookie", strings.Join(e.Cookies, "; "))
+	}
+
+	// Outside the $default stage the path starts with the stage's name.
+	path := e.RawPath
+	if stage := e.RequestContext.Stage; stage != "" && stage != "$default" {
+		path = strings.TrimPrefix(path, "/"+stage)
+	}
+
+	ctx = withClaims(ctx, v2Claims(e.RequestContext.Authorizer), h.config)
+	r, err := newRequest(ctx, e.RequestContext.HTTP.Method, path, e.RawQueryString, header, e.Body, e.IsBase64Encoded)
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, err
+	}
+	rec := h.serve(r)
+	resp := events.APIGatewayV2HTTPResponse{
+		StatusCode: rec.status,
+		Headers:    make(map[string]string, len(rec.header)),
+		Body:       rec.body.String(),
+	}
+	for k, vs := range rec.header {
+		resp.Headers[k] = strings.Join(vs, ",")
+	}
+	return resp, nil
+}
+
+func newRequest(ctx context.Context, method, path, rawQuery string, header http.Header, body string, isBase64 bool) (*http.Request, error) {
+	b := []byte(body)
+	if isBase64 {
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return nil, fmt.Errorf("decode body: %w", err)
+		}
+	}
+	if path == "" {
+		path = "/"
+	}
+	r, err := http.NewRequestWithContext(ctx, method, (&url.URL{Path: path, RawQuery: rawQuery}).String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	r.Header = header
+	return r, nil
+}
+
+// serve serves the request with the HTTP server, answering CORS preflight
+// requests itself and writing errors without a JSON body as JSON.
+func (h *Handler) serve(r *http.Request) *recorder {
+	rec := &recorder{header: http.Header{}}
+	origin := r.Header.Get("Origin")
+	allowed := origin != "" && h.allowsOrigin(origin)
+	if allowed {
+		rec.header.Set("Access-Control-Allow-Origin", origin)
+		rec.header.Set("Access-Control-Expose-Headers", "ETag")
+		rec.header.Add("Vary", "Origin")
+	}
+
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		if !allowed {
+			writeError(rec, http.StatusForbidden, "origin not allowed")
+			return rec
+		}
+		rec.header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		rec.header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match")
+		rec.header.Set("Access-Control-Max-Age", "600")
+		rec.WriteHeader(http.StatusNoContent)
+		return rec
+	}
+
+	h.http.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.status >= 400 && !isJSON(rec.header.Get("Content-Type")) {
+		rec.body.Reset()
+		writeJSONError(rec, rec.status, http.StatusText(rec.status))
+	}
+	return rec
+}
+
+func (h *Handler) allowsOrigin(origin string) bool {
+	return slices.Contains(h.config.AllowedOrigins, "*") || slices.Contains(h.config.AllowedOrigins, origin)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json"
+}
+
+func writeError(rec *recorder, status int, message string) {
+	rec.WriteHeader(status)
+	writeJSONError(rec, status, message)
+}
+
+// writeJSONError writes the body of an error as httpserver does, the code is
+// the status text without spaces, e.g. "NotFound".
+func writeJSONError(rec *recorder, status int, message string) {
+	rec.header.Set("Content-Type", "application/json")
+	body := map[string]string{"error": message, "code": strings.ReplaceAll(http.StatusText(status), " ", "")}
+	if err := json.NewEncoder(&rec.body).Encode(body); err != nil {
+		slog.Error("error writing response", "error", err.Error())
+	}
+}
+
+// recorder is the http.ResponseWriter the HTTP server writes the response of an event to.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+// v1Claims returns the claims of a Cognito authorizer, or a Lambda authorizer's context.
+func v1Claims(authorizer map[string]any) map[string]any {
+	if claims, ok := authorizer["claims"].(map[string]any); ok {
+		return claims
+	}
+	return authorizer
+}
+
+// v2Claims returns the claims of a JWT authorizer, or a Lambda authorizer's context.
+func v2Claims(authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription) map[string]any {
+	switch {
+	case authorizer == nil:
+		return nil
+	case authorizer.JWT != nil:
+		claims := make(map[string]any, len(authorizer.JWT.Claims))
+		for k, v := range authorizer.JWT.Claims {
+			claims[k] = v
+		}
+		return claims
+	default:
+		return authorizer.Lambda
+	}
+}
+
+// withClaims puts the Tenant and user in the claims into the context, those
+// which are missing or not strings are left out.
+func withClaims(ctx context.Context, claims map[string]any, config Config) context.Context {
+	if tenantID, ok := claims[config.TenantClaim].(string); ok && tenantID != "" {
+		ctx = contextkey.WithTenantID(ctx, tenantID)
+	}
+	if userID, ok := claims[config.UserClaim].(string); ok && userID != "" {
+		ctx = contextkey.WithUserID(ctx, userID)
+	}
+	return ctx
+}
@@ -0,0 +1,187 @@
+package scimserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+)
+
+// The schema URNs of RFC 7643 and RFC 7644.
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	schemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type name struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type email struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// userResource is a User as SCIM sends and receives it, with the attributes the service keeps.
+type userResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	Name        *name    `json:"name,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Emails      []email  `json:"emails,omitempty"`
+	// Active is true when it is absent from a request.
+	Active *bool `json:"active"`
+	Meta   *meta `json:"meta,omitempty"`
+}
+
+func (u userResource) user() scim.User {
+	user := scim.User{
+		UserName:    u.UserName,
+		ExternalID:  u.ExternalID,
+		DisplayName: u.DisplayName,
+		Active:      u.Active == nil || *u.Active,
+	}
+	if u.Name != nil {
+		user.GivenName, user.FamilyName = u.Name.GivenName, u.Name.FamilyName
+	}
+	// Only the primary email is kept, or the first when none is.
+	for i, e := range u.Emails {
+		if i == 0 || e.Primary {
+			user.Email = e.Value
+		}
+		if e.Primary {
+			break
+		}
+	}
+	return user
+}
+
+func (s *server) userResource(r *http.Request, u scim.User) userResource {
+	resource := userResource{
+		Schemas:     []string{schemaUser},
+		ID:          u.ID,
+		ExternalID:  u.ExternalID,
+		UserName:    u.UserName,
+		DisplayName: u.DisplayName,
+		Active:      &u.Active,
+		Meta:        s.meta(r, "User", u.ID, u.Created, u.LastModified),
+	}
+	if u.GivenName != "" || u.FamilyName != "" {
+		resource.Name = &name{GivenName: u.GivenName, FamilyName: u.FamilyName}
+	}
+	if u.Email != "" {
+		resource.Emails = []email{{Value: u.Email, Primary: true}}
+	}
+	return resource
+}
+
+type member struct {
+	Value string `json:"value"`
+	Ref   string `json:"$ref,omitempty"`
+}
+
+// groupResource is a Group as SCIM sends and receives it.
+type groupResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []member `json:"members"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+func (g groupResource) group() scim.Group {
+	group := scim.Group{DisplayName: g.DisplayName, ExternalID: g.ExternalID}
+	for _, m := range g.Members {
+		group.Members = append(group.Members, m.Value)
+	}
+	return group
+}
+
+func (s *server) groupResource(r *http.Request, g scim.Group) groupResource {
+	resource := groupResource{
+		Schemas:     []string{schemaGroup},
+		ID:          g.ID,
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Members:     make([]member, len(g.Members)),
+		Meta:        s.meta(r, "Group", g.ID, g.Created, g.LastModified),
+	}
+	for i, m := range g.Members {
+		resource.Members[i] = member{Value: m, Ref: s.location(r, "User", m)}
+	}
+	return resource
+}
+
+func (s *server) meta(r *http.Request, resourceType, id string, created, lastModified time.Time) *meta {
+	return &meta{
+		ResourceType: resourceType,
+		Created:      created.UTC().Format(time.RFC3339),
+		LastModified: lastModified.UTC().Format(time.RFC3339),
+		Location:     s.location(r, resourceType, id),
+	}
+}
+
+// location returns the URL of the resource, on the host the request was made to.
+func (s *server) location(r *http.Request, resourceType, id string) string {
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" && r.URL.Scheme != "https" {
+		scheme = "http"
+	}
+	prefix := s.prefix
+	if tenantID := r.PathValue("tenantId"); tenantID != "" {
+		prefix = "/tenants/" + tenantID + "/scim/v2"
+	}
+	return scheme + "://" + r.Host + prefix + "/" + resourceType + "s/" + id
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+func newListResponse(total, startIndex int, resources []any) listResponse {
+	return listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// patchRequest is a SCIM PATCH request, see RFC 7644 section 3.5.2.
+type patchRequest struct {
+	Schemas    []string `json:"schemas"`
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+func (p patchRequest) operations() []scim.Operation {
+	ops := make([]scim.Operation, len(p.Operations))
+	for i, o := range p.Operations {
+		ops[i] = scim.Operation{Op: o.Op, Path: o.Path, Value: o.Value}
+	}
+	return ops
+}
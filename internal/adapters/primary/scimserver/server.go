@@ -0,0 +1,336 @@
+// Package scimserver serves SCIM 2.0 Users and Groups over HTTP, see RFC 7644,
+// for the Tenant's identity provider to provision users and group membership:
+//
+//	GET    /tenants/{tenantId}/scim/v2/Users           filter, startIndex and count
+//	POST   /tenants/{tenantId}/scim/v2/Users
+//	GET    /tenants/{tenantId}/scim/v2/Users/{id}
+//	PUT    /tenants/{tenantId}/scim/v2/Users/{id}
+//	PATCH  /tenants/{tenantId}/scim/v2/Users/{id}
+//	DELETE /tenants/{tenantId}/scim/v2/Users/{id}
+//
+// and the same for /Groups, along with /ServiceProviderConfig and /ResourceTypes.
+// A user's id is their user ID in the permissions service. See package scim for
+// how groups are mapped to roles and groups.
+//
+// Identity providers authenticate with a bearer token, which an authorizer in
+// front of the service checks is for the Tenant, as it does for the rest of the
+// API. NewAuthorized serves the same without the Tenant in the path, under
+// /scim/v2, for an authorizer which puts it in the request's context instead.
+package scimserver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/api"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+const (
+	contentType = "application/scim+json"
+	// maxBodySize is the largest request body read, a group of a few thousand members fits.
+	maxBodySize = 1 << 20
+)
+
+// New returns the HTTP handler for SCIM, whose Tenant is that in the path.
+func New(s *scim.Service) http.Handler {
+	return newServer(s, "/tenants/{tenantId}/scim/v2", func(r *http.Request) (string, bool) {
+		return r.PathValue("tenantId"), true
+	})
+}
+
+// NewAuthorized returns the HTTP handler for SCIM whose Tenant is that in the
+// request's context, see contextkey. Requests without one are answered 401 Unauthorized.
+func NewAuthorized(s *scim.Service) http.Handler {
+	return newServer(s, "/scim/v2", func(r *http.Request) (string, bool) {
+		tenantID, _ := contextkey.TenantID(r.Context())
+		return tenantID, tenantID != ""
+	})
+}
+
+type server struct {
+	scim   *scim.Service
+	prefix string
+	// tenant returns the Tenant a request is for, ok is false when it names none.
+	tenant func(r *http.Request) (tenantID string, ok bool)
+}
+
+func newServer(s *scim.Service, prefix string, tenant func(r *http.Request) (string, bool)) http.Handler {
+	srv := &server{scim: s, prefix: prefix, tenant: tenant}
+	mux := http.NewServeMux()
+	handle := func(pattern string, h http.HandlerFunc) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.HandleFunc(method+" "+prefix+path, srv.withTenant(h))
+	}
+	handle("GET /ServiceProviderConfig", srv.serviceProviderConfig)
+	handle("GET /ResourceTypes", srv.resourceTypes)
+	handle("GET /Users", srv.listUsers)
+	handle("POST /Users", srv.createUser)
+	handle("GET /Users/{id}", srv.getUser)
+	handle("PUT /Users/{id}", srv.replaceUser)
+	handle("PATCH /Users/{id}", srv.patchUser)
+	handle("DELETE /Users/{id}", srv.deleteUser)
+	handle("GET /Groups", srv.listGroups)
+	handle("POST /Groups", srv.createGroup)
+	handle("GET /Groups/{id}", srv.getGroup)
+	handle("PUT /Groups/{id}", srv.replaceGroup)
+	handle("PATCH /Groups/{id}", srv.patchGroup)
+	handle("DELETE /Groups/{id}", srv.deleteGroup)
+	return mux
+}
+
+// withTenant puts the request's Tenant in its context, and limits the size of its body.
+func (s *server) withTenant(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := s.tenant(r)
+		if !ok {
+			writeError(w, api.Error{Code: api.CodeUnauthorized, Message: "tenant is required", Status: http.StatusUnauthorized}, nil)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		h(w, r.WithContext(contextkey.WithTenantID(r.Context(), tenantID)))
+	}
+}
+
+func (s *server) listUsers(w http.ResponseWriter, r *http.Request) {
+	q, err := query(r)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	list, err := s.scim.ListUsers(r.Context(), q)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	resources := make([]any, len(list.Resources))
+	for i, u := range list.Resources {
+		resources[i] = s.userResource(r, u)
+	}
+	writeJSON(w, http.StatusOK, newListResponse(list.TotalResults, list.StartIndex, resources))
+}
+
+func (s *server) getUser(w http.ResponseWriter, r *http.Request) {
+	user, err := s.scim.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.userResource(r, *user))
+}
+
+func (s *server) createUser(w http.ResponseWriter, r *http.Request) {
+	var body userResource
+	if err := decode(r, &body); err != nil {
+		s.fail(w, err)
+		return
+	}
+	user, err := s.scim.CreateUser(r.Context(), body.user())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	resource := s.userResource(r, *user)
+	w.Header().Set("Location", resource.Meta.Location)
+	writeJSON(w, http.StatusCreated, resource)
+}
+
+func (s *server) replaceUser(w http.ResponseWriter, r *http.Request) {
+	var body userResource
+	if err := decode(r, &body); err != nil {
+		s.fail(w, err)
+		return
+	}
+	user, err := s.scim.ReplaceUser(r.Context(), r.PathValue("id"), body.user())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.userResource(r, *user))
+}
+
+func (s *server) patchUser(w http.ResponseWriter, r *http.Request) {
+	var body patchRequest
+	if err := decode(r, &body); err != nil {
+		s.fail(w, err)
+		return
+	}
+	user, err := s.scim.PatchUser(r.Context(), r.PathValue("id"), body.operations())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.userResource(r, *user))
+}
+
+func (s *server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := s.scim.DeleteUser(r.Context(), r.PathValue("id")); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) listGroups(w http.ResponseWriter, r *http.Request) {
+	q, err := query(r)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	list, err := s.scim.ListGroups(r.Context(), q)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	resources := make([]any, len(list.Resources))
+	for i, g := range list.Resources {
+		resources[i] = s.groupResource(r, g)
+	}
+	writeJSON(w, http.StatusOK, newListResponse(list.TotalResults, list.StartIndex, resources))
+}
+
+func (s *server) getGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := s.scim.GetGroup(r.Context(), r.PathValue("id"))
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.groupResource(r, *group))
+}
+
+func (s *server) createGroup(w http.ResponseWriter, r *http.Request) {
+	var body groupResource
+	if err := decode(r, &body); err != nil {
+		s.fail(w, err)
+		return
+	}
+	group, err := s.scim.CreateGroup(r.Context(), body.group())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	resource := s.groupResource(r, *group)
+	w.Header().Set("Location", resource.Meta.Location)
+	writeJSON(w, http.StatusCreated, resource)
+}
+
+func (s *server) replaceGroup(w http.ResponseWriter, r *http.Request) {
+	var body groupResource
+	if err := decode(r, &body); err != nil {
+		s.fail(w, err)
+		return
+	}
+	group, err := s.scim.ReplaceGroup(r.Context(), r.PathValue("id"), body.group())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.groupResource(r, *group))
+}
+
+func (s *server) patchGroup(w http.ResponseWriter, r *http.Request) {
+	var body patchRequest
+	if err := decode(r, &body); err != nil {
+		s.fail(w, err)
+		return
+	}
+	group, err := s.scim.PatchGroup(r.Context(), r.PathValue("id"), body.operations())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.groupResource(r, *group))
+}
+
+func (s *server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := s.scim.DeleteGroup(r.Context(), r.PathValue("id")); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) serviceProviderConfig(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": scim.MaxResults},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with a bearer token issued for the Tenant",
+		}},
+	})
+}
+
+func (s *server) resourceTypes(w http.ResponseWriter, r *http.Request) {
+	types := []any{
+		map[string]any{"schemas": []string{schemaResourceType}, "id": "User", "name": "User", "endpoint": "/Users", "schema": schemaUser},
+		map[string]any{"schemas": []string{schemaResourceType}, "id": "Group", "name": "Group", "endpoint": "/Groups", "schema": schemaGroup},
+	}
+	writeJSON(w, http.StatusOK, newListResponse(len(types), 1, types))
+}
+
+// query reads the filter, startIndex and count parameters, count is MaxResults when absent.
+func query(r *http.Request) (scim.Query, error) {
+	q := scim.Query{Filter: r.URL.Query().Get("filter"), StartIndex: 1, Count: scim.MaxResults}
+	for name, n := range map[string]*int{"startIndex": &q.StartIndex, "count": &q.Count} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return scim.Query{}, scim.Errorf(scim.ErrInvalidValue, "%s %q is not a number", name, v)
+		}
+		*n = i
+	}
+	return q, nil
+}
+
+// decode reads the request's JSON body into v.
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return permissions.WrapError(permissions.ErrInvalidInput, err, "invalid request body")
+	}
+	return nil
+}
+
+// fail writes the error as a SCIM error.
+func (s *server) fail(w http.ResponseWriter, err error) {
+	writeError(w, api.NewError(err), scim.Type(err))
+}
+
+// writeError writes the error as a SCIM error, see RFC 7644 section 3.12, a
+// uniqueness error is 409 Conflict.
+func writeError(w http.ResponseWriter, e api.Error, scimType error) {
+	body := map[string]any{
+		"schemas": []string{schemaError},
+		"detail":  e.Message,
+	}
+	if scimType != nil {
+		body["scimType"] = scimType.Error()
+	}
+	if scimType == scim.ErrUniqueness {
+		e.Status = http.StatusConflict
+	}
+	body["status"] = strconv.Itoa(e.Status)
+	writeJSON(w, e.Status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("error writing response", "error", err.Error())
+	}
+}
@@ -0,0 +1,136 @@
+package scimserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/primary/scimserver"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo keeps users in memory, the methods of groups are left nil.
+type stubRepo struct {
+	scim.ReaderWriter
+	users []scim.User
+}
+
+func (r *stubRepo) GetSCIMUsers(context.Context) ([]scim.User, error) {
+	return slices.Clone(r.users), nil
+}
+
+func (r *stubRepo) GetSCIMUser(_ context.Context, userID string) (*scim.User, error) {
+	for _, u := range r.users {
+		if u.ID == userID {
+			return &u, nil
+		}
+	}
+	return nil, permissions.Errorf(permissions.ErrNotFound, "user %s not found", userID)
+}
+
+func (r *stubRepo) CreateSCIMUser(_ context.Context, user scim.User) error {
+	for _, u := range r.users {
+		if strings.EqualFold(u.UserName, user.UserName) {
+			return scim.Errorf(scim.ErrUniqueness, "userName %q is already in use", user.UserName)
+		}
+	}
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *stubRepo) UpdateSCIMUser(_ context.Context, user scim.User, _ bool) error {
+	i := slices.IndexFunc(r.users, func(u scim.User) bool { return u.ID == user.ID })
+	r.users[i] = user
+	return nil
+}
+
+func do(t *testing.T, method, url, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/scim+json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var v map[string]any
+	if resp.StatusCode != http.StatusNoContent {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+	}
+	return resp, v
+}
+
+func TestUsers(t *testing.T) {
+	srv := httptest.NewServer(scimserver.New(scim.NewService(&stubRepo{})))
+	defer srv.Close()
+	base := srv.URL + "/tenants/test/scim/v2"
+
+	resp, user := do(t, http.MethodPost, base+"/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "ada@example.com",
+		"name": {"givenName": "Ada", "familyName": "Lovelace"},
+		"emails": [{"value": "ada@home.example.com"}, {"value": "ada@example.com", "primary": true}],
+		"phoneNumbers": [{"value": "555-0100"}]
+	}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "application/scim+json", resp.Header.Get("Content-Type"))
+	id := user["id"].(string)
+	assert.Equal(t, base+"/Users/"+id, resp.Header.Get("Location"))
+	assert.Equal(t, true, user["active"], "users are active unless said otherwise")
+	assert.Equal(t, []any{map[string]any{"value": "ada@example.com", "primary": true}}, user["emails"])
+	assert.NotContains(t, user, "phoneNumbers", "attributes the service does not keep are ignored")
+
+	resp, body := do(t, http.MethodPost, base+"/Users", `{"userName": "ADA@example.com"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "uniqueness", body["scimType"])
+	assert.Equal(t, "409", body["status"])
+	assert.Equal(t, []any{"urn:ietf:params:scim:api:messages:2.0:Error"}, body["schemas"])
+
+	resp, body = do(t, http.MethodGet, base+`/Users?filter=userName+eq+%22ada@example.com%22`, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), body["totalResults"])
+	assert.Len(t, body["Resources"], 1)
+
+	resp, body = do(t, http.MethodGet, base+`/Users?filter=userName+eq`, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalidFilter", body["scimType"])
+
+	resp, body = do(t, http.MethodPatch, base+"/Users/"+id, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Replace", "path": "active", "value": "False"}]
+	}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, false, body["active"])
+
+	resp, body = do(t, http.MethodGet, base+"/Users/"+strings.Repeat("0", 8)+id[8:], "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "404", body["status"])
+}
+
+func TestServiceProviderConfig(t *testing.T) {
+	srv := httptest.NewServer(scimserver.New(scim.NewService(&stubRepo{})))
+	defer srv.Close()
+
+	resp, body := do(t, http.MethodGet, srv.URL+"/tenants/test/scim/v2/ServiceProviderConfig", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]any{"supported": true}, body["patch"])
+
+	resp, body = do(t, http.MethodGet, srv.URL+"/tenants/test/scim/v2/ResourceTypes", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(2), body["totalResults"])
+}
+
+func TestAuthorized_RequiresTenant(t *testing.T) {
+	srv := httptest.NewServer(scimserver.NewAuthorized(scim.NewService(&stubRepo{})))
+	defer srv.Close()
+
+	resp, body := do(t, http.MethodGet, srv.URL+"/scim/v2/Users", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "401", body["status"])
+}
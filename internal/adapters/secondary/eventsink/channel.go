@@ -0,0 +1,26 @@
+package eventsink
+
+import (
+	"context"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+)
+
+// ChannelSink sends each event on a channel, for tests and in-process consumers.
+// Publish blocks until the event is received or the context is cancelled.
+type ChannelSink struct {
+	C chan outbox.Event
+}
+
+func NewChannelSink(size int) *ChannelSink {
+	return &ChannelSink{C: make(chan outbox.Event, size)}
+}
+
+func (s *ChannelSink) Publish(ctx context.Context, event outbox.Event) error {
+	select {
+	case s.C <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
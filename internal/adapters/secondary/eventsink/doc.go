@@ -0,0 +1,2 @@
+// Package eventsink provides the outbox.Sink implementations the relay publishes events to.
+package eventsink
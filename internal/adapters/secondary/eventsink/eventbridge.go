@@ -0,0 +1,61 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// EventSource is the source of the events put on EventBridge.
+const EventSource = "user-permissions-service"
+
+// EventBridgePutter is the part of the EventBridge client used by EventBridgeSink.
+type EventBridgePutter interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgeSink puts each event on an EventBridge bus, with the event type as
+// the detail type and the whole event as the detail.
+type EventBridgeSink struct {
+	client  EventBridgePutter
+	busName string
+}
+
+func NewEventBridgeSink(client EventBridgePutter, busName string) *EventBridgeSink {
+	return &EventBridgeSink{client: client, busName: busName}
+}
+
+func (s *EventBridgeSink) Publish(ctx context.Context, event outbox.Event) error {
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	out, err := s.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String(s.busName),
+			Source:       aws.String(EventSource),
+			DetailType:   aws.String(string(event.Type)),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(event.CreatedAt),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("eventbridge put events: %w", err)
+	}
+	// PutEvents succeeds even when entries fail, they are reported individually.
+	if out.FailedEntryCount > 0 {
+		for _, e := range out.Entries {
+			if e.ErrorCode != nil {
+				return fmt.Errorf("eventbridge put events: %s: %s", aws.ToString(e.ErrorCode), aws.ToString(e.ErrorMessage))
+			}
+		}
+		return fmt.Errorf("eventbridge put events: %d entries failed", out.FailedEntryCount)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package eventsink_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/eventsink"
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(id int64) outbox.Event {
+	e := outbox.NewEvent(outbox.EventRoleGranted, outbox.RoleAssignment{UserID: "u1", Role: "admin"})
+	e.ID = id
+	e.TenantID = "test"
+	e.CreatedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	return e
+}
+
+type fakeSNS struct {
+	inputs []*sns.PublishInput
+}
+
+func (f *fakeSNS) Publish(_ context.Context, params *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	f.inputs = append(f.inputs, params)
+	return &sns.PublishOutput{}, nil
+}
+
+func TestSNSSink(t *testing.T) {
+	client := &fakeSNS{}
+	require.NoError(t, eventsink.NewSNSSink(client, "arn:aws:sns:eu-west-2:123456789012:permissions").Publish(context.Background(), testEvent(1)))
+	require.NoError(t, eventsink.NewSNSSink(client, "arn:aws:sns:eu-west-2:123456789012:permissions.fifo").Publish(context.Background(), testEvent(2)))
+	require.Len(t, client.inputs, 2)
+
+	standard := client.inputs[0]
+	assert.Equal(t, "role.granted", aws.ToString(standard.MessageAttributes["eventType"].StringValue))
+	assert.Equal(t, "test", aws.ToString(standard.MessageAttributes["tenantId"].StringValue))
+	assert.Equal(t, "info", aws.ToString(standard.MessageAttributes["severity"].StringValue))
+	assert.Nil(t, standard.MessageGroupId)
+	assert.JSONEq(t, `{"id":1,"tenantId":"test","type":"role.granted","data":{"userId":"u1","role":"admin"},"createdAt":"2025-01-02T03:04:05Z"}`, aws.ToString(standard.Message))
+
+	fifo := client.inputs[1]
+	assert.Equal(t, "test", aws.ToString(fifo.MessageGroupId))
+	assert.Equal(t, "test-2", aws.ToString(fifo.MessageDeduplicationId))
+}
+
+type fakeEventBridge struct {
+	failed bool
+	inputs []*eventbridge.PutEventsInput
+}
+
+func (f *fakeEventBridge) PutEvents(_ context.Context, params *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	f.inputs = append(f.inputs, params)
+	if f.failed {
+		return &eventbridge.PutEventsOutput{
+			FailedEntryCount: 1,
+			Entries:          []ebtypes.PutEventsResultEntry{{ErrorCode: aws.String("InternalFailure"), ErrorMessage: aws.String("try again")}},
+		}, nil
+	}
+	return &eventbridge.PutEventsOutput{Entries: []ebtypes.PutEventsResultEntry{{EventId: aws.String("1")}}}, nil
+}
+
+func TestEventBridgeSink(t *testing.T) {
+	client := &fakeEventBridge{}
+	sink := eventsink.NewEventBridgeSink(client, "permissions")
+	require.NoError(t, sink.Publish(context.Background(), testEvent(1)))
+
+	entry := client.inputs[0].Entries[0]
+	assert.Equal(t, "permissions", aws.ToString(entry.EventBusName))
+	assert.Equal(t, eventsink.EventSource, aws.ToString(entry.Source))
+	assert.Equal(t, "role.granted", aws.ToString(entry.DetailType))
+
+	// A failed entry fails the publish, so the relay retries it.
+	client.failed = true
+	err := sink.Publish(context.Background(), testEvent(2))
+	assert.ErrorContains(t, err, "InternalFailure")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := eventsink.NewFileSink(path)
+	require.NoError(t, sink.Publish(context.Background(), testEvent(1)))
+	require.NoError(t, sink.Publish(context.Background(), testEvent(2)))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e outbox.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		ids = append(ids, e.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+}
+
+func TestChannelSink(t *testing.T) {
+	sink := eventsink.NewChannelSink(1)
+	require.NoError(t, sink.Publish(context.Background(), testEvent(1)))
+	assert.Equal(t, int64(1), (<-sink.C).ID)
+
+	// A full channel blocks until the context is done.
+	require.NoError(t, sink.Publish(context.Background(), testEvent(2)))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sink.Publish(ctx, testEvent(3)), context.DeadlineExceeded)
+}
@@ -0,0 +1,46 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+)
+
+// FileSink appends each event as a line of JSON to a file, for local development and tests.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Publish(_ context.Context, event outbox.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", s.path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	// Sync so the event is durable before the relay marks it published.
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", s.path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,65 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// SNSPublisher is the part of the SNS client used by SNSSink.
+type SNSPublisher interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSSink publishes each event as a JSON message to an SNS topic, with the
+// event type, Tenant ID and severity as message attributes so that
+// subscriptions can filter on them, such as to notify whoever is on call of
+// high severity events.
+//
+// For a FIFO topic, events are grouped by Tenant and deduplicated by Tenant and event ID,
+// so an event published twice within the deduplication interval is delivered once.
+type SNSSink struct {
+	client   SNSPublisher
+	topicARN string
+	fifo     bool
+}
+
+func NewSNSSink(client SNSPublisher, topicARN string) *SNSSink {
+	return &SNSSink{
+		client:   client,
+		topicARN: topicARN,
+		fifo:     strings.HasSuffix(topicARN, ".fifo"),
+	}
+}
+
+func (s *SNSSink) Publish(ctx context.Context, event outbox.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	input := &sns.PublishInput{
+		TopicArn: aws.String(s.topicARN),
+		Message:  aws.String(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"eventType": {DataType: aws.String("String"), StringValue: aws.String(string(event.Type))},
+			"tenantId":  {DataType: aws.String("String"), StringValue: aws.String(event.TenantID)},
+			"severity":  {DataType: aws.String("String"), StringValue: aws.String(string(event.Type.Severity()))},
+		},
+	}
+	if s.fifo {
+		input.MessageGroupId = aws.String(event.TenantID)
+		input.MessageDeduplicationId = aws.String(fmt.Sprintf("%s-%d", event.TenantID, event.ID))
+	}
+
+	if _, err := s.client.Publish(ctx, input); err != nil {
+		return fmt.Errorf("sns publish: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/breakglass"
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetBreakGlassSettings(ctx context.Context) (*breakglass.Settings, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+
+	var settings breakglass.Settings
+	var seconds int
+	err = pool.QueryRow(ctx, `
+		SELECT
+			r.role_id, r.role_name, s.max_duration_seconds, s.updated_at
+		FROM
+			break_glass_settings s
+		JOIN
+			roles r ON s.role_id = r.role_id
+		`).Scan(&settings.Role.ID, &settings.Role.Name, &seconds, &settings.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "break-glass settings not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query break_glass_settings: %w", dbError(err))
+	}
+	settings.MaxDuration = time.Duration(seconds) * time.Second
+	return &settings, nil
+}
+
+func (pr *PermissionsRepo) SetBreakGlassSettings(ctx context.Context, roleID string, maxDuration time.Duration) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO break_glass_settings
+				(role_id, max_duration_seconds, updated_at)
+			SELECT
+				r.role_id, @max_duration_seconds, NOW()
+			FROM
+				roles r
+			WHERE
+				r.role_id = @role_id::uuid
+			ON CONFLICT (break_glass_settings_id) DO UPDATE SET
+				role_id = EXCLUDED.role_id,
+				max_duration_seconds = EXCLUDED.max_duration_seconds,
+				updated_at = EXCLUDED.updated_at
+			`, pgx.NamedArgs{
+			"role_id":              roleID,
+			"max_duration_seconds": int(maxDuration / time.Second),
+		})
+		if err != nil {
+			return fmt.Errorf("insert break_glass_settings: %w", dbError(err))
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "role %s not found", roleID)
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) CreateBreakGlassSession(ctx context.Context, session *breakglass.Session) error {
+	// The assignment is not checked against separation of duties rules, see breakglass.Service.Grant.
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		var assigned bool
+		err := tx.QueryRow(ctx, `
+			SELECT
+				r.role_name,
+				EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = @user_id::uuid AND ur.role_id = r.role_id AND (ur.expires_at IS NULL OR ur.expires_at > NOW()))
+			FROM
+				roles r
+			WHERE
+				r.role_id = @role_id::uuid
+			`, pgx.NamedArgs{
+			"user_id": session.UserID,
+			"role_id": session.Role.ID,
+		}).Scan(&session.Role.Name, &assigned)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permissions.Errorf(permissions.ErrNotFound, "role %s not found", session.Role.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("query roles: %w", dbError(err))
+		}
+		if assigned {
+			return permissions.Errorf(permissions.ErrInvalidInput, "user %s is already assigned the role %s", session.UserID, session.Role.Name)
+		}
+
+		var userRolesID int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO user_roles
+				(user_id, role_id, created_at, expires_at)
+			VALUES
+				(@user_id, @role_id, @started_at, @expires_at)
+			RETURNING
+				user_roles_id
+			`, pgx.NamedArgs{
+			"user_id":    session.UserID,
+			"role_id":    session.Role.ID,
+			"started_at": session.StartedAt,
+			"expires_at": session.ExpiresAt,
+		}).Scan(&userRolesID)
+		if err != nil {
+			return fmt.Errorf("insert user_roles: %w", dbError(err))
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO break_glass_sessions
+				(session_id, user_id, role_id, role_name, justification, granted_by, started_at, expires_at, user_roles_id)
+			VALUES
+				(@session_id, @user_id, @role_id, @role_name, @justification, NULLIF(@granted_by::text, '')::uuid, @started_at, @expires_at, @user_roles_id)
+			`, pgx.NamedArgs{
+			"session_id":    session.ID,
+			"user_id":       session.UserID,
+			"role_id":       session.Role.ID,
+			"role_name":     session.Role.Name,
+			"justification": session.Justification,
+			"granted_by":    session.GrantedBy,
+			"started_at":    session.StartedAt,
+			"expires_at":    session.ExpiresAt,
+			"user_roles_id": userRolesID,
+		})
+		if err != nil {
+			return fmt.Errorf("insert break_glass_sessions: %w", dbError(err))
+		}
+
+		return insertEvents(ctx, tx,
+			outbox.NewEvent(outbox.EventRoleGranted, outbox.RoleAssignment{UserID: session.UserID, Role: session.Role.Name, ExpiresAt: &session.ExpiresAt}),
+			outbox.NewEvent(outbox.EventBreakGlassGranted, outbox.BreakGlassSession{
+				SessionID:     session.ID,
+				UserID:        session.UserID,
+				Role:          session.Role.Name,
+				Justification: session.Justification,
+				GrantedBy:     session.GrantedBy,
+				ExpiresAt:     session.ExpiresAt,
+			}),
+		)
+	})
+}
+
+func (pr *PermissionsRepo) EndBreakGlassSession(ctx context.Context, sessionID, endedBy string, at time.Time) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		var session breakglass.Session
+		var userRolesID *int64
+		err := tx.QueryRow(ctx, `
+			UPDATE break_glass_sessions SET
+				ended_by = NULLIF(@ended_by::text, '')::uuid,
+				ended_at = @ended_at
+			WHERE
+				session_id = @session_id
+				AND
+				ended_at IS NULL
+				AND
+				expires_at > @ended_at
+			RETURNING
+				user_id, role_name, justification, COALESCE(granted_by::text, ''), expires_at, user_roles_id
+			`, pgx.NamedArgs{
+			"session_id": sessionID,
+			"ended_by":   endedBy,
+			"ended_at":   at,
+		}).Scan(&session.UserID, &session.Role.Name, &session.Justification, &session.GrantedBy, &session.ExpiresAt, &userRolesID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permissions.Errorf(permissions.ErrInvalidInput, "session %s is not active", sessionID)
+		}
+		if err != nil {
+			return fmt.Errorf("update break_glass_sessions: %w", dbError(err))
+		}
+
+		events := []outbox.Event{outbox.NewEvent(outbox.EventBreakGlassEnded, outbox.BreakGlassSession{
+			SessionID:     sessionID,
+			UserID:        session.UserID,
+			Role:          session.Role.Name,
+			Justification: session.Justification,
+			GrantedBy:     session.GrantedBy,
+			ExpiresAt:     session.ExpiresAt,
+			EndedBy:       endedBy,
+		})}
+		if userRolesID != nil {
+			_, err := tx.Exec(ctx, `
+				UPDATE user_roles SET
+					expires_at = @ended_at,
+					updated_at = NOW()
+				WHERE
+					user_roles_id = @user_roles_id
+				`, pgx.NamedArgs{
+				"user_roles_id": *userRolesID,
+				"ended_at":      at,
+			})
+			if err != nil {
+				return fmt.Errorf("update user_roles: %w", err)
+			}
+			revoked := outbox.NewEvent(outbox.EventRoleRevoked, outbox.RoleAssignment{UserID: session.UserID, Role: session.Role.Name})
+			events = append([]outbox.Event{revoked}, events...)
+		}
+		return insertEvents(ctx, tx, events...)
+	})
+}
+
+func (pr *PermissionsRepo) GetBreakGlassSession(ctx context.Context, sessionID string) (*breakglass.Session, error) {
+	sessions, err := pr.getBreakGlassSessions(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "session %s not found", sessionID)
+	}
+	return &sessions[0], nil
+}
+
+func (pr *PermissionsRepo) GetBreakGlassSessions(ctx context.Context) ([]breakglass.Session, error) {
+	return pr.getBreakGlassSessions(ctx, "")
+}
+
+// getBreakGlassSessions returns the session, or every session when sessionID is empty.
+func (pr *PermissionsRepo) getBreakGlassSessions(ctx context.Context, sessionID string) ([]breakglass.Session, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT
+			session_id, user_id, role_id, role_name, justification, COALESCE(granted_by::text, ''),
+			started_at, expires_at, COALESCE(ended_by::text, ''), ended_at
+		FROM
+			break_glass_sessions
+		WHERE
+			@session_id::text = '' OR session_id = NULLIF(@session_id::text, '')::uuid
+		ORDER BY
+			started_at DESC, session_id ASC
+		`, pgx.NamedArgs{
+		"session_id": sessionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query break_glass_sessions: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	sessions := make([]breakglass.Session, 0)
+	for rows.Next() {
+		var s breakglass.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Role.ID, &s.Role.Name, &s.Justification, &s.GrantedBy,
+			&s.StartedAt, &s.ExpiresAt, &s.EndedBy, &s.EndedAt); err != nil {
+			return nil, fmt.Errorf("scan break_glass_sessions: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows break_glass_sessions: %w", rows.Err())
+	}
+
+	return sessions, nil
+}
+
+// endBreakGlassSessions ends the user's active sessions of the roles, or of
+// every role when roleIDs is nil, whose assignments are about to be removed.
+func endBreakGlassSessions(ctx context.Context, tx pgx.Tx, userID string, roleIDs []string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE break_glass_sessions s SET
+			ended_at = NOW()
+		FROM
+			user_roles ur
+		WHERE
+			s.user_roles_id = ur.user_roles_id
+			AND
+			ur.user_id = @user_id::uuid
+			AND
+			(@role_ids::uuid[] IS NULL OR ur.role_id = ANY(@role_ids::uuid[]))
+			AND
+			s.ended_at IS NULL
+			AND
+			s.expires_at > NOW()
+		`, pgx.NamedArgs{
+		"user_id":  userID,
+		"role_ids": roleIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("update break_glass_sessions: %w", dbError(err))
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) CreateDelegation(ctx context.Context, delegation permissions.Delegation) error {
+	permissionNames := make([]string, len(delegation.Permissions))
+	for i, p := range delegation.Permissions {
+		permissionNames[i] = strings.ToLower(p.Name)
+	}
+
+	// The delegate holds what is delegated, so it must not leave them holding members of a static rule.
+	return inSoDTx(ctx, pr.tenantPool, sodScope{userIDs: []string{delegation.DelegateID}}, func(tx pgx.Tx) error {
+		var unknownRoles, unknownPermissions []string
+		err := tx.QueryRow(ctx, `
+			SELECT
+				COALESCE((SELECT ARRAY_AGG(id::text ORDER BY id::text) FROM UNNEST(@role_ids::uuid[]) AS id WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.role_id = id)), '{}'),
+				COALESCE((SELECT ARRAY_AGG(name ORDER BY name) FROM UNNEST(@permission_names::text[]) AS name WHERE NOT EXISTS (SELECT 1 FROM permissions p WHERE LOWER(p.permission_name) = name)), '{}')
+			`, pgx.NamedArgs{
+			"role_ids":         delegation.Roles.GetIDs(),
+			"permission_names": permissionNames,
+		}).Scan(&unknownRoles, &unknownPermissions)
+		if err != nil {
+			return fmt.Errorf("query roles and permissions: %w", dbError(err))
+		}
+		if len(unknownRoles) > 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "unknown roles: %s", strings.Join(unknownRoles, ", "))
+		}
+		if len(unknownPermissions) > 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "unknown permissions: %s", strings.Join(unknownPermissions, ", "))
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO delegations
+				(delegation_id, delegator_id, delegate_id, reason, starts_at, ends_at, created_at)
+			VALUES
+				(@delegation_id, @delegator_id, @delegate_id, @reason, @starts_at, @ends_at, NOW())
+			`, pgx.NamedArgs{
+			"delegation_id": delegation.ID,
+			"delegator_id":  delegation.DelegatorID,
+			"delegate_id":   delegation.DelegateID,
+			"reason":        delegation.Reason,
+			"starts_at":     delegation.StartsAt,
+			"ends_at":       delegation.EndsAt,
+		})
+		if err != nil {
+			return fmt.Errorf("insert delegations: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO delegation_roles
+				(delegation_id, role_id)
+			SELECT
+				@delegation_id::uuid, role_id
+			FROM
+				UNNEST(@role_ids::uuid[]) AS role_id
+			`, pgx.NamedArgs{
+			"delegation_id": delegation.ID,
+			"role_ids":      delegation.Roles.GetIDs(),
+		})
+		if err != nil {
+			return fmt.Errorf("insert delegation_roles: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO delegation_permissions
+				(delegation_id, permission_id)
+			SELECT
+				@delegation_id::uuid, p.permission_id
+			FROM
+				permissions p
+			WHERE
+				LOWER(p.permission_name) = ANY(@permission_names::text[])
+			`, pgx.NamedArgs{
+			"delegation_id":    delegation.ID,
+			"permission_names": permissionNames,
+		})
+		if err != nil {
+			return fmt.Errorf("insert delegation_permissions: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventDelegationChanged, outbox.DelegationChange{
+			DelegationID: delegation.ID,
+			DelegatorID:  delegation.DelegatorID,
+			DelegateID:   delegation.DelegateID,
+			Action:       "created",
+		}))
+	})
+}
+
+func (pr *PermissionsRepo) DeleteDelegation(ctx context.Context, delegationID string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		change := outbox.DelegationChange{DelegationID: delegationID, Action: "revoked"}
+		err := tx.QueryRow(ctx, `
+			DELETE FROM delegations
+			WHERE
+				delegation_id = @delegation_id
+			RETURNING
+				delegator_id, delegate_id
+			`, pgx.NamedArgs{
+			"delegation_id": delegationID,
+		}).Scan(&change.DelegatorID, &change.DelegateID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permissions.Errorf(permissions.ErrNotFound, "delegation %s not found", delegationID)
+		}
+		if err != nil {
+			return fmt.Errorf("delete delegations: %w", dbError(err))
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventDelegationChanged, change))
+	})
+}
+
+func (pr *PermissionsRepo) GetUserDelegations(ctx context.Context) (permissions.Delegations, error) {
+	userID, found := contextkey.UserID(ctx)
+	if !found {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	delegations, err := getDelegations(ctx, tx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return delegations, nil
+}
+
+func (pr *PermissionsRepo) GetDelegations(ctx context.Context, userID string) (permissions.Delegations, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	delegations, err := getDelegations(ctx, tx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return delegations, nil
+}
+
+// getDelegations returns the delegations which have not ended by or to the
+// user, every user's when userID is empty. When open is set they are only
+// those to the user whose window is open now.
+func getDelegations(ctx context.Context, tx pgx.Tx, userID string, open bool) (permissions.Delegations, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			d.delegation_id, d.delegator_id, d.delegate_id, d.reason, d.starts_at, d.ends_at, d.created_at,
+			COALESCE((SELECT ARRAY_AGG(r.role_id::text ORDER BY r.role_name, r.role_id::text) FROM delegation_roles dr JOIN roles r ON dr.role_id = r.role_id WHERE dr.delegation_id = d.delegation_id), '{}'),
+			COALESCE((SELECT ARRAY_AGG(r.role_name ORDER BY r.role_name, r.role_id::text) FROM delegation_roles dr JOIN roles r ON dr.role_id = r.role_id WHERE dr.delegation_id = d.delegation_id), '{}'),
+			COALESCE((SELECT ARRAY_AGG(p.permission_id::text ORDER BY p.permission_name) FROM delegation_permissions dp JOIN permissions p ON dp.permission_id = p.permission_id WHERE dp.delegation_id = d.delegation_id), '{}'),
+			COALESCE((SELECT ARRAY_AGG(p.permission_name ORDER BY p.permission_name) FROM delegation_permissions dp JOIN permissions p ON dp.permission_id = p.permission_id WHERE dp.delegation_id = d.delegation_id), '{}')
+		FROM
+			delegations d
+		WHERE
+			d.ends_at > NOW()
+			AND
+			(@user_id::text = '' OR d.delegate_id = NULLIF(@user_id::text, '')::uuid OR (NOT @open::bool AND d.delegator_id = NULLIF(@user_id::text, '')::uuid))
+			AND
+			(NOT @open::bool OR d.starts_at <= NOW())
+		ORDER BY
+			d.starts_at ASC, d.delegation_id ASC
+		`, pgx.NamedArgs{
+		"user_id": userID,
+		"open":    open,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query delegations: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	var delegations permissions.Delegations
+	for rows.Next() {
+		var d permissions.Delegation
+		var roleIDs, roleNames, permissionIDs, permissionNames []string
+		if err := rows.Scan(&d.ID, &d.DelegatorID, &d.DelegateID, &d.Reason, &d.StartsAt, &d.EndsAt, &d.CreatedAt,
+			&roleIDs, &roleNames, &permissionIDs, &permissionNames); err != nil {
+			return nil, fmt.Errorf("scan delegations: %w", err)
+		}
+		for i, id := range roleIDs {
+			d.Roles = append(d.Roles, permissions.Role{ID: id, Name: roleNames[i]})
+		}
+		for i, id := range permissionIDs {
+			d.Permissions = append(d.Permissions, permissions.Permission{ID: id, Name: permissionNames[i]})
+		}
+		delegations = append(delegations, d)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows delegations: %w", rows.Err())
+	}
+
+	return delegations, nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	codeInvalidTextRepresentation = "22P02"
+	codeInvalidCatalogName        = "3D000"
+	codeUniqueViolation           = "23505"
+)
+
+// dbError returns the permissions error of the kind err is, or err when it is
+// unexpected. A Tenant whose database does not exist is ErrNotFound, a value
+// Postgres cannot parse, such as a malformed UUID, is ErrInvalidInput, and a
+// database which cannot be reached or is shutting down is ErrUnavailable.
+func dbError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == codeInvalidCatalogName:
+			return permissions.WrapError(permissions.ErrNotFound, err, "tenant not found")
+		case pgErr.Code == codeInvalidTextRepresentation:
+			return permissions.WrapError(permissions.ErrInvalidInput, err, pgErr.Message)
+		// Connection exceptions, insufficient resources and operator intervention, such as a shutdown.
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "53"), strings.HasPrefix(pgErr.Code, "57"):
+			return permissions.WrapError(permissions.ErrUnavailable, err, "database unavailable")
+		}
+		return err
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) {
+		return permissions.WrapError(permissions.ErrUnavailable, err, "database unavailable")
+	}
+	return err
+}
+
+// isUniqueViolation reports whether the error is of a unique constraint violated.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == codeUniqueViolation
+}
+
+// isUniqueViolationOf reports whether the error is of the named unique constraint violated.
+func isUniqueViolationOf(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == codeUniqueViolation && pgErr.ConstraintName == constraint
+}
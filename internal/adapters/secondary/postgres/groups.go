@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) CreateGroup(ctx context.Context, group permissions.Group) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO groups 
+				(group_id, group_name, created_at)
+			VALUES 
+				(@group_id, @group_name, NOW())
+			`, pgx.NamedArgs{
+			"group_id":   group.ID,
+			"group_name": group.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("insert groups: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: group.ID, Action: "created"}))
+	})
+}
+
+func (pr *PermissionsRepo) DeleteGroup(ctx context.Context, groupID string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM groups
+			WHERE 
+				group_id = @group_id
+			`, pgx.NamedArgs{
+			"group_id": groupID,
+		})
+		if err != nil {
+			return fmt.Errorf("delete groups: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("group %s not found", groupID)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: groupID, Action: "deleted"}))
+	})
+}
+
+func (pr *PermissionsRepo) AddGroupMembers(ctx context.Context, groupID string, userIDs []string) error {
+	return inSoDTx(ctx, pr.tenantPool, sodScope{userIDs: userIDs}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO group_members 
+				(group_id, user_id, created_at)
+			SELECT 
+				@group_id::uuid, user_id, NOW()
+			FROM 
+				unnest(@user_ids::uuid[]) AS user_id
+			ON CONFLICT (group_id, user_id) DO NOTHING
+			`, pgx.NamedArgs{
+			"group_id": groupID,
+			"user_ids": userIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("insert group_members: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: groupID, Action: "members_added", UserIDs: userIDs}))
+	})
+}
+
+func (pr *PermissionsRepo) RemoveGroupMembers(ctx context.Context, groupID string, userIDs []string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM group_members
+			WHERE 
+				group_id = @group_id
+				AND
+				user_id = ANY(@user_ids::uuid[])
+			`, pgx.NamedArgs{
+			"group_id": groupID,
+			"user_ids": userIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("delete group_members: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: groupID, Action: "members_removed", UserIDs: userIDs}))
+	})
+}
+
+func (pr *PermissionsRepo) AddGroupRoles(ctx context.Context, groupID string, roleIDs []string) error {
+	return inSoDTx(ctx, pr.tenantPool, sodScope{groupID: groupID}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO group_roles 
+				(group_id, role_id, created_at)
+			SELECT 
+				@group_id::uuid, role_id, NOW()
+			FROM 
+				unnest(@role_ids::uuid[]) AS role_id
+			ON CONFLICT (group_id, role_id) DO NOTHING
+			`, pgx.NamedArgs{
+			"group_id": groupID,
+			"role_ids": roleIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("insert group_roles: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: groupID, Action: "roles_added", RoleIDs: roleIDs}))
+	})
+}
+
+func (pr *PermissionsRepo) RemoveGroupRoles(ctx context.Context, groupID string, roleIDs []string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM group_roles
+			WHERE 
+				group_id = @group_id
+				AND
+				role_id = ANY(@role_ids::uuid[])
+			`, pgx.NamedArgs{
+			"group_id": groupID,
+			"role_ids": roleIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("delete group_roles: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: groupID, Action: "roles_removed", RoleIDs: roleIDs}))
+	})
+}
+
+func (pr *PermissionsRepo) NestGroup(ctx context.Context, parentGroupID, childGroupID string) error {
+	// The child's members gain the parent's roles.
+	return inSoDTx(ctx, pr.tenantPool, sodScope{groupID: childGroupID}, func(tx pgx.Tx) error {
+		// The child must not already contain the parent, at any depth, or the groups would form a cycle.
+		var cycle bool
+		err := tx.QueryRow(ctx, `
+			WITH RECURSIVE ancestors AS (
+				SELECT 
+					@parent_group_id::uuid AS group_id
+				UNION
+				SELECT 
+					gh.parent_group_id
+				FROM 
+					group_hierarchy gh
+				JOIN 
+					ancestors a ON gh.child_group_id = a.group_id
+			)
+			SELECT EXISTS (
+				SELECT 1 FROM ancestors WHERE group_id = @child_group_id::uuid
+			)
+			`, pgx.NamedArgs{
+			"parent_group_id": parentGroupID,
+			"child_group_id":  childGroupID,
+		}).Scan(&cycle)
+		if err != nil {
+			return fmt.Errorf("query group_hierarchy: %w", err)
+		}
+		if cycle {
+			return fmt.Errorf("group %s already contains group %s", childGroupID, parentGroupID)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO group_hierarchy 
+				(parent_group_id, child_group_id)
+			VALUES 
+				(@parent_group_id, @child_group_id)
+			ON CONFLICT (parent_group_id, child_group_id) DO NOTHING
+			`, pgx.NamedArgs{
+			"parent_group_id": parentGroupID,
+			"child_group_id":  childGroupID,
+		})
+		if err != nil {
+			return fmt.Errorf("insert group_hierarchy: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: childGroupID, Action: "nested", ParentGroupID: parentGroupID}))
+	})
+}
+
+func (pr *PermissionsRepo) UnnestGroup(ctx context.Context, parentGroupID, childGroupID string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM group_hierarchy
+			WHERE 
+				parent_group_id = @parent_group_id
+				AND
+				child_group_id = @child_group_id
+			`, pgx.NamedArgs{
+			"parent_group_id": parentGroupID,
+			"child_group_id":  childGroupID,
+		})
+		if err != nil {
+			return fmt.Errorf("delete group_hierarchy: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: childGroupID, Action: "unnested", ParentGroupID: parentGroupID}))
+	})
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/imports"
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/jackc/pgx/v5"
+)
+
+// importRoleQuery assigns the role to the user unless they already hold it,
+// and it has not expired, which leaves the assignment as it is, expiry
+// included. An assignment made by break-glass is not held, the role is
+// assigned again.
+const importRoleQuery = `
+	INSERT INTO user_roles
+		(user_id, role_id, created_at, expires_at)
+	SELECT
+		@user_id::uuid, r.role_id, NOW(), @expires_at
+	FROM
+		roles r
+	WHERE
+		r.role_name = @role
+		AND
+		NOT EXISTS (
+			SELECT 1
+			FROM
+				user_roles ur
+			WHERE
+				ur.role_id = r.role_id
+				AND
+				ur.user_id = @user_id::uuid
+				AND
+				(ur.expires_at IS NULL OR ur.expires_at > NOW())
+				AND
+				NOT EXISTS (SELECT 1 FROM break_glass_sessions s WHERE s.user_roles_id = ur.user_roles_id)
+		)
+	`
+
+// importResourceQuery grants the permissions on the resource to the user, each
+// on its condition, if any. Those the user already holds unconditionally, or
+// on the same condition, and which have not expired, are left as they are.
+const importResourceQuery = `
+	INSERT INTO user_resources
+		(user_id, resource_type_id, resource_id, permission_id, condition, created_at, expires_at)
+	SELECT
+		@user_id::uuid, rt.resource_type_id, @resource_id::uuid, p.permission_id, NULLIF(g.condition, ''), NOW(), @expires_at
+	FROM
+		unnest(@permissions::text[], @conditions::text[]) AS g(permission_name, condition)
+		JOIN permissions p ON p.permission_name = g.permission_name,
+		resource_types rt
+	WHERE
+		rt.resource_type_name = @resource_type
+		AND
+		NOT EXISTS (
+			SELECT 1
+			FROM
+				user_resources ur
+			WHERE
+				ur.user_id = @user_id::uuid
+				AND
+				ur.resource_type_id = rt.resource_type_id
+				AND
+				ur.resource_id = @resource_id::uuid
+				AND
+				ur.permission_id = p.permission_id
+				AND
+				(ur.condition IS NULL OR ur.condition = NULLIF(g.condition, ''))
+				AND
+				(ur.expires_at IS NULL OR ur.expires_at > NOW())
+		)
+	`
+
+func (pr *PermissionsRepo) ImportAssignments(ctx context.Context, assignments []imports.Assignment) ([]int, error) {
+	userIDs := make([]string, len(assignments))
+	for i, a := range assignments {
+		userIDs[i] = a.UserID
+	}
+	var held []int
+	err := inSoDTx(ctx, pr.tenantPool, sodScope{userIDs: userIDs}, func(tx pgx.Tx) error {
+		held = nil
+		events := make([]outbox.Event, 0, len(assignments))
+		for _, a := range assignments {
+			query := importRoleQuery
+			args := pgx.NamedArgs{
+				"user_id":    a.UserID,
+				"role":       a.Role,
+				"expires_at": a.ExpiresAt,
+			}
+			event := outbox.RoleAssignment{UserID: a.UserID, Role: a.Role, ExpiresAt: a.ExpiresAt}
+			if a.Resource != nil {
+				query = importResourceQuery
+				args["resource_type"] = a.Resource.Type
+				args["resource_id"] = a.Resource.ID
+				args["permissions"] = a.Permissions
+				conditions := make([]string, len(a.Permissions))
+				for i, p := range a.Permissions {
+					conditions[i] = a.Conditions[p]
+				}
+				args["conditions"] = conditions
+				event.ResourceType, event.ResourceID = a.Resource.Type, a.Resource.ID
+			}
+
+			tag, err := tx.Exec(ctx, query, args)
+			if err != nil {
+				return fmt.Errorf("line %d: import %s for %s: %w", a.Line, a.Role, a.UserID, err)
+			}
+			if tag.RowsAffected() == 0 {
+				held = append(held, a.Line)
+				continue
+			}
+			events = append(events, outbox.NewEvent(outbox.EventRoleGranted, event))
+		}
+		return insertEvents(ctx, tx, events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return held, nil
+}
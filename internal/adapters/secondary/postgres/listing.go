@@ -0,0 +1,434 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/listing"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+// The listings are paged by keyset: each is ordered by text columns, and a page
+// starts after the key, the values of those columns for the previous page's
+// last item. A NULL key starts at the first item.
+
+func (pr *PermissionsRepo) ListRoleHolders(ctx context.Context, filter listing.RoleHoldersFilter, after listing.Key, limit int) ([]listing.RoleHolder, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	holders, err := pr.listRoleHolders(ctx, tx, filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return holders, nil
+}
+
+func (pr *PermissionsRepo) listRoleHolders(ctx context.Context, tx pgx.Tx, filter listing.RoleHoldersFilter, after listing.Key, limit int) ([]listing.RoleHolder, error) {
+	// A user holds the role when assigned it, or a role inheriting it, directly
+	// or through a group. Members of a nested group are members of its parents.
+	// Each user is listed once, with the most direct source.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE inheriting AS (
+			SELECT
+				@role_id::uuid AS role_id
+			UNION
+			SELECT
+				rh.parent_role_id
+			FROM
+				role_hierarchy rh
+			JOIN
+				inheriting i ON rh.child_role_id = i.role_id
+		),
+		member_groups AS (
+			SELECT
+				gr.group_id, gr.role_id, gr.group_id AS via_group_id
+			FROM
+				group_roles gr
+			JOIN
+				inheriting i ON gr.role_id = i.role_id
+			UNION
+			SELECT
+				gh.child_group_id, mg.role_id, mg.via_group_id
+			FROM
+				member_groups mg
+			JOIN
+				group_hierarchy gh ON gh.parent_group_id = mg.group_id
+		),
+		holders AS (
+			SELECT
+				ur.user_id, ur.role_id, NULL::uuid AS group_id
+			FROM
+				user_roles ur
+			JOIN
+				inheriting i ON ur.role_id = i.role_id
+			WHERE
+				ur.expires_at IS NULL OR ur.expires_at > NOW()
+			UNION ALL
+			SELECT
+				gm.user_id, mg.role_id, mg.via_group_id
+			FROM
+				group_members gm
+			JOIN
+				member_groups mg ON gm.group_id = mg.group_id
+		),
+		sourced AS (
+			SELECT
+				h.user_id,
+				CASE
+					WHEN h.role_id <> @role_id::uuid THEN 'inherited'
+					WHEN h.group_id IS NOT NULL THEN 'group'
+					ELSE 'direct'
+				END AS source,
+				CASE
+					WHEN h.role_id <> @role_id::uuid THEN r.role_name
+					WHEN h.group_id IS NOT NULL THEN g.group_name
+					ELSE ''
+				END AS via
+			FROM
+				holders h
+			JOIN
+				roles r ON h.role_id = r.role_id
+			LEFT JOIN
+				groups g ON h.group_id = g.group_id
+			WHERE
+				@after::text[] IS NULL OR h.user_id::text > (@after::text[])[1]
+		)
+		SELECT DISTINCT ON (s.user_id::text)
+			s.user_id, s.source, s.via
+		FROM
+			sourced s
+		WHERE
+			@source::text = '' OR s.source = @source::text
+		ORDER BY
+			s.user_id::text ASC,
+			CASE s.source WHEN 'direct' THEN 1 WHEN 'group' THEN 2 ELSE 3 END ASC,
+			s.via ASC
+		LIMIT @limit
+		`, pgx.NamedArgs{
+		"role_id": filter.RoleID,
+		"source":  string(filter.Source),
+		"after":   []string(after),
+		"limit":   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query user_roles: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	holders := make([]listing.RoleHolder, 0)
+	for rows.Next() {
+		var h listing.RoleHolder
+		if err := rows.Scan(&h.UserID, &h.Source, &h.Via); err != nil {
+			return nil, fmt.Errorf("scan user_roles: %w", err)
+		}
+		holders = append(holders, h)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_roles: %w", rows.Err())
+	}
+
+	return holders, nil
+}
+
+func (pr *PermissionsRepo) ListRoleGrants(ctx context.Context, filter listing.RoleGrantsFilter, after listing.Key, limit int) ([]listing.RoleGrant, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	grants, err := pr.listRoleGrants(ctx, tx, filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (pr *PermissionsRepo) listRoleGrants(ctx context.Context, tx pgx.Tx, filter listing.RoleGrantsFilter, after listing.Key, limit int) ([]listing.RoleGrant, error) {
+	// A role grants the permission when it, or a role it inherits, is granted
+	// the permission or a wildcard matching it. Each role is listed once,
+	// preferring a direct grant.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE granting AS (
+			SELECT
+				rp.role_id, rp.role_id AS via_role_id, p.permission_name, COALESCE(rp.condition, '') AS condition
+			FROM
+				role_permissions rp
+			JOIN
+				permissions p ON rp.permission_id = p.permission_id
+			JOIN
+				tenant_permissions tp ON rp.permission_id = tp.permission_id
+			WHERE
+				LOWER(p.permission_name) = ANY(@permission_names::text[])
+			UNION
+			SELECT
+				rh.parent_role_id, g.via_role_id, g.permission_name, g.condition
+			FROM
+				granting g
+			JOIN
+				role_hierarchy rh ON rh.child_role_id = g.role_id
+			WHERE
+				NOT @direct_only::boolean
+		)
+		SELECT DISTINCT ON (r.role_name, r.role_id::text)
+			r.role_id, r.role_name, g.permission_name, g.condition,
+			CASE WHEN g.via_role_id = g.role_id THEN '' ELSE v.role_name END
+		FROM
+			granting g
+		JOIN
+			roles r ON g.role_id = r.role_id
+		JOIN
+			roles v ON g.via_role_id = v.role_id
+		WHERE
+			@after::text[] IS NULL OR (r.role_name, r.role_id::text) > ((@after::text[])[1], (@after::text[])[2])
+		ORDER BY
+			r.role_name ASC, r.role_id::text ASC,
+			g.via_role_id = g.role_id DESC,
+			g.permission_name ASC, v.role_name ASC
+		LIMIT @limit
+		`, pgx.NamedArgs{
+		"permission_names": matchingPermissionNames(filter.Permission),
+		"direct_only":      filter.DirectOnly,
+		"after":            []string(after),
+		"limit":            limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query role_permissions: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	grants := make([]listing.RoleGrant, 0)
+	for rows.Next() {
+		var g listing.RoleGrant
+		if err := rows.Scan(&g.Role.ID, &g.Role.Name, &g.Permission, &g.Condition, &g.Via); err != nil {
+			return nil, fmt.Errorf("scan role_permissions: %w", err)
+		}
+		grants = append(grants, g)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows role_permissions: %w", rows.Err())
+	}
+
+	return grants, nil
+}
+
+// matchingPermissionNames returns the lower case names of the permissions which
+// grant the permission, itself and the wildcards matching it.
+func matchingPermissionNames(name string) []string {
+	name = strings.ToLower(name)
+	resource, action, _ := strings.Cut(name, permissions.PermissionSeparator)
+	return []string{
+		name,
+		resource + permissions.PermissionSeparator + permissions.Wildcard,
+		permissions.Wildcard + permissions.PermissionSeparator + action,
+		permissions.Wildcard + permissions.PermissionSeparator + permissions.Wildcard,
+	}
+}
+
+func (pr *PermissionsRepo) ListResourceTypePermissions(ctx context.Context, resourceType string, after listing.Key, limit int) ([]permissions.TenantPermission, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	tps, err := pr.listResourceTypePermissions(ctx, tx, resourceType, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return tps, nil
+}
+
+func (pr *PermissionsRepo) listResourceTypePermissions(ctx context.Context, tx pgx.Tx, resourceType string, after listing.Key, limit int) ([]permissions.TenantPermission, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			p.permission_id, p.permission_name
+		FROM
+			tenant_permissions tp
+		JOIN
+			permissions p ON tp.permission_id = p.permission_id
+		WHERE
+			LOWER(p.permission_name) LIKE @prefix::text
+			AND
+			(@after::text[] IS NULL OR (p.permission_name, p.permission_id::text) > ((@after::text[])[1], (@after::text[])[2]))
+		ORDER BY
+			p.permission_name ASC, p.permission_id::text ASC
+		LIMIT @limit
+		`, pgx.NamedArgs{
+		"prefix": likePrefix(strings.ToLower(resourceType) + permissions.PermissionSeparator),
+		"after":  []string(after),
+		"limit":  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query tenant_permissions: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	tps := make([]permissions.TenantPermission, 0)
+	for rows.Next() {
+		var tp permissions.TenantPermission
+		if err := rows.Scan(&tp.ID, &tp.Name); err != nil {
+			return nil, fmt.Errorf("scan tenant_permissions: %w", err)
+		}
+		tps = append(tps, tp)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows tenant_permissions: %w", rows.Err())
+	}
+
+	return tps, nil
+}
+
+// likePrefix returns the LIKE pattern matching strings starting with the prefix.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+func (pr *PermissionsRepo) ListResourceGrantees(ctx context.Context, filter listing.ResourceGranteesFilter, after listing.Key, limit int) ([]listing.ResourceGrantee, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	grantees, err := pr.listResourceGrantees(ctx, tx, filter, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return grantees, nil
+}
+
+func (pr *PermissionsRepo) listResourceGrantees(ctx context.Context, tx pgx.Tx, filter listing.ResourceGranteesFilter, after listing.Key, limit int) ([]listing.ResourceGrantee, error) {
+	// A grant on a resource applies to its descendants, so the grants on the
+	// resource's ancestors are included, with the ancestor they were made on.
+	// Repeated grants are listed once.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE ancestors AS (
+			SELECT
+				rt.resource_type_id, @resource_id::uuid AS resource_id,
+				rt.resource_type_id AS granted_resource_type_id, @resource_id::uuid AS granted_resource_id
+			FROM
+				resource_types rt
+			WHERE
+				@resource_type::text = '' OR LOWER(rt.resource_type_name) = LOWER(@resource_type::text)
+			UNION
+			SELECT
+				a.resource_type_id, a.resource_id,
+				rh.parent_resource_type_id, rh.parent_resource_id
+			FROM
+				ancestors a
+			JOIN
+				resource_hierarchy rh ON rh.child_resource_type_id = a.granted_resource_type_id AND rh.child_resource_id = a.granted_resource_id
+			WHERE
+				NOT @direct_only::boolean
+		),
+		grants AS (
+			SELECT
+				ur.user_id::text AS user_id, p.permission_name, COALESCE(ur.condition, '') AS condition,
+				rt.resource_type_name, a.resource_id::text AS resource_id,
+				CASE WHEN a.granted_resource_id = a.resource_id AND a.granted_resource_type_id = a.resource_type_id
+					THEN '' ELSE grt.resource_type_name END AS granted_resource_type_name,
+				CASE WHEN a.granted_resource_id = a.resource_id AND a.granted_resource_type_id = a.resource_type_id
+					THEN '' ELSE a.granted_resource_id::text END AS granted_resource_id
+			FROM
+				ancestors a
+			JOIN
+				user_resources ur ON ur.resource_type_id = a.granted_resource_type_id AND ur.resource_id = a.granted_resource_id
+			JOIN
+				permissions p ON ur.permission_id = p.permission_id
+			JOIN
+				resource_types rt ON a.resource_type_id = rt.resource_type_id
+			JOIN
+				resource_types grt ON a.granted_resource_type_id = grt.resource_type_id
+			WHERE
+				ur.expires_at IS NULL OR ur.expires_at > NOW()
+		)
+		SELECT DISTINCT ON (g.user_id, g.permission_name, g.resource_type_name, g.granted_resource_type_name, g.granted_resource_id)
+			g.user_id, g.permission_name, g.condition, g.resource_type_name, g.resource_id,
+			g.granted_resource_type_name, g.granted_resource_id
+		FROM
+			grants g
+		WHERE
+			@after::text[] IS NULL
+			OR
+			(g.user_id, g.permission_name, g.resource_type_name, g.granted_resource_type_name, g.granted_resource_id)
+				> ((@after::text[])[1], (@after::text[])[2], (@after::text[])[3], (@after::text[])[4], (@after::text[])[5])
+		ORDER BY
+			g.user_id ASC, g.permission_name ASC, g.resource_type_name ASC, g.granted_resource_type_name ASC, g.granted_resource_id ASC,
+			g.condition ASC
+		LIMIT @limit
+		`, pgx.NamedArgs{
+		"resource_id":   filter.ResourceID,
+		"resource_type": filter.ResourceType,
+		"direct_only":   filter.DirectOnly,
+		"after":         []string(after),
+		"limit":         limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query user_resources: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	grantees := make([]listing.ResourceGrantee, 0)
+	for rows.Next() {
+		var g listing.ResourceGrantee
+		var inheritedFrom permissions.Resource
+		if err := rows.Scan(&g.UserID, &g.Permission, &g.Condition, &g.Resource.Type, &g.Resource.ID, &inheritedFrom.Type, &inheritedFrom.ID); err != nil {
+			return nil, fmt.Errorf("scan user_resources: %w", err)
+		}
+		if inheritedFrom.ID != "" {
+			g.InheritedFrom = &inheritedFrom
+		}
+		grantees = append(grantees, g)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_resources: %w", rows.Err())
+	}
+
+	return grantees, nil
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/manifests"
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetRegistry(ctx context.Context) (*manifests.Registry, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	registry, err := pr.getRegistry(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return registry, nil
+}
+
+func (pr *PermissionsRepo) getRegistry(ctx context.Context, tx pgx.Tx) (*manifests.Registry, error) {
+	registry := &manifests.Registry{}
+
+	rows, err := tx.Query(ctx, `
+		SELECT
+			resource_type_name, COALESCE(description, ''), COALESCE(registered_by, '')
+		FROM
+			resource_types
+		ORDER BY
+			resource_type_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query resource_types: %w", dbError(err))
+	}
+	for rows.Next() {
+		var rt manifests.RegisteredResourceType
+		if err := rows.Scan(&rt.Name, &rt.Description, &rt.Service); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan resource_types: %w", err)
+		}
+		registry.ResourceTypes = append(registry.ResourceTypes, rt)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows resource_types: %w", rows.Err())
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			permission_name, COALESCE(description, ''), COALESCE(registered_by, ''), deprecated_at IS NOT NULL
+		FROM
+			permissions
+		ORDER BY
+			permission_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query permissions: %w", dbError(err))
+	}
+	for rows.Next() {
+		var p manifests.RegisteredPermission
+		if err := rows.Scan(&p.Name, &p.Description, &p.Service, &p.Deprecated); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan permissions: %w", err)
+		}
+		registry.Permissions = append(registry.Permissions, p)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows permissions: %w", rows.Err())
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			role_name
+		FROM
+			roles
+		ORDER BY
+			role_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query roles: %w", dbError(err))
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan roles: %w", err)
+		}
+		registry.Roles = append(registry.Roles, name)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows roles: %w", rows.Err())
+	}
+
+	return registry, nil
+}
+
+func (pr *PermissionsRepo) RegisterManifest(ctx context.Context, registration *manifests.Registration) error {
+	return inSoDTx(ctx, pr.tenantPool, sodAllUsers, func(tx pgx.Tx) error {
+		if err := lockResourceTypes(ctx, tx); err != nil {
+			return err
+		}
+		for _, rt := range registration.ResourceTypes {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO resource_types
+					(resource_type_id, resource_type_name, description, registered_by)
+				VALUES
+					((SELECT COALESCE(MAX(resource_type_id), 0) + 1 FROM resource_types), @name, NULLIF(@description::text, ''), @service)
+				ON CONFLICT (resource_type_name) DO UPDATE SET
+					description = EXCLUDED.description,
+					registered_by = EXCLUDED.registered_by
+				`, pgx.NamedArgs{
+				"name":        rt.Name,
+				"description": rt.Description,
+				"service":     registration.Service,
+			})
+			if err != nil {
+				return fmt.Errorf("upsert resource_types %s: %w", rt.Name, err)
+			}
+		}
+
+		for _, p := range registration.Permissions {
+			query := `
+				UPDATE permissions SET
+					description = NULLIF(@description::text, ''),
+					registered_by = @service,
+					deprecated_at = NULL
+				WHERE
+					permission_name = @name
+				`
+			switch p.Change {
+			case manifests.ChangeAdded:
+				query = `
+					INSERT INTO permissions
+						(permission_id, permission_name, description, registered_by)
+					VALUES
+						(@id, @name, NULLIF(@description::text, ''), @service)
+					`
+			case manifests.ChangeDeprecated:
+				query = `
+					UPDATE permissions SET
+						deprecated_at = @at
+					WHERE
+						permission_name = @name
+					`
+			}
+			_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+				"id":          uuid.NewString(),
+				"name":        p.Name,
+				"description": p.Description,
+				"service":     registration.Service,
+				"at":          registration.RegisteredAt,
+			})
+			if err != nil {
+				return fmt.Errorf("%s permissions %s: %w", p.Change, p.Name, err)
+			}
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tenant_permissions
+				(permission_id, created_at)
+			SELECT
+				permission_id, NOW()
+			FROM
+				permissions
+			WHERE
+				permission_name = ANY(@names::text[])
+			ON CONFLICT (permission_id) DO NOTHING
+			`, pgx.NamedArgs{
+			"names": registration.Enabled,
+		})
+		if err != nil {
+			return fmt.Errorf("insert tenant_permissions: %w", err)
+		}
+
+		events := []outbox.Event{outbox.NewEvent(outbox.EventRoleGraphChanged, outbox.RoleGraphChange{Source: "register"})}
+		for _, g := range registration.Grants {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO role_permissions
+					(role_id, permission_id, created_at)
+				SELECT
+					r.role_id, p.permission_id, NOW()
+				FROM
+					roles r, permissions p
+				WHERE
+					r.role_name = @role
+					AND
+					p.permission_name = @permission
+				ON CONFLICT (role_id, permission_id) DO NOTHING
+				`, pgx.NamedArgs{
+				"role":       g.Role,
+				"permission": g.Permission,
+			})
+			if err != nil {
+				return fmt.Errorf("insert role_permissions %s %s: %w", g.Role, g.Permission, err)
+			}
+			events = append(events, outbox.NewEvent(outbox.EventPermissionGranted, outbox.RolePermission{Role: g.Role, Permission: g.Permission}))
+		}
+
+		return insertEvents(ctx, tx, events...)
+	})
+}
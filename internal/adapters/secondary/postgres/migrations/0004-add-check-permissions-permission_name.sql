@@ -0,0 +1,6 @@
+-- Permission names are of the form resource:action.
+-- Either part may be the wildcard '*' (e.g. 'invoices:*' or '*:read'), which
+-- grants every permission it covers, but '*' may not appear within a part.
+ALTER TABLE permissions
+    ADD CONSTRAINT chk_permissions_permission_name
+    CHECK (permission_name ~ '^(\*|[^:*[:space:]]+):(\*|[^:*[:space:]]+)$');
@@ -0,0 +1,44 @@
+-- groups are the Tenant's groups of users.
+-- A role assigned to a group is held by every member of the group.
+CREATE TABLE groups (
+    group_id UUID PRIMARY KEY,
+    group_name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- group_members are the users that are members of a group.
+CREATE TABLE group_members (
+    group_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (group_id, user_id),
+    FOREIGN KEY (group_id) REFERENCES groups(group_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_group_members_user_id ON group_members (user_id);
+
+-- group_roles are the roles that are assigned to a group.
+CREATE TABLE group_roles (
+    group_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (group_id, role_id),
+    FOREIGN KEY (group_id) REFERENCES groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_group_roles_role_id ON group_roles (role_id);
+
+-- group_hierarchy is the nesting of groups.
+-- A group (child_group_id) can be nested in another group (parent_group_id),
+-- members of the child group are also members of the parent group.
+CREATE TABLE group_hierarchy (
+    parent_group_id UUID NOT NULL,
+    child_group_id UUID NOT NULL,
+    FOREIGN KEY (parent_group_id) REFERENCES groups(group_id) ON DELETE CASCADE,
+    FOREIGN KEY (child_group_id) REFERENCES groups(group_id) ON DELETE CASCADE,
+    PRIMARY KEY (parent_group_id, child_group_id),
+    CONSTRAINT chk_parent_child_group_different CHECK (parent_group_id <> child_group_id)
+);
+CREATE INDEX idx_group_hierarchy_child_group_id ON group_hierarchy (child_group_id);
@@ -0,0 +1,30 @@
+-- resource_type_hierarchy is the hierarchy of resource types.
+-- A resource type (child_resource_type_id) can belong to another resource type (parent_resource_type_id),
+-- e.g. contacts belong to customers. A resource type can belong to multiple resource types.
+CREATE TABLE resource_type_hierarchy (
+    parent_resource_type_id BIGINT NOT NULL,
+    child_resource_type_id BIGINT NOT NULL,
+    FOREIGN KEY (parent_resource_type_id) REFERENCES resource_types(resource_type_id) ON DELETE CASCADE,
+    FOREIGN KEY (child_resource_type_id) REFERENCES resource_types(resource_type_id) ON DELETE CASCADE,
+    PRIMARY KEY (parent_resource_type_id, child_resource_type_id),
+    CONSTRAINT chk_parent_child_resource_type_different CHECK (parent_resource_type_id <> child_resource_type_id)
+);
+CREATE INDEX idx_resource_type_hierarchy_child_resource_type_id ON resource_type_hierarchy (child_resource_type_id);
+
+-- resource_hierarchy is the hierarchy of resources, which are externally defined.
+-- A resource (child_resource_id) can belong to another resource (parent_resource_id),
+-- as long as its resource type belongs to the parent's resource type.
+-- A User's grant on a resource in user_resources applies to all of the resource's descendants.
+CREATE TABLE resource_hierarchy (
+    parent_resource_type_id BIGINT NOT NULL,
+    parent_resource_id UUID NOT NULL,
+    child_resource_type_id BIGINT NOT NULL,
+    child_resource_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (parent_resource_type_id, parent_resource_id, child_resource_type_id, child_resource_id),
+    FOREIGN KEY (parent_resource_type_id, child_resource_type_id) 
+        REFERENCES resource_type_hierarchy(parent_resource_type_id, child_resource_type_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_resource_hierarchy_parent_resource_id ON resource_hierarchy (parent_resource_id);
+CREATE INDEX idx_resource_hierarchy_child_resource_id ON resource_hierarchy (child_resource_id);
@@ -0,0 +1,12 @@
+-- condition is an optional expression which must hold, against the attributes supplied with a request,
+-- for the grant to apply, e.g. 'request.hour >= 9 && request.hour < 17'. NULL means the grant always applies.
+-- The expression language is implemented by pkg/condition.
+ALTER TABLE role_permissions
+    ADD COLUMN condition TEXT,
+    ADD CONSTRAINT chk_role_permissions_condition CHECK (condition <> '');
+ALTER TABLE user_permissions
+    ADD COLUMN condition TEXT,
+    ADD CONSTRAINT chk_user_permissions_condition CHECK (condition <> '');
+ALTER TABLE user_resources
+    ADD COLUMN condition TEXT,
+    ADD CONSTRAINT chk_user_resources_condition CHECK (condition <> '');
@@ -0,0 +1,65 @@
+-- relation_tuples are the relationships written by services, "object_type:object_id#relation@subject",
+-- e.g. document:readme#editor@user:<id>. The subject is either a single subject (subject_relation is ''),
+-- or every subject with subject_relation to subject_type:subject_id, e.g. document:readme#viewer@folder:reports#viewer.
+CREATE TABLE relation_tuples (
+    object_type TEXT NOT NULL,
+    object_id TEXT NOT NULL,
+    relation TEXT NOT NULL,
+    subject_type TEXT NOT NULL,
+    subject_id TEXT NOT NULL,
+    subject_relation TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (object_type, object_id, relation, subject_type, subject_id, subject_relation),
+    CONSTRAINT chk_relation_tuples_object_type CHECK (object_type NOT IN ('role', 'group'))
+);
+CREATE INDEX idx_relation_tuples_subject ON relation_tuples (subject_type, subject_id, subject_relation);
+
+-- relation_tuples_all are the relation_tuples along with the tuples projected from the RBAC tables,
+-- so that relationship checks and role based checks answer consistently:
+--   role:<role_id>#member@user:<user_id>               users assigned the role
+--   role:<role_id>#member@group:<group_id>#member      members of groups assigned the role
+--   role:<child_role_id>#member@role:<parent_role_id>#member  members of roles inheriting the role
+--   group:<group_id>#member@user:<user_id>             members of the group
+--   group:<parent_group_id>#member@group:<child_group_id>#member  members of nested groups
+--   <resource_type>:<resource_id>#<action>@user:<user_id>  unconditional grants in user_resources
+CREATE VIEW relation_tuples_all AS
+    SELECT
+        object_type, object_id, relation, subject_type, subject_id, subject_relation
+    FROM
+        relation_tuples
+    UNION ALL
+    SELECT
+        'role', ur.role_id::text, 'member', 'user', ur.user_id::text, ''
+    FROM
+        user_roles ur
+    UNION ALL
+    SELECT
+        'role', gr.role_id::text, 'member', 'group', gr.group_id::text, 'member'
+    FROM
+        group_roles gr
+    UNION ALL
+    SELECT
+        'role', rh.child_role_id::text, 'member', 'role', rh.parent_role_id::text, 'member'
+    FROM
+        role_hierarchy rh
+    UNION ALL
+    SELECT
+        'group', gm.group_id::text, 'member', 'user', gm.user_id::text, ''
+    FROM
+        group_members gm
+    UNION ALL
+    SELECT
+        'group', gh.parent_group_id::text, 'member', 'group', gh.child_group_id::text, 'member'
+    FROM
+        group_hierarchy gh
+    UNION ALL
+    SELECT
+        rt.resource_type_name, ur.resource_id::text, split_part(p.permission_name, ':', 2), 'user', ur.user_id::text, ''
+    FROM
+        user_resources ur
+    JOIN
+        resource_types rt ON ur.resource_type_id = rt.resource_type_id
+    JOIN
+        permissions p ON ur.permission_id = p.permission_id
+    WHERE
+        ur.condition IS NULL;
@@ -0,0 +1,15 @@
+-- outbox holds the events for changes to the Tenant's permissions, inserted in the same transaction as
+-- the change, and published by the relay in internal/app/outbox. An event is due for publishing when
+-- published_at is NULL and next_attempt_at has passed; claiming an event moves next_attempt_at
+-- forward by a lease, so that it is retried if the relay stops before marking it.
+CREATE TABLE outbox (
+    outbox_id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX idx_outbox_due ON outbox (next_attempt_at) WHERE published_at IS NULL;
@@ -0,0 +1,11 @@
+-- Indexes for the paginated listings in internal/app/listing, which page through each listing
+-- in order of the indexed columns.
+
+-- Users assigned a role, in order of user.
+CREATE INDEX idx_user_roles_role_id_user_id ON user_roles (role_id, user_id);
+
+-- Permissions of a resource type, matched by a case-insensitive prefix such as 'invoices:%'.
+CREATE INDEX idx_permissions_lower_permission_name ON permissions (LOWER(permission_name) text_pattern_ops);
+
+-- Users with grants on a resource, in order of user.
+CREATE INDEX idx_user_resources_resource_id_user_id ON user_resources (resource_id, user_id);
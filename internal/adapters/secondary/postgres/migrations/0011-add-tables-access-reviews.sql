@@ -0,0 +1,35 @@
+-- access_review_campaigns are reviews of who holds which role, of every role in the Tenant or of one role.
+-- The assignments are snapshotted into access_review_items when the campaign is created, and the
+-- reviewers' revoke decisions are applied when it is closed.
+CREATE TABLE access_review_campaigns (
+    campaign_id UUID PRIMARY KEY,
+    campaign_name TEXT NOT NULL,
+    role_id UUID,                           -- The role reviewed, NULL for every role. Not a foreign key, so the campaign outlives the role.
+    reviewer_ids UUID[] NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('open', 'closed')),
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_by UUID,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- access_review_items are the role assignments under review, as they were when the campaign was created.
+-- A role held through a group is reviewed as the user's membership of the group, which a revoke removes.
+CREATE TABLE access_review_items (
+    item_id UUID PRIMARY KEY,
+    campaign_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    role_name TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('direct', 'group')),
+    group_id UUID,                          -- The group the user is a member of, set when source is 'group'.
+    group_name TEXT,
+    reviewer_id UUID NOT NULL,
+    decision TEXT NOT NULL DEFAULT 'pending' CHECK (decision IN ('pending', 'keep', 'revoke')),
+    comment TEXT,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    applied_at TIMESTAMP WITH TIME ZONE,    -- When a revoke decision was applied.
+    FOREIGN KEY (campaign_id) REFERENCES access_review_campaigns(campaign_id) ON DELETE CASCADE,
+    CONSTRAINT chk_access_review_items_group CHECK ((source = 'group') = (group_id IS NOT NULL))
+);
+CREATE INDEX idx_access_review_items_campaign_id_reviewer_id ON access_review_items (campaign_id, reviewer_id);
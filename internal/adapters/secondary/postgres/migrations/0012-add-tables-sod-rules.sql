@@ -0,0 +1,29 @@
+-- sod_rules are the Tenant's separation of duties rules, each a set of roles or permissions
+-- of which no user may hold two. A static rule is enforced when roles and permissions are
+-- granted. A dynamic rule, only of roles, lets a user hold them but not have two active in
+-- the same request.
+CREATE TABLE sod_rules (
+    rule_id UUID PRIMARY KEY,
+    rule_name TEXT NOT NULL UNIQUE,
+    enforcement TEXT NOT NULL CHECK (enforcement IN ('static', 'dynamic')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- sod_rule_roles are the roles of a rule of roles.
+CREATE TABLE sod_rule_roles (
+    rule_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    PRIMARY KEY (rule_id, role_id),
+    FOREIGN KEY (rule_id) REFERENCES sod_rules(rule_id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_sod_rule_roles_role_id ON sod_rule_roles (role_id);
+
+-- sod_rule_permissions are the permissions of a rule of permissions, held through any role,
+-- extra permission or wildcard which grants them.
+CREATE TABLE sod_rule_permissions (
+    rule_id UUID NOT NULL,
+    permission_name TEXT NOT NULL,
+    PRIMARY KEY (rule_id, permission_name),
+    FOREIGN KEY (rule_id) REFERENCES sod_rules(rule_id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+-- role_template_adoptions are the Tenant's roles adopting a template of the shared role template
+-- catalogue. An adopting role is granted the template's permissions and its extensions, and is
+-- kept in step with the template by "userperms template sync". Detaching a role deletes its row
+-- and leaves the role as it is.
+CREATE TABLE role_template_adoptions (
+    role_id UUID PRIMARY KEY,
+    template_name TEXT NOT NULL,
+    -- The version of the template the role was last synced with.
+    template_version TEXT NOT NULL,
+    -- The permissions the Tenant grants the role beyond the template's.
+    extensions TEXT[] NOT NULL DEFAULT '{}',
+    adopted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE
+);
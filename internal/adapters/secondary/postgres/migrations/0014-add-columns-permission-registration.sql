@@ -0,0 +1,11 @@
+-- Resource types and permissions may be registered from the manifest a consuming service publishes,
+-- see "userperms manifest". registered_by is the service, NULL for those seeded by SQL or imported.
+-- A permission missing from its service's latest manifest is deprecated rather than deleted, as
+-- roles and users may still hold it, and restored if it comes back.
+ALTER TABLE resource_types
+    ADD COLUMN description TEXT,
+    ADD COLUMN registered_by TEXT;
+ALTER TABLE permissions
+    ADD COLUMN description TEXT,
+    ADD COLUMN registered_by TEXT,
+    ADD COLUMN deprecated_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,6 @@
+-- expires_at is when a role assignment or resource grant lapses, NULL for never. Once expired it
+-- no longer grants anything, and is left out wherever assignments and grants are read.
+ALTER TABLE user_roles
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE user_resources
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,43 @@
+-- scim_users are the users provisioned by the Tenant's identity provider over SCIM. The user_id is
+-- the user ID of the permissions service, assigned when the user is created. A user made inactive
+-- loses all their grants, and cannot be made a member of a group while inactive.
+CREATE TABLE scim_users (
+    user_id UUID PRIMARY KEY,
+    user_name TEXT NOT NULL,
+    external_id TEXT,
+    display_name TEXT,
+    given_name TEXT,
+    family_name TEXT,
+    email TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+-- userName is unique, compared case-insensitively as SCIM does.
+CREATE UNIQUE INDEX idx_scim_users_user_name ON scim_users (LOWER(user_name));
+
+-- scim_groups are the groups provisioned over SCIM, each mapped to either a role, whose members
+-- are the SCIM users assigned it directly, or a group, whose members are its SCIM users.
+CREATE TABLE scim_groups (
+    scim_group_id UUID PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    external_id TEXT,
+    role_id UUID UNIQUE,
+    group_id UUID UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(group_id) ON DELETE CASCADE,
+    CONSTRAINT chk_scim_groups_target CHECK (num_nonnulls(role_id, group_id) = 1)
+);
+CREATE UNIQUE INDEX idx_scim_groups_display_name ON scim_groups (LOWER(display_name));
+
+-- scim_settings is the Tenant's SCIM configuration, a single row when it has been set.
+-- group_target is what new SCIM groups are mapped to, the role of the same name or a group.
+CREATE TABLE scim_settings (
+    scim_settings_id BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    group_target TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT chk_scim_settings_single_row CHECK (scim_settings_id),
+    CONSTRAINT chk_scim_settings_group_target CHECK (group_target IN ('role', 'group'))
+);
@@ -0,0 +1,37 @@
+-- delegations lend some of the delegator's roles or permissions to the delegate for a window,
+-- from starts_at until ends_at. The delegate holds them only while the delegator does, which is
+-- checked when the delegate's permissions are read, so a delegation lapses as soon as the
+-- delegator loses what it delegates. A revoked delegation is deleted.
+CREATE TABLE delegations (
+    delegation_id UUID PRIMARY KEY,
+    delegator_id UUID NOT NULL,
+    delegate_id UUID NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (delegator_id <> delegate_id),
+    CHECK (starts_at < ends_at)
+);
+CREATE INDEX idx_delegations_delegate_id ON delegations (delegate_id, ends_at);
+CREATE INDEX idx_delegations_delegator_id ON delegations (delegator_id, ends_at);
+
+-- delegation_roles are the roles delegated, with the permissions they and the roles they inherit grant.
+CREATE TABLE delegation_roles (
+    delegation_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    PRIMARY KEY (delegation_id, role_id),
+    FOREIGN KEY (delegation_id) REFERENCES delegations(delegation_id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_delegation_roles_role_id ON delegation_roles (role_id);
+
+-- delegation_permissions are the single permissions delegated.
+CREATE TABLE delegation_permissions (
+    delegation_id UUID NOT NULL,
+    permission_id UUID NOT NULL,
+    PRIMARY KEY (delegation_id, permission_id),
+    FOREIGN KEY (delegation_id) REFERENCES delegations(delegation_id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(permission_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_delegation_permissions_permission_id ON delegation_permissions (permission_id);
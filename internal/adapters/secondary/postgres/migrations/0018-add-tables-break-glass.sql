@@ -0,0 +1,32 @@
+-- break_glass_settings is the Tenant's break-glass configuration, a single row when it has been set.
+-- role_id is the emergency role break-glass grants, for at most max_duration_seconds at a time.
+CREATE TABLE break_glass_settings (
+    break_glass_settings_id BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    role_id UUID NOT NULL,
+    max_duration_seconds INTEGER NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE,
+    CONSTRAINT chk_break_glass_settings_single_row CHECK (break_glass_settings_id),
+    CONSTRAINT chk_break_glass_settings_max_duration CHECK (max_duration_seconds > 0 AND max_duration_seconds <= 86400)
+);
+
+-- break_glass_sessions are the emergency role's grants through break-glass, each made as a user_roles
+-- row which lapses at expires_at. Ending a session early expires the row at ended_at. Sessions are the
+-- audit trail of break-glass, so they are kept once the assignment, or the role, is gone.
+CREATE TABLE break_glass_sessions (
+    session_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    role_id UUID NOT NULL,                  -- Not a foreign key, so the session outlives the role.
+    role_name TEXT NOT NULL,
+    justification TEXT NOT NULL CHECK (justification <> ''),
+    granted_by UUID,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_by UUID,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    user_roles_id BIGINT,                   -- The assignment made, NULL once it is removed.
+    FOREIGN KEY (user_roles_id) REFERENCES user_roles(user_roles_id) ON DELETE SET NULL,
+    CHECK (started_at < expires_at)
+);
+CREATE INDEX idx_break_glass_sessions_started_at ON break_glass_sessions (started_at);
+CREATE INDEX idx_break_glass_sessions_user_roles_id ON break_glass_sessions (user_roles_id);
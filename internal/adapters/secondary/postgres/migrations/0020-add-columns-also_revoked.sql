@@ -0,0 +1,4 @@
+-- also_revoked are the names of the other roles the user lost when a revoke decision on a role held
+-- through a group was applied, by removing them from the group. NULL until it is applied.
+ALTER TABLE access_review_items
+    ADD COLUMN also_revoked TEXT[];
@@ -0,0 +1,84 @@
+-- relation_tuples_all projects a User's grants on resources as AllowsOn reads them, so that
+-- relationship checks agree with it:
+-- - a grant only projects onto a resource of the type the permission names, or of any type for "*",
+-- - a grant of every action, e.g. "invoices:*", projects the relation "*", which holds every relation,
+-- - a grant also projects its action onto every descendant of the resource, in resource_hierarchy.
+-- Tuples stored in a resource type's namespace are left out, its tuples are only projected.
+CREATE OR REPLACE VIEW relation_tuples_all AS
+    SELECT
+        object_type, object_id, relation, subject_type, subject_id, subject_relation
+    FROM
+        relation_tuples t
+    WHERE
+        NOT EXISTS (SELECT 1 FROM resource_types rt WHERE lower(rt.resource_type_name) = lower(t.object_type))
+    UNION ALL
+    SELECT
+        'role', ur.role_id::text, 'member', 'user', ur.user_id::text, ''
+    FROM
+        user_roles ur
+    WHERE
+        ur.expires_at IS NULL OR ur.expires_at > NOW()
+    UNION ALL
+    SELECT
+        'role', gr.role_id::text, 'member', 'group', gr.group_id::text, 'member'
+    FROM
+        group_roles gr
+    UNION ALL
+    SELECT
+        'role', rh.child_role_id::text, 'member', 'role', rh.parent_role_id::text, 'member'
+    FROM
+        role_hierarchy rh
+    UNION ALL
+    SELECT
+        'group', gm.group_id::text, 'member', 'user', gm.user_id::text, ''
+    FROM
+        group_members gm
+    UNION ALL
+    SELECT
+        'group', gh.parent_group_id::text, 'member', 'group', gh.child_group_id::text, 'member'
+    FROM
+        group_hierarchy gh
+    UNION ALL
+    SELECT
+        rt.resource_type_name, ur.resource_id::text, split_part(p.permission_name, ':', 2), 'user', ur.user_id::text, ''
+    FROM
+        user_resources ur
+    JOIN
+        resource_types rt ON ur.resource_type_id = rt.resource_type_id
+    JOIN
+        permissions p ON ur.permission_id = p.permission_id
+    WHERE
+        ur.condition IS NULL
+        AND
+        (ur.expires_at IS NULL OR ur.expires_at > NOW())
+        AND
+        lower(split_part(p.permission_name, ':', 1)) IN (lower(rt.resource_type_name), '*')
+    UNION ALL
+    SELECT
+        rt.resource_type_name, d.resource_id::text, split_part(p.permission_name, ':', 2), 'user', d.user_id::text, ''
+    FROM (
+        WITH RECURSIVE descendants AS (
+            SELECT
+                ur.user_id, ur.permission_id, rh.child_resource_type_id AS resource_type_id, rh.child_resource_id AS resource_id
+            FROM
+                user_resources ur
+            JOIN
+                resource_hierarchy rh ON rh.parent_resource_type_id = ur.resource_type_id AND rh.parent_resource_id = ur.resource_id
+            WHERE
+                ur.condition IS NULL
+                AND
+                (ur.expires_at IS NULL OR ur.expires_at > NOW())
+            UNION
+            SELECT
+                d.user_id, d.permission_id, rh.child_resource_type_id, rh.child_resource_id
+            FROM
+                descendants d
+            JOIN
+                resource_hierarchy rh ON rh.parent_resource_type_id = d.resource_type_id AND rh.parent_resource_id = d.resource_id
+        )
+        SELECT * FROM descendants
+    ) d
+    JOIN
+        resource_types rt ON d.resource_type_id = rt.resource_type_id
+    JOIN
+        permissions p ON d.permission_id = p.permission_id;
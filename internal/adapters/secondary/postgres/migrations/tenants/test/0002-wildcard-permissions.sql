@@ -0,0 +1,24 @@
+-- Insert wildcard permissions
+INSERT INTO permissions (permission_id, permission_name) VALUES
+    ('0b8f6c3e-3f0a-4c57-9d0e-4d1a2f7c9e11', '*:read'),
+    ('5d2e9a71-8c4b-4f3e-b6a0-7e1c2d3f4a52', 'invoices:*');
+
+INSERT INTO tenant_permissions (permission_id, created_at) VALUES
+    ('0b8f6c3e-3f0a-4c57-9d0e-4d1a2f7c9e11', NOW()),
+    ('5d2e9a71-8c4b-4f3e-b6a0-7e1c2d3f4a52', NOW());
+
+-- The Read Only role can read every resource.
+INSERT INTO roles (role_id, role_name) VALUES
+    ('9a3c1e5f-6b2d-4e8a-a1f7-3c5b7d9e0f24', 'read only');
+
+INSERT INTO role_permissions (role_id, permission_id, created_at) VALUES
+    ('9a3c1e5f-6b2d-4e8a-a1f7-3c5b7d9e0f24', '0b8f6c3e-3f0a-4c57-9d0e-4d1a2f7c9e11', NOW());
+
+INSERT INTO user_roles (user_id, role_id, created_at) VALUES
+    ('e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42', '9a3c1e5f-6b2d-4e8a-a1f7-3c5b7d9e0f24', NOW());
+
+INSERT INTO user_permissions (user_id, permission_id, permission_type, created_at) VALUES
+    -- The Read Only user can also do everything with invoices...
+    ('e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42', '5d2e9a71-8c4b-4f3e-b6a0-7e1c2d3f4a52', 'extra', NOW()),
+    -- ...except delete them.
+    ('e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42', '41c21275-b7d5-4031-b551-b5e293b85319', 'revoked', NOW());
@@ -0,0 +1,16 @@
+-- Insert test data into groups
+INSERT INTO groups (group_id, group_name, created_at) VALUES
+    ('3f6b1d2c-8e4a-4c7b-9a1e-5d2c7b8e9f10', 'sales team', NOW()),
+    ('a8c4e2f1-7b3d-4e9a-8c6b-1d5f3a7e9c21', 'north sales team', NOW());
+
+-- The North Sales Team is part of the Sales Team.
+INSERT INTO group_hierarchy (parent_group_id, child_group_id) VALUES
+    ('3f6b1d2c-8e4a-4c7b-9a1e-5d2c7b8e9f10', 'a8c4e2f1-7b3d-4e9a-8c6b-1d5f3a7e9c21');
+
+-- Members of the Sales Team are Sales Auditors.
+INSERT INTO group_roles (group_id, role_id, created_at) VALUES
+    ('3f6b1d2c-8e4a-4c7b-9a1e-5d2c7b8e9f10', 'da244750-f014-415c-b7b9-43ead3d8fa25', NOW());
+
+-- The Read Only user is a member of the North Sales Team, so is a Sales Auditor through the Sales Team.
+INSERT INTO group_members (group_id, user_id, created_at) VALUES
+    ('a8c4e2f1-7b3d-4e9a-8c6b-1d5f3a7e9c21', 'e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42', NOW());
@@ -0,0 +1,27 @@
+-- Insert test data into resource_types
+INSERT INTO resource_types (resource_type_id, resource_type_name) VALUES
+    (3, 'customers'),
+    (4, 'contacts');
+
+-- Contacts belong to customers.
+INSERT INTO resource_type_hierarchy (parent_resource_type_id, child_resource_type_id) VALUES
+    (3, 4);
+
+-- Insert test data into permissions
+INSERT INTO permissions (permission_id, permission_name) VALUES
+    ('7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13', 'customers:read'),
+    ('b4d6f8a0-1c3e-4a5b-9d7f-0e2a4c6e8f35', 'contacts:read');
+
+-- Insert test data into tenant_permissions
+INSERT INTO tenant_permissions (permission_id, created_at) VALUES
+    ('7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13', NOW()),
+    ('b4d6f8a0-1c3e-4a5b-9d7f-0e2a4c6e8f35', NOW());
+
+-- The customer has two contacts.
+INSERT INTO resource_hierarchy (parent_resource_type_id, parent_resource_id, child_resource_type_id, child_resource_id, created_at) VALUES
+    (3, 'd2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92', 4, '1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71', NOW()),
+    (3, 'd2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92', 4, '5a7c9e1b-3d5f-4a7c-9e1b-3d5f7a9c1e28', NOW());
+
+-- The Account Manager has no roles, and can read the customer, so also its contacts.
+INSERT INTO user_resources (user_id, resource_type_id, resource_id, permission_id, created_at) VALUES
+    ('8e0a2c4e-6f1b-4d3a-b5c7-9d1f3b5d7e64', 3, 'd2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92', '7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13', NOW());
@@ -0,0 +1,7 @@
+-- The Field Agent has no roles. They can read customers during business hours,
+-- and can read a contact while they are working in its region.
+INSERT INTO user_permissions (user_id, permission_id, permission_type, condition, created_at) VALUES
+    ('c3e5a7c9-1b3d-4f5a-9c7e-1b3d5f7a9c46', '7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13', 'extra', 'request.hour >= 9 && request.hour < 17', NOW());
+
+INSERT INTO user_resources (user_id, resource_type_id, resource_id, permission_id, condition, created_at) VALUES
+    ('c3e5a7c9-1b3d-4f5a-9c7e-1b3d5f7a9c46', 4, '1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71', 'b4d6f8a0-1c3e-4a5b-9d7f-0e2a4c6e8f35', 'user.region == "north"', NOW());
@@ -0,0 +1,541 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetModel(ctx context.Context) (*rbac.Model, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	model, err := pr.getModel(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("get model: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return model, nil
+}
+
+func (pr *PermissionsRepo) getModel(ctx context.Context, tx pgx.Tx) (*rbac.Model, error) {
+	model := &rbac.Model{}
+
+	rows, err := tx.Query(ctx, `
+		SELECT
+			resource_type_id, resource_type_name
+		FROM
+			resource_types
+		ORDER BY
+			resource_type_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query resource_types: %w", err)
+	}
+	for rows.Next() {
+		var rt rbac.ResourceType
+		if err := rows.Scan(&rt.ID, &rt.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan resource_types: %w", err)
+		}
+		model.ResourceTypes = append(model.ResourceTypes, rt)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows resource_types: %w", rows.Err())
+	}
+
+	// The model is keyed by name, so names must identify a single permission and role.
+	if _, err := namedIDs(ctx, tx, "permissions"); err != nil {
+		return nil, err
+	}
+	roleNames := make(map[string]string) // role ID -> name
+	roleIDs, err := namedIDs(ctx, tx, "roles")
+	if err != nil {
+		return nil, err
+	}
+	for name, id := range roleIDs {
+		roleNames[id] = name
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			p.permission_name, tp.permission_id IS NOT NULL
+		FROM
+			permissions p
+			LEFT JOIN tenant_permissions tp ON p.permission_id = tp.permission_id
+		ORDER BY
+			p.permission_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query permissions: %w", err)
+	}
+	for rows.Next() {
+		var p rbac.Permission
+		if err := rows.Scan(&p.Name, &p.Enabled); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan permissions: %w", err)
+		}
+		model.Permissions = append(model.Permissions, p)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows permissions: %w", rows.Err())
+	}
+
+	roles := make(map[string]*rbac.Role, len(roleIDs)) // role ID -> role
+	for name, id := range roleIDs {
+		roles[id] = &rbac.Role{Name: name}
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			rp.role_id, p.permission_name, COALESCE(rp.condition, '')
+		FROM
+			role_permissions rp
+			JOIN permissions p ON rp.permission_id = p.permission_id
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query role_permissions: %w", err)
+	}
+	for rows.Next() {
+		var roleID, permissionName, condition string
+		if err := rows.Scan(&roleID, &permissionName, &condition); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan role_permissions: %w", err)
+		}
+		role := roles[roleID]
+		role.Permissions = append(role.Permissions, permissionName)
+		if condition != "" {
+			if role.Conditions == nil {
+				role.Conditions = make(map[string]string)
+			}
+			role.Conditions[permissionName] = condition
+		}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows role_permissions: %w", rows.Err())
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT
+			parent_role_id, child_role_id
+		FROM
+			role_hierarchy
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query role_hierarchy: %w", err)
+	}
+	for rows.Next() {
+		var parentID, childID string
+		if err := rows.Scan(&parentID, &childID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan role_hierarchy: %w", err)
+		}
+		roles[parentID].Inherits = append(roles[parentID].Inherits, roleNames[childID])
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows role_hierarchy: %w", rows.Err())
+	}
+
+	for _, r := range roles {
+		model.Roles = append(model.Roles, *r)
+	}
+
+	// user_roles may hold the same assignment more than once.
+	rows, err = tx.Query(ctx, `
+		SELECT DISTINCT
+			ur.user_id, r.role_name
+		FROM
+			user_roles ur
+			JOIN roles r ON ur.role_id = r.role_id
+		WHERE
+			ur.expires_at IS NULL OR ur.expires_at > NOW()
+		ORDER BY
+			ur.user_id ASC, r.role_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query user_roles: %w", err)
+	}
+	model.Assignments = make([]rbac.Assignment, 0)
+	for rows.Next() {
+		var userID, roleName string
+		if err := rows.Scan(&userID, &roleName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan user_roles: %w", err)
+		}
+		if n := len(model.Assignments); n == 0 || model.Assignments[n-1].UserID != userID {
+			model.Assignments = append(model.Assignments, rbac.Assignment{UserID: userID})
+		}
+		last := &model.Assignments[len(model.Assignments)-1]
+		last.Roles = append(last.Roles, roleName)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_roles: %w", rows.Err())
+	}
+
+	model.Sort()
+	return model, nil
+}
+
+func (pr *PermissionsRepo) GetUserGrants(ctx context.Context) (map[string]rbac.UserGrants, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	grants, err := pr.getUserGrants(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("get user grants: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (pr *PermissionsRepo) getUserGrants(ctx context.Context, tx pgx.Tx) (map[string]rbac.UserGrants, error) {
+	grants := make(map[string]rbac.UserGrants)
+
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE member_groups AS (
+			SELECT 
+				gm.user_id, gm.group_id
+			FROM 
+				group_members gm
+			UNION
+			SELECT 
+				mg.user_id, gh.parent_group_id
+			FROM 
+				group_hierarchy gh
+			JOIN 
+				member_groups mg ON gh.child_group_id = mg.group_id
+		)
+		SELECT DISTINCT
+			mg.user_id, r.role_name
+		FROM 
+			member_groups mg
+		JOIN 
+			group_roles gr ON mg.group_id = gr.group_id
+		JOIN 
+			roles r ON gr.role_id = r.role_id
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query group_roles: %w", err)
+	}
+	for rows.Next() {
+		var userID, roleName string
+		if err := rows.Scan(&userID, &roleName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan group_roles: %w", err)
+		}
+		g := grants[userID]
+		g.GroupRoles = append(g.GroupRoles, roleName)
+		grants[userID] = g
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows group_roles: %w", rows.Err())
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT 
+			up.user_id, p.permission_name, up.permission_type
+		FROM 
+			user_permissions up
+		JOIN 
+			permissions p ON up.permission_id = p.permission_id
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query user_permissions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, permissionName, permissionType string
+		if err := rows.Scan(&userID, &permissionName, &permissionType); err != nil {
+			return nil, fmt.Errorf("scan user_permissions: %w", err)
+		}
+		g := grants[userID]
+		switch permissionType {
+		case "extra":
+			g.Extra = append(g.Extra, permissionName)
+		case "revoked":
+			g.Revoked = append(g.Revoked, permissionName)
+		}
+		grants[userID] = g
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_permissions: %w", rows.Err())
+	}
+
+	return grants, nil
+}
+
+func (pr *PermissionsRepo) ImportModel(ctx context.Context, model *rbac.Model) error {
+	return inSoDTx(ctx, pr.tenantPool, sodAllUsers, func(tx pgx.Tx) error {
+		if err := lockResourceTypes(ctx, tx); err != nil {
+			return err
+		}
+		for _, rt := range model.ResourceTypes {
+			// A resource type without an ID is given the next free one.
+			var id *int64
+			if rt.ID != 0 {
+				id = &rt.ID
+			}
+			_, err := tx.Exec(ctx, `
+				INSERT INTO resource_types
+					(resource_type_id, resource_type_name)
+				VALUES
+					(COALESCE(@resource_type_id::bigint, (SELECT COALESCE(MAX(resource_type_id), 0) + 1 FROM resource_types)), @resource_type_name)
+				ON CONFLICT (resource_type_name) DO NOTHING
+				`, pgx.NamedArgs{
+				"resource_type_id":   id,
+				"resource_type_name": rt.Name,
+			})
+			if err != nil {
+				return fmt.Errorf("insert resource_types %q: %w", rt.Name, err)
+			}
+		}
+
+		for _, p := range model.Permissions {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO permissions
+					(permission_id, permission_name)
+				SELECT
+					@permission_id::uuid, @permission_name::text
+				WHERE NOT EXISTS (
+					SELECT 1 FROM permissions WHERE permission_name = @permission_name
+				)
+				`, pgx.NamedArgs{
+				"permission_id":   uuid.NewString(),
+				"permission_name": p.Name,
+			})
+			if err != nil {
+				return fmt.Errorf("insert permissions %q: %w", p.Name, err)
+			}
+		}
+		permissionIDs, err := namedIDs(ctx, tx, "permissions")
+		if err != nil {
+			return err
+		}
+
+		for _, p := range model.Permissions {
+			query := `
+				DELETE FROM tenant_permissions
+				WHERE
+					permission_id = @permission_id
+				`
+			if p.Enabled {
+				query = `
+				INSERT INTO tenant_permissions
+					(permission_id, created_at)
+				VALUES
+					(@permission_id, NOW())
+				ON CONFLICT (permission_id) DO NOTHING
+				`
+			}
+			if _, err := tx.Exec(ctx, query, pgx.NamedArgs{"permission_id": permissionIDs[p.Name]}); err != nil {
+				return fmt.Errorf("set tenant_permissions %q: %w", p.Name, err)
+			}
+		}
+
+		for _, r := range model.Roles {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO roles
+					(role_id, role_name)
+				SELECT
+					@role_id::uuid, @role_name::text
+				WHERE NOT EXISTS (
+					SELECT 1 FROM roles WHERE role_name = @role_name
+				)
+				`, pgx.NamedArgs{
+				"role_id":   uuid.NewString(),
+				"role_name": r.Name,
+			})
+			if err != nil {
+				return fmt.Errorf("insert roles %q: %w", r.Name, err)
+			}
+		}
+		roleIDs, err := namedIDs(ctx, tx, "roles")
+		if err != nil {
+			return err
+		}
+
+		for _, r := range model.Roles {
+			if err := setRolePermissions(ctx, tx, roleIDs[r.Name], permissionIDs, r); err != nil {
+				return fmt.Errorf("set role %q permissions: %w", r.Name, err)
+			}
+			if err := setRoleInherits(ctx, tx, roleIDs[r.Name], lookupIDs(roleIDs, r.Inherits)); err != nil {
+				return fmt.Errorf("set role %q inherits: %w", r.Name, err)
+			}
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventRoleGraphChanged, outbox.RoleGraphChange{Source: "import"}))
+	})
+}
+
+// setRolePermissions makes the role's permissions exactly those r grants, on
+// their conditions. permissionIDs are keyed by permission name.
+func setRolePermissions(ctx context.Context, tx pgx.Tx, roleID string, permissionIDs map[string]string, r rbac.Role) error {
+	ids := make([]string, 0, len(r.Permissions))
+	conditions := make([]string, 0, len(r.Permissions))
+	for _, name := range r.Permissions {
+		if id, ok := permissionIDs[name]; ok {
+			ids = append(ids, id)
+			conditions = append(conditions, r.Conditions[name])
+		}
+	}
+	args := pgx.NamedArgs{
+		"role_id":        roleID,
+		"permission_ids": ids,
+		"conditions":     conditions,
+	}
+	_, err := tx.Exec(ctx, `
+		DELETE FROM role_permissions
+		WHERE
+			role_id = @role_id
+			AND
+			NOT (permission_id = ANY(@permission_ids::uuid[]))
+		`, args)
+	if err != nil {
+		return fmt.Errorf("delete role_permissions: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO role_permissions
+			(role_id, permission_id, condition, created_at)
+		SELECT
+			@role_id::uuid, g.permission_id, NULLIF(g.condition, ''), NOW()
+		FROM
+			unnest(@permission_ids::uuid[], @conditions::text[]) AS g(permission_id, condition)
+		ON CONFLICT (role_id, permission_id) DO UPDATE SET
+			condition = EXCLUDED.condition,
+			updated_at = NOW()
+		WHERE
+			role_permissions.condition IS DISTINCT FROM EXCLUDED.condition
+		`, args)
+	if err != nil {
+		return fmt.Errorf("insert role_permissions: %w", err)
+	}
+	return nil
+}
+
+// setRoleInherits makes the roles the role inherits from exactly childRoleIDs.
+func setRoleInherits(ctx context.Context, tx pgx.Tx, roleID string, childRoleIDs []string) error {
+	args := pgx.NamedArgs{
+		"parent_role_id": roleID,
+		"child_role_ids": childRoleIDs,
+	}
+	_, err := tx.Exec(ctx, `
+		DELETE FROM role_hierarchy
+		WHERE
+			parent_role_id = @parent_role_id
+			AND
+			NOT (child_role_id = ANY(@child_role_ids::uuid[]))
+		`, args)
+	if err != nil {
+		return fmt.Errorf("delete role_hierarchy: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO role_hierarchy
+			(parent_role_id, child_role_id)
+		SELECT
+			@parent_role_id::uuid, child_role_id
+		FROM
+			unnest(@child_role_ids::uuid[]) AS child_role_id
+		ON CONFLICT (parent_role_id, child_role_id) DO NOTHING
+		`, args)
+	if err != nil {
+		return fmt.Errorf("insert role_hierarchy: %w", err)
+	}
+	return nil
+}
+
+// namedIDs returns the IDs of every permission or role keyed by name.
+// It is an error for two of them to share a name.
+func namedIDs(ctx context.Context, tx pgx.Tx, table string) (map[string]string, error) {
+	var query string
+	switch table {
+	case "permissions":
+		query = `
+		SELECT
+			permission_id, permission_name
+		FROM
+			permissions
+		`
+	case "roles":
+		query = `
+		SELECT
+			role_id, role_name
+		FROM
+			roles
+		`
+	default:
+		return nil, fmt.Errorf("no named IDs for %s", table)
+	}
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", table, err)
+		}
+		if _, ok := ids[name]; ok {
+			return nil, fmt.Errorf("name %q is not unique in %s", name, table)
+		}
+		ids[name] = id
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows %s: %w", table, rows.Err())
+	}
+	return ids, nil
+}
+
+func lookupIDs(ids map[string]string, names []string) []string {
+	found := make([]string, 0, len(names))
+	for _, name := range names {
+		if id, ok := ids[name]; ok {
+			found = append(found, id)
+		}
+	}
+	return found
+}
+
+// lockResourceTypes locks resource_types against other writers until the
+// transaction ends, as ApplyPlan does, so that the next free resource_type_id,
+// MAX + 1, cannot be taken by a concurrent import, registration or plan.
+func lockResourceTypes(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, `LOCK TABLE resource_types IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("lock resource_types: %w", dbError(err))
+	}
+	return nil
+}
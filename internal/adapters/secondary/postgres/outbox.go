@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/jackc/pgx/v5"
+)
+
+// insertEvents writes the events to the outbox, in the transaction making the change they describe.
+func insertEvents(ctx context.Context, tx pgx.Tx, events ...outbox.Event) error {
+	for _, e := range events {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO outbox
+				(event_type, payload, created_at, next_attempt_at)
+			VALUES
+				(@event_type, @payload::jsonb, NOW(), NOW())
+			`, pgx.NamedArgs{
+			"event_type": string(e.Type),
+			"payload":    string(e.Data),
+		})
+		if err != nil {
+			return fmt.Errorf("insert outbox: %w", err)
+		}
+	}
+	return nil
+}
+
+// planEvents are the events for the plan's changes: one for each role assignment
+// and role permission changed, and one for the role graph if anything else changed.
+func planEvents(plan *rbac.Plan) []outbox.Event {
+	var events []outbox.Event
+	graphChanged := false
+	for _, c := range plan.Changes {
+		switch {
+		case c.Kind == rbac.ChangeKindAssignment && c.Action == rbac.ActionAdd:
+			events = append(events, outbox.NewEvent(outbox.EventRoleGranted, outbox.RoleAssignment{UserID: c.Subject, Role: c.Object}))
+		case c.Kind == rbac.ChangeKindAssignment && c.Action == rbac.ActionRemove:
+			events = append(events, outbox.NewEvent(outbox.EventRoleRevoked, outbox.RoleAssignment{UserID: c.Subject, Role: c.Object}))
+		case c.Kind == rbac.ChangeKindRolePermission && c.Action == rbac.ActionAdd:
+			events = append(events, outbox.NewEvent(outbox.EventPermissionGranted, outbox.RolePermission{Role: c.Subject, Permission: c.Object}))
+			graphChanged = true
+		case c.Kind == rbac.ChangeKindRolePermission && c.Action == rbac.ActionRemove:
+			events = append(events, outbox.NewEvent(outbox.EventPermissionRevoked, outbox.RolePermission{Role: c.Subject, Permission: c.Object}))
+			graphChanged = true
+		default:
+			graphChanged = true
+		}
+	}
+	if graphChanged {
+		events = append(events, outbox.NewEvent(outbox.EventRoleGraphChanged, outbox.RoleGraphChange{Source: "apply"}))
+	}
+	return events
+}
+
+func (pr *PermissionsRepo) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]outbox.Event, error) {
+	var events []outbox.Event
+	err := inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		// SKIP LOCKED lets relays run side by side, each claiming different events.
+		rows, err := tx.Query(ctx, `
+			UPDATE outbox
+			SET
+				next_attempt_at = NOW() + make_interval(secs => @lease_seconds::double precision)
+			WHERE
+				outbox_id IN (
+					SELECT
+						outbox_id
+					FROM
+						outbox
+					WHERE
+						published_at IS NULL
+						AND
+						next_attempt_at <= NOW()
+					ORDER BY
+						outbox_id ASC
+					LIMIT @limit::integer
+					FOR UPDATE SKIP LOCKED
+				)
+			RETURNING
+				outbox_id, event_type, payload, created_at, attempts
+			`, pgx.NamedArgs{
+			"lease_seconds": lease.Seconds(),
+			"limit":         limit,
+		})
+		if err != nil {
+			return fmt.Errorf("update outbox: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var e outbox.Event
+			var eventType string
+			if err := rows.Scan(&e.ID, &eventType, &e.Data, &e.CreatedAt, &e.Attempts); err != nil {
+				return fmt.Errorf("scan outbox: %w", err)
+			}
+			e.Type = outbox.EventType(eventType)
+			events = append(events, e)
+		}
+		if rows.Err() != nil {
+			return fmt.Errorf("rows outbox: %w", rows.Err())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(events, func(a, b outbox.Event) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+func (pr *PermissionsRepo) MarkPublished(ctx context.Context, ids []int64) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE outbox
+			SET
+				published_at = NOW(),
+				last_error = NULL
+			WHERE
+				outbox_id = ANY(@outbox_ids::bigint[])
+			`, pgx.NamedArgs{
+			"outbox_ids": ids,
+		})
+		if err != nil {
+			return fmt.Errorf("update outbox: %w", err)
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) MarkFailed(ctx context.Context, id int64, cause string, retryAt time.Time) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE outbox
+			SET
+				attempts = attempts + 1,
+				last_error = @last_error,
+				next_attempt_at = @next_attempt_at
+			WHERE
+				outbox_id = @outbox_id
+			`, pgx.NamedArgs{
+			"outbox_id":       id,
+			"last_error":      cause,
+			"next_attempt_at": retryAt,
+		})
+		if err != nil {
+			return fmt.Errorf("update outbox: %w", err)
+		}
+		return nil
+	})
+}
@@ -35,7 +35,7 @@ func (pr *PermissionsRepo) GetTenantPermissions(ctx context.Context, resources [
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin: %w", err)
+		return nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -98,7 +98,7 @@ func (pr *PermissionsRepo) GetUserPermissions(ctx context.Context, resources []s
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin: %w", err)
+		return nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -131,7 +131,7 @@ func (pr *PermissionsRepo) GetUserPermissionsExtraAndRevoked(ctx context.Context
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("begin: %w", err)
+		return nil, nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -158,7 +158,8 @@ func (pr *PermissionsRepo) getUserPermissionsFromRoles(ctx context.Context, tx p
 		SELECT 
             rp.role_id, 
             p.permission_id, 
-            p.permission_name
+            p.permission_name,
+            COALESCE(rp.condition, '')
         FROM 
             role_permissions rp
         JOIN 
@@ -182,7 +183,7 @@ func (pr *PermissionsRepo) getUserPermissionsFromRoles(ctx context.Context, tx p
 	for rows.Next() {
 		var up permissions.UserPermission
 		var rid string // Role ID - unused.
-		if err := rows.Scan(&rid, &up.ID, &up.Name); err != nil {
+		if err := rows.Scan(&rid, &up.ID, &up.Name, &up.Condition); err != nil {
 			return nil, fmt.Errorf("scan role_permissions: %w", err)
 		}
 		rolePermissions = append(rolePermissions, up)
@@ -201,7 +202,8 @@ func (pr *PermissionsRepo) getUserPermissionsExtraAndRevoked(ctx context.Context
 			SELECT 
 				up.permission_id, 
 				p.permission_name, 
-				up.permission_type
+				up.permission_type,
+				COALESCE(up.condition, '')
 			FROM 
 				user_permissions up
 			JOIN 
@@ -227,7 +229,7 @@ func (pr *PermissionsRepo) getUserPermissionsExtraAndRevoked(ctx context.Context
 
 		var up permissions.UserPermission
 		var permissionType string
-		if err := rows.Scan(&up.ID, &up.Name, &permissionType); err != nil {
+		if err := rows.Scan(&up.ID, &up.Name, &permissionType, &up.Condition); err != nil {
 			return nil, nil, fmt.Errorf("scan user_permissions: %w", err)
 		}
 
@@ -260,7 +262,7 @@ func (pr *PermissionsRepo) GetUserResources(ctx context.Context, resources []str
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin: %w", err)
+		return nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -291,6 +293,8 @@ func (pr *PermissionsRepo) getUserResources(ctx context.Context, tx pgx.Tx, user
 		WHERE 
 			ur.user_id = @user_id
 			AND
+			(ur.expires_at IS NULL OR ur.expires_at > NOW())
+			AND
 			rt.resource_type_name ILIKE ANY(@resource_types::text[])
 		ORDER BY
         	rt.resource_type_name ASC
@@ -320,6 +324,152 @@ func (pr *PermissionsRepo) getUserResources(ctx context.Context, tx pgx.Tx, user
 	return userResources, nil
 }
 
+func (pr *PermissionsRepo) GetUserResourceGrants(ctx context.Context, resources []string) (permissions.ResourceGrants, error) {
+	userID, found := contextkey.UserID(ctx)
+	if !found {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	grants, err := pr.getUserResourceGrants(ctx, tx, userID, resources)
+	if err != nil {
+		return nil, fmt.Errorf("get user resource grants: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (pr *PermissionsRepo) getUserResourceGrants(ctx context.Context, tx pgx.Tx, userID string, resources []string) (permissions.ResourceGrants, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT 
+			ur.resource_id, rt.resource_type_name, p.permission_id, p.permission_name, COALESCE(ur.condition, '')
+		FROM 
+			user_resources ur
+		JOIN 
+			resource_types rt ON ur.resource_type_id = rt.resource_type_id
+		JOIN 
+			permissions p ON ur.permission_id = p.permission_id
+		WHERE 
+			ur.user_id = @user_id
+			AND
+			(ur.expires_at IS NULL OR ur.expires_at > NOW())
+			AND
+			p.permission_name ILIKE ANY (@permission_names::text[])
+		ORDER BY
+			rt.resource_type_name ASC, p.permission_name ASC
+		`, pgx.NamedArgs{
+		"user_id":          userID,
+		"permission_names": permissionNamesForResources(resources),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query user_resources: %w", err)
+	}
+	defer rows.Close()
+
+	var grants permissions.ResourceGrants
+	for rows.Next() {
+		var rg permissions.ResourceGrant
+		if err := rows.Scan(&rg.Resource.ID, &rg.Resource.Type, &rg.Permission.ID, &rg.Permission.Name, &rg.Permission.Condition); err != nil {
+			return nil, fmt.Errorf("scan user_resources: %w", err)
+		}
+		grants = append(grants, rg)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_resources: %w", rows.Err())
+	}
+
+	inherited, err := pr.getUserInheritedResourceGrants(ctx, tx, userID, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(grants, inherited...), nil
+}
+
+// getUserInheritedResourceGrants returns the User's grants on the descendants of the resources they have been granted.
+// The resource types filter applies to the descendants, not to the granted resources.
+func (pr *PermissionsRepo) getUserInheritedResourceGrants(ctx context.Context, tx pgx.Tx, userID string, resources []string) (permissions.ResourceGrants, error) {
+	if len(resources) == 0 {
+		resources = []string{"%"} // Match everything
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE descendants AS (
+			SELECT 
+				ur.resource_type_id AS granted_resource_type_id, ur.resource_id AS granted_resource_id, ur.permission_id, ur.condition,
+				rh.child_resource_type_id AS resource_type_id, rh.child_resource_id AS resource_id
+			FROM 
+				user_resources ur
+			JOIN 
+				resource_hierarchy rh ON rh.parent_resource_type_id = ur.resource_type_id AND rh.parent_resource_id = ur.resource_id
+			WHERE 
+				ur.user_id = @user_id
+				AND
+				(ur.expires_at IS NULL OR ur.expires_at > NOW())
+			UNION
+			SELECT 
+				d.granted_resource_type_id, d.granted_resource_id, d.permission_id, d.condition,
+				rh.child_resource_type_id, rh.child_resource_id
+			FROM 
+				descendants d
+			JOIN 
+				resource_hierarchy rh ON rh.parent_resource_type_id = d.resource_type_id AND rh.parent_resource_id = d.resource_id
+		)
+		SELECT 
+			d.resource_id, rt.resource_type_name, p.permission_id, p.permission_name, COALESCE(d.condition, ''),
+			d.granted_resource_id, grt.resource_type_name
+		FROM 
+			descendants d
+		JOIN 
+			resource_types rt ON d.resource_type_id = rt.resource_type_id
+		JOIN 
+			resource_types grt ON d.granted_resource_type_id = grt.resource_type_id
+		JOIN 
+			permissions p ON d.permission_id = p.permission_id
+		WHERE 
+			rt.resource_type_name ILIKE ANY(@resource_types::text[])
+		ORDER BY
+			rt.resource_type_name ASC, d.resource_id ASC, p.permission_name ASC
+		`, pgx.NamedArgs{
+		"user_id":        userID,
+		"resource_types": resources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query resource_hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	var grants permissions.ResourceGrants
+	for rows.Next() {
+		var rg permissions.ResourceGrant
+		rg.InheritedFrom = &permissions.Resource{}
+		if err := rows.Scan(&rg.Resource.ID, &rg.Resource.Type, &rg.Permission.ID, &rg.Permission.Name, &rg.Permission.Condition, &rg.InheritedFrom.ID, &rg.InheritedFrom.Type); err != nil {
+			return nil, fmt.Errorf("scan resource_hierarchy: %w", err)
+		}
+		grants = append(grants, rg)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows resource_hierarchy: %w", rows.Err())
+	}
+
+	return grants, nil
+}
+
 func (pr *PermissionsRepo) GetUserRoles(ctx context.Context) (permissions.Roles, error) {
 	userID, found := contextkey.UserID(ctx)
 	if !found {
@@ -332,7 +482,7 @@ func (pr *PermissionsRepo) GetUserRoles(ctx context.Context) (permissions.Roles,
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin: %w", err)
+		return nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -348,35 +498,93 @@ func (pr *PermissionsRepo) GetUserRoles(ctx context.Context) (permissions.Roles,
 	return roles, nil
 }
 
+func (pr *PermissionsRepo) GetUserRoleAssignments(ctx context.Context) (permissions.RoleAssignments, error) {
+	userID, found := contextkey.UserID(ctx)
+	if !found {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	assignments, err := pr.getUserRoleAssignments(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user role assignments: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return assignments, nil
+}
+
 func (pr *PermissionsRepo) getUserRoles(ctx context.Context, tx pgx.Tx, userID string) (permissions.Roles, error) {
+	assignments, err := pr.getUserRoleAssignments(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return assignments.Roles(), nil
+}
+
+// getUserRoleAssignments returns the roles assigned to the user, then the roles
+// assigned to the user's groups, then the roles inherited from those.
+func (pr *PermissionsRepo) getUserRoleAssignments(ctx context.Context, tx pgx.Tx, userID string) (permissions.RoleAssignments, error) {
 	directRoles, err := pr.getUserDirectRoles(ctx, tx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("get direct roles: %w", err)
 	}
-	if len(directRoles) == 0 {
+	groupRoles, err := pr.getUserGroupRoles(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get group roles: %w", err)
+	}
+
+	var assignments permissions.RoleAssignments
+	for _, role := range directRoles {
+		assignments = append(assignments, permissions.RoleAssignment{Role: role, Source: permissions.RoleSourceDirect})
+	}
+	assignments = append(assignments, groupRoles...)
+	if len(assignments) == 0 {
 		return nil, nil
 	}
 
-	var allChildRoles permissions.Roles
-	currentRoles := directRoles
+	// Maintain the order of the roles, don't sort them.
+	// Each role's children are only fetched once, which also guards against cycles.
+	expanded := make(map[string]bool)
+	currentRoles := assignments.Roles()
 	for {
-		childRoles, err := pr.getChildRoles(ctx, tx, currentRoles.GetIDs())
-		if err != nil {
-			return nil, fmt.Errorf("get child roles: %w", err)
+		var parents permissions.Roles
+		for _, role := range currentRoles {
+			if !expanded[role.ID] {
+				expanded[role.ID] = true
+				parents = append(parents, role)
+			}
 		}
-		if len(childRoles) == 0 {
+		if len(parents) == 0 {
 			break
 		}
-		allChildRoles = append(allChildRoles, childRoles...)
-		currentRoles = childRoles
+
+		inherited, err := pr.getInheritedRoles(ctx, tx, parents)
+		if err != nil {
+			return nil, fmt.Errorf("get inherited roles: %w", err)
+		}
+		assignments = append(assignments, inherited...)
+		currentRoles = inherited.Roles()
 	}
-	// Maintain the order of the roles, don't sort them.
-	return append(directRoles, allChildRoles...), nil
+	return assignments, nil
 }
 
 func (pr *PermissionsRepo) getUserDirectRoles(ctx context.Context, tx pgx.Tx, userID string) (permissions.Roles, error) {
+	// user_roles may hold the same assignment more than once.
 	rows, err := tx.Query(ctx, `
-		SELECT 
+		SELECT DISTINCT
 			ur.role_id, r.role_name
 		FROM 
 			user_roles ur
@@ -384,6 +592,8 @@ func (pr *PermissionsRepo) getUserDirectRoles(ctx context.Context, tx pgx.Tx, us
 			roles r ON ur.role_id = r.role_id
 		WHERE 
 			ur.user_id = @user_id
+			AND
+			(ur.expires_at IS NULL OR ur.expires_at > NOW())
 		ORDER BY
 			r.role_name ASC
 		`, pgx.NamedArgs{
@@ -410,6 +620,99 @@ func (pr *PermissionsRepo) getUserDirectRoles(ctx context.Context, tx pgx.Tx, us
 	return userRoles, nil
 }
 
+func (pr *PermissionsRepo) getUserGroupRoles(ctx context.Context, tx pgx.Tx, userID string) (permissions.RoleAssignments, error) {
+	// Members of a nested group are also members of every group it is nested within.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE user_groups AS (
+			SELECT 
+				gm.group_id
+			FROM 
+				group_members gm
+			WHERE 
+				gm.user_id = @user_id
+			UNION
+			SELECT 
+				gh.parent_group_id
+			FROM 
+				group_hierarchy gh
+			JOIN 
+				user_groups ug ON gh.child_group_id = ug.group_id
+		)
+		SELECT 
+			r.role_id, r.role_name, g.group_name
+		FROM 
+			user_groups ug
+		JOIN 
+			group_roles gr ON ug.group_id = gr.group_id
+		JOIN 
+			groups g ON gr.group_id = g.group_id
+		JOIN 
+			roles r ON gr.role_id = r.role_id
+		ORDER BY
+			r.role_name ASC, g.group_name ASC
+		`, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query group_roles: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments permissions.RoleAssignments
+	for rows.Next() {
+		ra := permissions.RoleAssignment{Source: permissions.RoleSourceGroup}
+		if err := rows.Scan(&ra.Role.ID, &ra.Role.Name, &ra.Via); err != nil {
+			return nil, fmt.Errorf("scan group_roles: %w", err)
+		}
+		assignments = append(assignments, ra)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows group_roles: %w", rows.Err())
+	}
+
+	return assignments, nil
+}
+
+func (pr *PermissionsRepo) getInheritedRoles(ctx context.Context, tx pgx.Tx, parentRoles permissions.Roles) (permissions.RoleAssignments, error) {
+
+	rows, err := tx.Query(ctx, `
+		SELECT 
+			rh.child_role_id, r.role_name, p.role_name
+		FROM 
+			role_hierarchy rh
+		JOIN 
+			roles r ON rh.child_role_id = r.role_id
+		JOIN 
+			roles p ON rh.parent_role_id = p.role_id
+		WHERE 
+			rh.parent_role_id = ANY(@parent_roles)
+		ORDER BY
+			r.role_name ASC, p.role_name ASC
+		`, pgx.NamedArgs{
+		"parent_roles": parentRoles.GetIDs(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query role_hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments permissions.RoleAssignments
+	for rows.Next() {
+		ra := permissions.RoleAssignment{Source: permissions.RoleSourceInherited}
+		if err := rows.Scan(&ra.Role.ID, &ra.Role.Name, &ra.Via); err != nil {
+			return nil, fmt.Errorf("scan role_hierarchy: %w", err)
+		}
+		assignments = append(assignments, ra)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows role_hierarchy: %w", rows.Err())
+	}
+
+	return assignments, nil
+}
+
 func (pr *PermissionsRepo) getChildRoles(ctx context.Context, tx pgx.Tx, assignedRoles []string) (permissions.Roles, error) {
 
 	rows, err := tx.Query(ctx, `
@@ -454,7 +757,7 @@ func (pr *PermissionsRepo) GetTenantRoles(ctx context.Context) (permissions.Role
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin: %w", err)
+		return nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -520,7 +823,7 @@ func (pr *PermissionsRepo) GetTenantRoleMap(ctx context.Context, resources []str
 	}
 	tx, err := pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin: %w", err)
+		return nil, fmt.Errorf("begin: %w", dbError(err))
 	}
 	defer rollback(ctx, tx)
 
@@ -555,11 +858,97 @@ func (pr *PermissionsRepo) GetTenantRoleMap(ctx context.Context, resources []str
 	return rolemap, nil
 }
 
+func (pr *PermissionsRepo) GetTenantRoleAssignments(ctx context.Context) (permissions.TenantRoleAssignments, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	assignments, err := pr.getTenantRoleAssignments(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant role assignments: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// getTenantRoleAssignments returns the roles assigned to every user, directly or through a group.
+// Inherited roles are not included, they can be found from the TenantRoleMap.
+func (pr *PermissionsRepo) getTenantRoleAssignments(ctx context.Context, tx pgx.Tx) (permissions.TenantRoleAssignments, error) {
+	// user_roles may hold the same assignment more than once.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE member_groups AS (
+			SELECT 
+				gm.user_id, gm.group_id
+			FROM 
+				group_members gm
+			UNION
+			SELECT 
+				mg.user_id, gh.parent_group_id
+			FROM 
+				group_hierarchy gh
+			JOIN 
+				member_groups mg ON gh.child_group_id = mg.group_id
+		)
+		SELECT DISTINCT
+			ur.user_id, r.role_id, r.role_name, 'direct' AS source, '' AS via
+		FROM 
+			user_roles ur
+		JOIN 
+			roles r ON ur.role_id = r.role_id
+		WHERE 
+			ur.expires_at IS NULL OR ur.expires_at > NOW()
+		UNION ALL
+		SELECT 
+			mg.user_id, r.role_id, r.role_name, 'group' AS source, g.group_name AS via
+		FROM 
+			member_groups mg
+		JOIN 
+			group_roles gr ON mg.group_id = gr.group_id
+		JOIN 
+			groups g ON gr.group_id = g.group_id
+		JOIN 
+			roles r ON gr.role_id = r.role_id
+		ORDER BY
+			user_id ASC, source ASC, role_name ASC, via ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query user_roles: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := make(permissions.TenantRoleAssignments)
+	for rows.Next() {
+		var userID, source string
+		var ra permissions.RoleAssignment
+		if err := rows.Scan(&userID, &ra.Role.ID, &ra.Role.Name, &source, &ra.Via); err != nil {
+			return nil, fmt.Errorf("scan user_roles: %w", err)
+		}
+		ra.Source = permissions.RoleSource(source)
+		assignments[userID] = append(assignments[userID], ra)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_roles: %w", rows.Err())
+	}
+
+	return assignments, nil
+}
+
 func (pr *PermissionsRepo) getRoleTenantPermissions(ctx context.Context, tx pgx.Tx, roleID string, resources []string) (permissions.TenantPermissions, error) {
 
 	rows, err := tx.Query(ctx, `
 		SELECT 
-			rp.permission_id, p.permission_name
+			rp.permission_id, p.permission_name, COALESCE(rp.condition, '')
 		FROM 
 			role_permissions rp
 		JOIN 
@@ -582,7 +971,7 @@ func (pr *PermissionsRepo) getRoleTenantPermissions(ctx context.Context, tx pgx.
 	var tenantPermissions permissions.TenantPermissions
 	for rows.Next() {
 		var tp permissions.TenantPermission
-		if err := rows.Scan(&tp.ID, &tp.Name); err != nil {
+		if err := rows.Scan(&tp.ID, &tp.Name, &tp.Condition); err != nil {
 			return nil, fmt.Errorf("scan role_permissions: %w", err)
 		}
 		tenantPermissions = append(tenantPermissions, tp)
@@ -599,9 +988,10 @@ func permissionNamesForResources(resources []string) []string {
 	if len(resources) == 0 {
 		return []string{"%"} // Match everything
 	}
-	permissionNames := make([]string, len(resources))
+	permissionNames := make([]string, len(resources), len(resources)+1)
 	for i, resource := range resources {
-		permissionNames[i] = resource + ":%"
+		permissionNames[i] = resource + permissions.PermissionSeparator + "%"
 	}
-	return permissionNames
+	// Wildcard resource grants, e.g. "*:read", apply to every resource.
+	return append(permissionNames, permissions.Wildcard+permissions.PermissionSeparator+"%")
 }
@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// changeQueries are the statements that make each kind of change. The named
+// args are subject, object, enabled, condition, id and resource_type_id, see rbac.Change.
+var changeQueries = map[rbac.ChangeKind]map[rbac.Action]string{
+	rbac.ChangeKindResourceType: {
+		rbac.ActionAdd: `
+			INSERT INTO resource_types
+				(resource_type_id, resource_type_name)
+			VALUES
+				(COALESCE(@resource_type_id::bigint, (SELECT COALESCE(MAX(resource_type_id), 0) + 1 FROM resource_types)), @subject)
+			`,
+		rbac.ActionRemove: `
+			DELETE FROM resource_types
+			WHERE
+				resource_type_name = @subject
+			`,
+	},
+	rbac.ChangeKindPermission: {
+		rbac.ActionAdd: `
+			WITH inserted AS (
+				INSERT INTO permissions
+					(permission_id, permission_name)
+				VALUES
+					(@id, @subject)
+				RETURNING permission_id
+			)
+			INSERT INTO tenant_permissions
+				(permission_id, created_at)
+			SELECT
+				permission_id, NOW()
+			FROM
+				inserted
+			WHERE
+				@enabled::boolean
+			`,
+		rbac.ActionRemove: `
+			DELETE FROM permissions
+			WHERE
+				permission_name = @subject
+			`,
+	},
+	rbac.ChangeKindRole: {
+		rbac.ActionAdd: `
+			INSERT INTO roles
+				(role_id, role_name)
+			VALUES
+				(@id, @subject)
+			`,
+		rbac.ActionRemove: `
+			DELETE FROM roles
+			WHERE
+				role_name = @subject
+			`,
+	},
+	rbac.ChangeKindRolePermission: {
+		rbac.ActionAdd: `
+			INSERT INTO role_permissions
+				(role_id, permission_id, condition, created_at)
+			SELECT
+				r.role_id, p.permission_id, NULLIF(@condition::text, ''), NOW()
+			FROM
+				roles r, permissions p
+			WHERE
+				r.role_name = @subject
+				AND
+				p.permission_name = @object
+			`,
+		rbac.ActionChange: `
+			UPDATE role_permissions rp SET
+				condition = NULLIF(@condition::text, ''),
+				updated_at = NOW()
+			FROM
+				roles r, permissions p
+			WHERE
+				rp.role_id = r.role_id
+				AND
+				rp.permission_id = p.permission_id
+				AND
+				r.role_name = @subject
+				AND
+				p.permission_name = @object
+			`,
+		rbac.ActionRemove: `
+			DELETE FROM role_permissions rp
+			USING
+				roles r, permissions p
+			WHERE
+				rp.role_id = r.role_id
+				AND
+				rp.permission_id = p.permission_id
+				AND
+				r.role_name = @subject
+				AND
+				p.permission_name = @object
+			`,
+	},
+	rbac.ChangeKindRoleInherit: {
+		rbac.ActionAdd: `
+			INSERT INTO role_hierarchy
+				(parent_role_id, child_role_id)
+			SELECT
+				parent.role_id, child.role_id
+			FROM
+				roles parent, roles child
+			WHERE
+				parent.role_name = @subject
+				AND
+				child.role_name = @object
+			`,
+		rbac.ActionRemove: `
+			DELETE FROM role_hierarchy rh
+			USING
+				roles parent, roles child
+			WHERE
+				rh.parent_role_id = parent.role_id
+				AND
+				rh.child_role_id = child.role_id
+				AND
+				parent.role_name = @subject
+				AND
+				child.role_name = @object
+			`,
+	},
+	rbac.ChangeKindAssignment: {
+		rbac.ActionAdd: `
+			INSERT INTO user_roles
+				(user_id, role_id, created_at)
+			SELECT
+				@subject::uuid, role_id, NOW()
+			FROM
+				roles
+			WHERE
+				role_name = @object
+			`,
+		rbac.ActionRemove: `
+			DELETE FROM user_roles ur
+			USING
+				roles r
+			WHERE
+				ur.role_id = r.role_id
+				AND
+				ur.user_id = @subject::uuid
+				AND
+				r.role_name = @object
+			`,
+	},
+}
+
+const enablePermissionQuery = `
+	INSERT INTO tenant_permissions
+		(permission_id, created_at)
+	SELECT
+		permission_id, NOW()
+	FROM
+		permissions
+	WHERE
+		permission_name = @subject
+	`
+
+const disablePermissionQuery = `
+	DELETE FROM tenant_permissions tp
+	USING
+		permissions p
+	WHERE
+		tp.permission_id = p.permission_id
+		AND
+		p.permission_name = @subject
+	`
+
+func (pr *PermissionsRepo) ApplyPlan(ctx context.Context, plan *rbac.Plan) error {
+	return inSoDTx(ctx, pr.tenantPool, sodAllUsers, func(tx pgx.Tx) error {
+		// Other writers wait until the plan is applied, so the model cannot change once checked.
+		_, err := tx.Exec(ctx, `
+			LOCK TABLE
+				resource_types, permissions, tenant_permissions, roles, role_permissions, role_hierarchy, user_roles
+			IN SHARE ROW EXCLUSIVE MODE
+			`)
+		if err != nil {
+			return fmt.Errorf("lock tables: %w", err)
+		}
+
+		current, err := pr.getModel(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("get model: %w", err)
+		}
+		if current.Fingerprint() != plan.Fingerprint {
+			return rbac.ErrPlanStale
+		}
+
+		for _, c := range plan.Changes {
+			if err := applyChange(ctx, tx, c); err != nil {
+				return fmt.Errorf("%s %s %s %s: %w", c.Action, c.Kind, c.Subject, c.Object, err)
+			}
+		}
+		return insertEvents(ctx, tx, planEvents(plan)...)
+	})
+}
+
+func applyChange(ctx context.Context, tx pgx.Tx, c rbac.Change) error {
+	query := changeQueries[c.Kind][c.Action]
+	if c.Kind == rbac.ChangeKindPermission && c.Action == rbac.ActionChange {
+		query = disablePermissionQuery
+		if c.Enabled {
+			query = enablePermissionQuery
+		}
+	}
+	if query == "" {
+		return fmt.Errorf("unknown change")
+	}
+
+	var resourceTypeID *int64
+	if c.ResourceTypeID != 0 {
+		resourceTypeID = &c.ResourceTypeID
+	}
+	tag, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"subject":          c.Subject,
+		"object":           c.Object,
+		"enabled":          c.Enabled,
+		"condition":        c.Condition,
+		"id":               uuid.NewString(),
+		"resource_type_id": resourceTypeID,
+	})
+	if err != nil {
+		return err
+	}
+	// A permission added disabled inserts nothing into tenant_permissions.
+	if tag.RowsAffected() == 0 && !(c.Kind == rbac.ChangeKindPermission && c.Action == rbac.ActionAdd) {
+		return fmt.Errorf("nothing changed")
+	}
+	return nil
+}
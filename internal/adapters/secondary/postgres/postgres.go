@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"github.com/jackc/pgx/v5"
@@ -12,3 +13,34 @@ func rollback(ctx context.Context, tx pgx.Tx) {
 		slog.Error("Failed to rollback transaction", "error", err.Error())
 	}
 }
+
+// inTx runs fn in a transaction on the Tenant's database, committing only if fn succeeds.
+func inTx(ctx context.Context, tenantPool *TenantPool, fn func(tx pgx.Tx) error) error {
+	pool, err := tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	if err := fn(tx); err != nil {
+		return dbError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// inSoDTx runs fn in a transaction as inTx does, rejecting what it writes to the
+// users in the scope when that would violate a separation of duties rule, see
+// enforceSoD.
+func inSoDTx(ctx context.Context, tenantPool *TenantPool, scope sodScope, fn func(tx pgx.Tx) error) error {
+	return inTx(ctx, tenantPool, func(tx pgx.Tx) error {
+		return enforceSoD(ctx, tx, scope, func() error { return fn(tx) })
+	})
+}
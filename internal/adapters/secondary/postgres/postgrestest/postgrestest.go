@@ -0,0 +1,35 @@
+//go:build test
+// +build test
+
+// Package postgrestest provides a test database, holding the test Tenant's
+// data, for the tests of the app packages.
+package postgrestest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/testdatabase"
+)
+
+// TenantID is the Tenant whose data, migrations/tenants/test, NewTenantRepo loads.
+const TenantID = "test_tenant"
+
+// NewTenantRepo creates a test database with the service's migrations and the
+// test Tenant's data, torn down when the test ends, and returns a repo for the
+// Tenant and a context holding its ID.
+func NewTenantRepo(t testing.TB) (context.Context, *postgres.PermissionsRepo) {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), contextkey.CtxKeyTenantID, TenantID)
+
+	db, err := testdatabase.NewTestDatabase(ctx, postgres.Migrations, postgres.Tenants)
+	if err != nil {
+		t.Fatalf("new test database: %s", err)
+	}
+	t.Cleanup(db.TearDown)
+
+	tp := postgres.NewTenantPoolFromSuppliedPool(ctx, TenantID, db.DB)
+	return ctx, postgres.NewPermissionsRepoWithTenantPool(tp)
+}
@@ -0,0 +1,298 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/relations"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) ReadTuples(ctx context.Context, filter relations.Filter) (relations.Tuples, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	res, err := pr.readTuples(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return res, nil
+}
+
+func (pr *PermissionsRepo) readTuples(ctx context.Context, tx pgx.Tx, filter relations.Filter) (relations.Tuples, error) {
+	args := pgx.NamedArgs{
+		"object_type": filter.Namespace,
+		"object_id":   filter.ObjectID,
+		"relation":    filter.Relation,
+		"has_subject": filter.Subject != nil,
+	}
+	if filter.Subject != nil {
+		args["subject_type"] = filter.Subject.Object.Namespace
+		args["subject_id"] = filter.Subject.Object.ID
+		args["subject_relation"] = filter.Subject.Relation
+	} else {
+		args["subject_type"], args["subject_id"], args["subject_relation"] = "", "", ""
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT
+			object_type, object_id, relation, subject_type, subject_id, subject_relation
+		FROM
+			relation_tuples_all
+		WHERE
+			(@object_type::text = '' OR object_type = @object_type)
+			AND
+			(@object_id::text = '' OR object_id = @object_id)
+			AND
+			(@relation::text = '' OR relation = @relation)
+			AND
+			(NOT @has_subject::boolean OR (subject_type = @subject_type AND subject_id = @subject_id AND subject_relation = @subject_relation))
+		ORDER BY
+			object_type ASC, object_id ASC, relation ASC, subject_type ASC, subject_id ASC, subject_relation ASC
+		`, args)
+	if err != nil {
+		return nil, fmt.Errorf("query relation_tuples_all: %w", err)
+	}
+	return scanTuples(rows)
+}
+
+func (pr *PermissionsRepo) CheckTuple(ctx context.Context, userset relations.Userset, subject relations.Subject) (bool, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	res, err := pr.checkTuple(ctx, tx, userset, subject)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit: %w", err)
+	}
+
+	return res, nil
+}
+
+func (pr *PermissionsRepo) checkTuple(ctx context.Context, tx pgx.Tx, userset relations.Userset, subject relations.Subject) (bool, error) {
+	// Every userset and subject reachable from the userset, UNION discards repeats so cycles terminate.
+	// A tuple with the relation "*" relates its subject to the object by every relation.
+	var ok bool
+	err := tx.QueryRow(ctx, `
+		WITH RECURSIVE reachable AS (
+			SELECT
+				@object_type::text AS object_type, @object_id::text AS object_id, @relation::text AS relation
+			UNION
+			SELECT
+				t.subject_type, t.subject_id, t.subject_relation
+			FROM
+				relation_tuples_all t
+			JOIN
+				reachable r ON t.object_type = r.object_type AND t.object_id = r.object_id AND t.relation IN (r.relation, @any_relation)
+		)
+		SELECT EXISTS (
+			SELECT 1 FROM reachable WHERE object_type = @subject_type AND object_id = @subject_id AND relation = @subject_relation
+		)
+		`, pgx.NamedArgs{
+		"object_type":      userset.Object.Namespace,
+		"object_id":        userset.Object.ID,
+		"relation":         userset.Relation,
+		"subject_type":     subject.Object.Namespace,
+		"subject_id":       subject.Object.ID,
+		"subject_relation": subject.Relation,
+		"any_relation":     relations.RelationAny,
+	}).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("query relation_tuples_all: %w", err)
+	}
+	return ok, nil
+}
+
+func (pr *PermissionsRepo) ExpandTuples(ctx context.Context, userset relations.Userset) (relations.Tuples, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	res, err := pr.expandTuples(ctx, tx, userset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return res, nil
+}
+
+func (pr *PermissionsRepo) expandTuples(ctx context.Context, tx pgx.Tx, userset relations.Userset) (relations.Tuples, error) {
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE usersets AS (
+			SELECT
+				@object_type::text AS object_type, @object_id::text AS object_id, @relation::text AS relation
+			UNION
+			SELECT
+				t.subject_type, t.subject_id, t.subject_relation
+			FROM
+				relation_tuples_all t
+			JOIN
+				usersets u ON t.object_type = u.object_type AND t.object_id = u.object_id AND t.relation IN (u.relation, @any_relation)
+			WHERE
+				t.subject_relation <> ''
+		)
+		SELECT DISTINCT
+			t.object_type, t.object_id, t.relation, t.subject_type, t.subject_id, t.subject_relation
+		FROM
+			relation_tuples_all t
+		JOIN
+			usersets u ON t.object_type = u.object_type AND t.object_id = u.object_id AND t.relation IN (u.relation, @any_relation)
+		ORDER BY
+			t.object_type ASC, t.object_id ASC, t.relation ASC, t.subject_type ASC, t.subject_id ASC, t.subject_relation ASC
+		`, pgx.NamedArgs{
+		"object_type":  userset.Object.Namespace,
+		"object_id":    userset.Object.ID,
+		"relation":     userset.Relation,
+		"any_relation": relations.RelationAny,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query relation_tuples_all: %w", err)
+	}
+	return scanTuples(rows)
+}
+
+func (pr *PermissionsRepo) WriteTuples(ctx context.Context, tuples relations.Tuples) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		if err := checkNotProjected(ctx, tx, tuples); err != nil {
+			return err
+		}
+		for _, t := range tuples {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO relation_tuples
+					(object_type, object_id, relation, subject_type, subject_id, subject_relation, created_at)
+				VALUES
+					(@object_type, @object_id, @relation, @subject_type, @subject_id, @subject_relation, NOW())
+				ON CONFLICT (object_type, object_id, relation, subject_type, subject_id, subject_relation) DO NOTHING
+				`, tupleArgs(t))
+			if err != nil {
+				return fmt.Errorf("insert relation_tuples: %w", err)
+			}
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventRelationsChanged, outbox.RelationsChange{Written: tuples.StringSlice()}))
+	})
+}
+
+func (pr *PermissionsRepo) DeleteTuples(ctx context.Context, tuples relations.Tuples) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		if err := checkNotProjected(ctx, tx, tuples); err != nil {
+			return err
+		}
+		for _, t := range tuples {
+			_, err := tx.Exec(ctx, `
+				DELETE FROM relation_tuples
+				WHERE
+					object_type = @object_type
+					AND
+					object_id = @object_id
+					AND
+					relation = @relation
+					AND
+					subject_type = @subject_type
+					AND
+					subject_id = @subject_id
+					AND
+					subject_relation = @subject_relation
+				`, tupleArgs(t))
+			if err != nil {
+				return fmt.Errorf("delete relation_tuples: %w", err)
+			}
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventRelationsChanged, outbox.RelationsChange{Deleted: tuples.StringSlice()}))
+	})
+}
+
+// checkNotProjected returns an error if any of the tuples is in the namespace of
+// a resource type, whose tuples are projected from the grants on its resources.
+func checkNotProjected(ctx context.Context, tx pgx.Tx, tuples relations.Tuples) error {
+	namespaces := make([]string, len(tuples))
+	for i, t := range tuples {
+		namespaces[i] = t.Object.Namespace
+	}
+
+	var resourceType string
+	err := tx.QueryRow(ctx, `
+		SELECT
+			rt.resource_type_name
+		FROM
+			resource_types rt
+		WHERE
+			lower(rt.resource_type_name) IN (SELECT lower(n) FROM unnest(@namespaces::text[]) AS n)
+		ORDER BY
+			rt.resource_type_name ASC
+		LIMIT 1
+		`, pgx.NamedArgs{
+		"namespaces": namespaces,
+	}).Scan(&resourceType)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("query resource_types: %w", err)
+	}
+	return permissions.Errorf(permissions.ErrInvalidInput, "tuples in the %s namespace are projected from the grants on resources of that type", resourceType)
+}
+
+func tupleArgs(t relations.Tuple) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"object_type":      t.Object.Namespace,
+		"object_id":        t.Object.ID,
+		"relation":         t.Relation,
+		"subject_type":     t.Subject.Object.Namespace,
+		"subject_id":       t.Subject.Object.ID,
+		"subject_relation": t.Subject.Relation,
+	}
+}
+
+func scanTuples(rows pgx.Rows) (relations.Tuples, error) {
+	defer rows.Close()
+
+	tuples := make(relations.Tuples, 0)
+	for rows.Next() {
+		var t relations.Tuple
+		if err := rows.Scan(&t.Object.Namespace, &t.Object.ID, &t.Relation, &t.Subject.Object.Namespace, &t.Subject.Object.ID, &t.Subject.Relation); err != nil {
+			return nil, fmt.Errorf("scan relation_tuples_all: %w", err)
+		}
+		tuples = append(tuples, t)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows relation_tuples_all: %w", rows.Err())
+	}
+
+	return tuples, nil
+}
@@ -0,0 +1,310 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) AddResourceTypeChild(ctx context.Context, parentType, childType string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		parentID, err := resourceTypeID(ctx, tx, parentType)
+		if err != nil {
+			return err
+		}
+		childID, err := resourceTypeID(ctx, tx, childType)
+		if err != nil {
+			return err
+		}
+
+		// The child must not already contain the parent, at any depth, or the resource types would form a cycle.
+		var cycle bool
+		err = tx.QueryRow(ctx, `
+			WITH RECURSIVE ancestors AS (
+				SELECT
+					@parent_resource_type_id::bigint AS resource_type_id
+				UNION
+				SELECT
+					rth.parent_resource_type_id
+				FROM
+					resource_type_hierarchy rth
+				JOIN
+					ancestors a ON rth.child_resource_type_id = a.resource_type_id
+			)
+			SELECT EXISTS (
+				SELECT 1 FROM ancestors WHERE resource_type_id = @child_resource_type_id::bigint
+			)
+			`, pgx.NamedArgs{
+			"parent_resource_type_id": parentID,
+			"child_resource_type_id":  childID,
+		}).Scan(&cycle)
+		if err != nil {
+			return fmt.Errorf("query resource_type_hierarchy: %w", err)
+		}
+		if cycle {
+			return fmt.Errorf("resource type %s already contains resource type %s", childType, parentType)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO resource_type_hierarchy
+				(parent_resource_type_id, child_resource_type_id)
+			VALUES
+				(@parent_resource_type_id, @child_resource_type_id)
+			ON CONFLICT (parent_resource_type_id, child_resource_type_id) DO NOTHING
+			`, pgx.NamedArgs{
+			"parent_resource_type_id": parentID,
+			"child_resource_type_id":  childID,
+		})
+		if err != nil {
+			return fmt.Errorf("insert resource_type_hierarchy: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventResourceHierarchyChanged, outbox.ResourceHierarchyChange{Action: "type_child_added", ParentType: parentType, ChildType: childType}))
+	})
+}
+
+func (pr *PermissionsRepo) RemoveResourceTypeChild(ctx context.Context, parentType, childType string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM resource_type_hierarchy rth
+			USING
+				resource_types parent, resource_types child
+			WHERE
+				rth.parent_resource_type_id = parent.resource_type_id
+				AND
+				rth.child_resource_type_id = child.resource_type_id
+				AND
+				parent.resource_type_name = @parent_resource_type_name
+				AND
+				child.resource_type_name = @child_resource_type_name
+			`, pgx.NamedArgs{
+			"parent_resource_type_name": parentType,
+			"child_resource_type_name":  childType,
+		})
+		if err != nil {
+			return fmt.Errorf("delete resource_type_hierarchy: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventResourceHierarchyChanged, outbox.ResourceHierarchyChange{Action: "type_child_removed", ParentType: parentType, ChildType: childType}))
+	})
+}
+
+func (pr *PermissionsRepo) AddResourceChildren(ctx context.Context, parent permissions.Resource, children permissions.Resources) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		parentTypeID, err := resourceTypeID(ctx, tx, parent.Type)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			childTypeID, err := resourceTypeID(ctx, tx, child.Type)
+			if err != nil {
+				return err
+			}
+
+			// The child must not already contain the parent, at any depth, or the resources would form a cycle.
+			var cycle bool
+			err = tx.QueryRow(ctx, `
+				WITH RECURSIVE ancestors AS (
+					SELECT
+						@parent_resource_type_id::bigint AS resource_type_id, @parent_resource_id::uuid AS resource_id
+					UNION
+					SELECT
+						rh.parent_resource_type_id, rh.parent_resource_id
+					FROM
+						resource_hierarchy rh
+					JOIN
+						ancestors a ON rh.child_resource_type_id = a.resource_type_id AND rh.child_resource_id = a.resource_id
+				)
+				SELECT EXISTS (
+					SELECT 1 FROM ancestors WHERE resource_type_id = @child_resource_type_id::bigint AND resource_id = @child_resource_id::uuid
+				)
+				`, pgx.NamedArgs{
+				"parent_resource_type_id": parentTypeID,
+				"parent_resource_id":      parent.ID,
+				"child_resource_type_id":  childTypeID,
+				"child_resource_id":       child.ID,
+			}).Scan(&cycle)
+			if err != nil {
+				return fmt.Errorf("query resource_hierarchy: %w", err)
+			}
+			if cycle {
+				return fmt.Errorf("%s %s already contains %s %s", child.Type, child.ID, parent.Type, parent.ID)
+			}
+
+			var allowed bool
+			err = tx.QueryRow(ctx, `
+				SELECT EXISTS (
+					SELECT 1
+					FROM
+						resource_type_hierarchy
+					WHERE
+						parent_resource_type_id = @parent_resource_type_id
+						AND
+						child_resource_type_id = @child_resource_type_id
+				)
+				`, pgx.NamedArgs{
+				"parent_resource_type_id": parentTypeID,
+				"child_resource_type_id":  childTypeID,
+			}).Scan(&allowed)
+			if err != nil {
+				return fmt.Errorf("query resource_type_hierarchy: %w", err)
+			}
+			if !allowed {
+				return fmt.Errorf("resource type %s does not belong to resource type %s", child.Type, parent.Type)
+			}
+
+			_, err = tx.Exec(ctx, `
+				INSERT INTO resource_hierarchy
+					(parent_resource_type_id, parent_resource_id, child_resource_type_id, child_resource_id, created_at)
+				VALUES
+					(@parent_resource_type_id, @parent_resource_id, @child_resource_type_id, @child_resource_id, NOW())
+				ON CONFLICT (parent_resource_type_id, parent_resource_id, child_resource_type_id, child_resource_id) DO NOTHING
+				`, pgx.NamedArgs{
+				"parent_resource_type_id": parentTypeID,
+				"parent_resource_id":      parent.ID,
+				"child_resource_type_id":  childTypeID,
+				"child_resource_id":       child.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("insert resource_hierarchy: %w", err)
+			}
+		}
+		return insertEvents(ctx, tx, resourceChildrenEvents("children_added", parent, children)...)
+	})
+}
+
+func (pr *PermissionsRepo) RemoveResourceChildren(ctx context.Context, parent permissions.Resource, children permissions.Resources) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		for _, child := range children {
+			_, err := tx.Exec(ctx, `
+				DELETE FROM resource_hierarchy rh
+				USING
+					resource_types parent, resource_types child
+				WHERE
+					rh.parent_resource_type_id = parent.resource_type_id
+					AND
+					rh.child_resource_type_id = child.resource_type_id
+					AND
+					parent.resource_type_name = @parent_resource_type_name
+					AND
+					rh.parent_resource_id = @parent_resource_id
+					AND
+					child.resource_type_name = @child_resource_type_name
+					AND
+					rh.child_resource_id = @child_resource_id
+				`, pgx.NamedArgs{
+				"parent_resource_type_name": parent.Type,
+				"parent_resource_id":        parent.ID,
+				"child_resource_type_name":  child.Type,
+				"child_resource_id":         child.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("delete resource_hierarchy: %w", err)
+			}
+		}
+		return insertEvents(ctx, tx, resourceChildrenEvents("children_removed", parent, children)...)
+	})
+}
+
+func resourceTypeID(ctx context.Context, tx pgx.Tx, name string) (int64, error) {
+	var id int64
+	err := tx.QueryRow(ctx, `
+		SELECT
+			resource_type_id
+		FROM
+			resource_types
+		WHERE
+			resource_type_name = @resource_type_name
+		`, pgx.NamedArgs{
+		"resource_type_name": name,
+	}).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, permissions.Errorf(permissions.ErrNotFound, "resource type %s not found", name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query resource_types: %w", err)
+	}
+	return id, nil
+}
+
+// resourceChildrenEvents are the events for children added to or removed from
+// the parent, one for each type of child.
+func resourceChildrenEvents(action string, parent permissions.Resource, children permissions.Resources) []outbox.Event {
+	var changes []outbox.ResourceHierarchyChange
+	byType := make(map[string]int)
+	for _, child := range children {
+		i, ok := byType[child.Type]
+		if !ok {
+			i = len(changes)
+			byType[child.Type] = i
+			changes = append(changes, outbox.ResourceHierarchyChange{Action: action, ParentType: parent.Type, ChildType: child.Type, ParentID: parent.ID})
+		}
+		changes[i].ChildIDs = append(changes[i].ChildIDs, child.ID)
+	}
+
+	events := make([]outbox.Event, len(changes))
+	for i, c := range changes {
+		events[i] = outbox.NewEvent(outbox.EventResourceHierarchyChanged, c)
+	}
+	return events
+}
+
+func (pr *PermissionsRepo) GetUnknownResourceTypes(ctx context.Context, names []string) ([]string, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	unknown, err := pr.getUnknownResourceTypes(ctx, tx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return unknown, nil
+}
+
+func (pr *PermissionsRepo) getUnknownResourceTypes(ctx context.Context, tx pgx.Tx, names []string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			n.name
+		FROM
+			UNNEST(@names::text[]) AS n(name)
+		WHERE
+			NOT EXISTS (SELECT 1 FROM resource_types rt WHERE LOWER(rt.resource_type_name) = LOWER(n.name))
+		ORDER BY
+			n.name ASC
+		`, pgx.NamedArgs{
+		"names": names,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query resource_types: %w", err)
+	}
+	defer rows.Close()
+
+	unknown := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan resource_types: %w", err)
+		}
+		unknown = append(unknown, name)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows resource_types: %w", rows.Err())
+	}
+
+	return unknown, nil
+}
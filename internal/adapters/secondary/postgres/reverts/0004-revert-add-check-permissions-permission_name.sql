@@ -0,0 +1 @@
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS chk_permissions_permission_name;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS group_hierarchy;
+DROP TABLE IF EXISTS group_roles;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
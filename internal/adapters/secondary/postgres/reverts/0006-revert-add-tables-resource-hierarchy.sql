@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS resource_hierarchy;
+DROP TABLE IF EXISTS resource_type_hierarchy;
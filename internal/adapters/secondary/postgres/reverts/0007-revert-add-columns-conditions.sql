@@ -0,0 +1,3 @@
+ALTER TABLE user_resources DROP COLUMN IF EXISTS condition;
+ALTER TABLE user_permissions DROP COLUMN IF EXISTS condition;
+ALTER TABLE role_permissions DROP COLUMN IF EXISTS condition;
@@ -0,0 +1,2 @@
+DROP VIEW IF EXISTS relation_tuples_all;
+DROP TABLE IF EXISTS relation_tuples;
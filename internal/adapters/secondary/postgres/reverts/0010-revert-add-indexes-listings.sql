@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_resources_resource_id_user_id;
+DROP INDEX IF EXISTS idx_permissions_lower_permission_name;
+DROP INDEX IF EXISTS idx_user_roles_role_id_user_id;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sod_rule_permissions;
+DROP TABLE IF EXISTS sod_rule_roles;
+DROP TABLE IF EXISTS sod_rules;
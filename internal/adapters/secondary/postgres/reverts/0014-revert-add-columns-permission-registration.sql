@@ -0,0 +1,5 @@
+ALTER TABLE permissions DROP COLUMN IF EXISTS deprecated_at;
+ALTER TABLE permissions DROP COLUMN IF EXISTS registered_by;
+ALTER TABLE permissions DROP COLUMN IF EXISTS description;
+ALTER TABLE resource_types DROP COLUMN IF EXISTS registered_by;
+ALTER TABLE resource_types DROP COLUMN IF EXISTS description;
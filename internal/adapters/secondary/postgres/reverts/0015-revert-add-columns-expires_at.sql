@@ -0,0 +1,2 @@
+ALTER TABLE user_resources DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE user_roles DROP COLUMN IF EXISTS expires_at;
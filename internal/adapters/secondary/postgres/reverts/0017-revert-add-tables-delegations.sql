@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS delegation_permissions;
+DROP TABLE IF EXISTS delegation_roles;
+DROP TABLE IF EXISTS delegations;
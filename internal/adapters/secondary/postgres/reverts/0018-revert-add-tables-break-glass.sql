@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS break_glass_sessions;
+DROP TABLE IF EXISTS break_glass_settings;
@@ -0,0 +1,41 @@
+CREATE OR REPLACE VIEW relation_tuples_all AS
+    SELECT
+        object_type, object_id, relation, subject_type, subject_id, subject_relation
+    FROM
+        relation_tuples
+    UNION ALL
+    SELECT
+        'role', ur.role_id::text, 'member', 'user', ur.user_id::text, ''
+    FROM
+        user_roles ur
+    UNION ALL
+    SELECT
+        'role', gr.role_id::text, 'member', 'group', gr.group_id::text, 'member'
+    FROM
+        group_roles gr
+    UNION ALL
+    SELECT
+        'role', rh.child_role_id::text, 'member', 'role', rh.parent_role_id::text, 'member'
+    FROM
+        role_hierarchy rh
+    UNION ALL
+    SELECT
+        'group', gm.group_id::text, 'member', 'user', gm.user_id::text, ''
+    FROM
+        group_members gm
+    UNION ALL
+    SELECT
+        'group', gh.parent_group_id::text, 'member', 'group', gh.child_group_id::text, 'member'
+    FROM
+        group_hierarchy gh
+    UNION ALL
+    SELECT
+        rt.resource_type_name, ur.resource_id::text, split_part(p.permission_name, ':', 2), 'user', ur.user_id::text, ''
+    FROM
+        user_resources ur
+    JOIN
+        resource_types rt ON ur.resource_type_id = rt.resource_type_id
+    JOIN
+        permissions p ON ur.permission_id = p.permission_id
+    WHERE
+        ur.condition IS NULL;
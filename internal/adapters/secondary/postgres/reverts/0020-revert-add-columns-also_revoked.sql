@@ -0,0 +1 @@
+ALTER TABLE access_review_items DROP COLUMN IF EXISTS also_revoked;
@@ -0,0 +1,470 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/reviews"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetReviewableAssignments(ctx context.Context, roleID string) ([]reviews.Item, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	items, err := pr.getReviewableAssignments(ctx, tx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return items, nil
+}
+
+func (pr *PermissionsRepo) getReviewableAssignments(ctx context.Context, tx pgx.Tx, roleID string) ([]reviews.Item, error) {
+	// A role held through a nested group is reviewed as the user's membership
+	// of the group they are a member of, member_group_id, rather than of the
+	// group the role is assigned to.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE member_groups AS (
+			SELECT
+				gm.user_id, gm.group_id AS member_group_id, gm.group_id
+			FROM
+				group_members gm
+			UNION
+			SELECT
+				mg.user_id, mg.member_group_id, gh.parent_group_id
+			FROM
+				group_hierarchy gh
+			JOIN
+				member_groups mg ON gh.child_group_id = mg.group_id
+		),
+		assignments AS (
+			SELECT
+				ur.user_id, r.role_id, r.role_name, 'direct' AS source, NULL::uuid AS group_id, NULL::text AS group_name
+			FROM
+				user_roles ur
+			JOIN
+				roles r ON ur.role_id = r.role_id
+			WHERE
+				ur.expires_at IS NULL OR ur.expires_at > NOW()
+			UNION
+			SELECT
+				mg.user_id, r.role_id, r.role_name, 'group' AS source, g.group_id, g.group_name
+			FROM
+				member_groups mg
+			JOIN
+				group_roles gr ON mg.group_id = gr.group_id
+			JOIN
+				roles r ON gr.role_id = r.role_id
+			JOIN
+				groups g ON mg.member_group_id = g.group_id
+		)
+		SELECT
+			a.user_id, a.role_id, a.role_name, a.source, COALESCE(a.group_id::text, ''), COALESCE(a.group_name, '')
+		FROM
+			assignments a
+		WHERE
+			@role_id::text = '' OR a.role_id::text = @role_id::text
+		ORDER BY
+			a.user_id ASC, a.role_name ASC, a.source ASC, a.group_name ASC
+		`, pgx.NamedArgs{
+		"role_id": roleID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query user_roles: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	items := make([]reviews.Item, 0)
+	for rows.Next() {
+		var item reviews.Item
+		var source string
+		var group permissions.Group
+		if err := rows.Scan(&item.UserID, &item.Role.ID, &item.Role.Name, &source, &group.ID, &group.Name); err != nil {
+			return nil, fmt.Errorf("scan user_roles: %w", err)
+		}
+		item.Source = permissions.RoleSource(source)
+		if group.ID != "" {
+			item.Group = &group
+		}
+		items = append(items, item)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows user_roles: %w", rows.Err())
+	}
+
+	return items, nil
+}
+
+func (pr *PermissionsRepo) CreateCampaign(ctx context.Context, campaign reviews.Campaign, items []reviews.Item) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO access_review_campaigns
+				(campaign_id, campaign_name, role_id, reviewer_ids, status, created_by, created_at)
+			VALUES
+				(@campaign_id, @campaign_name, NULLIF(@role_id::text, '')::uuid, @reviewer_ids::uuid[], @status, NULLIF(@created_by::text, '')::uuid, @created_at)
+			`, pgx.NamedArgs{
+			"campaign_id":   campaign.ID,
+			"campaign_name": campaign.Name,
+			"role_id":       campaign.RoleID,
+			"reviewer_ids":  campaign.ReviewerIDs,
+			"status":        string(campaign.Status),
+			"created_by":    campaign.CreatedBy,
+			"created_at":    campaign.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("insert access_review_campaigns: %w", dbError(err))
+		}
+
+		for _, item := range items {
+			var groupID, groupName string
+			if item.Group != nil {
+				groupID, groupName = item.Group.ID, item.Group.Name
+			}
+			_, err := tx.Exec(ctx, `
+				INSERT INTO access_review_items
+					(item_id, campaign_id, user_id, role_id, role_name, source, group_id, group_name, reviewer_id, decision)
+				VALUES
+					(@item_id, @campaign_id, @user_id, @role_id, @role_name, @source, NULLIF(@group_id::text, '')::uuid, NULLIF(@group_name::text, ''), @reviewer_id, @decision)
+				`, pgx.NamedArgs{
+				"item_id":     item.ID,
+				"campaign_id": campaign.ID,
+				"user_id":     item.UserID,
+				"role_id":     item.Role.ID,
+				"role_name":   item.Role.Name,
+				"source":      string(item.Source),
+				"group_id":    groupID,
+				"group_name":  groupName,
+				"reviewer_id": item.ReviewerID,
+				"decision":    string(item.Decision),
+			})
+			if err != nil {
+				return fmt.Errorf("insert access_review_items: %w", dbError(err))
+			}
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) GetCampaign(ctx context.Context, campaignID string) (*reviews.Campaign, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	campaigns, err := pr.getCampaigns(ctx, tx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if len(campaigns) == 0 {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "campaign %s not found", campaignID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return &campaigns[0], nil
+}
+
+func (pr *PermissionsRepo) GetCampaigns(ctx context.Context) ([]reviews.Campaign, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	campaigns, err := pr.getCampaigns(ctx, tx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// getCampaigns returns the campaign, or every campaign when campaignID is empty, most recent first.
+func (pr *PermissionsRepo) getCampaigns(ctx context.Context, tx pgx.Tx, campaignID string) ([]reviews.Campaign, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			c.campaign_id, c.campaign_name, COALESCE(c.role_id::text, ''), c.reviewer_ids::text[], c.status,
+			COALESCE(c.created_by::text, ''), c.created_at, COALESCE(c.closed_by::text, ''), c.closed_at
+		FROM
+			access_review_campaigns c
+		WHERE
+			@campaign_id::text = '' OR c.campaign_id = NULLIF(@campaign_id::text, '')::uuid
+		ORDER BY
+			c.created_at DESC, c.campaign_id ASC
+		`, pgx.NamedArgs{
+		"campaign_id": campaignID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query access_review_campaigns: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	campaigns := make([]reviews.Campaign, 0)
+	for rows.Next() {
+		var c reviews.Campaign
+		var status string
+		if err := rows.Scan(&c.ID, &c.Name, &c.RoleID, &c.ReviewerIDs, &status, &c.CreatedBy, &c.CreatedAt, &c.ClosedBy, &c.ClosedAt); err != nil {
+			return nil, fmt.Errorf("scan access_review_campaigns: %w", err)
+		}
+		c.Status = reviews.Status(status)
+		campaigns = append(campaigns, c)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows access_review_campaigns: %w", rows.Err())
+	}
+
+	return campaigns, nil
+}
+
+func (pr *PermissionsRepo) GetItem(ctx context.Context, campaignID, itemID string) (*reviews.Item, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	items, err := pr.getItems(ctx, tx, campaignID, "", itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "item %s of campaign %s not found", itemID, campaignID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return &items[0], nil
+}
+
+func (pr *PermissionsRepo) GetItems(ctx context.Context, campaignID, reviewerID string) ([]reviews.Item, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	items, err := pr.getItems(ctx, tx, campaignID, reviewerID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return items, nil
+}
+
+// getItems returns the campaign's items, limited to those of the reviewer, or
+// to the one item, when reviewerID or itemID are not empty.
+func (pr *PermissionsRepo) getItems(ctx context.Context, tx pgx.Tx, campaignID, reviewerID, itemID string) ([]reviews.Item, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			i.item_id, i.user_id, i.role_id, i.role_name, i.source, COALESCE(i.group_id::text, ''), COALESCE(i.group_name, ''),
+			i.reviewer_id, i.decision, COALESCE(i.comment, ''), i.decided_at, i.applied_at, i.also_revoked
+		FROM
+			access_review_items i
+		WHERE
+			i.campaign_id = @campaign_id::uuid
+			AND
+			(@reviewer_id::text = '' OR i.reviewer_id = NULLIF(@reviewer_id::text, '')::uuid)
+			AND
+			(@item_id::text = '' OR i.item_id = NULLIF(@item_id::text, '')::uuid)
+		ORDER BY
+			i.user_id ASC, i.role_name ASC, i.source ASC, i.group_name ASC
+		`, pgx.NamedArgs{
+		"campaign_id": campaignID,
+		"reviewer_id": reviewerID,
+		"item_id":     itemID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query access_review_items: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	items := make([]reviews.Item, 0)
+	for rows.Next() {
+		var item reviews.Item
+		var source, decision string
+		var group permissions.Group
+		err := rows.Scan(&item.ID, &item.UserID, &item.Role.ID, &item.Role.Name, &source, &group.ID, &group.Name,
+			&item.ReviewerID, &decision, &item.Comment, &item.DecidedAt, &item.AppliedAt, &item.AlsoRevoked)
+		if err != nil {
+			return nil, fmt.Errorf("scan access_review_items: %w", err)
+		}
+		item.Source = permissions.RoleSource(source)
+		item.Decision = reviews.Decision(decision)
+		if group.ID != "" {
+			item.Group = &group
+		}
+		items = append(items, item)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows access_review_items: %w", rows.Err())
+	}
+
+	return items, nil
+}
+
+func (pr *PermissionsRepo) SetDecision(ctx context.Context, campaignID, itemID string, decision reviews.Decision, comment string, at time.Time) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		// The campaign is locked so that it cannot be closed while the decision is recorded.
+		if err := lockOpenCampaign(ctx, tx, campaignID, "FOR SHARE"); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `
+			UPDATE access_review_items
+			SET
+				decision = @decision, comment = NULLIF(@comment::text, ''), decided_at = @decided_at
+			WHERE
+				campaign_id = @campaign_id::uuid
+				AND
+				item_id = @item_id::uuid
+			`, pgx.NamedArgs{
+			"campaign_id": campaignID,
+			"item_id":     itemID,
+			"decision":    string(decision),
+			"comment":     comment,
+			"decided_at":  at,
+		})
+		if err != nil {
+			return fmt.Errorf("update access_review_items: %w", dbError(err))
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "item %s of campaign %s not found", itemID, campaignID)
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) SetApplied(ctx context.Context, itemID string, alsoRevoked []string, at time.Time) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE access_review_items
+			SET
+				applied_at = @applied_at,
+				also_revoked = @also_revoked::text[]
+			WHERE
+				item_id = @item_id::uuid
+			`, pgx.NamedArgs{
+			"item_id":      itemID,
+			"applied_at":   at,
+			"also_revoked": alsoRevoked,
+		})
+		if err != nil {
+			return fmt.Errorf("update access_review_items: %w", dbError(err))
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) CloseCampaign(ctx context.Context, campaignID, closedBy string, at time.Time) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		if err := lockOpenCampaign(ctx, tx, campaignID, "FOR UPDATE"); err != nil {
+			return err
+		}
+
+		// A revoke decided after the campaign's revocations were applied must be applied first.
+		var unapplied bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM access_review_items
+				WHERE campaign_id = @campaign_id::uuid AND decision = 'revoke' AND applied_at IS NULL
+			)
+			`, pgx.NamedArgs{
+			"campaign_id": campaignID,
+		}).Scan(&unapplied)
+		if err != nil {
+			return fmt.Errorf("query access_review_items: %w", dbError(err))
+		}
+		if unapplied {
+			return fmt.Errorf("campaign %s has revoke decisions which have not been applied", campaignID)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE access_review_campaigns
+			SET
+				status = 'closed', closed_by = NULLIF(@closed_by::text, '')::uuid, closed_at = @closed_at
+			WHERE
+				campaign_id = @campaign_id::uuid
+			`, pgx.NamedArgs{
+			"campaign_id": campaignID,
+			"closed_by":   closedBy,
+			"closed_at":   at,
+		})
+		if err != nil {
+			return fmt.Errorf("update access_review_campaigns: %w", dbError(err))
+		}
+		return nil
+	})
+}
+
+// lockOpenCampaign locks the campaign's row with the locking clause, it is
+// ErrNotFound when there is no such campaign and ErrInvalidInput when it is closed.
+func lockOpenCampaign(ctx context.Context, tx pgx.Tx, campaignID, lock string) error {
+	var status string
+	err := tx.QueryRow(ctx, `
+		SELECT
+			status
+		FROM
+			access_review_campaigns
+		WHERE
+			campaign_id = @campaign_id::uuid
+		`+lock, pgx.NamedArgs{
+		"campaign_id": campaignID,
+	}).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return permissions.Errorf(permissions.ErrNotFound, "campaign %s not found", campaignID)
+	}
+	if err != nil {
+		return fmt.Errorf("query access_review_campaigns: %w", dbError(err))
+	}
+	if reviews.Status(status) != reviews.StatusOpen {
+		return permissions.Errorf(permissions.ErrInvalidInput, "campaign %s is closed", campaignID)
+	}
+	return nil
+}
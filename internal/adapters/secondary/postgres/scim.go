@@ -0,0 +1,749 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetSCIMUsers(ctx context.Context) ([]scim.User, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	users, err := pr.getSCIMUsers(ctx, tx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return users, nil
+}
+
+func (pr *PermissionsRepo) GetSCIMUser(ctx context.Context, userID string) (*scim.User, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	users, err := pr.getSCIMUsers(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "user %s not found", userID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return &users[0], nil
+}
+
+// getSCIMUsers returns the user, or every user when userID is empty, by userName.
+func (pr *PermissionsRepo) getSCIMUsers(ctx context.Context, tx pgx.Tx, userID string) ([]scim.User, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			su.user_id, su.user_name, COALESCE(su.external_id, ''), COALESCE(su.display_name, ''),
+			COALESCE(su.given_name, ''), COALESCE(su.family_name, ''), COALESCE(su.email, ''),
+			su.active, su.created_at, su.updated_at
+		FROM
+			scim_users su
+		WHERE
+			@user_id::text = '' OR su.user_id = NULLIF(@user_id::text, '')::uuid
+		ORDER BY
+			LOWER(su.user_name) ASC
+		`, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query scim_users: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	users := make([]scim.User, 0)
+	for rows.Next() {
+		var u scim.User
+		if err := rows.Scan(&u.ID, &u.UserName, &u.ExternalID, &u.DisplayName, &u.GivenName, &u.FamilyName, &u.Email, &u.Active, &u.Created, &u.LastModified); err != nil {
+			return nil, fmt.Errorf("scan scim_users: %w", err)
+		}
+		users = append(users, u)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows scim_users: %w", rows.Err())
+	}
+
+	return users, nil
+}
+
+func (pr *PermissionsRepo) GetSCIMGroups(ctx context.Context) ([]scim.Group, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	groups, err := pr.getSCIMGroups(ctx, tx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (pr *PermissionsRepo) GetSCIMGroup(ctx context.Context, groupID string) (*scim.Group, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	group, err := pr.getSCIMGroup(ctx, tx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return group, nil
+}
+
+func (pr *PermissionsRepo) getSCIMGroup(ctx context.Context, tx pgx.Tx, groupID string) (*scim.Group, error) {
+	groups, err := pr.getSCIMGroups(ctx, tx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "group %s not found", groupID)
+	}
+	return &groups[0], nil
+}
+
+// getSCIMGroups returns the group, or every group when groupID is empty, by
+// displayName. The members of a group mapped to a role are the users assigned
+// the role directly, and not expired, those of one mapped to a group are the
+// group's members, in either case only the SCIM users among them.
+func (pr *PermissionsRepo) getSCIMGroups(ctx context.Context, tx pgx.Tx, groupID string) ([]scim.Group, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			sg.scim_group_id, sg.display_name, COALESCE(sg.external_id, ''),
+			CASE WHEN sg.role_id IS NOT NULL THEN 'role' ELSE 'group' END,
+			COALESCE(sg.role_id, sg.group_id)::text, COALESCE(r.role_name, g.group_name),
+			sg.created_at, sg.updated_at,
+			ARRAY(
+				SELECT
+					ur.user_id::text
+				FROM
+					user_roles ur
+				JOIN
+					scim_users su ON ur.user_id = su.user_id
+				WHERE
+					ur.role_id = sg.role_id
+					AND
+					(ur.expires_at IS NULL OR ur.expires_at > NOW())
+				UNION
+				SELECT
+					gm.user_id::text
+				FROM
+					group_members gm
+				JOIN
+					scim_users su ON gm.user_id = su.user_id
+				WHERE
+					gm.group_id = sg.group_id
+				ORDER BY
+					1
+			)
+		FROM
+			scim_groups sg
+		LEFT JOIN
+			roles r ON sg.role_id = r.role_id
+		LEFT JOIN
+			groups g ON sg.group_id = g.group_id
+		WHERE
+			@scim_group_id::text = '' OR sg.scim_group_id = NULLIF(@scim_group_id::text, '')::uuid
+		ORDER BY
+			LOWER(sg.display_name) ASC
+		`, pgx.NamedArgs{
+		"scim_group_id": groupID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query scim_groups: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	groups := make([]scim.Group, 0)
+	for rows.Next() {
+		var g scim.Group
+		var target string
+		if err := rows.Scan(&g.ID, &g.DisplayName, &g.ExternalID, &target, &g.TargetID, &g.TargetName, &g.Created, &g.LastModified, &g.Members); err != nil {
+			return nil, fmt.Errorf("scan scim_groups: %w", err)
+		}
+		g.Target = scim.Target(target)
+		groups = append(groups, g)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows scim_groups: %w", rows.Err())
+	}
+
+	return groups, nil
+}
+
+func (pr *PermissionsRepo) GetSCIMGroupTarget(ctx context.Context) (scim.Target, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get tenant connection: %w", err)
+	}
+
+	var target string
+	err = pool.QueryRow(ctx, `
+		SELECT
+			group_target
+		FROM
+			scim_settings
+		`).Scan(&target)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return scim.TargetGroup, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("query scim_settings: %w", dbError(err))
+	}
+	return scim.Target(target), nil
+}
+
+func (pr *PermissionsRepo) SetSCIMGroupTarget(ctx context.Context, target scim.Target) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO scim_settings
+				(group_target, updated_at)
+			VALUES
+				(@group_target, NOW())
+			ON CONFLICT (scim_settings_id) DO UPDATE SET
+				group_target = EXCLUDED.group_target,
+				updated_at = EXCLUDED.updated_at
+			`, pgx.NamedArgs{
+			"group_target": string(target),
+		})
+		if err != nil {
+			return fmt.Errorf("insert scim_settings: %w", err)
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) CreateSCIMUser(ctx context.Context, user scim.User) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO scim_users
+				(user_id, user_name, external_id, display_name, given_name, family_name, email, active, created_at, updated_at)
+			VALUES
+				(@user_id, @user_name, NULLIF(@external_id, ''), NULLIF(@display_name, ''), NULLIF(@given_name, ''),
+				NULLIF(@family_name, ''), NULLIF(@email, ''), @active, @created_at, @updated_at)
+			`, scimUserArgs(user))
+		// The user ID is taken from the externalId when it is a UUID, see scim.Service.CreateUser.
+		if isUniqueViolationOf(err, "scim_users_pkey") {
+			return scim.Errorf(scim.ErrUniqueness, "externalId %q is already in use", user.ExternalID)
+		}
+		if isUniqueViolation(err) {
+			return scim.Errorf(scim.ErrUniqueness, "userName %q is already in use", user.UserName)
+		}
+		if err != nil {
+			return fmt.Errorf("insert scim_users: %w", err)
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) UpdateSCIMUser(ctx context.Context, user scim.User, revokeGrants bool) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE scim_users SET
+				user_name = @user_name,
+				external_id = NULLIF(@external_id, ''),
+				display_name = NULLIF(@display_name, ''),
+				given_name = NULLIF(@given_name, ''),
+				family_name = NULLIF(@family_name, ''),
+				email = NULLIF(@email, ''),
+				active = @active,
+				updated_at = @updated_at
+			WHERE
+				user_id = @user_id
+			`, scimUserArgs(user))
+		if isUniqueViolation(err) {
+			return scim.Errorf(scim.ErrUniqueness, "userName %q is already in use", user.UserName)
+		}
+		if err != nil {
+			return fmt.Errorf("update scim_users: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "user %s not found", user.ID)
+		}
+		if revokeGrants {
+			return revokeUserGrants(ctx, tx, user.ID, "deactivated")
+		}
+		return nil
+	})
+}
+
+func scimUserArgs(user scim.User) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"user_id":      user.ID,
+		"user_name":    user.UserName,
+		"external_id":  user.ExternalID,
+		"display_name": user.DisplayName,
+		"given_name":   user.GivenName,
+		"family_name":  user.FamilyName,
+		"email":        user.Email,
+		"active":       user.Active,
+		"created_at":   user.Created,
+		"updated_at":   user.LastModified,
+	}
+}
+
+func (pr *PermissionsRepo) DeleteSCIMUser(ctx context.Context, userID string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM scim_users
+			WHERE
+				user_id = @user_id
+			`, pgx.NamedArgs{
+			"user_id": userID,
+		})
+		if err != nil {
+			return fmt.Errorf("delete scim_users: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "user %s not found", userID)
+		}
+		return revokeUserGrants(ctx, tx, userID, "deleted")
+	})
+}
+
+// revokeUserGrants removes every grant of the user: their role assignments,
+// group memberships, extra permissions, resource grants, the relationship tuples
+// of which they are the subject and the delegations by or to them. Their active
+// break-glass sessions are ended. Their revoked permissions are kept, so that
+// granting them roles again does not give back what was revoked.
+func revokeUserGrants(ctx context.Context, tx pgx.Tx, userID, reason string) error {
+	if err := endBreakGlassSessions(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+	args := pgx.NamedArgs{"user_id": userID}
+	rows, err := tx.Query(ctx, `
+		DELETE FROM user_roles ur
+		USING
+			roles r
+		WHERE
+			ur.role_id = r.role_id
+			AND
+			ur.user_id = @user_id::uuid
+			AND
+			(ur.expires_at IS NULL OR ur.expires_at > NOW())
+		RETURNING
+			r.role_name
+		`, args)
+	if err != nil {
+		return fmt.Errorf("delete user_roles: %w", err)
+	}
+	if err := insertRoleEvents(ctx, tx, rows, outbox.EventRoleRevoked, userID); err != nil {
+		return err
+	}
+
+	groupIDs, err := collectStrings(tx.Query(ctx, `
+		DELETE FROM group_members
+		WHERE
+			user_id = @user_id::uuid
+		RETURNING
+			group_id::text
+		`, args))
+	if err != nil {
+		return fmt.Errorf("delete group_members: %w", err)
+	}
+	events := make([]outbox.Event, 0, len(groupIDs)+2)
+	for _, groupID := range groupIDs {
+		events = append(events, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: groupID, Action: "members_removed", UserIDs: []string{userID}}))
+	}
+
+	for _, table := range []string{"user_roles", "user_resources"} {
+		// Expired role assignments are left by the query above, and are removed with the rest.
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE user_id = @user_id::uuid`, args); err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM user_permissions
+		WHERE
+			user_id = @user_id::uuid
+			AND
+			permission_type = 'extra'
+		`, args); err != nil {
+		return fmt.Errorf("delete user_permissions: %w", err)
+	}
+
+	tuples, err := collectStrings(tx.Query(ctx, `
+		DELETE FROM relation_tuples
+		WHERE
+			subject_type = 'user'
+			AND
+			subject_id = @user_id::text
+			AND
+			subject_relation = ''
+		RETURNING
+			object_type || ':' || object_id || '#' || relation || '@user:' || subject_id
+		`, args))
+	if err != nil {
+		return fmt.Errorf("delete relation_tuples: %w", err)
+	}
+	if len(tuples) > 0 {
+		events = append(events, outbox.NewEvent(outbox.EventRelationsChanged, outbox.RelationsChange{Deleted: tuples}))
+	}
+
+	rows, err = tx.Query(ctx, `
+		DELETE FROM delegations
+		WHERE
+			delegator_id = @user_id::uuid
+			OR
+			delegate_id = @user_id::uuid
+		RETURNING
+			delegation_id::text, delegator_id::text, delegate_id::text
+		`, args)
+	if err != nil {
+		return fmt.Errorf("delete delegations: %w", err)
+	}
+	delegations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (outbox.DelegationChange, error) {
+		change := outbox.DelegationChange{Action: "revoked"}
+		return change, row.Scan(&change.DelegationID, &change.DelegatorID, &change.DelegateID)
+	})
+	if err != nil {
+		return fmt.Errorf("rows delegations: %w", err)
+	}
+	for _, change := range delegations {
+		events = append(events, outbox.NewEvent(outbox.EventDelegationChanged, change))
+	}
+
+	events = append(events, outbox.NewEvent(outbox.EventUserGrantsRevoked, outbox.UserGrantsRevoked{UserID: userID, Reason: reason}))
+	return insertEvents(ctx, tx, events...)
+}
+
+func (pr *PermissionsRepo) CreateSCIMGroup(ctx context.Context, group scim.Group) error {
+	return inSoDTx(ctx, pr.tenantPool, sodScope{userIDs: group.Members}, func(tx pgx.Tx) error {
+		var events []outbox.Event
+		switch group.Target {
+		case scim.TargetRole:
+			err := tx.QueryRow(ctx, `
+				SELECT
+					r.role_id::text, r.role_name
+				FROM
+					roles r
+				WHERE
+					r.role_name = @name
+				`, pgx.NamedArgs{
+				"name": group.DisplayName,
+			}).Scan(&group.TargetID, &group.TargetName)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return scim.Errorf(scim.ErrInvalidValue, "there is no role named %q", group.DisplayName)
+			}
+			if err != nil {
+				return fmt.Errorf("query roles: %w", err)
+			}
+		default:
+			var created bool
+			err := tx.QueryRow(ctx, `
+				WITH inserted AS (
+					INSERT INTO groups
+						(group_id, group_name, created_at)
+					VALUES
+						(@group_id, @name, NOW())
+					ON CONFLICT (group_name) DO NOTHING
+					RETURNING
+						group_id, group_name
+				)
+				SELECT
+					group_id::text, group_name, TRUE
+				FROM
+					inserted
+				UNION ALL
+				SELECT
+					g.group_id::text, g.group_name, FALSE
+				FROM
+					groups g
+				WHERE
+					g.group_name = @name
+				`, pgx.NamedArgs{
+				"group_id": uuid.NewString(),
+				"name":     group.DisplayName,
+			}).Scan(&group.TargetID, &group.TargetName, &created)
+			if err != nil {
+				return fmt.Errorf("insert groups: %w", err)
+			}
+			if created {
+				events = append(events, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: group.TargetID, Action: "created"}))
+			}
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO scim_groups
+				(scim_group_id, display_name, external_id, role_id, group_id, created_at, updated_at)
+			VALUES
+				(@scim_group_id, @display_name, NULLIF(@external_id, ''), @role_id::uuid, @group_id::uuid, @created_at, @updated_at)
+			`, pgx.NamedArgs{
+			"scim_group_id": group.ID,
+			"display_name":  group.DisplayName,
+			"external_id":   group.ExternalID,
+			"role_id":       targetID(group, scim.TargetRole),
+			"group_id":      targetID(group, scim.TargetGroup),
+			"created_at":    group.Created,
+			"updated_at":    group.LastModified,
+		})
+		if isUniqueViolation(err) {
+			return scim.Errorf(scim.ErrUniqueness, "group %q is already provisioned, or its %s mapped", group.DisplayName, group.Target)
+		}
+		if err != nil {
+			return fmt.Errorf("insert scim_groups: %w", err)
+		}
+		if err := insertEvents(ctx, tx, events...); err != nil {
+			return err
+		}
+		return addSCIMMembers(ctx, tx, group, group.Members)
+	})
+}
+
+// targetID returns the group's TargetID when it is mapped to the target, or nil.
+func targetID(group scim.Group, target scim.Target) *string {
+	if group.Target != target {
+		return nil
+	}
+	return &group.TargetID
+}
+
+func (pr *PermissionsRepo) UpdateSCIMGroup(ctx context.Context, group scim.Group, added, removed []string) error {
+	return inSoDTx(ctx, pr.tenantPool, sodScope{userIDs: added}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE scim_groups SET
+				display_name = @display_name,
+				external_id = NULLIF(@external_id, ''),
+				updated_at = @updated_at
+			WHERE
+				scim_group_id = @scim_group_id
+			`, pgx.NamedArgs{
+			"scim_group_id": group.ID,
+			"display_name":  group.DisplayName,
+			"external_id":   group.ExternalID,
+			"updated_at":    group.LastModified,
+		})
+		if isUniqueViolation(err) {
+			return scim.Errorf(scim.ErrUniqueness, "displayName %q is already in use", group.DisplayName)
+		}
+		if err != nil {
+			return fmt.Errorf("update scim_groups: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "group %s not found", group.ID)
+		}
+		if err := removeSCIMMembers(ctx, tx, group, removed); err != nil {
+			return err
+		}
+		return addSCIMMembers(ctx, tx, group, added)
+	})
+}
+
+func (pr *PermissionsRepo) DeleteSCIMGroup(ctx context.Context, groupID string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		group, err := pr.getSCIMGroup(ctx, tx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := removeSCIMMembers(ctx, tx, *group, group.Members); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM scim_groups
+			WHERE
+				scim_group_id = @scim_group_id
+			`, pgx.NamedArgs{
+			"scim_group_id": groupID,
+		}); err != nil {
+			return fmt.Errorf("delete scim_groups: %w", err)
+		}
+		return nil
+	})
+}
+
+// addSCIMMembers assigns the group's role to the users, or makes them members
+// of its group. A user who is not a SCIM user is ErrInvalidValue, and an
+// inactive one is left out.
+func addSCIMMembers(ctx context.Context, tx pgx.Tx, group scim.Group, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	args := pgx.NamedArgs{
+		"target_id": group.TargetID,
+		"user_ids":  userIDs,
+	}
+	var unknown []string
+	err := tx.QueryRow(ctx, `
+		SELECT
+			COALESCE(ARRAY_AGG(id::text ORDER BY id::text), '{}')
+		FROM
+			UNNEST(@user_ids::uuid[]) AS id
+		WHERE
+			NOT EXISTS (SELECT 1 FROM scim_users su WHERE su.user_id = id)
+		`, args).Scan(&unknown)
+	if err != nil {
+		return fmt.Errorf("query scim_users: %w", err)
+	}
+	if len(unknown) > 0 {
+		return scim.Errorf(scim.ErrInvalidValue, "members are not users: %s", strings.Join(unknown, ", "))
+	}
+
+	if group.Target == scim.TargetRole {
+		// As for AddUserRoles, a role held only until an expiry is assigned again without one.
+		added, err := collectStrings(tx.Query(ctx, `
+			INSERT INTO user_roles
+				(user_id, role_id, created_at)
+			SELECT
+				su.user_id, @target_id::uuid, NOW()
+			FROM
+				scim_users su
+			WHERE
+				su.user_id = ANY(@user_ids::uuid[])
+				AND
+				su.active
+				AND
+				NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = su.user_id AND ur.role_id = @target_id::uuid AND ur.expires_at IS NULL)
+			RETURNING
+				user_id::text
+			`, args))
+		if err != nil {
+			return fmt.Errorf("insert user_roles: %w", err)
+		}
+		events := make([]outbox.Event, len(added))
+		for i, userID := range added {
+			events[i] = outbox.NewEvent(outbox.EventRoleGranted, outbox.RoleAssignment{UserID: userID, Role: group.TargetName})
+		}
+		return insertEvents(ctx, tx, events...)
+	}
+
+	added, err := collectStrings(tx.Query(ctx, `
+		INSERT INTO group_members
+			(group_id, user_id, created_at)
+		SELECT
+			@target_id::uuid, su.user_id, NOW()
+		FROM
+			scim_users su
+		WHERE
+			su.user_id = ANY(@user_ids::uuid[])
+			AND
+			su.active
+		ON CONFLICT (group_id, user_id) DO NOTHING
+		RETURNING
+			user_id::text
+		`, args))
+	if err != nil {
+		return fmt.Errorf("insert group_members: %w", err)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: group.TargetID, Action: "members_added", UserIDs: added}))
+}
+
+// removeSCIMMembers unassigns the group's role from the users, or removes them from its group.
+func removeSCIMMembers(ctx context.Context, tx pgx.Tx, group scim.Group, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	args := pgx.NamedArgs{
+		"target_id": group.TargetID,
+		"user_ids":  userIDs,
+	}
+
+	if group.Target == scim.TargetRole {
+		// user_roles may hold the role more than once for a user, each is removed.
+		removed, err := collectStrings(tx.Query(ctx, `
+			WITH deleted AS (
+				DELETE FROM user_roles
+				WHERE
+					role_id = @target_id::uuid
+					AND
+					user_id = ANY(@user_ids::uuid[])
+				RETURNING
+					user_id
+			)
+			SELECT DISTINCT
+				user_id::text
+			FROM
+				deleted
+			`, args))
+		if err != nil {
+			return fmt.Errorf("delete user_roles: %w", err)
+		}
+		events := make([]outbox.Event, len(removed))
+		for i, userID := range removed {
+			events[i] = outbox.NewEvent(outbox.EventRoleRevoked, outbox.RoleAssignment{UserID: userID, Role: group.TargetName})
+		}
+		return insertEvents(ctx, tx, events...)
+	}
+
+	removed, err := collectStrings(tx.Query(ctx, `
+		DELETE FROM group_members
+		WHERE
+			group_id = @target_id::uuid
+			AND
+			user_id = ANY(@user_ids::uuid[])
+		RETURNING
+			user_id::text
+		`, args))
+	if err != nil {
+		return fmt.Errorf("delete group_members: %w", err)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventGroupChanged, outbox.GroupChange{GroupID: group.TargetID, Action: "members_removed", UserIDs: removed}))
+}
+
+// collectStrings returns the strings of the rows' single column, taking the result of tx.Query.
+func collectStrings(rows pgx.Rows, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
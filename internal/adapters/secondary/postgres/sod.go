@@ -0,0 +1,565 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) CreateSoDRule(ctx context.Context, rule permissions.SoDRule) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		// A guarded write under way finishes before the rule exists, see enforceSoD.
+		if err := lockSoD(ctx, tx); err != nil {
+			return err
+		}
+
+		var unknown []string
+		var exists bool
+		err := tx.QueryRow(ctx, `
+			SELECT
+				COALESCE(ARRAY_AGG(id::text ORDER BY id::text) FILTER (WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.role_id = id)), '{}'),
+				EXISTS (SELECT 1 FROM sod_rules WHERE LOWER(rule_name) = LOWER(@rule_name::text))
+			FROM
+				UNNEST(@role_ids::uuid[]) AS id
+			`, pgx.NamedArgs{
+			"role_ids":  rule.Roles.GetIDs(),
+			"rule_name": rule.Name,
+		}).Scan(&unknown, &exists)
+		if err != nil {
+			return fmt.Errorf("query roles: %w", dbError(err))
+		}
+		if len(unknown) > 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "unknown roles: %s", strings.Join(unknown, ", "))
+		}
+		if exists {
+			return permissions.Errorf(permissions.ErrInvalidInput, "rule %q already exists", rule.Name)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO sod_rules
+				(rule_id, rule_name, enforcement, created_at)
+			VALUES
+				(@rule_id, @rule_name, @enforcement, NOW())
+			`, pgx.NamedArgs{
+			"rule_id":     rule.ID,
+			"rule_name":   rule.Name,
+			"enforcement": string(rule.Enforcement),
+		})
+		if err != nil {
+			return fmt.Errorf("insert sod_rules: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO sod_rule_roles
+				(rule_id, role_id)
+			SELECT
+				@rule_id::uuid, role_id
+			FROM
+				UNNEST(@role_ids::uuid[]) AS role_id
+			`, pgx.NamedArgs{
+			"rule_id":  rule.ID,
+			"role_ids": rule.Roles.GetIDs(),
+		})
+		if err != nil {
+			return fmt.Errorf("insert sod_rule_roles: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO sod_rule_permissions
+				(rule_id, permission_name)
+			SELECT
+				@rule_id::uuid, permission_name
+			FROM
+				UNNEST(@permission_names::text[]) AS permission_name
+			`, pgx.NamedArgs{
+			"rule_id":          rule.ID,
+			"permission_names": rule.Permissions,
+		})
+		if err != nil {
+			return fmt.Errorf("insert sod_rule_permissions: %w", err)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventSoDRulesChanged, outbox.SoDRuleChange{RuleID: rule.ID, Action: "created"}))
+	})
+}
+
+func (pr *PermissionsRepo) DeleteSoDRule(ctx context.Context, ruleID string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM sod_rules
+			WHERE
+				rule_id = @rule_id
+			`, pgx.NamedArgs{
+			"rule_id": ruleID,
+		})
+		if err != nil {
+			return fmt.Errorf("delete sod_rules: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "rule %s not found", ruleID)
+		}
+		return insertEvents(ctx, tx, outbox.NewEvent(outbox.EventSoDRulesChanged, outbox.SoDRuleChange{RuleID: ruleID, Action: "deleted"}))
+	})
+}
+
+func (pr *PermissionsRepo) GetSoDRules(ctx context.Context) (permissions.SoDRules, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	rules, err := pr.getSoDRules(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (pr *PermissionsRepo) getSoDRules(ctx context.Context, tx pgx.Tx) (permissions.SoDRules, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			s.rule_id, s.rule_name, s.enforcement,
+			COALESCE((SELECT ARRAY_AGG(r.role_id::text ORDER BY r.role_name, r.role_id::text) FROM sod_rule_roles srr JOIN roles r ON srr.role_id = r.role_id WHERE srr.rule_id = s.rule_id), '{}'),
+			COALESCE((SELECT ARRAY_AGG(r.role_name ORDER BY r.role_name, r.role_id::text) FROM sod_rule_roles srr JOIN roles r ON srr.role_id = r.role_id WHERE srr.rule_id = s.rule_id), '{}'),
+			COALESCE((SELECT ARRAY_AGG(srp.permission_name ORDER BY srp.permission_name) FROM sod_rule_permissions srp WHERE srp.rule_id = s.rule_id), '{}')
+		FROM
+			sod_rules s
+		ORDER BY
+			s.rule_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query sod_rules: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	var rules permissions.SoDRules
+	for rows.Next() {
+		var rule permissions.SoDRule
+		var roleIDs, roleNames []string
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Enforcement, &roleIDs, &roleNames, &rule.Permissions); err != nil {
+			return nil, fmt.Errorf("scan sod_rules: %w", err)
+		}
+		for i, id := range roleIDs {
+			rule.Roles = append(rule.Roles, permissions.Role{ID: id, Name: roleNames[i]})
+		}
+		if len(rule.Permissions) == 0 {
+			rule.Permissions = nil
+		}
+		rules = append(rules, rule)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows sod_rules: %w", rows.Err())
+	}
+
+	return rules, nil
+}
+
+func (pr *PermissionsRepo) GetSoDViolations(ctx context.Context) ([]permissions.SoDViolation, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	violations, err := getSoDViolations(ctx, tx, sodAllUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return violations, nil
+}
+
+// sodScope is the users a guarded write may grant roles or permissions to:
+// every user of the Tenant, or the users listed and the members of the group,
+// and of the groups nested in it, which gain its roles. The delegates of those
+// users gain them too, through their Delegations.
+type sodScope struct {
+	allUsers bool
+	userIDs  []string
+	groupID  string
+}
+
+// sodAllUsers is the scope of a write which may grant to any user, such as one
+// changing the permissions of roles.
+var sodAllUsers = sodScope{allUsers: true}
+
+func (s sodScope) empty() bool {
+	return !s.allUsers && len(s.userIDs) == 0 && s.groupID == ""
+}
+
+func (s sodScope) args() pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"all_users": s.allUsers,
+		"user_ids":  s.userIDs,
+		"group_id":  s.groupID,
+	}
+}
+
+// getSoDViolations returns the violations of the users in the scope.
+func getSoDViolations(ctx context.Context, tx pgx.Tx, scope sodScope) ([]permissions.SoDViolation, error) {
+	// A user holds the roles assigned to them and to their groups, and the
+	// roles those inherit. They hold a permission when one of those roles, or
+	// an extra permission, grants it or a wildcard matching it, even on a
+	// condition, unless it is revoked without one. A Delegation which has not
+	// ended lends its roles, and the permissions it delegates, to the delegate
+	// as long as the delegator holds them themselves. Only the scope's users,
+	// the delegates of those among them who delegate, and the delegators of
+	// them all are looked at, so a write to a few users does not scan the
+	// whole Tenant.
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE scope_groups AS (
+			SELECT
+				g.group_id
+			FROM
+				groups g
+			WHERE
+				g.group_id = NULLIF(@group_id::text, '')::uuid
+			UNION
+			SELECT
+				gh.child_group_id
+			FROM
+				scope_groups sg
+			JOIN
+				group_hierarchy gh ON gh.parent_group_id = sg.group_id
+		),
+		scope_users AS (
+			SELECT
+				UNNEST(@user_ids::uuid[]) AS user_id
+			UNION
+			SELECT
+				gm.user_id
+			FROM
+				group_members gm
+			JOIN
+				scope_groups sg ON sg.group_id = gm.group_id
+		),
+		affected_users AS (
+			SELECT
+				user_id
+			FROM
+				scope_users
+			UNION
+			SELECT
+				d.delegate_id
+			FROM
+				delegations d
+			JOIN
+				scope_users su ON su.user_id = d.delegator_id
+			WHERE
+				d.ends_at > NOW()
+		),
+		open_delegations AS (
+			SELECT
+				d.delegation_id, d.delegator_id, d.delegate_id
+			FROM
+				delegations d
+			WHERE
+				d.ends_at > NOW()
+				AND
+				(@all_users::bool OR d.delegate_id IN (SELECT user_id FROM affected_users))
+		),
+		looked_at AS (
+			SELECT
+				user_id
+			FROM
+				affected_users
+			UNION
+			SELECT
+				delegator_id
+			FROM
+				open_delegations
+		),
+		user_groups AS (
+			SELECT
+				gm.user_id, gm.group_id
+			FROM
+				group_members gm
+			WHERE
+				@all_users::bool OR gm.user_id IN (SELECT user_id FROM looked_at)
+			UNION
+			SELECT
+				ug.user_id, gh.parent_group_id
+			FROM
+				user_groups ug
+			JOIN
+				group_hierarchy gh ON gh.child_group_id = ug.group_id
+		),
+		own_roles AS (
+			SELECT
+				ur.user_id, ur.role_id
+			FROM
+				user_roles ur
+			WHERE
+				(ur.expires_at IS NULL OR ur.expires_at > NOW())
+				AND
+				(@all_users::bool OR ur.user_id IN (SELECT user_id FROM looked_at))
+			UNION
+			SELECT
+				ug.user_id, gr.role_id
+			FROM
+				user_groups ug
+			JOIN
+				group_roles gr ON gr.group_id = ug.group_id
+			UNION
+			SELECT
+				o.user_id, rh.child_role_id
+			FROM
+				own_roles o
+			JOIN
+				role_hierarchy rh ON rh.parent_role_id = o.role_id
+		),
+		held_roles AS (
+			SELECT
+				o.user_id, o.role_id
+			FROM
+				own_roles o
+			UNION
+			SELECT
+				od.delegate_id, dr.role_id
+			FROM
+				open_delegations od
+			JOIN
+				delegation_roles dr ON dr.delegation_id = od.delegation_id
+			JOIN
+				own_roles o ON o.user_id = od.delegator_id AND o.role_id = dr.role_id
+			UNION
+			SELECT
+				hr.user_id, rh.child_role_id
+			FROM
+				held_roles hr
+			JOIN
+				role_hierarchy rh ON rh.parent_role_id = hr.role_id
+		),
+		own_granted AS (
+			SELECT
+				o.user_id, LOWER(p.permission_name) AS permission_name
+			FROM
+				own_roles o
+			JOIN
+				role_permissions rp ON rp.role_id = o.role_id
+			JOIN
+				tenant_permissions tp ON tp.permission_id = rp.permission_id
+			JOIN
+				permissions p ON p.permission_id = rp.permission_id
+			UNION
+			SELECT
+				up.user_id, LOWER(p.permission_name)
+			FROM
+				user_permissions up
+			JOIN
+				permissions p ON p.permission_id = up.permission_id
+			WHERE
+				up.permission_type = 'extra'
+				AND
+				(@all_users::bool OR up.user_id IN (SELECT user_id FROM looked_at))
+		),
+		revoked AS (
+			SELECT
+				up.user_id, LOWER(p.permission_name) AS permission_name
+			FROM
+				user_permissions up
+			JOIN
+				permissions p ON p.permission_id = up.permission_id
+			WHERE
+				up.permission_type = 'revoked'
+				AND
+				up.condition IS NULL
+				AND
+				(@all_users::bool OR up.user_id IN (SELECT user_id FROM looked_at))
+		),
+		delegated AS (
+			SELECT
+				od.delegator_id, od.delegate_id, LOWER(p.permission_name) AS permission_name,
+				ARRAY[
+					LOWER(p.permission_name),
+					SPLIT_PART(LOWER(p.permission_name), ':', 1) || ':*',
+					'*:' || SPLIT_PART(LOWER(p.permission_name), ':', 2),
+					'*:*'
+				] AS matched_by
+			FROM
+				open_delegations od
+			JOIN
+				delegation_permissions dp ON dp.delegation_id = od.delegation_id
+			JOIN
+				permissions p ON p.permission_id = dp.permission_id
+		),
+		granted AS (
+			SELECT
+				hr.user_id, LOWER(p.permission_name) AS permission_name
+			FROM
+				held_roles hr
+			JOIN
+				role_permissions rp ON rp.role_id = hr.role_id
+			JOIN
+				tenant_permissions tp ON tp.permission_id = rp.permission_id
+			JOIN
+				permissions p ON p.permission_id = rp.permission_id
+			UNION
+			SELECT
+				og.user_id, og.permission_name
+			FROM
+				own_granted og
+			UNION
+			SELECT
+				d.delegate_id, d.permission_name
+			FROM
+				delegated d
+			WHERE
+				EXISTS (SELECT 1 FROM own_granted og WHERE og.user_id = d.delegator_id AND og.permission_name = ANY(d.matched_by))
+				AND
+				NOT EXISTS (SELECT 1 FROM revoked rv WHERE rv.user_id = d.delegator_id AND rv.permission_name = ANY(d.matched_by))
+		),
+		matching AS (
+			SELECT
+				srp.rule_id, srp.permission_name, m.name
+			FROM
+				sod_rule_permissions srp,
+				UNNEST(ARRAY[
+					LOWER(srp.permission_name),
+					SPLIT_PART(LOWER(srp.permission_name), ':', 1) || ':*',
+					'*:' || SPLIT_PART(LOWER(srp.permission_name), ':', 2),
+					'*:*'
+				]) AS m(name)
+		),
+		held AS (
+			SELECT
+				srr.rule_id, hr.user_id, r.role_name AS member
+			FROM
+				sod_rule_roles srr
+			JOIN
+				held_roles hr ON hr.role_id = srr.role_id
+			JOIN
+				roles r ON r.role_id = srr.role_id
+			UNION
+			SELECT
+				m.rule_id, g.user_id, m.permission_name
+			FROM
+				matching m
+			JOIN
+				granted g ON g.permission_name = m.name
+			WHERE
+				NOT EXISTS (
+					SELECT 1
+					FROM
+						matching rm
+					JOIN
+						revoked rv ON rv.permission_name = rm.name
+					WHERE
+						rm.rule_id = m.rule_id AND rm.permission_name = m.permission_name AND rv.user_id = g.user_id
+				)
+		)
+		SELECT
+			s.rule_id, s.rule_name, h.user_id, ARRAY_AGG(h.member ORDER BY h.member)
+		FROM
+			held h
+		JOIN
+			sod_rules s ON s.rule_id = h.rule_id
+		WHERE
+			s.enforcement = 'static'
+		GROUP BY
+			s.rule_id, s.rule_name, h.user_id
+		HAVING
+			COUNT(*) > 1
+		ORDER BY
+			s.rule_name ASC, h.user_id::text ASC
+		`, scope.args())
+	if err != nil {
+		return nil, fmt.Errorf("query sod violations: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	violations := make([]permissions.SoDViolation, 0)
+	for rows.Next() {
+		var v permissions.SoDViolation
+		if err := rows.Scan(&v.RuleID, &v.RuleName, &v.UserID, &v.Held); err != nil {
+			return nil, fmt.Errorf("scan sod violations: %w", err)
+		}
+		violations = append(violations, v)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows sod violations: %w", rows.Err())
+	}
+
+	return violations, nil
+}
+
+// enforceSoD runs write, which grants roles or permissions to the users in the
+// scope, and rejects it with ErrForbidden when it leaves one of them holding
+// members of a static rule they did not hold before. Violations which already
+// existed, such as those of a rule created after the grants, do not block
+// unrelated writes.
+func enforceSoD(ctx context.Context, tx pgx.Tx, scope sodScope, write func() error) error {
+	// Two writes checked alone could together violate a rule, so they take
+	// turns, and a rule is only created between them. The lock is taken before
+	// looking for rules, so a rule created meanwhile is seen.
+	if err := lockSoD(ctx, tx); err != nil {
+		return err
+	}
+	var enforced bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM sod_rules WHERE enforcement = 'static'
+		)
+		`).Scan(&enforced)
+	if err != nil {
+		return fmt.Errorf("query sod_rules: %w", err)
+	}
+	if !enforced || scope.empty() {
+		return write()
+	}
+
+	before, err := getSoDViolations(ctx, tx, scope)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := getSoDViolations(ctx, tx, scope)
+	if err != nil {
+		return err
+	}
+
+	var added []string
+	for _, v := range after {
+		if !slices.ContainsFunc(before, v.Equal) {
+			added = append(added, v.String())
+		}
+	}
+	if len(added) > 0 {
+		return permissions.Errorf(permissions.ErrForbidden, "separation of duties: %s", strings.Join(added, "; "))
+	}
+	return nil
+}
+
+// lockSoD waits until the other guarded writes, and rule creations, are committed.
+func lockSoD(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext(current_schema() || '.sod_rules'))`); err != nil {
+		return fmt.Errorf("lock sod_rules: %w", err)
+	}
+	return nil
+}
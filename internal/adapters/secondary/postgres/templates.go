@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/templates"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetAdoptions(ctx context.Context) ([]templates.Adoption, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	adoptions, err := pr.getAdoptions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return adoptions, nil
+}
+
+func (pr *PermissionsRepo) getAdoptions(ctx context.Context, tx pgx.Tx) ([]templates.Adoption, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			r.role_name, a.template_name, a.template_version, a.extensions, a.adopted_at, a.synced_at
+		FROM
+			role_template_adoptions a
+		JOIN
+			roles r ON a.role_id = r.role_id
+		ORDER BY
+			r.role_name ASC
+		`)
+	if err != nil {
+		return nil, fmt.Errorf("query role_template_adoptions: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	adoptions := make([]templates.Adoption, 0)
+	for rows.Next() {
+		var a templates.Adoption
+		if err := rows.Scan(&a.Role, &a.Template, &a.Version, &a.Extensions, &a.AdoptedAt, &a.SyncedAt); err != nil {
+			return nil, fmt.Errorf("scan role_template_adoptions: %w", err)
+		}
+		adoptions = append(adoptions, a)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows role_template_adoptions: %w", rows.Err())
+	}
+
+	return adoptions, nil
+}
+
+func (pr *PermissionsRepo) SaveAdoption(ctx context.Context, adoption templates.Adoption) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		extensions := adoption.Extensions
+		if extensions == nil {
+			extensions = []string{}
+		}
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO role_template_adoptions
+				(role_id, template_name, template_version, extensions, adopted_at, synced_at)
+			SELECT
+				r.role_id, @template_name, @template_version, @extensions::text[], @adopted_at, @synced_at
+			FROM
+				roles r
+			WHERE
+				r.role_name = @role_name
+			ON CONFLICT (role_id) DO UPDATE SET
+				template_name = EXCLUDED.template_name,
+				template_version = EXCLUDED.template_version,
+				extensions = EXCLUDED.extensions,
+				synced_at = EXCLUDED.synced_at
+			`, pgx.NamedArgs{
+			"role_name":        adoption.Role,
+			"template_name":    adoption.Template,
+			"template_version": adoption.Version,
+			"extensions":       extensions,
+			"adopted_at":       adoption.AdoptedAt,
+			"synced_at":        adoption.SyncedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("insert role_template_adoptions: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "role %q not found", adoption.Role)
+		}
+		return nil
+	})
+}
+
+func (pr *PermissionsRepo) DeleteAdoption(ctx context.Context, role string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM role_template_adoptions a
+			USING
+				roles r
+			WHERE
+				a.role_id = r.role_id
+				AND r.role_name = @role_name
+			`, pgx.NamedArgs{
+			"role_name": role,
+		})
+		if err != nil {
+			return fmt.Errorf("delete role_template_adoptions: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "role %q adopts no template", role)
+		}
+		return nil
+	})
+}
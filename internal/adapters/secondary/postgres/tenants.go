@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
 	"github.com/Equineregister/user-permissions-service/internal/config"
 	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -26,28 +27,32 @@ type TenantPool struct {
 
 func (tp *TenantPool) GetTenantConnection(ctx context.Context) (*pgxpool.Pool, error) {
 	tenantID, ok := contextkey.TenantID(ctx)
-	if !ok {
-		return nil, fmt.Errorf("tenantID not found in context: %v", tenantID)
+	if !ok || tenantID == "" {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "tenant ID not found in context")
 	}
 
 	tp.mu.RLock()
 	tenant, ok := tp.tenantPool[tenantID]
+	tp.mu.RUnlock()
 	if ok {
 		return tenant, nil
 	}
-	tp.mu.RUnlock()
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	// Another request may have connected while the lock was released.
+	if tenant, ok := tp.tenantPool[tenantID]; ok {
+		return tenant, nil
+	}
 
 	dsn := fmt.Sprintf("user=%s host=%s port=%d dbname=%s", "root", tp.host, tp.port, tenantID)
 	pool, err := NewWithIAM(ctx, tp.cfg.AWSConfig, dsn)
 	if err != nil {
-		return nil, fmt.Errorf("new with iam: %w", err)
+		return nil, permissions.WrapError(permissions.ErrUnavailable, fmt.Errorf("new with iam: %w", err), "database unavailable")
 	}
-
-	tp.mu.Lock()
-	defer tp.mu.Unlock()
 	tp.tenantPool[tenantID] = pool
 
-	return tenant, nil
+	return pool, nil
 }
 
 func NewTenantPoolFromSuppliedPool(ctx context.Context, tenantID string, pool *pgxpool.Pool) *TenantPool {
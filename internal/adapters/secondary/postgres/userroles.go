@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) AddUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	return inSoDTx(ctx, pr.tenantPool, sodScope{userIDs: []string{userID}}, func(tx pgx.Tx) error {
+		var unknown []string
+		err := tx.QueryRow(ctx, `
+			SELECT
+				COALESCE(ARRAY_AGG(id::text ORDER BY id::text), '{}')
+			FROM
+				UNNEST(@role_ids::uuid[]) AS id
+			WHERE
+				NOT EXISTS (SELECT 1 FROM roles r WHERE r.role_id = id)
+			`, pgx.NamedArgs{
+			"role_ids": roleIDs,
+		}).Scan(&unknown)
+		if err != nil {
+			return fmt.Errorf("query roles: %w", dbError(err))
+		}
+		if len(unknown) > 0 {
+			return permissions.Errorf(permissions.ErrNotFound, "unknown roles: %s", strings.Join(unknown, ", "))
+		}
+
+		// user_roles has no unique constraint, so roles already assigned without
+		// an expiry are left out. A role held only until an expiry, from an
+		// import or a break-glass session, is assigned again without one, so it
+		// does not lapse when that assignment expires.
+		rows, err := tx.Query(ctx, `
+			WITH inserted AS (
+				INSERT INTO user_roles
+					(user_id, role_id, created_at)
+				SELECT
+					@user_id::uuid, r.role_id, NOW()
+				FROM
+					roles r
+				WHERE
+					r.role_id = ANY(@role_ids::uuid[])
+					AND
+					NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = @user_id::uuid AND ur.role_id = r.role_id AND ur.expires_at IS NULL)
+				RETURNING
+					role_id
+			)
+			SELECT
+				r.role_name
+			FROM
+				inserted i
+			JOIN
+				roles r ON i.role_id = r.role_id
+			`, pgx.NamedArgs{
+			"user_id":  userID,
+			"role_ids": roleIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("insert user_roles: %w", dbError(err))
+		}
+		return insertRoleEvents(ctx, tx, rows, outbox.EventRoleGranted, userID)
+	})
+}
+
+func (pr *PermissionsRepo) RemoveUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	return inTx(ctx, pr.tenantPool, func(tx pgx.Tx) error {
+		if err := endBreakGlassSessions(ctx, tx, userID, roleIDs); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, `
+			DELETE FROM user_roles ur
+			USING
+				roles r
+			WHERE
+				ur.role_id = r.role_id
+				AND
+				ur.user_id = @user_id::uuid
+				AND
+				ur.role_id = ANY(@role_ids::uuid[])
+			RETURNING
+				r.role_name
+			`, pgx.NamedArgs{
+			"user_id":  userID,
+			"role_ids": roleIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("delete user_roles: %w", dbError(err))
+		}
+		return insertRoleEvents(ctx, tx, rows, outbox.EventRoleRevoked, userID)
+	})
+}
+
+// insertRoleEvents writes an event of the type for each role name in the rows,
+// those of the roles the statement assigned to, or removed from, the user.
+func insertRoleEvents(ctx context.Context, tx pgx.Tx, rows pgx.Rows, eventType outbox.EventType, userID string) error {
+	var events []outbox.Event
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan user_roles: %w", err)
+		}
+		events = append(events, outbox.NewEvent(eventType, outbox.RoleAssignment{UserID: userID, Role: role}))
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return fmt.Errorf("rows user_roles: %w", rows.Err())
+	}
+	return insertEvents(ctx, tx, events...)
+}
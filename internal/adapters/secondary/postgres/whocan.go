@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/jackc/pgx/v5"
+)
+
+func (pr *PermissionsRepo) GetCandidateUsers(ctx context.Context, permission string, resource permissions.Resource, after string, limit int) ([]string, error) {
+	pool, err := pr.tenantPool.GetTenantConnection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant connection: %w", err)
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", dbError(err))
+	}
+	defer rollback(ctx, tx)
+
+	userIDs, err := pr.getCandidateUsers(ctx, tx, permission, resource, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+func (pr *PermissionsRepo) getCandidateUsers(ctx context.Context, tx pgx.Tx, permission string, resource permissions.Resource, after string, limit int) ([]string, error) {
+	// The roles granting the permission are those granted it, or a wildcard
+	// matching it, and the roles inheriting them. A grant on one of the
+	// resource's ancestors allows the same action on the resource, so only the
+	// action of resource grants is matched.
+	_, action, _ := strings.Cut(strings.ToLower(permission), permissions.PermissionSeparator)
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE granting_roles AS (
+			SELECT
+				rp.role_id
+			FROM
+				role_permissions rp
+			JOIN
+				permissions p ON rp.permission_id = p.permission_id
+			WHERE
+				LOWER(p.permission_name) = ANY(@permission_names::text[])
+			UNION
+			SELECT
+				rh.parent_role_id
+			FROM
+				role_hierarchy rh
+			JOIN
+				granting_roles gr ON rh.child_role_id = gr.role_id
+		),
+		granting_groups AS (
+			SELECT
+				gr.group_id
+			FROM
+				group_roles gr
+			JOIN
+				granting_roles r ON gr.role_id = r.role_id
+			UNION
+			SELECT
+				gh.child_group_id
+			FROM
+				group_hierarchy gh
+			JOIN
+				granting_groups gg ON gh.parent_group_id = gg.group_id
+		),
+		ancestors AS (
+			SELECT
+				rt.resource_type_id, @resource_id::uuid AS resource_id
+			FROM
+				resource_types rt
+			WHERE
+				LOWER(rt.resource_type_name) = LOWER(@resource_type::text)
+			UNION
+			SELECT
+				rh.parent_resource_type_id, rh.parent_resource_id
+			FROM
+				resource_hierarchy rh
+			JOIN
+				ancestors a ON rh.child_resource_type_id = a.resource_type_id AND rh.child_resource_id = a.resource_id
+		),
+		candidates AS (
+			SELECT
+				ur.user_id
+			FROM
+				user_roles ur
+			JOIN
+				granting_roles gr ON ur.role_id = gr.role_id
+			UNION
+			SELECT
+				gm.user_id
+			FROM
+				group_members gm
+			JOIN
+				granting_groups gg ON gm.group_id = gg.group_id
+			UNION
+			SELECT
+				up.user_id
+			FROM
+				user_permissions up
+			JOIN
+				permissions p ON up.permission_id = p.permission_id
+			WHERE
+				up.permission_type = 'extra'
+				AND
+				LOWER(p.permission_name) = ANY(@permission_names::text[])
+			UNION
+			SELECT
+				ur.user_id
+			FROM
+				user_resources ur
+			JOIN
+				ancestors a ON ur.resource_type_id = a.resource_type_id AND ur.resource_id = a.resource_id
+			JOIN
+				permissions p ON ur.permission_id = p.permission_id
+			WHERE
+				SPLIT_PART(LOWER(p.permission_name), ':', 2) IN (@action::text, '*')
+			UNION
+			SELECT
+				d.delegate_id
+			FROM
+				delegations d
+			WHERE
+				d.starts_at <= NOW()
+				AND
+				d.ends_at > NOW()
+				AND
+				(
+					EXISTS (SELECT 1 FROM delegation_roles dr JOIN granting_roles gr ON dr.role_id = gr.role_id WHERE dr.delegation_id = d.delegation_id)
+					OR
+					EXISTS (SELECT 1 FROM delegation_permissions dp JOIN permissions p ON dp.permission_id = p.permission_id WHERE dp.delegation_id = d.delegation_id AND LOWER(p.permission_name) = ANY(@permission_names::text[]))
+				)
+		)
+		SELECT
+			c.user_id
+		FROM
+			candidates c
+		WHERE
+			c.user_id::text > @after::text
+		ORDER BY
+			c.user_id::text ASC
+		LIMIT @limit
+		`, pgx.NamedArgs{
+		"permission_names": matchingPermissionNames(permission),
+		"resource_type":    resource.Type,
+		"resource_id":      resource.ID,
+		"action":           action,
+		"after":            after,
+		"limit":            limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query candidate users: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	userIDs := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan candidate users: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("rows candidate users: %w", rows.Err())
+	}
+
+	return userIDs, nil
+}
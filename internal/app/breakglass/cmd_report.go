@@ -0,0 +1,15 @@
+package breakglass
+
+import (
+	"context"
+	"fmt"
+)
+
+// Report returns the report of every break-glass session of the Tenant.
+func (s *Service) Report(ctx context.Context) (*Report, error) {
+	sessions, err := s.GetSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	return &Report{Sessions: sessions, GeneratedAt: s.now().UTC()}, nil
+}
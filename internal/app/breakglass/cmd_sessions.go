@@ -0,0 +1,106 @@
+package breakglass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/google/uuid"
+)
+
+// Grant breaks the glass: it assigns the Tenant's emergency role to the user
+// for the duration, or for the Settings' MaxDuration when it is zero, after
+// which the assignment lapses by itself. The justification is required, and the
+// user in the context, if any, is recorded as granting it. It is
+// ErrInvalidInput when break-glass has not been set up, the duration is longer
+// than the Settings allow, or the user is already assigned the role.
+//
+// The assignment is not checked against separation of duties rules, break-glass
+// is for when the usual controls are in the way; the high severity event it
+// raises, EventBreakGlassGranted, is how it is held to account instead.
+func (s *Service) Grant(ctx context.Context, userID, justification string, duration time.Duration) (*Session, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("grant: %w", err)
+	}
+	if err := permissions.ValidateUserID(userID); err != nil {
+		return nil, fmt.Errorf("grant: %w", err)
+	}
+	justification = strings.TrimSpace(justification)
+	if justification == "" {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "grant: a justification is required")
+	}
+	if duration < 0 {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "grant: the duration must not be negative")
+	}
+
+	settings, err := s.repo.GetBreakGlassSettings(ctx)
+	if errors.Is(err, permissions.ErrNotFound) {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "grant: break-glass has not been set up for the Tenant")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("grant: %w", err)
+	}
+	if duration == 0 {
+		duration = settings.MaxDuration
+	}
+	if duration > settings.MaxDuration {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "grant: break-glass lasts at most %s", settings.MaxDuration)
+	}
+
+	grantedBy, _ := contextkey.UserID(ctx)
+	now := s.now().UTC()
+	session := &Session{
+		ID:            uuid.NewString(),
+		UserID:        userID,
+		Role:          permissions.Role{ID: settings.Role.ID},
+		Justification: justification,
+		GrantedBy:     grantedBy,
+		StartedAt:     now,
+		ExpiresAt:     now.Add(duration),
+	}
+	if err := s.repo.CreateBreakGlassSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("grant: %w", err)
+	}
+	return session, nil
+}
+
+// End ends the active session before it expires, revoking the emergency role.
+// The user in the context, if any, is recorded as ending it.
+func (s *Service) End(ctx context.Context, sessionID string) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	if err := uuid.Validate(sessionID); err != nil {
+		return permissions.Errorf(permissions.ErrInvalidInput, "end: session ID %q is not a UUID", sessionID)
+	}
+	session, err := s.repo.GetBreakGlassSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	now := s.now().UTC()
+	if status := session.Status(now); status != StatusActive {
+		return permissions.Errorf(permissions.ErrInvalidInput, "end: session %s has already %s", sessionID, status)
+	}
+
+	endedBy, _ := contextkey.UserID(ctx)
+	if err := s.repo.EndBreakGlassSession(ctx, sessionID, endedBy, now); err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	return nil
+}
+
+// GetSessions returns the Tenant's sessions, most recent first.
+func (s *Service) GetSessions(ctx context.Context) ([]Session, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get sessions: %w", err)
+	}
+	sessions, err := s.repo.GetBreakGlassSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get sessions: %w", err)
+	}
+	return sessions, nil
+}
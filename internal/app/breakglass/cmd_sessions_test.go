@@ -0,0 +1,80 @@
+package breakglass_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/breakglass"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	engineer = "0c5d7e9f-1a3b-4c5d-8e7f-9a1b3c5d7e90"
+	oncall   = "1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01"
+)
+
+var admin = permissions.Role{ID: "550e8400-e29b-41d4-a716-446655440000", Name: "admin"}
+
+// stubRepo returns its settings, and keeps the session created, the other methods are left nil.
+type stubRepo struct {
+	breakglass.ReaderWriter
+	settings *breakglass.Settings
+	session  *breakglass.Session
+}
+
+func (r *stubRepo) GetBreakGlassSettings(context.Context) (*breakglass.Settings, error) {
+	if r.settings == nil {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "break-glass settings not found")
+	}
+	return r.settings, nil
+}
+
+func (r *stubRepo) CreateBreakGlassSession(_ context.Context, session *breakglass.Session) error {
+	session.Role.Name = admin.Name
+	r.session = session
+	return nil
+}
+
+func TestGrant(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	ctx = contextkey.WithUserID(ctx, oncall)
+	settings := &breakglass.Settings{Role: admin, MaxDuration: 2 * time.Hour}
+
+	for name, tc := range map[string]struct {
+		settings      *breakglass.Settings
+		justification string
+		duration      time.Duration
+		wantErr       error
+		wantDuration  time.Duration
+	}{
+		"for the duration asked":       {settings: settings, justification: "INC-1234", duration: 30 * time.Minute, wantDuration: 30 * time.Minute},
+		"for the most allowed":         {settings: settings, justification: "INC-1234", wantDuration: 2 * time.Hour},
+		"longer than allowed":          {settings: settings, justification: "INC-1234", duration: 3 * time.Hour, wantErr: permissions.ErrInvalidInput},
+		"a negative duration":          {settings: settings, justification: "INC-1234", duration: -time.Hour, wantErr: permissions.ErrInvalidInput},
+		"without a justification":      {settings: settings, justification: " ", wantErr: permissions.ErrInvalidInput},
+		"break-glass has not been set": {justification: "INC-1234", wantErr: permissions.ErrInvalidInput},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := &stubRepo{settings: tc.settings}
+			session, err := breakglass.NewService(repo).Grant(ctx, engineer, tc.justification, tc.duration)
+			if tc.wantErr != nil {
+				assert.True(t, errors.Is(err, tc.wantErr), err)
+				assert.Nil(t, repo.session)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, repo.session, session)
+			assert.Equal(t, engineer, session.UserID)
+			assert.Equal(t, admin, session.Role)
+			assert.Equal(t, "INC-1234", session.Justification)
+			assert.Equal(t, oncall, session.GrantedBy)
+			assert.Equal(t, tc.wantDuration, session.ExpiresAt.Sub(session.StartedAt))
+			assert.Equal(t, breakglass.StatusActive, session.Status(session.StartedAt))
+		})
+	}
+}
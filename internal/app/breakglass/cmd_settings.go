@@ -0,0 +1,44 @@
+package breakglass
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/google/uuid"
+)
+
+// GetSettings returns the Tenant's settings, ErrNotFound when break-glass has not been set up.
+func (s *Service) GetSettings(ctx context.Context) (*Settings, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get settings: %w", err)
+	}
+	settings, err := s.repo.GetBreakGlassSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SetSettings sets the Tenant's emergency role, and the longest a session may
+// last, DefaultMaxDuration when maxDuration is zero. Sessions already started
+// keep their role and expiry.
+func (s *Service) SetSettings(ctx context.Context, roleID string, maxDuration time.Duration) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("set settings: %w", err)
+	}
+	if err := uuid.Validate(roleID); err != nil {
+		return permissions.Errorf(permissions.ErrInvalidInput, "set settings: role ID %q is not a UUID", roleID)
+	}
+	if maxDuration == 0 {
+		maxDuration = DefaultMaxDuration
+	}
+	if maxDuration < time.Minute || maxDuration > MaxDuration {
+		return permissions.Errorf(permissions.ErrInvalidInput, "set settings: the maximum duration must be from 1m to %s", MaxDuration)
+	}
+	if err := s.repo.SetBreakGlassSettings(ctx, roleID, maxDuration.Truncate(time.Second)); err != nil {
+		return fmt.Errorf("set settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package breakglass
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+// Report lists every break-glass session of the Tenant, most recent first.
+type Report struct {
+	Sessions    []Session
+	GeneratedAt time.Time
+}
+
+// Summary counts the report's sessions by their status when it was generated.
+type Summary struct {
+	Total   int
+	Active  int
+	Expired int
+	Ended   int
+}
+
+func (r *Report) Summary() Summary {
+	s := Summary{Total: len(r.Sessions)}
+	for _, session := range r.Sessions {
+		switch session.Status(r.GeneratedAt) {
+		case StatusActive:
+			s.Active++
+		case StatusExpired:
+			s.Expired++
+		case StatusEnded:
+			s.Ended++
+		}
+	}
+	return s
+}
+
+// reportColumns are the columns of the report written by WriteCSV.
+var reportColumns = []string{
+	"session_id", "user_id", "role_id", "role_name", "justification", "granted_by",
+	"started_at", "expires_at", "ended_by", "ended_at", "status",
+}
+
+// WriteCSV writes the report as CSV, a header and then a row for each session.
+// Times are RFC 3339, in UTC, and empty when not set.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportColumns); err != nil {
+		return err
+	}
+	for _, s := range r.Sessions {
+		err := cw.Write([]string{
+			s.ID, s.UserID, s.Role.ID, s.Role.Name, s.Justification, s.GrantedBy,
+			formatTime(&s.StartedAt), formatTime(&s.ExpiresAt), s.EndedBy, formatTime(s.EndedAt), string(s.Status(r.GeneratedAt)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
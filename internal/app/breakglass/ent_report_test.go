@@ -0,0 +1,34 @@
+package breakglass_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/breakglass"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	started := time.Date(2026, 9, 30, 14, 5, 0, 0, time.FixedZone("BST", 3600))
+	ended := started.Add(20 * time.Minute)
+	report := &breakglass.Report{
+		Sessions: []breakglass.Session{
+			{ID: "s3", UserID: engineer, Role: admin, Justification: "INC-9, payments down", GrantedBy: oncall, StartedAt: started.Add(2 * time.Hour), ExpiresAt: started.Add(3 * time.Hour)},
+			{ID: "s2", UserID: oncall, Role: admin, Justification: "INC-8", StartedAt: started.Add(time.Hour), ExpiresAt: started.Add(90 * time.Minute)},
+			{ID: "s1", UserID: engineer, Role: admin, Justification: "INC-7", GrantedBy: engineer, StartedAt: started, ExpiresAt: started.Add(time.Hour), EndedBy: oncall, EndedAt: &ended},
+		},
+		GeneratedAt: started.Add(150 * time.Minute),
+	}
+
+	assert.Equal(t, breakglass.Summary{Total: 3, Active: 1, Expired: 1, Ended: 1}, report.Summary())
+
+	var b strings.Builder
+	require.NoError(t, report.WriteCSV(&b))
+	assert.Equal(t, `session_id,user_id,role_id,role_name,justification,granted_by,started_at,expires_at,ended_by,ended_at,status
+s3,`+engineer+`,`+admin.ID+`,admin,"INC-9, payments down",`+oncall+`,2026-09-30T15:05:00Z,2026-09-30T16:05:00Z,,,active
+s2,`+oncall+`,`+admin.ID+`,admin,INC-8,,2026-09-30T14:05:00Z,2026-09-30T14:35:00Z,,,expired
+s1,`+engineer+`,`+admin.ID+`,admin,INC-7,`+engineer+`,2026-09-30T13:05:00Z,2026-09-30T14:05:00Z,`+oncall+`,2026-09-30T13:25:00Z,ended
+`, b.String())
+}
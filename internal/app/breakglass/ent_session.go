@@ -0,0 +1,61 @@
+package breakglass
+
+import (
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+const (
+	// MaxDuration is the longest any Tenant may let a session last.
+	MaxDuration = 24 * time.Hour
+	// DefaultMaxDuration is how long a session may last when the Settings do not say.
+	DefaultMaxDuration = 4 * time.Hour
+)
+
+// Settings are the Tenant's break-glass configuration.
+type Settings struct {
+	// Role is the emergency role granted.
+	Role permissions.Role
+	// MaxDuration is the longest a session may last, and how long it lasts when no duration is asked for.
+	MaxDuration time.Duration
+	UpdatedAt   time.Time
+}
+
+type Status string
+
+const (
+	StatusActive  Status = "active"
+	StatusExpired Status = "expired"
+	// StatusEnded is a session ended before it expired, by a user or by its
+	// assignment being removed, such as when the user is deactivated.
+	StatusEnded Status = "ended"
+)
+
+// Session is a grant of the emergency role through break-glass.
+type Session struct {
+	ID     string
+	UserID string
+	// Role is the emergency role as it was when the session started.
+	Role          permissions.Role
+	Justification string
+	// GrantedBy is the user who broke the glass, empty when not known.
+	GrantedBy string
+	StartedAt time.Time
+	ExpiresAt time.Time
+	// EndedBy and EndedAt are set once the session is ended before it expired,
+	// EndedBy is empty when the user is not known.
+	EndedBy string
+	EndedAt *time.Time
+}
+
+// Status returns the status of the session at the time.
+func (s Session) Status(at time.Time) Status {
+	switch {
+	case s.EndedAt != nil:
+		return StatusEnded
+	case !at.Before(s.ExpiresAt):
+		return StatusExpired
+	}
+	return StatusActive
+}
@@ -0,0 +1,33 @@
+package breakglass
+
+import (
+	"context"
+	"time"
+)
+
+type Reader interface {
+	// GetBreakGlassSettings returns the Tenant's settings, ErrNotFound when they have not been set.
+	GetBreakGlassSettings(ctx context.Context) (*Settings, error)
+	// GetBreakGlassSession returns the session, ErrNotFound when there is no such session.
+	GetBreakGlassSession(ctx context.Context, sessionID string) (*Session, error)
+	// GetBreakGlassSessions returns the Tenant's sessions, most recent first.
+	GetBreakGlassSessions(ctx context.Context) ([]Session, error)
+}
+
+type Writer interface {
+	// SetBreakGlassSettings sets the Tenant's settings, it is ErrNotFound when there is no such role.
+	SetBreakGlassSettings(ctx context.Context, roleID string, maxDuration time.Duration) error
+	// CreateBreakGlassSession assigns the session's role to its user until it
+	// expires and records the session, filling in the role's name. It is
+	// ErrNotFound when there is no such role and ErrInvalidInput when the user
+	// is already assigned the role.
+	CreateBreakGlassSession(ctx context.Context, session *Session) error
+	// EndBreakGlassSession ends the active session, expiring its assignment, it
+	// is ErrInvalidInput when there is no such active session.
+	EndBreakGlassSession(ctx context.Context, sessionID, endedBy string, at time.Time) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
@@ -0,0 +1,20 @@
+// Package breakglass grants the Tenant's emergency role, as when an on-call
+// engineer needs admin access during an incident. Each grant is a session: a
+// role assignment which lapses after a short duration, capped by the Tenant's
+// Settings, made with a justification and announced by a high severity event.
+// The sessions are kept as the audit trail of break-glass, see Report.
+package breakglass
+
+import "time"
+
+type Service struct {
+	repo ReaderWriter
+	now  func() time.Time
+}
+
+func NewService(repo ReaderWriter) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
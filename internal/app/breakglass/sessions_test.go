@@ -0,0 +1,77 @@
+//go:build test
+// +build test
+
+package breakglass_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/breakglass"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := breakglass.NewService(repo)
+	const (
+		userAdmin       = "032fb302-4aee-4a68-b426-0c6faf12081e"
+		userSalesPerson = "2133479c-35a8-4a49-a682-2952d4772ecc"
+	)
+	holdsAdmin := func(t *testing.T) bool {
+		t.Helper()
+		roles, err := repo.GetUserRoles(contextkey.WithUserID(ctx, userSalesPerson))
+		require.NoError(t, err)
+		return slices.Contains(roles.StringSlice(), admin.Name)
+	}
+
+	_, err := svc.Grant(ctx, userSalesPerson, "INC-1234", 0)
+	assert.True(t, errors.Is(err, permissions.ErrInvalidInput), "break-glass has not been set up: %v", err)
+
+	require.NoError(t, svc.SetSettings(ctx, admin.ID, time.Hour))
+	settings, err := svc.GetSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, admin, settings.Role)
+	assert.Equal(t, time.Hour, settings.MaxDuration)
+
+	session, err := svc.Grant(contextkey.WithUserID(ctx, oncall), userSalesPerson, "INC-1234, invoices failing", 0)
+	require.NoError(t, err)
+	assert.Equal(t, admin, session.Role)
+	assert.True(t, holdsAdmin(t))
+
+	t.Run("The user cannot break the glass again while they hold the role", func(t *testing.T) {
+		_, err := svc.Grant(ctx, userSalesPerson, "INC-1234", 0)
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+		_, err = svc.Grant(ctx, userAdmin, "INC-1234", 0)
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+	})
+
+	t.Run("An ended session revokes the role", func(t *testing.T) {
+		require.NoError(t, svc.End(contextkey.WithUserID(ctx, oncall), session.ID))
+		assert.False(t, holdsAdmin(t))
+
+		err := svc.End(ctx, session.ID)
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+	})
+
+	t.Run("Unassigning the role ends the session", func(t *testing.T) {
+		_, err := svc.Grant(ctx, userSalesPerson, "INC-1235", 10*time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, permissions.NewService(repo).UnassignUserRoles(ctx, userSalesPerson, []string{admin.ID}))
+		assert.False(t, holdsAdmin(t))
+	})
+
+	report, err := svc.Report(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Sessions, 2)
+	assert.Equal(t, breakglass.Summary{Total: 2, Ended: 2}, report.Summary())
+	assert.Equal(t, "INC-1235", report.Sessions[0].Justification, "most recent first")
+	assert.Equal(t, oncall, report.Sessions[1].GrantedBy)
+	assert.Equal(t, oncall, report.Sessions[1].EndedBy)
+}
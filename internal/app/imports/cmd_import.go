@@ -0,0 +1,236 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/google/uuid"
+)
+
+// Options are how Import writes the valid rows.
+type Options struct {
+	// DryRun checks every row without importing any.
+	DryRun bool
+	// ChunkSize is how many rows are imported in each transaction, all of them
+	// in one when zero. A chunk which fails is reported and the next one tried.
+	ChunkSize int
+	// Progress, when set, is called after each chunk.
+	Progress func(Progress)
+}
+
+type Progress struct {
+	// Imported and Failed are the valid rows imported or already held, or in chunks which failed, so far.
+	Imported int
+	Failed   int
+	Total    int
+}
+
+// ChunkError is a chunk of valid rows which failed to import, such as for
+// violating a separation of duties rule.
+type ChunkError struct {
+	FirstLine int
+	LastLine  int
+	Err       error
+}
+
+func (e ChunkError) Error() string {
+	return fmt.Sprintf("lines %d to %d: %v", e.FirstLine, e.LastLine, e.Err)
+}
+
+// Report is the outcome of an import.
+type Report struct {
+	// Rows is how many rows were read, of which Valid passed every check.
+	Rows  int
+	Valid int
+	// Errors are the rows which failed their checks, in line order.
+	Errors   []RowError
+	Imported int
+	// Held are the lines of the valid rows the users already held, which were
+	// left as they are rather than imported, so that an expiry is neither
+	// added nor removed.
+	Held   []int
+	Failed []ChunkError
+	DryRun bool
+}
+
+// Import reads role assignments from the CSV, see ReadCSV, and imports those
+// which pass every check against the Tenant in the context: the user ID is a
+// UUID, the role exists, the resource, if any, is of one of the Tenant's types
+// and the role grants some permission on that type, the expiry has not passed,
+// and no earlier row is for the same assignment. Rows with a resource grant the
+// role's permissions on the resource alone, rather than assigning the role.
+func (s *Service) Import(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+	rows, readErrs, err := ReadCSV(r)
+	if err != nil {
+		return nil, permissions.WrapError(permissions.ErrInvalidInput, err, "import: invalid csv")
+	}
+	model, err := s.repo.GetModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("import: get model: %w", err)
+	}
+
+	assignments, checkErrs := check(model, rows, s.now())
+	report := &Report{
+		Rows:   len(rows) + len(readErrs),
+		Valid:  len(assignments),
+		Errors: append(readErrs, checkErrs...),
+		DryRun: opts.DryRun,
+	}
+	slices.SortFunc(report.Errors, func(a, b RowError) int { return a.Line - b.Line })
+	if opts.DryRun || len(assignments) == 0 {
+		return report, nil
+	}
+
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = len(assignments)
+	}
+	failed := 0
+	for chunk := range slices.Chunk(assignments, size) {
+		if held, err := s.repo.ImportAssignments(ctx, chunk); err != nil {
+			if size == len(assignments) || !isRowError(err) {
+				return nil, fmt.Errorf("import: %w", err)
+			}
+			report.Failed = append(report.Failed, ChunkError{FirstLine: chunk[0].Line, LastLine: chunk[len(chunk)-1].Line, Err: err})
+			failed += len(chunk)
+		} else {
+			report.Imported += len(chunk) - len(held)
+			report.Held = append(report.Held, held...)
+		}
+		if opts.Progress != nil {
+			opts.Progress(Progress{Imported: report.Imported + len(report.Held), Failed: failed, Total: len(assignments)})
+		}
+	}
+	return report, nil
+}
+
+// isRowError reports whether the error is of the rows imported, rather than
+// of the database, so that importing the next chunk may yet succeed.
+func isRowError(err error) bool {
+	return errors.Is(err, permissions.ErrForbidden) || errors.Is(err, permissions.ErrInvalidInput) || errors.Is(err, permissions.ErrNotFound)
+}
+
+// check returns the assignments of the rows which pass every check, and the errors of those which do not.
+func check(model *rbac.Model, rows []Row, now time.Time) ([]Assignment, []RowError) {
+	roles := make(map[string]rbac.Role, len(model.Roles))
+	for _, r := range model.Roles {
+		roles[r.Name] = r
+	}
+
+	var assignments []Assignment
+	var errs []RowError
+	seen := make(map[string]int)
+	for _, row := range rows {
+		fail := func(format string, args ...any) {
+			errs = append(errs, RowError{Line: row.Line, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if err := permissions.ValidateUserID(row.UserID); err != nil {
+			fail("user ID %q is not a UUID", row.UserID)
+			continue
+		}
+		if _, ok := roles[row.Role]; !ok {
+			fail("role %q does not exist", row.Role)
+			continue
+		}
+		a := Assignment{Line: row.Line, UserID: row.UserID, Role: row.Role, ExpiresAt: row.ExpiresAt}
+		if row.ExpiresAt != nil && !row.ExpiresAt.After(now) {
+			fail("expiry %s has passed", row.ExpiresAt.Format(time.RFC3339))
+			continue
+		}
+
+		key := row.UserID + "\x00" + row.Role
+		if row.Resource != nil {
+			i := slices.IndexFunc(model.ResourceTypes, func(rt rbac.ResourceType) bool { return strings.EqualFold(rt.Name, row.Resource.Type) })
+			switch {
+			case row.Resource.Type == "" || row.Resource.ID == "":
+				fail("a resource needs both a type and an ID")
+				continue
+			case i < 0:
+				fail("resource type %q does not exist", row.Resource.Type)
+				continue
+			case uuid.Validate(row.Resource.ID) != nil:
+				fail("resource ID %q is not a UUID", row.Resource.ID)
+				continue
+			}
+			a.Resource = &permissions.Resource{Type: model.ResourceTypes[i].Name, ID: row.Resource.ID}
+			a.Permissions, a.Conditions = permissionsOn(roles, row.Role, a.Resource.Type)
+			if len(a.Permissions) == 0 {
+				fail("role %q grants no permission on %s", row.Role, a.Resource.Type)
+				continue
+			}
+			key += "\x00" + a.Resource.Type + "\x00" + a.Resource.ID
+		}
+		if line, ok := seen[key]; ok {
+			fail("repeats the assignment on line %d", line)
+			continue
+		}
+		seen[key] = row.Line
+		assignments = append(assignments, a)
+	}
+	return assignments, errs
+}
+
+// permissionsOn returns the names of the permissions the role grants, itself
+// or by inheritance, which apply to the resource type, and the conditions of
+// those it only grants conditionally. A permission granted on more than one
+// condition is granted when any of them holds.
+func permissionsOn(roles map[string]rbac.Role, role, resourceType string) ([]string, map[string]string) {
+	var names []string
+	conditional := make(map[string][]string)
+	unconditional := make(map[string]bool)
+	seen := map[string]bool{role: true}
+	queue := []string{role}
+	for len(queue) > 0 {
+		r := roles[queue[0]]
+		queue = queue[1:]
+		for _, name := range r.Permissions {
+			p := permissions.Permission{Name: name}
+			if p.Resource() != permissions.Wildcard && !strings.EqualFold(p.Resource(), resourceType) {
+				continue
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+			if c := r.Conditions[name]; c != "" {
+				conditional[name] = append(conditional[name], c)
+			} else {
+				unconditional[name] = true
+			}
+		}
+		for _, child := range r.Inherits {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	var conditions map[string]string
+	for _, name := range names {
+		conds := conditional[name]
+		if unconditional[name] || len(conds) == 0 {
+			continue
+		}
+		if conditions == nil {
+			conditions = make(map[string]string)
+		}
+		if len(conds) == 1 {
+			conditions[name] = conds[0]
+			continue
+		}
+		conditions[name] = "(" + strings.Join(conds, ") || (") + ")"
+	}
+	return names, conditions
+}
@@ -0,0 +1,192 @@
+package imports_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/imports"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	alice = "0c5d7e9f-1a3b-4c5d-8e7f-9a1b3c5d7e90"
+	bob   = "1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01"
+	carol = "2133479c-35a8-4a49-a682-2952d4772ecc"
+
+	invoice = "6b63b489-61cb-4087-8636-f10716bd724e"
+)
+
+// stubRepo returns its model, and keeps the chunks imported, failing those with
+// a user in failFor. The assignments of heldBy are already held.
+type stubRepo struct {
+	failFor string
+	heldBy  string
+	chunks  [][]imports.Assignment
+}
+
+func (r *stubRepo) GetModel(context.Context) (*rbac.Model, error) {
+	return &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}, {ID: 2, Name: "products"}},
+		Permissions: []rbac.Permission{
+			{Name: "invoices:approve", Enabled: true},
+			{Name: "invoices:create", Enabled: true},
+			{Name: "invoices:read", Enabled: true},
+			{Name: "products:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "auditor", Permissions: []string{"invoices:read", "products:read"}},
+			{Name: "clerk", Permissions: []string{"invoices:create"}, Inherits: []string{"auditor"}},
+			{
+				Name:        "approver",
+				Permissions: []string{"invoices:approve", "invoices:read"},
+				Conditions:  map[string]string{"invoices:approve": "invoice.amount < 10000", "invoices:read": "request.hour >= 9"},
+				Inherits:    []string{"clerk"},
+			},
+			{
+				Name:        "night approver",
+				Permissions: []string{"invoices:approve"},
+				Conditions:  map[string]string{"invoices:approve": "request.hour >= 17"},
+				Inherits:    []string{"approver"},
+			},
+		},
+	}, nil
+}
+
+func (r *stubRepo) ImportAssignments(_ context.Context, assignments []imports.Assignment) ([]int, error) {
+	var held []int
+	for _, a := range assignments {
+		if a.UserID == r.failFor {
+			return nil, permissions.Errorf(permissions.ErrForbidden, "violates a separation of duties rule")
+		}
+		if a.UserID == r.heldBy {
+			held = append(held, a.Line)
+		}
+	}
+	r.chunks = append(r.chunks, assignments)
+	return held, nil
+}
+
+func newService(repo *stubRepo) *imports.Service {
+	return imports.NewService(repo)
+}
+
+func TestImport_DryRunReportsEveryError(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{}
+	input := strings.Join([]string{
+		"user_id,role,resource_type,resource_id,expires_at",
+		alice + ",clerk,,,",
+		"not-a-uuid,clerk,,,",
+		bob + ",manager,,,",
+		bob + ",clerk,horses," + invoice + ",",
+		bob + ",clerk,invoices,,",
+		bob + ",clerk,invoices," + invoice + ",2020-01-01",
+		bob + ",clerk,invoices," + invoice + ",tomorrow",
+		carol + ",clerk,Invoices," + invoice + ",2999-01-01",
+		carol + ",clerk,invoices," + invoice + ",",
+		alice + ",clerk",
+	}, "\n")
+
+	report, err := newService(repo).Import(ctx, strings.NewReader(input), imports.Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, repo.chunks, "a dry run imports nothing")
+	assert.Equal(t, 10, report.Rows)
+	assert.Equal(t, 2, report.Valid)
+
+	lines := make([]int, len(report.Errors))
+	for i, e := range report.Errors {
+		lines[i] = e.Line
+	}
+	assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 10, 11}, lines)
+	assert.Equal(t, "line 8: expiry \"tomorrow\" is neither RFC 3339 nor a date", report.Errors[5].Error())
+	assert.Contains(t, report.Errors[6].Message, "line 9")
+}
+
+func TestImport_ExpandsResourceRows(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{}
+	input := "role,user_id,resource_type,resource_id,expires_at\n" +
+		"clerk," + alice + ",invoices," + invoice + ",2999-01-01T00:00:00Z\n"
+
+	report, err := newService(repo).Import(ctx, strings.NewReader(input), imports.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+
+	require.Len(t, repo.chunks, 1)
+	a := repo.chunks[0][0]
+	assert.Equal(t, &permissions.Resource{Type: "invoices", ID: invoice}, a.Resource)
+	assert.Equal(t, []string{"invoices:create", "invoices:read"}, a.Permissions, "inherited permissions on other types are left out")
+	assert.Equal(t, time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC), *a.ExpiresAt)
+}
+
+func TestImport_KeepsConditionsOnResourceRows(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{}
+	input := "user_id,role,resource_type,resource_id\n" +
+		alice + ",approver,invoices," + invoice + "\n" +
+		bob + ",night approver,invoices," + invoice + "\n"
+
+	_, err := newService(repo).Import(ctx, strings.NewReader(input), imports.Options{})
+	require.NoError(t, err)
+
+	require.Len(t, repo.chunks, 1)
+	approver, nightApprover := repo.chunks[0][0], repo.chunks[0][1]
+	assert.Equal(t, []string{"invoices:approve", "invoices:create", "invoices:read"}, approver.Permissions)
+	assert.Equal(t, map[string]string{"invoices:approve": "invoice.amount < 10000"}, approver.Conditions,
+		"a permission the role also grants unconditionally, by inheritance, is granted unconditionally")
+	assert.Equal(t, map[string]string{"invoices:approve": "(request.hour >= 17) || (invoice.amount < 10000)"}, nightApprover.Conditions)
+}
+
+func TestImport_Chunks(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{failFor: bob}
+	input := "user_id,role\n" + alice + ",clerk\n" + alice + ",auditor\n" + bob + ",clerk\n" + carol + ",clerk\n"
+
+	var progress []imports.Progress
+	report, err := newService(repo).Import(ctx, strings.NewReader(input), imports.Options{
+		ChunkSize: 2,
+		Progress:  func(p imports.Progress) { progress = append(progress, p) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, 4, report.Failed[0].FirstLine)
+	assert.Equal(t, 5, report.Failed[0].LastLine)
+	assert.Equal(t, []imports.Progress{{Imported: 2, Total: 4}, {Imported: 2, Failed: 2, Total: 4}}, progress)
+}
+
+func TestImport_ReportsHeldAssignments(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{heldBy: carol}
+	input := "user_id,role,expires_at\n" + alice + ",clerk,2999-01-01\n" + carol + ",clerk,2999-01-01\n"
+
+	report, err := newService(repo).Import(ctx, strings.NewReader(input), imports.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, []int{3}, report.Held, "an assignment already held keeps its expiry, and is reported")
+}
+
+func TestImport_AtomicFailsWhole(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{failFor: bob}
+	input := "user_id,role\n" + alice + ",clerk\n" + bob + ",clerk\n"
+
+	_, err := newService(repo).Import(ctx, strings.NewReader(input), imports.Options{})
+	assert.True(t, errors.Is(err, permissions.ErrForbidden))
+	assert.Empty(t, repo.chunks)
+}
+
+func TestImport_RejectsHeader(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	for _, header := range []string{"user_id,role,group", "user_id,resource_type"} {
+		_, err := newService(&stubRepo{}).Import(ctx, strings.NewReader(header+"\n"), imports.Options{DryRun: true})
+		assert.ErrorIs(t, err, permissions.ErrInvalidInput, header)
+	}
+}
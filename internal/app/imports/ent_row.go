@@ -0,0 +1,128 @@
+package imports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Columns are the columns ReadCSV understands, the first two are required.
+var Columns = []string{"user_id", "role", "resource_type", "resource_id", "expires_at"}
+
+// Row is a role assignment as read from the CSV.
+type Row struct {
+	// Line is the row's line in the CSV, the header is line 1.
+	Line   int
+	UserID string
+	Role   string
+	// Resource is the resource the role's permissions are granted on, nil when the role itself is assigned.
+	Resource  *permissions.Resource
+	ExpiresAt *time.Time
+}
+
+// RowError is why the row at the line cannot be imported.
+type RowError struct {
+	Line    int
+	Message string
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Assignment is a checked row, ready to be imported.
+type Assignment struct {
+	Line   int
+	UserID string
+	Role   string
+	// Resource and Permissions are set when the role's permissions, those
+	// which apply to the resource's type, are granted on the resource alone.
+	Resource    *permissions.Resource
+	Permissions []string
+	// Conditions are those of the Permissions the role only grants
+	// conditionally, keyed by name, the grants on the resource keep them.
+	Conditions map[string]string
+	ExpiresAt  *time.Time
+}
+
+// ReadCSV reads the rows of the CSV, which starts with a header naming its
+// Columns in any order. Expiries are RFC 3339, or a date which expires at its
+// start in UTC. Rows which cannot be read are RowErrors, and reading carries
+// on; the error is only for a header which cannot be used.
+func ReadCSV(r io.Reader) ([]Row, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(Columns, name) {
+			return nil, nil, fmt.Errorf("unknown column %q, expected %s", name, strings.Join(Columns, ", "))
+		}
+		index[name] = i
+	}
+	for _, name := range Columns[:2] {
+		if _, ok := index[name]; !ok {
+			return nil, nil, fmt.Errorf("column %q is required", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := index[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []Row
+	var errs []RowError
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				errs = append(errs, RowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, fmt.Errorf("read csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		row := Row{Line: line, UserID: field(record, "user_id"), Role: field(record, "role")}
+		resourceType, resourceID := field(record, "resource_type"), field(record, "resource_id")
+		if resourceType != "" || resourceID != "" {
+			row.Resource = &permissions.Resource{Type: resourceType, ID: resourceID}
+		}
+		if expiresAt := field(record, "expires_at"); expiresAt != "" {
+			t, err := parseExpiry(expiresAt)
+			if err != nil {
+				errs = append(errs, RowError{Line: line, Message: err.Error()})
+				continue
+			}
+			row.ExpiresAt = &t
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs, nil
+}
+
+func parseExpiry(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expiry %q is neither RFC 3339 nor a date", s)
+}
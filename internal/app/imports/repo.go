@@ -0,0 +1,25 @@
+package imports
+
+import (
+	"context"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+)
+
+type Reader interface {
+	// GetModel returns the Tenant's model, its roles and resource types are what rows are checked against.
+	GetModel(ctx context.Context) (*rbac.Model, error)
+}
+
+type Writer interface {
+	// ImportAssignments makes the assignments in one transaction, and returns
+	// the lines of those the users already held, and which have not expired,
+	// which are left as they are, expiry included. It is ErrForbidden when an
+	// assignment violates a separation of duties rule.
+	ImportAssignments(ctx context.Context, assignments []Assignment) ([]int, error)
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
@@ -0,0 +1,21 @@
+// Package imports bulk imports role assignments, such as those a customer
+// migrating to us hands over as a spreadsheet. Every row is checked against
+// the Tenant's roles and resource types before anything is written, so a dry
+// run reports every error with its line, and the valid rows are then imported
+// in one transaction or in chunks, reporting progress after each.
+package imports
+
+import "time"
+
+type Service struct {
+	repo ReaderWriter
+	now  func() time.Time
+}
+
+// NewService creates a bulk import service.
+func NewService(repo ReaderWriter) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
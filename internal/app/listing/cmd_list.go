@@ -0,0 +1,134 @@
+package listing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/google/uuid"
+)
+
+// ListRoleHolders returns a page of the users holding the role, directly,
+// through a group, or through a role which inherits it.
+func (s *Service) ListRoleHolders(ctx context.Context, filter RoleHoldersFilter, page PageRequest) (Page[RoleHolder], error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return Page[RoleHolder]{}, err
+	}
+	if err := validateUUID("role ID", filter.RoleID); err != nil {
+		return Page[RoleHolder]{}, err
+	}
+	switch filter.Source {
+	case "", permissions.RoleSourceDirect, permissions.RoleSourceGroup, permissions.RoleSourceInherited:
+	default:
+		return Page[RoleHolder]{}, permissions.Errorf(permissions.ErrInvalidInput, "role source %q is not valid", filter.Source)
+	}
+	after, err := decodeCursor(page.Cursor, 1)
+	if err != nil {
+		return Page[RoleHolder]{}, err
+	}
+
+	limit := page.limit()
+	holders, err := s.repo.ListRoleHolders(ctx, filter, after, limit+1)
+	if err != nil {
+		return Page[RoleHolder]{}, fmt.Errorf("list role holders: %w", err)
+	}
+	return newPage(holders, limit, RoleHolder.key), nil
+}
+
+// ListRoleGrants returns a page of the roles granting the permission, directly
+// or by inheriting it, including through wildcards which match it.
+func (s *Service) ListRoleGrants(ctx context.Context, filter RoleGrantsFilter, page PageRequest) (Page[RoleGrant], error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return Page[RoleGrant]{}, err
+	}
+	if err := permissions.ValidatePermissionName(filter.Permission); err != nil {
+		return Page[RoleGrant]{}, err
+	}
+	after, err := decodeCursor(page.Cursor, 2)
+	if err != nil {
+		return Page[RoleGrant]{}, err
+	}
+
+	limit := page.limit()
+	grants, err := s.repo.ListRoleGrants(ctx, filter, after, limit+1)
+	if err != nil {
+		return Page[RoleGrant]{}, fmt.Errorf("list role grants: %w", err)
+	}
+	return newPage(grants, limit, RoleGrant.key), nil
+}
+
+// ListResourceTypePermissions returns a page of the Tenant's permissions on the
+// resource type, those whose resource part is the type, compared case-insensitively.
+func (s *Service) ListResourceTypePermissions(ctx context.Context, resourceType string, page PageRequest) (Page[permissions.TenantPermission], error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return Page[permissions.TenantPermission]{}, err
+	}
+	if err := s.validateResourceType(ctx, resourceType); err != nil {
+		return Page[permissions.TenantPermission]{}, err
+	}
+	after, err := decodeCursor(page.Cursor, 2)
+	if err != nil {
+		return Page[permissions.TenantPermission]{}, err
+	}
+
+	limit := page.limit()
+	perms, err := s.repo.ListResourceTypePermissions(ctx, resourceType, after, limit+1)
+	if err != nil {
+		return Page[permissions.TenantPermission]{}, fmt.Errorf("list resource type permissions: %w", err)
+	}
+	return newPage(perms, limit, permissionKey), nil
+}
+
+// ListResourceGrantees returns a page of the users with grants on the
+// resource, including grants on its ancestors unless filter.DirectOnly.
+func (s *Service) ListResourceGrantees(ctx context.Context, filter ResourceGranteesFilter, page PageRequest) (Page[ResourceGrantee], error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return Page[ResourceGrantee]{}, err
+	}
+	if err := validateUUID("resource ID", filter.ResourceID); err != nil {
+		return Page[ResourceGrantee]{}, err
+	}
+	if filter.ResourceType != "" {
+		if err := s.validateResourceType(ctx, filter.ResourceType); err != nil {
+			return Page[ResourceGrantee]{}, err
+		}
+	}
+	after, err := decodeCursor(page.Cursor, 5)
+	if err != nil {
+		return Page[ResourceGrantee]{}, err
+	}
+
+	limit := page.limit()
+	grantees, err := s.repo.ListResourceGrantees(ctx, filter, after, limit+1)
+	if err != nil {
+		return Page[ResourceGrantee]{}, fmt.Errorf("list resource grantees: %w", err)
+	}
+	return newPage(grantees, limit, ResourceGrantee.key), nil
+}
+
+// validateResourceType checks the resource type is valid and one of the
+// Tenant's, an unknown resource type is ErrNotFound.
+func (s *Service) validateResourceType(ctx context.Context, resourceType string) error {
+	if err := permissions.ValidateResourceType(resourceType); err != nil {
+		return err
+	}
+	unknown, err := s.repo.GetUnknownResourceTypes(ctx, []string{resourceType})
+	if err != nil {
+		return fmt.Errorf("get unknown resource types: %w", err)
+	}
+	if len(unknown) > 0 {
+		return permissions.Errorf(permissions.ErrNotFound, "unknown resource types: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func validateUUID(name, id string) error {
+	if id == "" {
+		return permissions.Errorf(permissions.ErrInvalidInput, "%s is required", name)
+	}
+	if err := uuid.Validate(id); err != nil {
+		return permissions.Errorf(permissions.ErrInvalidInput, "%s %q is not a UUID", name, id)
+	}
+	return nil
+}
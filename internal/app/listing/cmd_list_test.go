@@ -0,0 +1,117 @@
+//go:build test
+// +build test
+
+package listing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/listing"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (context.Context, *listing.Service) {
+	t.Helper()
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	return ctx, listing.NewService(repo)
+}
+
+func TestListRoleHolders(t *testing.T) {
+	ctx, svc := newTestService(t)
+	const salesAuditor = "da244750-f014-415c-b7b9-43ead3d8fa25"
+
+	t.Run("Directly, through groups and by inheritance", func(t *testing.T) {
+		page, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: salesAuditor}, listing.PageRequest{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []listing.RoleHolder{
+			{UserID: "2133479c-35a8-4a49-a682-2952d4772ecc", Source: permissions.RoleSourceInherited, Via: "sales person"},
+			{UserID: "652f4d18-dd3d-40c0-874e-cbe3566abccf", Source: permissions.RoleSourceInherited, Via: "sales manager"},
+			{UserID: "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42", Source: permissions.RoleSourceGroup, Via: "sales team"},
+		}, page.Items)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("A page at a time", func(t *testing.T) {
+		first, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: salesAuditor}, listing.PageRequest{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, first.Items, 2)
+		require.NotEmpty(t, first.NextCursor)
+
+		second, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: salesAuditor}, listing.PageRequest{Limit: 2, Cursor: first.NextCursor})
+		require.NoError(t, err)
+		require.Len(t, second.Items, 1)
+		assert.Equal(t, "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42", second.Items[0].UserID)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("By source", func(t *testing.T) {
+		page, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: salesAuditor, Source: permissions.RoleSourceGroup}, listing.PageRequest{})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42", page.Items[0].UserID)
+	})
+}
+
+func TestListRoleGrants(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	page, err := svc.ListRoleGrants(ctx, listing.RoleGrantsFilter{Permission: "Invoices:Read"}, listing.PageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []listing.RoleGrant{
+		{Role: permissions.Role{ID: "550e8400-e29b-41d4-a716-446655440000", Name: "admin"}, Permission: "invoices:read"},
+		{Role: permissions.Role{ID: "9a3c1e5f-6b2d-4e8a-a1f7-3c5b7d9e0f24", Name: "read only"}, Permission: "*:read"},
+		{Role: permissions.Role{ID: "da244750-f014-415c-b7b9-43ead3d8fa25", Name: "sales auditor"}, Permission: "invoices:read"},
+		{Role: permissions.Role{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Name: "sales manager"}, Permission: "invoices:read", Via: "sales auditor"},
+		{Role: permissions.Role{ID: "123e4567-e89b-12d3-a456-426614174000", Name: "sales person"}, Permission: "invoices:read", Via: "sales auditor"},
+	}, page.Items)
+
+	direct, err := svc.ListRoleGrants(ctx, listing.RoleGrantsFilter{Permission: "invoices:read", DirectOnly: true}, listing.PageRequest{})
+	require.NoError(t, err)
+	assert.Len(t, direct.Items, 3)
+}
+
+func TestListResourceTypePermissions(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	var names []string
+	page := listing.PageRequest{Limit: 2}
+	for {
+		p, err := svc.ListResourceTypePermissions(ctx, "Products", page)
+		require.NoError(t, err)
+		for _, tp := range p.Items {
+			names = append(names, tp.Name)
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		page.Cursor = p.NextCursor
+	}
+	assert.Equal(t, []string{"products:create", "products:delete", "products:disable", "products:read", "products:update"}, names)
+
+	_, err := svc.ListResourceTypePermissions(ctx, "stables", listing.PageRequest{})
+	assert.True(t, errors.Is(err, permissions.ErrNotFound), err)
+}
+
+func TestListResourceGrantees(t *testing.T) {
+	ctx, svc := newTestService(t)
+	contact := permissions.Resource{Type: "contacts", ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71"}
+	customer := permissions.Resource{Type: "customers", ID: "d2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92"}
+
+	page, err := svc.ListResourceGrantees(ctx, listing.ResourceGranteesFilter{ResourceID: contact.ID}, listing.PageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []listing.ResourceGrantee{
+		{UserID: "8e0a2c4e-6f1b-4d3a-b5c7-9d1f3b5d7e64", Resource: contact, Permission: "customers:read", InheritedFrom: &customer},
+		{UserID: "c3e5a7c9-1b3d-4f5a-9c7e-1b3d5f7a9c46", Resource: contact, Permission: "contacts:read", Condition: `user.region == "north"`},
+	}, page.Items)
+
+	direct, err := svc.ListResourceGrantees(ctx, listing.ResourceGranteesFilter{ResourceID: contact.ID, ResourceType: "contacts", DirectOnly: true}, listing.PageRequest{})
+	require.NoError(t, err)
+	require.Len(t, direct.Items, 1)
+	assert.Nil(t, direct.Items[0].InheritedFrom)
+}
@@ -0,0 +1,88 @@
+package listing
+
+import (
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// RoleHoldersFilter selects the users holding a role.
+type RoleHoldersFilter struct {
+	RoleID string
+	// Source, when set, selects only users holding the role from that source.
+	Source permissions.RoleSource
+}
+
+// RoleHolder is a user holding a role, in order of UserID. A user holding the
+// role in several ways is listed once, with the most direct Source.
+type RoleHolder struct {
+	UserID string
+	// Source is RoleSourceDirect when the user is assigned the role,
+	// RoleSourceGroup when a group they are a member of is, and
+	// RoleSourceInherited when they hold a role which inherits it.
+	Source permissions.RoleSource
+	// Via is the group for RoleSourceGroup, or the inheriting role for RoleSourceInherited.
+	Via string
+}
+
+func (h RoleHolder) key() Key {
+	return Key{h.UserID}
+}
+
+// RoleGrantsFilter selects the roles granting a permission.
+type RoleGrantsFilter struct {
+	// Permission is a permission name, roles granting it through a wildcard,
+	// such as "invoices:*" for "invoices:read", are included.
+	Permission string
+	// DirectOnly leaves out roles which only inherit the permission.
+	DirectOnly bool
+}
+
+// RoleGrant is a role granting a permission, in order of role name and ID. A
+// role granting the permission in several ways is listed once, preferring a direct grant.
+type RoleGrant struct {
+	Role permissions.Role
+	// Permission is the permission granted, the filter's or a wildcard matching it.
+	Permission string
+	// Condition is set when the grant applies only if it holds.
+	Condition string
+	// Via is the role the permission is inherited from, empty when the role is granted it directly.
+	Via string
+}
+
+func (g RoleGrant) key() Key {
+	return Key{g.Role.Name, g.Role.ID}
+}
+
+// permissionKey is the key of a permission in the order of name and ID.
+func permissionKey(p permissions.TenantPermission) Key {
+	return Key{p.Name, p.ID}
+}
+
+// ResourceGranteesFilter selects the users with grants on a resource.
+type ResourceGranteesFilter struct {
+	ResourceID string
+	// ResourceType, when set, selects only the resource of the type.
+	ResourceType string
+	// DirectOnly leaves out grants inherited from the resource's ancestors.
+	DirectOnly bool
+}
+
+// ResourceGrantee is a user with a grant on a resource, in order of user,
+// permission, resource type and InheritedFrom, with direct grants first.
+type ResourceGrantee struct {
+	UserID   string
+	Resource permissions.Resource
+	// Permission is the permission granted, on InheritedFrom when the grant is inherited.
+	Permission string
+	// Condition is set when the grant applies only if it holds.
+	Condition string
+	// InheritedFrom is the ancestor of the resource the grant was made on.
+	InheritedFrom *permissions.Resource
+}
+
+func (g ResourceGrantee) key() Key {
+	var inheritedFrom permissions.Resource
+	if g.InheritedFrom != nil {
+		inheritedFrom = *g.InheritedFrom
+	}
+	return Key{g.UserID, g.Permission, g.Resource.Type, inheritedFrom.Type, inheritedFrom.ID}
+}
@@ -0,0 +1,77 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+const (
+	// DefaultLimit is the number of items in a page when the PageRequest does not say.
+	DefaultLimit = 50
+	// MaxLimit is the most items in a page, larger limits are reduced to it.
+	MaxLimit = 500
+)
+
+// PageRequest asks for a page of a listing.
+type PageRequest struct {
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+	// Limit is the most items in the page, DefaultLimit when zero.
+	Limit int
+}
+
+func (p PageRequest) limit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultLimit
+	case p.Limit > MaxLimit:
+		return MaxLimit
+	default:
+		return p.Limit
+	}
+}
+
+// Page is a page of a listing.
+type Page[T any] struct {
+	Items []T
+	// NextCursor continues the listing after the page, it is empty on the last page.
+	NextCursor string
+}
+
+// Key is an item's position in the order of its listing, the values of the columns it is sorted by.
+type Key []string
+
+// encodeCursor returns the opaque cursor for the key.
+func encodeCursor(key Key) string {
+	b, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns the key in the cursor, which must have the listing's
+// number of values. An empty cursor is a nil key.
+func decodeCursor(cursor string, values int) (Key, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "cursor is not valid")
+	}
+	var key Key
+	if err := json.Unmarshal(b, &key); err != nil || len(key) != values {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "cursor is not valid")
+	}
+	return key, nil
+}
+
+// newPage returns the page of the items, which the repo was asked for one more
+// of than the limit, so that a full page is known to have more after it.
+func newPage[T any](items []T, limit int, key func(T) Key) Page[T] {
+	if len(items) <= limit {
+		return Page[T]{Items: items}
+	}
+	items = items[:limit]
+	return Page[T]{Items: items, NextCursor: encodeCursor(key(items[limit-1]))}
+}
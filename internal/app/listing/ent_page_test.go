@@ -0,0 +1,112 @@
+package listing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/listing"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo lists its holders, which are in order of user, as the postgres repo
+// would, the other methods are left nil.
+type stubRepo struct {
+	listing.Reader
+	holders []listing.RoleHolder
+	limits  []int
+}
+
+func (r *stubRepo) ListRoleHolders(_ context.Context, _ listing.RoleHoldersFilter, after listing.Key, limit int) ([]listing.RoleHolder, error) {
+	r.limits = append(r.limits, limit)
+	var holders []listing.RoleHolder
+	for _, h := range r.holders {
+		if (after == nil || h.UserID > after[0]) && len(holders) < limit {
+			holders = append(holders, h)
+		}
+	}
+	return holders, nil
+}
+
+const roleID = "da244750-f014-415c-b7b9-43ead3d8fa25"
+
+func TestListRoleHolders_Pages(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{holders: []listing.RoleHolder{
+		{UserID: "1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01", Source: permissions.RoleSourceDirect},
+		{UserID: "2133479c-35a8-4a49-a682-2952d4772ecc", Source: permissions.RoleSourceInherited, Via: "sales person"},
+		{UserID: "652f4d18-dd3d-40c0-874e-cbe3566abccf", Source: permissions.RoleSourceInherited, Via: "sales manager"},
+		{UserID: "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42", Source: permissions.RoleSourceGroup, Via: "sales team"},
+		{UserID: "f0b2d4e6-8a1c-4e3b-9d5f-7a9c1e3b5d80", Source: permissions.RoleSourceDirect},
+	}}
+	svc := listing.NewService(repo)
+
+	var listed []listing.RoleHolder
+	var pages int
+	page := listing.PageRequest{Limit: 2}
+	for {
+		p, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: roleID}, page)
+		require.NoError(t, err)
+		listed = append(listed, p.Items...)
+		pages++
+		if p.NextCursor == "" {
+			break
+		}
+		page.Cursor = p.NextCursor
+	}
+
+	assert.Equal(t, repo.holders, listed)
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []int{3, 3, 3}, repo.limits, "one more than the limit is asked for, to know whether there are more")
+}
+
+func TestListRoleHolders_LastPageIsFull(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{holders: []listing.RoleHolder{
+		{UserID: "1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01", Source: permissions.RoleSourceDirect},
+		{UserID: "2133479c-35a8-4a49-a682-2952d4772ecc", Source: permissions.RoleSourceDirect},
+	}}
+
+	p, err := listing.NewService(repo).ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: roleID}, listing.PageRequest{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, p.Items, 2)
+	assert.Empty(t, p.NextCursor)
+}
+
+func TestListRoleHolders_Limits(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{}
+	svc := listing.NewService(repo)
+
+	for _, limit := range []int{0, -1, listing.MaxLimit + 1} {
+		_, err := svc.ListRoleHolders(ctx, listing.RoleHoldersFilter{RoleID: roleID}, listing.PageRequest{Limit: limit})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int{listing.DefaultLimit + 1, listing.DefaultLimit + 1, listing.MaxLimit + 1}, repo.limits)
+}
+
+func TestListRoleHolders_InvalidInput(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	svc := listing.NewService(&stubRepo{})
+
+	for name, tc := range map[string]struct {
+		filter listing.RoleHoldersFilter
+		cursor string
+	}{
+		"role ID not a UUID":                  {filter: listing.RoleHoldersFilter{RoleID: "admin"}},
+		"unknown source":                      {filter: listing.RoleHoldersFilter{RoleID: roleID, Source: "delegated"}},
+		"cursor not base64":                   {filter: listing.RoleHoldersFilter{RoleID: roleID}, cursor: "!"},
+		"cursor of a listing with other keys": {filter: listing.RoleHoldersFilter{RoleID: roleID}, cursor: "WyJhIiwiYiJd"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.ListRoleHolders(ctx, tc.filter, listing.PageRequest{Cursor: tc.cursor})
+			assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+		})
+	}
+
+	_, err := svc.ListRoleHolders(context.Background(), listing.RoleHoldersFilter{RoleID: roleID}, listing.PageRequest{})
+	assert.True(t, errors.Is(err, permissions.ErrInvalidInput), "the tenant is required")
+}
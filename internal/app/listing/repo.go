@@ -0,0 +1,18 @@
+package listing
+
+import (
+	"context"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Reader returns at most limit items of each listing which come after the key
+// in the listing's order, a nil key starts at the first item.
+type Reader interface {
+	ListRoleHolders(ctx context.Context, filter RoleHoldersFilter, after Key, limit int) ([]RoleHolder, error)
+	ListRoleGrants(ctx context.Context, filter RoleGrantsFilter, after Key, limit int) ([]RoleGrant, error)
+	ListResourceTypePermissions(ctx context.Context, resourceType string, after Key, limit int) ([]permissions.TenantPermission, error)
+	ListResourceGrantees(ctx context.Context, filter ResourceGranteesFilter, after Key, limit int) ([]ResourceGrantee, error)
+	// GetUnknownResourceTypes returns the names which are not resource types of the Tenant, compared case-insensitively.
+	GetUnknownResourceTypes(ctx context.Context, names []string) ([]string, error)
+}
@@ -0,0 +1,14 @@
+// Package listing pages through a Tenant's users, roles, permissions and
+// resource grants for admin tooling. Each listing is in a fixed order, and a
+// page's NextCursor continues the listing after its last item, so pages do not
+// skip or repeat items when others are added or removed meanwhile.
+package listing
+
+type Service struct {
+	repo Reader
+}
+
+// NewService creates a new listing service
+func NewService(repo Reader) *Service {
+	return &Service{repo: repo}
+}
@@ -0,0 +1,101 @@
+package manifests
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Register registers the manifest with the Tenant in the context. Resource
+// types and permissions new to the Tenant are added, each new permission
+// granted to its default roles and, when enableNew is true, made active for
+// the Tenant. Those the Tenant has take the manifest's descriptions, and the
+// permissions the service registered before but are missing from the
+// manifest are deprecated. Registering a permission another service has is
+// ErrInvalidInput. The changes are only worked out when dryRun is true.
+func (s *Service) Register(ctx context.Context, manifest *Manifest, enableNew, dryRun bool) (*Registration, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+
+	registry, err := s.repo.GetRegistry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("register: get registry: %w", err)
+	}
+	registration, err := register(manifest, registry, enableNew)
+	if err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+	registration.RegisteredAt = s.now().UTC()
+	if dryRun || registration.Empty() {
+		return registration, nil
+	}
+
+	if err := s.repo.RegisterManifest(ctx, registration); err != nil {
+		return nil, fmt.Errorf("register: %w", err)
+	}
+	registration.Applied = true
+	return registration, nil
+}
+
+// register works out the changes registering the manifest makes to the registry.
+func register(manifest *Manifest, registry *Registry, enableNew bool) (*Registration, error) {
+	resourceTypes := make(map[string]RegisteredResourceType, len(registry.ResourceTypes))
+	for _, rt := range registry.ResourceTypes {
+		resourceTypes[rt.Name] = rt
+	}
+	registered := make(map[string]RegisteredPermission, len(registry.Permissions))
+	for _, p := range registry.Permissions {
+		registered[p.Name] = p
+	}
+
+	reg := &Registration{Service: manifest.Service}
+	published := make(map[string]bool)
+	for _, rt := range manifest.ResourceTypes {
+		current, ok := resourceTypes[rt.Name]
+		switch {
+		case !ok:
+			reg.ResourceTypes = append(reg.ResourceTypes, Entry{Name: rt.Name, Description: rt.Description, Change: ChangeAdded})
+		case current.Service == "" || (current.Service == manifest.Service && current.Description != rt.Description):
+			reg.ResourceTypes = append(reg.ResourceTypes, Entry{Name: rt.Name, Description: rt.Description, Change: ChangeUpdated})
+		}
+
+		for _, a := range rt.Actions {
+			name := rt.Permission(a)
+			published[name] = true
+			current, ok := registered[name]
+			switch {
+			case !ok:
+				reg.Permissions = append(reg.Permissions, Entry{Name: name, Description: a.Description, Change: ChangeAdded})
+				if enableNew {
+					reg.Enabled = append(reg.Enabled, name)
+				}
+				for _, role := range a.DefaultRoles {
+					if slices.Contains(registry.Roles, role) {
+						reg.Grants = append(reg.Grants, Grant{Role: role, Permission: name})
+					} else {
+						reg.MissingRoles = append(reg.MissingRoles, Grant{Role: role, Permission: name})
+					}
+				}
+			case current.Service != "" && current.Service != manifest.Service:
+				return nil, permissions.Errorf(permissions.ErrInvalidInput, "permission %q is registered by service %q", name, current.Service)
+			case current.Deprecated:
+				reg.Permissions = append(reg.Permissions, Entry{Name: name, Description: a.Description, Change: ChangeRestored})
+			case current.Service == "" || current.Description != a.Description:
+				reg.Permissions = append(reg.Permissions, Entry{Name: name, Description: a.Description, Change: ChangeUpdated})
+			}
+		}
+	}
+
+	for _, p := range registry.Permissions {
+		if p.Service == manifest.Service && !p.Deprecated && !published[p.Name] {
+			reg.Permissions = append(reg.Permissions, Entry{Name: p.Name, Description: p.Description, Change: ChangeDeprecated})
+		}
+	}
+	return reg, nil
+}
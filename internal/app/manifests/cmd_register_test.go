@@ -0,0 +1,124 @@
+package manifests_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/manifests"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo returns its registry, and keeps the registration made.
+type stubRepo struct {
+	registry     *manifests.Registry
+	registration *manifests.Registration
+}
+
+func (r *stubRepo) GetRegistry(context.Context) (*manifests.Registry, error) {
+	return r.registry, nil
+}
+
+func (r *stubRepo) RegisterManifest(_ context.Context, registration *manifests.Registration) error {
+	r.registration = registration
+	return nil
+}
+
+func newRegistry() *manifests.Registry {
+	return &manifests.Registry{
+		ResourceTypes: []manifests.RegisteredResourceType{
+			{Name: "invoices"},
+			{Name: "products", Description: "Things sold.", Service: "catalogue"},
+		},
+		Permissions: []manifests.RegisteredPermission{
+			{Name: "invoices:create"},
+			{Name: "invoices:read", Description: "Read an invoice.", Service: "invoicing"},
+			{Name: "invoices:void", Description: "Void an invoice.", Service: "invoicing", Deprecated: true},
+			{Name: "invoices:archive", Description: "Archive an invoice.", Service: "invoicing"},
+			{Name: "products:read", Service: "catalogue"},
+		},
+		Roles: []string{"admin", "sales person"},
+	}
+}
+
+func newManifest() *manifests.Manifest {
+	return &manifests.Manifest{
+		Service: "invoicing",
+		ResourceTypes: []manifests.ResourceType{{
+			Name:        "invoices",
+			Description: "Bills sent to customers.",
+			Actions: []manifests.Action{
+				{Name: "create", Description: "Create an invoice."},
+				{Name: "read", Description: "Read an invoice."},
+				{Name: "void", Description: "Void an invoice."},
+				{Name: "send", Description: "Send an invoice.", DefaultRoles: []string{"admin", "sales person", "clerk"}},
+			},
+		}},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{registry: newRegistry()}
+
+	registration, err := manifests.NewService(repo).Register(ctx, newManifest(), true, false)
+	require.NoError(t, err)
+	assert.True(t, registration.Applied)
+	assert.Same(t, registration, repo.registration)
+
+	assert.Equal(t, []manifests.Entry{
+		{Name: "invoices", Description: "Bills sent to customers.", Change: manifests.ChangeUpdated},
+	}, registration.ResourceTypes, "a seeded resource type is claimed by the service")
+	assert.Equal(t, []manifests.Entry{
+		{Name: "invoices:create", Description: "Create an invoice.", Change: manifests.ChangeUpdated},
+		{Name: "invoices:void", Description: "Void an invoice.", Change: manifests.ChangeRestored},
+		{Name: "invoices:send", Description: "Send an invoice.", Change: manifests.ChangeAdded},
+		{Name: "invoices:archive", Description: "Archive an invoice.", Change: manifests.ChangeDeprecated},
+	}, registration.Permissions, "the unchanged invoices:read and the other service's products:read are left out")
+	assert.Equal(t, []string{"invoices:send"}, registration.Enabled)
+	assert.Equal(t, []manifests.Grant{
+		{Role: "admin", Permission: "invoices:send"},
+		{Role: "sales person", Permission: "invoices:send"},
+	}, registration.Grants)
+	assert.Equal(t, []manifests.Grant{{Role: "clerk", Permission: "invoices:send"}}, registration.MissingRoles)
+}
+
+func TestRegister_DryRun(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{registry: newRegistry()}
+
+	registration, err := manifests.NewService(repo).Register(ctx, newManifest(), false, true)
+	require.NoError(t, err)
+	assert.False(t, registration.Applied)
+	assert.Nil(t, repo.registration)
+	assert.Empty(t, registration.Enabled, "new permissions are only enabled when asked")
+}
+
+func TestRegister_Rejects(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	tests := []struct {
+		name   string
+		modify func(m *manifests.Manifest)
+	}{
+		{name: "no service", modify: func(m *manifests.Manifest) { m.Service = "" }},
+		{name: "wildcard action", modify: func(m *manifests.Manifest) { m.ResourceTypes[0].Actions[0].Name = "*" }},
+		{name: "invalid resource type", modify: func(m *manifests.Manifest) { m.ResourceTypes[0].Name = "in voices" }},
+		{name: "repeated action", modify: func(m *manifests.Manifest) { m.ResourceTypes[0].Actions[1].Name = "create" }},
+		{name: "other service's permission", modify: func(m *manifests.Manifest) {
+			m.ResourceTypes = append(m.ResourceTypes, manifests.ResourceType{Name: "products", Actions: []manifests.Action{{Name: "read"}}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepo{registry: newRegistry()}
+			m := newManifest()
+			tt.modify(m)
+
+			_, err := manifests.NewService(repo).Register(ctx, m, false, false)
+			assert.ErrorIs(t, err, permissions.ErrInvalidInput)
+			assert.Nil(t, repo.registration)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package manifests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Manifest is the resource types, and the actions on them, a consuming service publishes.
+type Manifest struct {
+	// Service is the name of the publishing service, which owns the permissions it registers.
+	Service       string
+	ResourceTypes []ResourceType
+}
+
+type ResourceType struct {
+	Name        string
+	Description string
+	Actions     []Action
+}
+
+type Action struct {
+	Name        string
+	Description string
+	// DefaultRoles are the names of the roles granted the action's permission
+	// when it is first registered, the Tenant may revoke it after.
+	DefaultRoles []string
+}
+
+// Permission returns the name of the permission for the action on the resource type.
+func (rt ResourceType) Permission(a Action) string {
+	return rt.Name + permissions.PermissionSeparator + a.Name
+}
+
+// Validate checks the manifest names its service, and that its resource types
+// and actions are named validly, without the Wildcard, and only once. The
+// error is ErrInvalidInput.
+func (m *Manifest) Validate() error {
+	var errs []error
+	if strings.TrimSpace(m.Service) == "" {
+		errs = append(errs, errors.New("service is required"))
+	}
+
+	resourceTypes := make(map[string]bool, len(m.ResourceTypes))
+	for _, rt := range m.ResourceTypes {
+		if err := permissions.ValidateResourceType(rt.Name); err != nil {
+			errs = append(errs, err)
+		}
+		if resourceTypes[rt.Name] {
+			errs = append(errs, fmt.Errorf("resource type %q is repeated", rt.Name))
+		}
+		resourceTypes[rt.Name] = true
+
+		if len(rt.Actions) == 0 {
+			errs = append(errs, fmt.Errorf("resource type %q has no actions", rt.Name))
+		}
+		actions := make(map[string]bool, len(rt.Actions))
+		for _, a := range rt.Actions {
+			if a.Name == permissions.Wildcard {
+				errs = append(errs, fmt.Errorf("resource type %q: the wildcard is not an action", rt.Name))
+			} else if err := permissions.ValidatePermissionName(rt.Permission(a)); err != nil {
+				errs = append(errs, err)
+			}
+			if actions[a.Name] {
+				errs = append(errs, fmt.Errorf("action %q is repeated", rt.Permission(a)))
+			}
+			actions[a.Name] = true
+			for _, role := range a.DefaultRoles {
+				if strings.TrimSpace(role) == "" {
+					errs = append(errs, fmt.Errorf("action %q has a default role with no name", rt.Permission(a)))
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return permissions.WrapError(permissions.ErrInvalidInput, errors.Join(errs...), "invalid manifest")
+	}
+	return nil
+}
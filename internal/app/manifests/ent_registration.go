@@ -0,0 +1,75 @@
+package manifests
+
+import "time"
+
+// Registry is what the Tenant has of the resource types, permissions and roles a manifest names.
+type Registry struct {
+	ResourceTypes []RegisteredResourceType
+	Permissions   []RegisteredPermission
+	// Roles are the names of the Tenant's roles.
+	Roles []string
+}
+
+type RegisteredResourceType struct {
+	Name        string
+	Description string
+	// Service is the service which registered the resource type, empty when none did.
+	Service string
+}
+
+type RegisteredPermission struct {
+	Name        string
+	Description string
+	// Service is the service which registered the permission, empty when none did.
+	Service    string
+	Deprecated bool
+}
+
+type Change string
+
+const (
+	ChangeAdded Change = "added"
+	// ChangeUpdated is a new description, or a resource type or permission
+	// seeded before being registered by the service.
+	ChangeUpdated Change = "updated"
+	// ChangeRestored is a deprecated permission back in the service's manifest.
+	ChangeRestored Change = "restored"
+	// ChangeDeprecated is a permission the service registered before but no longer publishes.
+	ChangeDeprecated Change = "deprecated"
+)
+
+// Entry is a change to a resource type or permission.
+type Entry struct {
+	Name        string
+	Description string
+	Change      Change
+}
+
+// Grant is a permission granted to one of its default roles.
+type Grant struct {
+	Role       string
+	Permission string
+}
+
+// Registration is the changes registering a manifest makes to a Tenant.
+type Registration struct {
+	Service string
+	// ResourceTypes and Permissions are those which change, in manifest order,
+	// followed by the deprecated permissions.
+	ResourceTypes []Entry
+	Permissions   []Entry
+	// Enabled are the names of the added permissions made active for the Tenant.
+	Enabled []string
+	// Grants are the added permissions granted to their default roles.
+	Grants []Grant
+	// MissingRoles are the default roles the Tenant does not have, whose grants are left out.
+	MissingRoles []Grant
+	RegisteredAt time.Time
+	// Applied is false for a dry run.
+	Applied bool
+}
+
+// Empty reports whether registering the manifest changes nothing.
+func (r *Registration) Empty() bool {
+	return len(r.ResourceTypes) == 0 && len(r.Permissions) == 0 && len(r.Grants) == 0
+}
@@ -0,0 +1,56 @@
+//go:build test
+// +build test
+
+package manifests_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/manifests"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister_Tenant(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := manifests.NewService(repo)
+	const userSalesPerson = "2133479c-35a8-4a49-a682-2952d4772ecc"
+
+	manifest := &manifests.Manifest{
+		Service: "invoicing",
+		ResourceTypes: []manifests.ResourceType{
+			{Name: "invoices", Description: "Bills sent to customers.", Actions: []manifests.Action{
+				{Name: "create"}, {Name: "read"}, {Name: "delete"},
+				{Name: "send", Description: "Send an invoice.", DefaultRoles: []string{"sales person"}},
+			}},
+			{Name: "payments", Actions: []manifests.Action{{Name: "read", DefaultRoles: []string{"sales auditor"}}}},
+		},
+	}
+	registration, err := svc.Register(ctx, manifest, true, false)
+	require.NoError(t, err)
+	assert.True(t, registration.Applied)
+	assert.Len(t, registration.Grants, 2)
+
+	forUser, err := permissions.NewService(repo).GetForUser(contextkey.WithUserID(ctx, userSalesPerson), nil)
+	require.NoError(t, err)
+	assert.True(t, forUser.Allows("invoices:send"), "the default role is granted the new permission")
+
+	registry, err := repo.GetRegistry(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, registry.ResourceTypes, manifests.RegisteredResourceType{Name: "payments", Service: "invoicing"})
+
+	// The service stops publishing invoices:delete.
+	manifest.ResourceTypes[0].Actions = manifest.ResourceTypes[0].Actions[:2]
+	manifest.ResourceTypes[0].Actions = append(manifest.ResourceTypes[0].Actions, manifests.Action{Name: "send", Description: "Send an invoice."})
+	registration, err = svc.Register(ctx, manifest, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, []manifests.Entry{{Name: "invoices:delete", Change: manifests.ChangeDeprecated}}, registration.Permissions)
+
+	registration, err = svc.Register(ctx, manifest, true, false)
+	require.NoError(t, err)
+	assert.True(t, registration.Empty(), "registering the same manifest again changes nothing")
+	assert.False(t, registration.Applied)
+}
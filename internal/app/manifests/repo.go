@@ -0,0 +1,19 @@
+package manifests
+
+import "context"
+
+type Reader interface {
+	// GetRegistry returns the Tenant's resource types, permissions and role names.
+	GetRegistry(ctx context.Context) (*Registry, error)
+}
+
+type Writer interface {
+	// RegisterManifest makes the registration's changes in one transaction, it
+	// is ErrForbidden when a grant violates a separation of duties rule.
+	RegisterManifest(ctx context.Context, registration *Registration) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
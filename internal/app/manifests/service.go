@@ -0,0 +1,22 @@
+// Package manifests registers the resource types and actions consuming
+// services publish in their manifests, so that their permissions no longer
+// need seeding into each Tenant by hand. Registering a manifest adds its
+// resource types and permissions to the Tenant, grants new permissions to
+// their default roles, and deprecates the permissions the service registered
+// before but no longer publishes.
+package manifests
+
+import "time"
+
+type Service struct {
+	repo ReaderWriter
+	now  func() time.Time
+}
+
+// NewService creates a manifest registration service.
+func NewService(repo ReaderWriter) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
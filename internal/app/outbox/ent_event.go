@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	// EventRoleGranted is a role assigned directly to a user, or its permissions granted
+	// to them on a single resource, Data is a RoleAssignment.
+	EventRoleGranted EventType = "role.granted"
+	// EventRoleRevoked is a role unassigned from a user, Data is a RoleAssignment.
+	EventRoleRevoked EventType = "role.revoked"
+	// EventPermissionGranted is a permission granted to a role, Data is a RolePermission.
+	EventPermissionGranted EventType = "permission.granted"
+	// EventPermissionRevoked is a permission revoked from a role, Data is a RolePermission.
+	EventPermissionRevoked EventType = "permission.revoked"
+	// EventRoleGraphChanged is any change to the Tenant's resource types, permissions,
+	// roles or role hierarchy, Data is a RoleGraphChange. Cached role graphs should be refreshed.
+	EventRoleGraphChanged EventType = "role_graph.changed"
+	// EventGroupChanged is any change to a group, its members, roles or nesting, Data is a GroupChange.
+	EventGroupChanged EventType = "group.changed"
+	// EventResourceHierarchyChanged is any change to the resource type or resource hierarchies,
+	// Data is a ResourceHierarchyChange.
+	EventResourceHierarchyChanged EventType = "resource_hierarchy.changed"
+	// EventRelationsChanged is relationship tuples written or deleted, Data is a RelationsChange.
+	EventRelationsChanged EventType = "relations.changed"
+	// EventSoDRulesChanged is a separation of duties rule created or deleted, Data is a SoDRuleChange.
+	// A dynamic rule changes which roles are active, so cached permissions should be refreshed.
+	EventSoDRulesChanged EventType = "sod_rules.changed"
+	// EventUserGrantsRevoked is every grant of a user removed at once, as when their identity
+	// provider deactivates them, Data is a UserGrantsRevoked. It follows the events of the
+	// roles and group memberships removed, cached permissions of the user should be dropped.
+	EventUserGrantsRevoked EventType = "user.grants_revoked"
+	// EventDelegationChanged is a delegation created or revoked, Data is a DelegationChange.
+	// Cached permissions of the delegate should be refreshed. A delegation also lapses
+	// without an event, when its window ends or its delegator loses what it delegates.
+	EventDelegationChanged EventType = "delegation.changed"
+	// EventBreakGlassGranted is a user granted the Tenant's emergency role through break-glass,
+	// Data is a BreakGlassSession. It follows the EventRoleGranted of the assignment, and is
+	// SeverityHigh, whoever is on call should be told. The assignment lapses without an event.
+	EventBreakGlassGranted EventType = "break_glass.granted"
+	// EventBreakGlassEnded is a break-glass session ended before it expired, Data is a
+	// BreakGlassSession. It follows the EventRoleRevoked of the assignment.
+	EventBreakGlassEnded EventType = "break_glass.ended"
+)
+
+// Severity is how urgently someone should look at an event.
+type Severity string
+
+const (
+	SeverityInfo Severity = "info"
+	// SeverityHigh events are audited access outside the usual controls, such as break-glass.
+	SeverityHigh Severity = "high"
+)
+
+// Severity returns the severity of events of the type.
+func (t EventType) Severity() Severity {
+	if t == EventBreakGlassGranted {
+		return SeverityHigh
+	}
+	return SeverityInfo
+}
+
+// Event is a change to a Tenant's permissions, written to the outbox in the
+// same transaction as the change and published by the Relay.
+type Event struct {
+	// ID is assigned by the outbox, it increases with each event in a Tenant.
+	ID int64 `json:"id"`
+	// TenantID is set by the Relay when the event is published.
+	TenantID  string          `json:"tenantId"`
+	Type      EventType       `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt"`
+	// Attempts is the number of failed attempts to publish the event.
+	Attempts int `json:"-"`
+}
+
+type RoleAssignment struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+	// ResourceType and ResourceID are set when the role's permissions were granted on a single resource.
+	ResourceType string `json:"resourceType,omitempty"`
+	ResourceID   string `json:"resourceId,omitempty"`
+	// ExpiresAt is when the assignment lapses, nil for never.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type RolePermission struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+}
+
+type RoleGraphChange struct {
+	// Source is the operation which changed the role graph, "import", "apply" or "register".
+	Source string `json:"source"`
+}
+
+type GroupChange struct {
+	GroupID string `json:"groupId"`
+	// Action is one of "created", "deleted", "members_added", "members_removed",
+	// "roles_added", "roles_removed", "nested" or "unnested".
+	Action string `json:"action"`
+	// UserIDs are the members added or removed.
+	UserIDs []string `json:"userIds,omitempty"`
+	// RoleIDs are the roles added or removed.
+	RoleIDs []string `json:"roleIds,omitempty"`
+	// ParentGroupID is the group nested in, or unnested from.
+	ParentGroupID string `json:"parentGroupId,omitempty"`
+}
+
+type ResourceHierarchyChange struct {
+	// Action is one of "type_child_added", "type_child_removed", "children_added" or "children_removed".
+	Action     string   `json:"action"`
+	ParentType string   `json:"parentType"`
+	ChildType  string   `json:"childType"`
+	ParentID   string   `json:"parentId,omitempty"`
+	ChildIDs   []string `json:"childIds,omitempty"`
+}
+
+type RelationsChange struct {
+	Written []string `json:"written,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// NewEvent creates an event of the type with the data, which must marshal to JSON.
+func NewEvent(eventType EventType, data any) Event {
+	b, err := json.Marshal(data)
+	if err != nil {
+		// The data is always one of the types above, which cannot fail to marshal.
+		panic(err)
+	}
+	return Event{Type: eventType, Data: b}
+}
+
+type SoDRuleChange struct {
+	RuleID string `json:"ruleId"`
+	// Action is "created" or "deleted".
+	Action string `json:"action"`
+}
+
+type UserGrantsRevoked struct {
+	UserID string `json:"userId"`
+	// Reason is "deactivated" or "deleted".
+	Reason string `json:"reason"`
+}
+
+type DelegationChange struct {
+	DelegationID string `json:"delegationId"`
+	DelegatorID  string `json:"delegatorId"`
+	DelegateID   string `json:"delegateId"`
+	// Action is "created" or "revoked".
+	Action string `json:"action"`
+}
+
+type BreakGlassSession struct {
+	SessionID     string    `json:"sessionId"`
+	UserID        string    `json:"userId"`
+	Role          string    `json:"role"`
+	Justification string    `json:"justification"`
+	GrantedBy     string    `json:"grantedBy,omitempty"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	// EndedBy is the user who ended the session, set for EventBreakGlassEnded when known.
+	EndedBy string `json:"endedBy,omitempty"`
+}
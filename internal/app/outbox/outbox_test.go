@@ -0,0 +1,82 @@
+//go:build test
+// +build test
+
+package outbox_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/eventsink"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/relations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userSalesPerson = "2133479c-35a8-4a49-a682-2952d4772ecc"
+
+func TestOutbox_WritesAreRelayed(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+
+	group, err := permissions.NewService(repo).CreateGroup(ctx, "Outbox Testers")
+	require.NoError(t, err)
+	require.NoError(t, permissions.NewService(repo).AddGroupMembers(ctx, group.ID, []string{userSalesPerson}))
+
+	tuple, err := relations.ParseTuple("document:readme#viewer@user:" + userSalesPerson)
+	require.NoError(t, err)
+	require.NoError(t, relations.NewService(repo).Write(ctx, relations.Tuples{tuple}))
+
+	// A failed write leaves no event.
+	assert.Error(t, permissions.NewService(repo).DeleteGroup(ctx, "00000000-0000-0000-0000-000000000000"))
+
+	sink := eventsink.NewChannelSink(10)
+	relay := outbox.NewRelay(repo, sink, outbox.RelayConfig{})
+	published, failed, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+	assert.Zero(t, failed)
+
+	var types []outbox.EventType
+	for range published {
+		e := <-sink.C
+		assert.Equal(t, postgrestest.TenantID, e.TenantID)
+		types = append(types, e.Type)
+		if e.Type == outbox.EventRelationsChanged {
+			var change outbox.RelationsChange
+			require.NoError(t, json.Unmarshal(e.Data, &change))
+			assert.Equal(t, []string{tuple.String()}, change.Written)
+		}
+	}
+	assert.Equal(t, []outbox.EventType{outbox.EventGroupChanged, outbox.EventGroupChanged, outbox.EventRelationsChanged}, types)
+
+	// Published events are not published again.
+	published, _, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published)
+}
+
+func TestOutbox_ClaimedEventsAreLeased(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+
+	_, err := permissions.NewService(repo).CreateGroup(ctx, "Outbox Testers")
+	require.NoError(t, err)
+
+	claimed, err := repo.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	// Until the lease expires, or the event fails, another relay cannot claim it.
+	again, err := repo.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	require.NoError(t, repo.MarkFailed(ctx, claimed[0].ID, "unavailable", time.Now().Add(-time.Second)))
+	again, err = repo.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, 1, again[0].Attempts)
+}
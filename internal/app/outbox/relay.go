@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+// RelayConfig tunes a Relay, zero fields take the defaults.
+type RelayConfig struct {
+	// BatchSize is the most events claimed at once, default 100.
+	BatchSize int
+	// Lease is how long claimed events are hidden from other relays, default 1 minute.
+	// It must be longer than publishing a batch takes, or events are published twice.
+	Lease time.Duration
+	// PollInterval is the wait after a batch which did not fill, default 5 seconds.
+	PollInterval time.Duration
+	// MinBackoff is the wait before retrying an event which failed once, default 1 second.
+	// It doubles with each failed attempt, up to MaxBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff is the longest wait before retrying an event, default 10 minutes.
+	MaxBackoff time.Duration
+}
+
+func (c RelayConfig) withDefaults() RelayConfig {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.Lease <= 0 {
+		c.Lease = time.Minute
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 5 * time.Second
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 10 * time.Minute
+	}
+	return c
+}
+
+// Relay publishes the events in a Tenant's outbox to a Sink.
+//
+// Delivery is at least once: an event is only marked published after the sink
+// accepts it, so a crash in between publishes it again. An event which fails is
+// retried with exponential backoff, and the events after it are not held up, so
+// consumers must tolerate repeated and out of order events.
+type Relay struct {
+	repo   Repo
+	sink   Sink
+	config RelayConfig
+	now    func() time.Time
+}
+
+func NewRelay(repo Repo, sink Sink, config RelayConfig) *Relay {
+	return &Relay{
+		repo:   repo,
+		sink:   sink,
+		config: config.withDefaults(),
+		now:    time.Now,
+	}
+}
+
+// RelayOnce publishes a batch of due events, it returns how many were published and how many failed.
+// The Tenant is read from the context.
+func (r *Relay) RelayOnce(ctx context.Context) (published, failed int, err error) {
+	tenantID, ok := contextkey.TenantID(ctx)
+	if !ok {
+		return 0, 0, fmt.Errorf("tenant ID not in context")
+	}
+
+	events, err := r.repo.ClaimEvents(ctx, r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, 0, fmt.Errorf("claim events: %w", err)
+	}
+
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		e.TenantID = tenantID
+		if err := r.sink.Publish(ctx, e); err != nil {
+			failed++
+			retryAt := r.now().Add(r.backoff(e.Attempts + 1))
+			slog.WarnContext(ctx, "publish event failed", "eventId", e.ID, "type", e.Type, "attempts", e.Attempts+1, "retryAt", retryAt, "error", err)
+			if err := r.repo.MarkFailed(ctx, e.ID, err.Error(), retryAt); err != nil {
+				return 0, failed, fmt.Errorf("mark event %d failed: %w", e.ID, err)
+			}
+			continue
+		}
+		ids = append(ids, e.ID)
+	}
+
+	if len(ids) > 0 {
+		if err := r.repo.MarkPublished(ctx, ids); err != nil {
+			return 0, failed, fmt.Errorf("mark events published: %w", err)
+		}
+	}
+	return len(ids), failed, nil
+}
+
+// Run relays events until the context is cancelled. Full batches are followed
+// immediately by the next, otherwise it waits for the poll interval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		published, failed, err := r.RelayOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			slog.ErrorContext(ctx, "relay events", "error", err)
+		}
+		if err == nil && published+failed == r.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+// backoff is the wait before the attempt, doubling from MinBackoff for the first retry up to MaxBackoff.
+func (r *Relay) backoff(attempt int) time.Duration {
+	d := r.config.MinBackoff
+	for i := 1; i < attempt && d < r.config.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.config.MaxBackoff)
+}
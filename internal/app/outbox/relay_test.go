@@ -0,0 +1,161 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/outbox"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo is an in-memory outbox, events are due when not published and retryAt has passed.
+type fakeRepo struct {
+	mu        sync.Mutex
+	events    []outbox.Event
+	published map[int64]bool
+	retryAt   map[int64]time.Time
+	errors    map[int64]string
+	now       time.Time
+}
+
+func newFakeRepo(events ...outbox.Event) *fakeRepo {
+	return &fakeRepo{
+		events:    events,
+		published: make(map[int64]bool),
+		retryAt:   make(map[int64]time.Time),
+		errors:    make(map[int64]string),
+	}
+}
+
+func (r *fakeRepo) ClaimEvents(_ context.Context, limit int, _ time.Duration) ([]outbox.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed []outbox.Event
+	for _, e := range r.events {
+		if len(claimed) == limit {
+			break
+		}
+		if r.published[e.ID] || r.retryAt[e.ID].After(r.now) {
+			continue
+		}
+		claimed = append(claimed, e)
+	}
+	return claimed, nil
+}
+
+func (r *fakeRepo) publishedCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.published)
+}
+
+func (r *fakeRepo) MarkPublished(_ context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		r.published[id] = true
+	}
+	return nil
+}
+
+func (r *fakeRepo) MarkFailed(_ context.Context, id int64, cause string, retryAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.events {
+		if r.events[i].ID == id {
+			r.events[i].Attempts++
+		}
+	}
+	r.errors[id] = cause
+	r.retryAt[id] = retryAt
+	return nil
+}
+
+// flakySink fails to publish an event the number of times in failures, then accepts it.
+type flakySink struct {
+	failures  map[int64]int
+	published []outbox.Event
+}
+
+func (s *flakySink) Publish(_ context.Context, e outbox.Event) error {
+	if s.failures[e.ID] > 0 {
+		s.failures[e.ID]--
+		return errors.New("unavailable")
+	}
+	s.published = append(s.published, e)
+	return nil
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkey.CtxKeyTenantID, "test")
+	event := func(id int64) outbox.Event {
+		e := outbox.NewEvent(outbox.EventRoleGranted, outbox.RoleAssignment{UserID: "u1", Role: "admin"})
+		e.ID = id
+		return e
+	}
+	repo := newFakeRepo(event(1), event(2), event(3))
+	sink := &flakySink{failures: map[int64]int{2: 2}}
+	relay := outbox.NewRelay(repo, sink, outbox.RelayConfig{MinBackoff: time.Second, MaxBackoff: 3 * time.Second})
+
+	published, failed, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, "unavailable", repo.errors[2])
+	require.Len(t, sink.published, 2)
+	assert.Equal(t, "test", sink.published[0].TenantID)
+
+	// The failed event is not retried until its backoff has passed.
+	published, failed, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published+failed)
+
+	// The backoff doubles with each failure.
+	repo.now = repo.retryAt[2]
+	_, failed, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failed)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), repo.retryAt[2], 100*time.Millisecond)
+
+	repo.now = repo.retryAt[2]
+	published, _, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.True(t, repo.published[2])
+	assert.Len(t, sink.published, 3)
+}
+
+func TestRelay_RelayOnceNeedsTenant(t *testing.T) {
+	relay := outbox.NewRelay(newFakeRepo(), &flakySink{}, outbox.RelayConfig{})
+	_, _, err := relay.RelayOnce(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRelay_Run(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextkey.CtxKeyTenantID, "test")
+	ctx, cancel := context.WithCancel(ctx)
+
+	var events []outbox.Event
+	for id := int64(1); id <= 5; id++ {
+		e := outbox.NewEvent(outbox.EventRelationsChanged, outbox.RelationsChange{Written: []string{"document:readme#viewer@user:u1"}})
+		e.ID = id
+		events = append(events, e)
+	}
+	repo := newFakeRepo(events...)
+	sink := &flakySink{}
+	relay := outbox.NewRelay(repo, sink, outbox.RelayConfig{BatchSize: 2, PollInterval: time.Millisecond})
+
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+	assert.Eventually(t, func() bool { return repo.publishedCount() == 5 }, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Repo is the outbox of a Tenant, events are written to it by the writes which cause them.
+type Repo interface {
+	// ClaimEvents returns up to limit unpublished events which are due, oldest
+	// first, and hides them from other claims for the lease. An event which is
+	// neither published nor failed before the lease expires is claimed again.
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]Event, error)
+	// MarkPublished records the events as published, they are not claimed again.
+	MarkPublished(ctx context.Context, ids []int64) error
+	// MarkFailed records a failed attempt to publish the event, it is due again at retryAt.
+	MarkFailed(ctx context.Context, id int64, cause string, retryAt time.Time) error
+}
@@ -0,0 +1,9 @@
+package outbox
+
+import "context"
+
+// Sink publishes events, such as to an SNS topic or an EventBridge bus.
+// Publish must only return nil once the event is durably accepted.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
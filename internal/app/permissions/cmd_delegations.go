@@ -0,0 +1,174 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+// CreateDelegation delegates the Delegation's roles, by ID, and permissions, by
+// name, from its delegator to its delegate for its window, which starts now
+// when StartsAt is zero. The delegator must hold each of them themselves,
+// through their roles and extra permissions rather than another Delegation, or
+// it is ErrForbidden, as is a Delegation which would leave the delegate holding
+// members of a static SoDRule they did not before, which the repository checks
+// as it creates it. What is delegated lapses as soon as the delegator no longer
+// holds it, see DelegatedPermission.
+func (s *Service) CreateDelegation(ctx context.Context, d Delegation) (*Delegation, error) {
+	now := time.Now()
+	if d.StartsAt.IsZero() {
+		d.StartsAt = now
+	}
+	delegation := Delegation{
+		ID:          uuid.NewString(),
+		DelegatorID: d.DelegatorID,
+		DelegateID:  d.DelegateID,
+		Reason:      strings.TrimSpace(d.Reason),
+		StartsAt:    d.StartsAt,
+		EndsAt:      d.EndsAt,
+	}
+	for _, userID := range []string{d.DelegatorID, d.DelegateID} {
+		if err := ValidateUserID(userID); err != nil {
+			return nil, fmt.Errorf("create delegation: %w", err)
+		}
+	}
+	for _, role := range d.Roles {
+		if err := uuid.Validate(role.ID); err != nil {
+			return nil, fmt.Errorf("create delegation: %w", Errorf(ErrInvalidInput, "role ID %q is not a UUID", role.ID))
+		}
+		if !delegation.Roles.contains(role) {
+			delegation.Roles = append(delegation.Roles, Role{ID: role.ID})
+		}
+	}
+	for _, p := range d.Permissions {
+		if !slices.ContainsFunc(delegation.Permissions, func(dp Permission) bool { return strings.EqualFold(dp.Name, p.Name) }) {
+			delegation.Permissions = append(delegation.Permissions, Permission{Name: p.Name})
+		}
+	}
+	if err := validateDelegation(delegation, now); err != nil {
+		return nil, fmt.Errorf("create delegation: %w", err)
+	}
+
+	roleMap, err := s.repo.GetTenantRoleMap(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create delegation: %w", err)
+	}
+	delegator, err := s.holdings(contextkey.WithUserID(ctx, delegation.DelegatorID), roleMap, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create delegation: %w", err)
+	}
+	held := delegator.RoleAssignments.Roles()
+	for i, role := range delegation.Roles {
+		j := slices.IndexFunc(held, func(r Role) bool { return r.ID == role.ID })
+		if j < 0 {
+			return nil, fmt.Errorf("create delegation: %w", Errorf(ErrForbidden, "role %s is not held by the delegator", role.ID))
+		}
+		delegation.Roles[i] = held[j]
+	}
+	for _, p := range delegation.Permissions {
+		if delegator.Decide(p.Name, nil).Effect == EffectDeny {
+			return nil, fmt.Errorf("create delegation: %w", Errorf(ErrForbidden, "permission %q is not held by the delegator", p.Name))
+		}
+	}
+	if err := s.repo.CreateDelegation(ctx, delegation); err != nil {
+		return nil, fmt.Errorf("create delegation: %w", err)
+	}
+	delegations, err := s.repo.GetDelegations(ctx, delegation.DelegatorID)
+	if err != nil {
+		return nil, fmt.Errorf("create delegation: %w", err)
+	}
+	i := slices.IndexFunc(delegations, func(created Delegation) bool { return created.ID == delegation.ID })
+	if i < 0 {
+		return nil, fmt.Errorf("create delegation: delegation %s not found once created", delegation.ID)
+	}
+	return &delegations[i], nil
+}
+
+// RevokeDelegation ends the Delegation before its window does.
+func (s *Service) RevokeDelegation(ctx context.Context, delegationID string) error {
+	if err := uuid.Validate(delegationID); err != nil {
+		return fmt.Errorf("revoke delegation: %w", Errorf(ErrInvalidInput, "delegation ID %q is not a UUID", delegationID))
+	}
+	if err := s.repo.DeleteDelegation(ctx, delegationID); err != nil {
+		return fmt.Errorf("revoke delegation: %w", err)
+	}
+	return nil
+}
+
+// GetDelegations returns the Delegations by or to the user which have not
+// ended, every user's when userID is empty, by when they start.
+func (s *Service) GetDelegations(ctx context.Context, userID string) (Delegations, error) {
+	if userID != "" {
+		if err := ValidateUserID(userID); err != nil {
+			return nil, fmt.Errorf("get delegations: %w", err)
+		}
+	}
+	delegations, err := s.repo.GetDelegations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get delegations: %w", err)
+	}
+	return delegations, nil
+}
+
+// holdings returns what the user in the context holds themselves, their role
+// assignments, all of them active, and extra and revoked permissions, limited
+// to the resource types when any are given. Their delegated permissions and
+// resource grants are left out, neither can be delegated on.
+func (s *Service) holdings(ctx context.Context, roleMap TenantRoleMap, resources []string) (*ForUser, error) {
+	holder := &ForUser{RoleMap: roleMap}
+
+	eg, ctxEg := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		assignments, err := s.repo.GetUserRoleAssignments(ctxEg)
+		if err != nil {
+			return err
+		}
+		holder.RoleAssignments = assignments
+		holder.Roles = assignments.Roles()
+		return nil
+	})
+	eg.Go(func() error {
+		ex, rv, err := s.repo.GetUserPermissionsExtraAndRevoked(ctxEg, resources)
+		if err != nil {
+			return err
+		}
+		holder.ExtraPermissions, holder.RevokedPermissions = ex, rv
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return holder, nil
+}
+
+// delegated returns the permissions the Delegations grant, limited to the
+// resource types when any are given, as far as each delegator still holds them.
+func (s *Service) delegated(ctx context.Context, delegations Delegations, roleMap TenantRoleMap, resources []string) (DelegatedPermissions, error) {
+	if len(delegations) == 0 {
+		return nil, nil
+	}
+	tenantPermissions, err := s.repo.GetTenantPermissions(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make(map[string]*ForUser)
+	var delegated DelegatedPermissions
+	for _, d := range delegations {
+		holder, ok := holders[d.DelegatorID]
+		if !ok {
+			if holder, err = s.holdings(contextkey.WithUserID(ctx, d.DelegatorID), roleMap, resources); err != nil {
+				return nil, fmt.Errorf("delegator %s: %w", d.DelegatorID, err)
+			}
+			holders[d.DelegatorID] = holder
+		}
+		delegated = append(delegated, delegatedPermissions(d, holder, tenantPermissions)...)
+	}
+	return delegated, nil
+}
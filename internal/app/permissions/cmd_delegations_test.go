@@ -0,0 +1,130 @@
+//go:build test
+// +build test
+
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegations(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	roleAdmin := permissions.Role{Name: "admin", ID: "550e8400-e29b-41d4-a716-446655440000"}
+	roleSalesManager := permissions.Role{Name: "sales manager", ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479"}
+
+	delegated := func(t *testing.T) []string {
+		t.Helper()
+		forUser, err := svc.GetForUser(contextkey.WithUserID(ctx, userSalesPerson), nil)
+		require.NoError(t, err)
+		var names []string
+		for _, dp := range forUser.DelegatedPermissions {
+			names = append(names, dp.Role+"/"+dp.Permission.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	t.Run("Only what the delegator holds can be delegated", func(t *testing.T) {
+		for _, d := range []permissions.Delegation{
+			{Roles: permissions.Roles{roleAdmin}},
+			{Permissions: permissions.Permissions{{Name: "products:disable"}}},
+		} {
+			d.DelegatorID, d.DelegateID, d.EndsAt = userSalesManager, userSalesPerson, time.Now().Add(time.Hour)
+			_, err := svc.CreateDelegation(ctx, d)
+			assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+		}
+	})
+
+	delegation, err := svc.CreateDelegation(ctx, permissions.Delegation{
+		DelegatorID: userSalesManager,
+		DelegateID:  userSalesPerson,
+		Roles:       permissions.Roles{{ID: roleSalesManager.ID}},
+		Permissions: permissions.Permissions{{Name: "products:update"}},
+		Reason:      "annual leave",
+		EndsAt:      time.Now().Add(14 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, permissions.Roles{roleSalesManager}, delegation.Roles)
+	assert.Equal(t, "products:update", delegation.Permissions[0].Name)
+
+	t.Run("The delegate holds what is delegated, but not what is revoked from the delegator", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"/products:update",
+			"sales manager/invoices:create",
+			"sales manager/invoices:delete",
+			"sales manager/invoices:read",
+			"sales manager/products:create",
+			"sales manager/products:read",
+		}, delegated(t))
+
+		delegations, err := svc.GetDelegations(ctx, userSalesPerson)
+		require.NoError(t, err)
+		require.Len(t, delegations, 1)
+		assert.Equal(t, "annual leave", delegations[0].Reason)
+	})
+
+	t.Run("A role the delegator loses lapses", func(t *testing.T) {
+		require.NoError(t, svc.UnassignUserRoles(ctx, userSalesManager, []string{roleSalesManager.ID}))
+		assert.Equal(t, []string{"/products:update"}, delegated(t))
+	})
+
+	t.Run("A revoked delegation ends", func(t *testing.T) {
+		require.NoError(t, svc.RevokeDelegation(ctx, delegation.ID))
+		assert.Empty(t, delegated(t))
+
+		err := svc.RevokeDelegation(ctx, delegation.ID)
+		assert.True(t, errors.Is(err, permissions.ErrNotFound), err)
+	})
+}
+
+func TestDelegations_SoD(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	roleAdmin := permissions.Role{Name: "admin", ID: "550e8400-e29b-41d4-a716-446655440000"}
+	roleSalesManager := permissions.Role{Name: "sales manager", ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479"}
+
+	_, err := svc.CreateSoDRule(ctx, "administer or manage sales", permissions.SoDStatic, []string{roleAdmin.ID, roleSalesManager.ID}, nil)
+	require.NoError(t, err)
+
+	delegate := func(delegateID string) error {
+		_, err := svc.CreateDelegation(ctx, permissions.Delegation{
+			DelegatorID: userSalesManager,
+			DelegateID:  delegateID,
+			Roles:       permissions.Roles{{ID: roleSalesManager.ID}},
+			EndsAt:      time.Now().Add(time.Hour),
+		})
+		return err
+	}
+
+	t.Run("A delegation which would violate a static rule is rejected", func(t *testing.T) {
+		err := delegate(userAdmin)
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+
+		delegations, err := svc.GetDelegations(ctx, userAdmin)
+		require.NoError(t, err)
+		assert.Empty(t, delegations)
+	})
+
+	t.Run("What is delegated counts against later grants", func(t *testing.T) {
+		require.NoError(t, delegate(userSalesPerson))
+
+		err := svc.AssignUserRoles(ctx, userSalesPerson, []string{roleAdmin.ID})
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+	})
+}
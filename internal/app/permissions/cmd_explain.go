@@ -0,0 +1,130 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+)
+
+// Explain returns every derivation of the permission for the user in the context,
+// along with the final verdict.
+func (s *Service) Explain(ctx context.Context, permission string) (*Explanation, error) {
+	if err := ValidatePermissionName(permission); err != nil {
+		return nil, fmt.Errorf("explain: %w", err)
+	}
+	if (Permission{Name: permission}).IsWildcard() {
+		return nil, Errorf(ErrInvalidInput, "explain: permission %q must not be a wildcard", permission)
+	}
+
+	// Only the permissions for the requested resource, and wildcard resource grants, are relevant.
+	resources := []string{Permission{Name: permission}.Resource()}
+
+	forUser, err := s.GetForUser(ctx, resources)
+	if err != nil {
+		return nil, fmt.Errorf("explain: %w", err)
+	}
+
+	return explain(permission, forUser), nil
+}
+
+func explain(permission string, forUser *ForUser) *Explanation {
+	e := &Explanation{
+		Permission:  permission,
+		Verdict:     VerdictDenied,
+		Derivations: make(Derivations, 0),
+	}
+
+	// Inherited roles are followed from the roles assigned to the user, or to their groups.
+	for _, ra := range forUser.RoleAssignments {
+		var group string
+		switch ra.Source {
+		case RoleSourceDirect:
+		case RoleSourceGroup:
+			group = ra.Via
+		default:
+			continue
+		}
+		for _, d := range roleDerivations(permission, forUser.RoleMap, Roles{ra.Role}) {
+			d.Group = group
+			e.Derivations = append(e.Derivations, d)
+		}
+	}
+	for _, ep := range forUser.ExtraPermissions {
+		if Permission(ep).Matches(permission) {
+			e.Derivations = append(e.Derivations, Derivation{Kind: DerivationExtra, Grant: Permission(ep)})
+		}
+	}
+	for _, dp := range forUser.DelegatedPermissions {
+		if Permission(dp.Permission).Matches(permission) {
+			e.Derivations = append(e.Derivations, Derivation{
+				Kind:          DerivationDelegated,
+				Grant:         Permission(dp.Permission),
+				DelegatorID:   dp.DelegatorID,
+				DelegatedRole: dp.Role,
+			})
+		}
+	}
+	for _, rp := range forUser.RevokedPermissions {
+		if Permission(rp).Matches(permission) {
+			e.Derivations = append(e.Derivations, Derivation{Kind: DerivationRevoked, Grant: Permission(rp)})
+		}
+	}
+	for _, rg := range forUser.ResourceGrants {
+		if rg.Grants(permission) {
+			resource := rg.Resource
+			e.Derivations = append(e.Derivations, Derivation{
+				Kind:          DerivationResource,
+				Grant:         rg.Permission,
+				Resource:      &resource,
+				InheritedFrom: rg.InheritedFrom,
+			})
+		}
+	}
+
+	switch forUser.Decide(permission, nil).Effect {
+	case EffectAllow:
+		e.Verdict = VerdictAllowed
+	case EffectConditional:
+		e.Verdict = VerdictConditional
+	}
+	if e.Verdict == VerdictDenied && e.hasKind(DerivationResource) {
+		e.Verdict = VerdictResources
+	}
+
+	return e
+}
+
+// roleDerivations walks the role hierarchy from the last role in the path,
+// returning a derivation for every role along the way which grants the permission.
+func roleDerivations(permission string, roleMap TenantRoleMap, path Roles) Derivations {
+	role := path[len(path)-1]
+	mapped := roleMap[role]
+
+	var derivations Derivations
+	for _, tp := range mapped.Permissions {
+		if Permission(tp).Matches(permission) {
+			derivations = append(derivations, Derivation{
+				Kind:     DerivationRole,
+				Grant:    Permission(tp),
+				RolePath: append(Roles(nil), path...),
+			})
+		}
+	}
+
+	for _, child := range mapped.Inherits {
+		if path.contains(child) {
+			continue // A cycle in the role hierarchy, the permissions have already been considered.
+		}
+		derivations = append(derivations, roleDerivations(permission, roleMap, append(path, child))...)
+	}
+
+	return derivations
+}
+
+func (e *Explanation) hasKind(kind DerivationKind) bool {
+	for _, d := range e.Derivations {
+		if d.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+//go:build test
+// +build test
+
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	var (
+		roleSalesManager = permissions.Role{Name: "sales manager", ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479"}
+		roleSalesPerson  = permissions.Role{Name: "sales person", ID: "123e4567-e89b-12d3-a456-426614174000"}
+		roleSalesAuditor = permissions.Role{Name: "sales auditor", ID: "da244750-f014-415c-b7b9-43ead3d8fa25"}
+	)
+
+	tests := []struct {
+		name       string
+		userID     string
+		permission string
+		want       *permissions.Explanation
+	}{
+		{
+			name:       "Inherited through two roles",
+			userID:     userSalesManager,
+			permission: "invoices:read",
+			want: &permissions.Explanation{
+				Permission: "invoices:read",
+				Verdict:    permissions.VerdictAllowed,
+				Derivations: permissions.Derivations{
+					{
+						Kind:     permissions.DerivationRole,
+						Grant:    permissions.Permission{Name: "invoices:read", ID: "8f20eca6-9859-4532-babb-65a528e1611e"},
+						RolePath: permissions.Roles{roleSalesManager, roleSalesPerson, roleSalesAuditor},
+					},
+				},
+			},
+		},
+		{
+			name:       "Resource grant only",
+			userID:     userSalesPerson,
+			permission: "invoices:delete",
+			want: &permissions.Explanation{
+				Permission: "invoices:delete",
+				Verdict:    permissions.VerdictResources,
+				Derivations: permissions.Derivations{
+					{
+						Kind:     permissions.DerivationResource,
+						Grant:    permissions.Permission{Name: "invoices:delete", ID: "41c21275-b7d5-4031-b551-b5e293b85319"},
+						Resource: &permissions.Resource{ID: "6b63b489-61cb-4087-8636-f10716bd724e", Type: "invoices"},
+					},
+				},
+			},
+		},
+		{
+			name:       "Wildcard extra overridden by revoke",
+			userID:     userReadOnly,
+			permission: "invoices:delete",
+			want: &permissions.Explanation{
+				Permission: "invoices:delete",
+				Verdict:    permissions.VerdictDenied,
+				Derivations: permissions.Derivations{
+					{Kind: permissions.DerivationExtra, Grant: permissions.Permission(permissionInvoicesAny)},
+					{Kind: permissions.DerivationRevoked, Grant: permissions.Permission(permissionInvoicesDelete)},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, tt.userID)
+
+			got, err := svc.Explain(ctx, tt.permission)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Invalid permission", func(t *testing.T) {
+		ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, userAdmin)
+
+		_, err := svc.Explain(ctx, "invoices:*")
+		assert.Error(t, err)
+	})
+}
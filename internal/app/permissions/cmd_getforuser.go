@@ -3,22 +3,73 @@ package permissions
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
 	"golang.org/x/sync/errgroup"
 )
 
 type ForUser struct {
 	Roles              Roles
+	RoleAssignments    RoleAssignments
 	RevokedPermissions UserRevokedPermissions
 	ExtraPermissions   UserExtraPermissions
-	Resources          Resources
-	RoleMap            TenantRoleMap
+	// DelegatedPermissions are the permissions delegated to the user which
+	// their delegators still hold, see Delegation.
+	DelegatedPermissions DelegatedPermissions
+	Resources            Resources
+	// ResourceGrants are the user's grants on single resources, including
+	// those inherited by the descendants of a granted resource.
+	ResourceGrants ResourceGrants
+	RoleMap        TenantRoleMap
 }
 
+// GetForUser returns the permissions of the user in the context, limited to the
+// resource types when any are given. The Tenant, user and resource types are
+// validated before the database is queried, and a resource type the Tenant does
+// not have is ErrNotFound. Only the roles active in the request are returned,
+// see contextkey.WithActiveRoles and the Tenant's dynamic SoDRules, along with
+// the permissions delegated to the user which their delegators still hold.
 func (s *Service) GetForUser(ctx context.Context, resources []string) (*ForUser, error) {
+	forUser, rules, err := s.getForUser(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+	activeRoles, _ := contextkey.ActiveRoles(ctx)
+	if err := forUser.activate(activeRoles, rules); err != nil {
+		return nil, fmt.Errorf("get for user: %w", err)
+	}
+	return forUser, nil
+}
+
+// getForUser returns the permissions of the user in the context as GetForUser
+// does, but with every role they hold active, and the Tenant's SoDRules which
+// decide the roles a request may have active.
+func (s *Service) getForUser(ctx context.Context, resources []string) (*ForUser, SoDRules, error) {
+	if err := validateIdentity(ctx); err != nil {
+		return nil, nil, err
+	}
+	for _, r := range resources {
+		if err := ValidateResourceType(r); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	eg, ctxEg := errgroup.WithContext(ctx)
 
+	if len(resources) > 0 {
+		eg.Go(func() error {
+			unknown, err := s.repo.GetUnknownResourceTypes(ctxEg, resources)
+			if err != nil {
+				return err
+			}
+			if len(unknown) > 0 {
+				return Errorf(ErrNotFound, "unknown resource types: %s", strings.Join(unknown, ", "))
+			}
+			return nil
+		})
+	}
+
 	chTenantRoleMap := make(chan TenantRoleMap, 1)
 	eg.Go(func() error {
 		trm, err := s.repo.GetTenantRoleMap(ctxEg, resources)
@@ -41,13 +92,13 @@ func (s *Service) GetForUser(ctx context.Context, resources []string) (*ForUser,
 		return nil
 	})
 
-	chRoles := make(chan Roles, 1)
+	chRoleAssignments := make(chan RoleAssignments, 1)
 	eg.Go(func() error {
-		roles, err := s.repo.GetUserRoles(ctxEg)
+		assignments, err := s.repo.GetUserRoleAssignments(ctxEg)
 		if err != nil {
 			return err
 		}
-		chRoles <- roles
+		chRoleAssignments <- assignments
 		return nil
 	})
 
@@ -61,21 +112,65 @@ func (s *Service) GetForUser(ctx context.Context, resources []string) (*ForUser,
 		return nil
 	})
 
+	chResourceGrants := make(chan ResourceGrants, 1)
+	eg.Go(func() error {
+		grants, err := s.repo.GetUserResourceGrants(ctxEg, resources)
+		if err != nil {
+			return err
+		}
+		chResourceGrants <- grants
+		return nil
+	})
+
+	chDelegations := make(chan Delegations, 1)
+	eg.Go(func() error {
+		delegations, err := s.repo.GetUserDelegations(ctxEg)
+		if err != nil {
+			return err
+		}
+		chDelegations <- delegations
+		return nil
+	})
+
+	chSoDRules := make(chan SoDRules, 1)
+	eg.Go(func() error {
+		rules, err := s.repo.GetSoDRules(ctxEg)
+		if err != nil {
+			return err
+		}
+		chSoDRules <- rules
+		return nil
+	})
+
 	if err := eg.Wait(); err != nil {
-		return nil, fmt.Errorf("get for user: %w", err)
+		return nil, nil, fmt.Errorf("get for user: %w", err)
 	}
 
 	close(chTenantRoleMap)
 	close(chUserExtraPermissions)
 	close(chUserRevokedPermissions)
-	close(chRoles)
+	close(chRoleAssignments)
 	close(chResources)
+	close(chResourceGrants)
+	close(chDelegations)
+	close(chSoDRules)
+
+	roleAssignments := <-chRoleAssignments
+	roleMap := <-chTenantRoleMap
+	delegated, err := s.delegated(ctx, <-chDelegations, roleMap, resources)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get for user: get delegated permissions: %w", err)
+	}
 
-	return &ForUser{
-		ExtraPermissions:   <-chUserExtraPermissions,
-		RevokedPermissions: <-chUserRevokedPermissions,
-		Resources:          <-chResources,
-		Roles:              <-chRoles,
-		RoleMap:            <-chTenantRoleMap,
-	}, nil
+	forUser := &ForUser{
+		ExtraPermissions:     <-chUserExtraPermissions,
+		RevokedPermissions:   <-chUserRevokedPermissions,
+		DelegatedPermissions: delegated,
+		Resources:            <-chResources,
+		ResourceGrants:       <-chResourceGrants,
+		Roles:                roleAssignments.Roles(),
+		RoleAssignments:      roleAssignments,
+		RoleMap:              roleMap,
+	}
+	return forUser, <-chSoDRules, nil
 }
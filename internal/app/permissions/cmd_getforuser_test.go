@@ -9,13 +9,23 @@ import (
 
 	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
 	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
 	userAdmin        = "032fb302-4aee-4a68-b426-0c6faf12081e"
 	userSalesManager = "652f4d18-dd3d-40c0-874e-cbe3566abccf"
 	userSalesPerson  = "2133479c-35a8-4a49-a682-2952d4772ecc"
+	userReadOnly     = "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42"
+)
+
+var (
+	roleReadOnly             = permissions.Role{Name: "read only", ID: "9a3c1e5f-6b2d-4e8a-a1f7-3c5b7d9e0f24"}
+	permissionAnyRead        = permissions.TenantPermission{Name: "*:read", ID: "0b8f6c3e-3f0a-4c57-9d0e-4d1a2f7c9e11"}
+	permissionInvoicesAny    = permissions.UserPermission{Name: "invoices:*", ID: "5d2e9a71-8c4b-4f3e-b6a0-7e1c2d3f4a52"}
+	permissionInvoicesDelete = permissions.UserPermission{Name: "invoices:delete", ID: "41c21275-b7d5-4031-b551-b5e293b85319"}
 )
 
 var expectedTenantRoleMapNoResources = permissions.TenantRoleMap{
@@ -55,6 +65,10 @@ var expectedTenantRoleMapNoResources = permissions.TenantRoleMap{
 			{Name: "sales person", ID: "123e4567-e89b-12d3-a456-426614174000"},
 		},
 	},
+	roleReadOnly: {
+		Permissions: permissions.TenantPermissions{permissionAnyRead},
+		Inherits:    nil,
+	},
 }
 
 var testExpectations_NoResourcesInRequest = map[string]permissions.ForUser{
@@ -96,6 +110,16 @@ var testExpectations_NoResourcesInRequest = map[string]permissions.ForUser{
 		RevokedPermissions: permissions.UserRevokedPermissions{},
 		ExtraPermissions:   permissions.UserExtraPermissions{},
 	},
+	userReadOnly: {
+		RoleMap: expectedTenantRoleMapNoResources,
+		Roles: permissions.Roles{
+			roleReadOnly,
+			{Name: "sales auditor", ID: "da244750-f014-415c-b7b9-43ead3d8fa25"},
+		},
+		Resources:          nil,
+		RevokedPermissions: permissions.UserRevokedPermissions{permissionInvoicesDelete},
+		ExtraPermissions:   permissions.UserExtraPermissions{permissionInvoicesAny},
+	},
 }
 
 var expectedTenantRoleMapProductsResource = permissions.TenantRoleMap{
@@ -127,6 +151,10 @@ var expectedTenantRoleMapProductsResource = permissions.TenantRoleMap{
 			{Name: "sales person", ID: "123e4567-e89b-12d3-a456-426614174000"},
 		},
 	},
+	roleReadOnly: {
+		Permissions: permissions.TenantPermissions{permissionAnyRead},
+		Inherits:    nil,
+	},
 }
 
 var testExpectations_WithProductsResourceInRequest = map[string]permissions.ForUser{
@@ -166,6 +194,16 @@ var testExpectations_WithProductsResourceInRequest = map[string]permissions.ForU
 		RevokedPermissions: permissions.UserRevokedPermissions{},
 		ExtraPermissions:   permissions.UserExtraPermissions{},
 	},
+	userReadOnly: {
+		RoleMap: expectedTenantRoleMapProductsResource,
+		Roles: permissions.Roles{
+			roleReadOnly,
+			{Name: "sales auditor", ID: "da244750-f014-415c-b7b9-43ead3d8fa25"},
+		},
+		Resources:          nil,
+		RevokedPermissions: permissions.UserRevokedPermissions{},
+		ExtraPermissions:   permissions.UserExtraPermissions{},
+	},
 }
 
 var expectedTenantRoleMapInvoicesResource = permissions.TenantRoleMap{
@@ -199,6 +237,10 @@ var expectedTenantRoleMapInvoicesResource = permissions.TenantRoleMap{
 			{Name: "sales person", ID: "123e4567-e89b-12d3-a456-426614174000"},
 		},
 	},
+	roleReadOnly: {
+		Permissions: permissions.TenantPermissions{permissionAnyRead},
+		Inherits:    nil,
+	},
 }
 
 var testExpectations_WithInvoicesResourceInRequest = map[string]permissions.ForUser{
@@ -235,6 +277,16 @@ var testExpectations_WithInvoicesResourceInRequest = map[string]permissions.ForU
 		RevokedPermissions: permissions.UserRevokedPermissions{},
 		ExtraPermissions:   permissions.UserExtraPermissions{},
 	},
+	userReadOnly: {
+		RoleMap: expectedTenantRoleMapInvoicesResource,
+		Roles: permissions.Roles{
+			roleReadOnly,
+			{Name: "sales auditor", ID: "da244750-f014-415c-b7b9-43ead3d8fa25"},
+		},
+		Resources:          nil,
+		RevokedPermissions: permissions.UserRevokedPermissions{permissionInvoicesDelete},
+		ExtraPermissions:   permissions.UserExtraPermissions{permissionInvoicesAny},
+	},
 }
 
 func TestGetForUser_LambdaGetUserPermissions(t *testing.T) {
@@ -299,3 +351,83 @@ func TestGetForUser_LambdaGetUserPermissions(t *testing.T) {
 		}
 	})
 }
+
+func TestGetForUser_Allows(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	tests := []struct {
+		userID     string
+		permission string
+		want       bool
+	}{
+		{userID: userSalesManager, permission: "invoices:read", want: true},     // Inherited from Sales Auditor.
+		{userID: userSalesManager, permission: "products:update", want: true},   // Extra.
+		{userID: userSalesManager, permission: "products:disable", want: false}, // Revoked.
+		{userID: userSalesPerson, permission: "invoices:delete", want: false},
+		{userID: userReadOnly, permission: "products:read", want: true},    // Wildcard role grant.
+		{userID: userReadOnly, permission: "products:create", want: false}, // Not covered by the wildcard.
+		{userID: userReadOnly, permission: "invoices:create", want: true},  // Wildcard extra grant.
+		{userID: userReadOnly, permission: "invoices:delete", want: false}, // Revoked despite the wildcard.
+	}
+	for _, tt := range tests {
+		ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, tt.userID)
+
+		fu, err := svc.GetForUser(ctx, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		assert.Equal(t, tt.want, fu.Allows(tt.permission), "user ID: %s, permission: %s", tt.userID, tt.permission)
+	}
+}
+
+func TestGetForUser_Conditions(t *testing.T) {
+	const userFieldAgent = "c3e5a7c9-1b3d-4f5a-9c7e-1b3d5f7a9c46"
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+	ctx = context.WithValue(ctx, contextkey.CtxKeyUserID, userFieldAgent)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	fu, err := svc.GetForUser(ctx, nil)
+	require.NoError(t, err)
+
+	contact := permissions.Resource{ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71", Type: "contacts"}
+	assert.EqualValues(t, permissions.UserExtraPermissions{
+		{Name: "customers:read", ID: "7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13", Condition: "request.hour >= 9 && request.hour < 17"},
+	}, fu.ExtraPermissions)
+	assert.Equal(t, permissions.ResourceGrants{
+		{
+			Resource:   contact,
+			Permission: permissions.Permission{Name: "contacts:read", ID: "b4d6f8a0-1c3e-4a5b-9d7f-0e2a4c6e8f35", Condition: `user.region == "north"`},
+		},
+	}, fu.ResourceGrants)
+
+	assert.False(t, fu.Allows("customers:read"))
+	assert.Equal(t, permissions.EffectConditional, fu.Decide("customers:read", nil).Effect)
+	assert.Equal(t, permissions.EffectAllow, fu.Decide("customers:read", condition.Attributes{"request.hour": 10}).Effect)
+	assert.Equal(t, permissions.EffectDeny, fu.Decide("customers:read", condition.Attributes{"request.hour": 22}).Effect)
+	assert.Equal(t, permissions.EffectAllow, fu.DecideOn("contacts:read", contact, condition.Attributes{"user.region": "north"}).Effect)
+	assert.Equal(t, permissions.EffectDeny, fu.DecideOn("contacts:read", contact, condition.Attributes{"user.region": "south"}).Effect)
+}
+
+func TestGetForUser_Errors(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	// A malformed user ID is rejected before it reaches a query.
+	_, err := svc.GetForUser(context.WithValue(ctx, contextkey.CtxKeyUserID, "not-a-uuid"), nil)
+	assert.ErrorIs(t, err, permissions.ErrInvalidInput)
+
+	ctx = context.WithValue(ctx, contextkey.CtxKeyUserID, userSalesManager)
+	_, err = svc.GetForUser(ctx, []string{"invoices", "unknown"})
+	assert.ErrorIs(t, err, permissions.ErrNotFound)
+	message, _ := permissions.Message(err)
+	assert.Equal(t, "unknown resource types: unknown", message)
+}
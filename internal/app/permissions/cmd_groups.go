@@ -0,0 +1,86 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CreateGroup creates a new, empty, group in the Tenant.
+func (s *Service) CreateGroup(ctx context.Context, name string) (*Group, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("create group: name is required")
+	}
+
+	group := Group{
+		ID:   uuid.NewString(),
+		Name: name,
+	}
+	if err := s.repo.CreateGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+	return &group, nil
+}
+
+// DeleteGroup deletes the group, its members lose any roles they held through it.
+func (s *Service) DeleteGroup(ctx context.Context, groupID string) error {
+	if err := s.repo.DeleteGroup(ctx, groupID); err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	return nil
+}
+
+// AddGroupMembers adds the users to the group, users already in the group are ignored.
+func (s *Service) AddGroupMembers(ctx context.Context, groupID string, userIDs []string) error {
+	if err := s.repo.AddGroupMembers(ctx, groupID, userIDs); err != nil {
+		return fmt.Errorf("add group members: %w", err)
+	}
+	return nil
+}
+
+// RemoveGroupMembers removes the users from the group.
+func (s *Service) RemoveGroupMembers(ctx context.Context, groupID string, userIDs []string) error {
+	if err := s.repo.RemoveGroupMembers(ctx, groupID, userIDs); err != nil {
+		return fmt.Errorf("remove group members: %w", err)
+	}
+	return nil
+}
+
+// AssignGroupRoles assigns the roles to the group, roles already assigned are ignored.
+func (s *Service) AssignGroupRoles(ctx context.Context, groupID string, roleIDs []string) error {
+	if err := s.repo.AddGroupRoles(ctx, groupID, roleIDs); err != nil {
+		return fmt.Errorf("assign group roles: %w", err)
+	}
+	return nil
+}
+
+// UnassignGroupRoles removes the roles from the group.
+func (s *Service) UnassignGroupRoles(ctx context.Context, groupID string, roleIDs []string) error {
+	if err := s.repo.RemoveGroupRoles(ctx, groupID, roleIDs); err != nil {
+		return fmt.Errorf("unassign group roles: %w", err)
+	}
+	return nil
+}
+
+// NestGroup nests the child group within the parent group, so members of the
+// child group also hold the roles of the parent group.
+func (s *Service) NestGroup(ctx context.Context, parentGroupID, childGroupID string) error {
+	if parentGroupID == childGroupID {
+		return fmt.Errorf("nest group: a group cannot be nested within itself")
+	}
+	if err := s.repo.NestGroup(ctx, parentGroupID, childGroupID); err != nil {
+		return fmt.Errorf("nest group: %w", err)
+	}
+	return nil
+}
+
+// UnnestGroup removes the child group from the parent group.
+func (s *Service) UnnestGroup(ctx context.Context, parentGroupID, childGroupID string) error {
+	if err := s.repo.UnnestGroup(ctx, parentGroupID, childGroupID); err != nil {
+		return fmt.Errorf("unnest group: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+//go:build test
+// +build test
+
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	groupSalesTeam      = "3f6b1d2c-8e4a-4c7b-9a1e-5d2c7b8e9f10"
+	groupNorthSalesTeam = "a8c4e2f1-7b3d-4e9a-8c6b-1d5f3a7e9c21"
+)
+
+func TestGroups(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	roleSalesAuditor := permissions.Role{Name: "sales auditor", ID: "da244750-f014-415c-b7b9-43ead3d8fa25"}
+	roleSalesPerson := permissions.Role{Name: "sales person", ID: "123e4567-e89b-12d3-a456-426614174000"}
+
+	t.Run("Roles from nested groups", func(t *testing.T) {
+		ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, userReadOnly)
+
+		fu, err := svc.GetForUser(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, permissions.RoleAssignments{
+			{Role: roleReadOnly, Source: permissions.RoleSourceDirect},
+			{Role: roleSalesAuditor, Source: permissions.RoleSourceGroup, Via: "sales team"},
+		}, fu.RoleAssignments)
+	})
+
+	t.Run("Onboard a team", func(t *testing.T) {
+		newStarters := []string{
+			"0c5d7e9f-1a3b-4c5d-8e7f-9a1b3c5d7e90",
+			"1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01",
+		}
+
+		group, err := svc.CreateGroup(ctx, "sales starters")
+		require.NoError(t, err)
+		require.NoError(t, svc.AssignGroupRoles(ctx, group.ID, []string{roleSalesPerson.ID}))
+		require.NoError(t, svc.AddGroupMembers(ctx, group.ID, newStarters))
+		// Adding the same members again is ignored.
+		require.NoError(t, svc.AddGroupMembers(ctx, group.ID, newStarters))
+
+		for _, userID := range newStarters {
+			ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, userID)
+
+			fu, err := svc.GetForUser(ctx, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, permissions.Roles{roleSalesPerson, roleSalesAuditor}, fu.Roles, "user ID: %s", userID)
+			assert.Equal(t, permissions.RoleAssignments{
+				{Role: roleSalesPerson, Source: permissions.RoleSourceGroup, Via: "sales starters"},
+				{Role: roleSalesAuditor, Source: permissions.RoleSourceInherited, Via: "sales person"},
+			}, fu.RoleAssignments, "user ID: %s", userID)
+		}
+
+		// Offboarding removes the roles held through the group.
+		require.NoError(t, svc.RemoveGroupMembers(ctx, group.ID, newStarters[:1]))
+
+		ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, newStarters[0])
+		fu, err := svc.GetForUser(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, fu.Roles)
+	})
+
+	t.Run("Nesting cycle rejected", func(t *testing.T) {
+		err := svc.NestGroup(ctx, groupNorthSalesTeam, groupSalesTeam)
+		assert.Error(t, err)
+	})
+}
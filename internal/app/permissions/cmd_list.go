@@ -0,0 +1,47 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+)
+
+// ListPermissions decides each of the Tenant's concrete permissions on the
+// resources, or all resources when empty, for the user against the attributes.
+// Permissions which are denied are left out, so every Decision is either
+// EffectAllow or EffectConditional.
+func (s *Service) ListPermissions(ctx context.Context, resources []string, attrs condition.Attributes) (Decisions, error) {
+	forUser, err := s.GetForUser(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+	tenantPermissions, err := s.repo.GetTenantPermissions(ctx, resources)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant permissions: %w", err)
+	}
+
+	decisions := make(Decisions, 0)
+	for _, tp := range tenantPermissions {
+		p := Permission(tp)
+		if p.IsWildcard() {
+			continue
+		}
+		if d := forUser.Decide(p.Name, attrs); d.Effect != EffectDeny {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, nil
+}
+
+// GetRoleAssignments returns the roles the user holds and how they came to hold each.
+func (s *Service) GetRoleAssignments(ctx context.Context) (RoleAssignments, error) {
+	if err := validateIdentity(ctx); err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.GetUserRoleAssignments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get role assignments: %w", err)
+	}
+	return assignments, nil
+}
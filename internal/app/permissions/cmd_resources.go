@@ -0,0 +1,48 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+)
+
+// AddResourceTypeChild makes resources of the child type able to belong to resources of the parent type.
+func (s *Service) AddResourceTypeChild(ctx context.Context, parentType, childType string) error {
+	if parentType == childType {
+		return fmt.Errorf("add resource type child: a resource type cannot belong to itself")
+	}
+	if err := s.repo.AddResourceTypeChild(ctx, parentType, childType); err != nil {
+		return fmt.Errorf("add resource type child: %w", err)
+	}
+	return nil
+}
+
+// RemoveResourceTypeChild removes the child type from the parent type, along
+// with every resource of the child type belonging to a resource of the parent type.
+func (s *Service) RemoveResourceTypeChild(ctx context.Context, parentType, childType string) error {
+	if err := s.repo.RemoveResourceTypeChild(ctx, parentType, childType); err != nil {
+		return fmt.Errorf("remove resource type child: %w", err)
+	}
+	return nil
+}
+
+// AddResourceChildren makes the children belong to the parent, so grants on the
+// parent apply to them. Each child's type must belong to the parent's type.
+func (s *Service) AddResourceChildren(ctx context.Context, parent Resource, children Resources) error {
+	for _, child := range children {
+		if child.Is(parent) {
+			return fmt.Errorf("add resource children: a resource cannot belong to itself")
+		}
+	}
+	if err := s.repo.AddResourceChildren(ctx, parent, children); err != nil {
+		return fmt.Errorf("add resource children: %w", err)
+	}
+	return nil
+}
+
+// RemoveResourceChildren removes the children from the parent.
+func (s *Service) RemoveResourceChildren(ctx context.Context, parent Resource, children Resources) error {
+	if err := s.repo.RemoveResourceChildren(ctx, parent, children); err != nil {
+		return fmt.Errorf("remove resource children: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+//go:build test
+// +build test
+
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userAccountManager = "8e0a2c4e-6f1b-4d3a-b5c7-9d1f3b5d7e64"
+
+var (
+	resourceCustomer        = permissions.Resource{ID: "d2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92", Type: "customers"}
+	resourceContact1        = permissions.Resource{ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71", Type: "contacts"}
+	resourceContact2        = permissions.Resource{ID: "5a7c9e1b-3d5f-4a7c-9e1b-3d5f7a9c1e28", Type: "contacts"}
+	permissionCustomersRead = permissions.Permission{Name: "customers:read", ID: "7c1e3a5b-9d2f-4b6e-8a0c-2e4f6a8b0d13"}
+)
+
+func TestResourceHierarchy(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+	ctx = context.WithValue(ctx, contextkey.CtxKeyUserID, userAccountManager)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	t.Run("Grants are inherited by descendants", func(t *testing.T) {
+		fu, err := svc.GetForUser(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, permissions.ResourceGrants{
+			{Resource: resourceCustomer, Permission: permissionCustomersRead},
+			{Resource: resourceContact1, Permission: permissionCustomersRead, InheritedFrom: &resourceCustomer},
+			{Resource: resourceContact2, Permission: permissionCustomersRead, InheritedFrom: &resourceCustomer},
+		}, fu.ResourceGrants)
+		assert.True(t, fu.AllowsOn("contacts:read", resourceContact1))
+		assert.False(t, fu.AllowsOn("contacts:delete", resourceContact1))
+	})
+
+	t.Run("Resource types filter the descendants", func(t *testing.T) {
+		fu, err := svc.GetForUser(ctx, []string{"contacts"})
+		require.NoError(t, err)
+
+		assert.Equal(t, permissions.ResourceGrants{
+			{Resource: resourceContact1, Permission: permissionCustomersRead, InheritedFrom: &resourceCustomer},
+			{Resource: resourceContact2, Permission: permissionCustomersRead, InheritedFrom: &resourceCustomer},
+		}, fu.ResourceGrants)
+	})
+
+	t.Run("Removed children no longer inherit", func(t *testing.T) {
+		err := svc.RemoveResourceChildren(ctx, resourceCustomer, permissions.Resources{resourceContact2})
+		require.NoError(t, err)
+
+		fu, err := svc.GetForUser(ctx, []string{"contacts"})
+		require.NoError(t, err)
+		assert.False(t, fu.AllowsOn("contacts:read", resourceContact2))
+	})
+
+	t.Run("Children must be of a child resource type", func(t *testing.T) {
+		invoice := permissions.Resource{ID: "6b63b489-61cb-4087-8636-f10716bd724e", Type: "invoices"}
+		err := svc.AddResourceChildren(ctx, resourceCustomer, permissions.Resources{invoice})
+		assert.ErrorContains(t, err, "does not belong to resource type customers")
+
+		require.NoError(t, svc.AddResourceTypeChild(ctx, "customers", "invoices"))
+		require.NoError(t, svc.AddResourceChildren(ctx, resourceCustomer, permissions.Resources{invoice}))
+
+		fu, err := svc.GetForUser(ctx, []string{"invoices"})
+		require.NoError(t, err)
+		assert.True(t, fu.AllowsOn("invoices:read", invoice))
+	})
+
+	t.Run("Cycles are rejected", func(t *testing.T) {
+		err := svc.AddResourceTypeChild(ctx, "contacts", "customers")
+		assert.ErrorContains(t, err, "already contains")
+	})
+}
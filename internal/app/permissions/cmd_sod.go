@@ -0,0 +1,112 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CreateSoDRule creates a separation of duties rule of the roles, or of the
+// permissions, of which no user may hold two, see SoDRule. Users who already
+// hold two are not changed, ScanSoDViolations reports them, but a static rule
+// rejects any later grant which would leave another user holding two.
+func (s *Service) CreateSoDRule(ctx context.Context, name string, enforcement SoDEnforcement, roleIDs, permissionNames []string) (*SoDRule, error) {
+	rule := SoDRule{
+		ID:          uuid.NewString(),
+		Name:        strings.TrimSpace(name),
+		Enforcement: enforcement,
+	}
+	for _, id := range roleIDs {
+		if err := uuid.Validate(id); err != nil {
+			return nil, fmt.Errorf("create sod rule: %w", Errorf(ErrInvalidInput, "role ID %q is not a UUID", id))
+		}
+		if !slices.ContainsFunc(rule.Roles, func(r Role) bool { return r.ID == id }) {
+			rule.Roles = append(rule.Roles, Role{ID: id})
+		}
+	}
+	for _, p := range permissionNames {
+		if err := validateSoDPermission(p); err != nil {
+			return nil, fmt.Errorf("create sod rule: %w", err)
+		}
+		if !slices.ContainsFunc(rule.Permissions, func(rp string) bool { return strings.EqualFold(rp, p) }) {
+			rule.Permissions = append(rule.Permissions, p)
+		}
+	}
+	if err := validateSoDRule(rule); err != nil {
+		return nil, fmt.Errorf("create sod rule: %w", err)
+	}
+
+	if err := s.repo.CreateSoDRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("create sod rule: %w", err)
+	}
+	rules, err := s.repo.GetSoDRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create sod rule: %w", err)
+	}
+	i := slices.IndexFunc(rules, func(r SoDRule) bool { return r.ID == rule.ID })
+	if i < 0 {
+		return nil, fmt.Errorf("create sod rule: rule %s not found once created", rule.ID)
+	}
+	return &rules[i], nil
+}
+
+// DeleteSoDRule deletes the rule, the grants it rejected may then be made.
+func (s *Service) DeleteSoDRule(ctx context.Context, ruleID string) error {
+	if err := uuid.Validate(ruleID); err != nil {
+		return fmt.Errorf("delete sod rule: %w", Errorf(ErrInvalidInput, "rule ID %q is not a UUID", ruleID))
+	}
+	if err := s.repo.DeleteSoDRule(ctx, ruleID); err != nil {
+		return fmt.Errorf("delete sod rule: %w", err)
+	}
+	return nil
+}
+
+// GetSoDRules returns the Tenant's separation of duties rules, by name.
+func (s *Service) GetSoDRules(ctx context.Context) (SoDRules, error) {
+	rules, err := s.repo.GetSoDRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get sod rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ScanSoDViolations returns the users holding more than one member of a static
+// rule, such as those who held them before the rule was created, by rule then user.
+func (s *Service) ScanSoDViolations(ctx context.Context) ([]SoDViolation, error) {
+	violations, err := s.repo.GetSoDViolations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("scan sod violations: %w", err)
+	}
+	return violations, nil
+}
+
+func validateSoDRule(rule SoDRule) error {
+	switch {
+	case rule.Name == "":
+		return Errorf(ErrInvalidInput, "name is required")
+	case rule.Enforcement != SoDStatic && rule.Enforcement != SoDDynamic:
+		return Errorf(ErrInvalidInput, "enforcement %q is not %q or %q", rule.Enforcement, SoDStatic, SoDDynamic)
+	case len(rule.Roles) > 0 && len(rule.Permissions) > 0:
+		return Errorf(ErrInvalidInput, "a rule separates roles or permissions, not both")
+	case len(rule.Roles) == 1 || len(rule.Permissions) == 1 || len(rule.Roles)+len(rule.Permissions) == 0:
+		return Errorf(ErrInvalidInput, "a rule separates at least two roles or permissions")
+	case rule.Enforcement == SoDDynamic && len(rule.Permissions) > 0:
+		return Errorf(ErrInvalidInput, "only roles may be separated dynamically")
+	}
+	return nil
+}
+
+// validateSoDPermission checks the name is of a single permission, a rule of a
+// wildcard would separate every permission it covers from each other.
+func validateSoDPermission(name string) error {
+	if err := ValidatePermissionName(name); err != nil {
+		return err
+	}
+	if strings.Contains(name, Wildcard) {
+		return Errorf(ErrInvalidInput, "permission %q is a wildcard, a rule separates single permissions", name)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+//go:build test
+// +build test
+
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoD(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	roleAdmin := permissions.Role{Name: "admin", ID: "550e8400-e29b-41d4-a716-446655440000"}
+	roleSalesPerson := permissions.Role{Name: "sales person", ID: "123e4567-e89b-12d3-a456-426614174000"}
+	roleSalesAuditor := permissions.Role{Name: "sales auditor", ID: "da244750-f014-415c-b7b9-43ead3d8fa25"}
+
+	t.Run("Scan reports who already holds both", func(t *testing.T) {
+		_, err := svc.CreateSoDRule(ctx, "raise or remove invoices", permissions.SoDStatic, nil, []string{"invoices:create", "invoices:delete"})
+		require.NoError(t, err)
+
+		violations, err := svc.ScanSoDViolations(ctx)
+		require.NoError(t, err)
+
+		// The sales manager inherits invoices:create, the read only user's
+		// invoices:* is revoked invoices:delete.
+		var userIDs []string
+		for _, v := range violations {
+			assert.Equal(t, []string{"invoices:create", "invoices:delete"}, v.Held)
+			userIDs = append(userIDs, v.UserID)
+		}
+		assert.Equal(t, []string{userAdmin, userSalesManager}, userIDs)
+	})
+
+	rule, err := svc.CreateSoDRule(ctx, "administer or sell", permissions.SoDStatic, []string{roleAdmin.ID, roleSalesPerson.ID}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, permissions.Roles{roleAdmin, roleSalesPerson}, rule.Roles)
+
+	t.Run("Grants which would violate a static rule are rejected", func(t *testing.T) {
+		err := svc.AssignUserRoles(ctx, userAdmin, []string{roleSalesPerson.ID})
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+
+		// The sales manager holds sales person by inheritance.
+		err = svc.AssignUserRoles(ctx, userSalesManager, []string{roleAdmin.ID})
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+
+		group, err := svc.CreateGroup(ctx, "administrators")
+		require.NoError(t, err)
+		require.NoError(t, svc.AssignGroupRoles(ctx, group.ID, []string{roleAdmin.ID}))
+		err = svc.AddGroupMembers(ctx, group.ID, []string{userSalesPerson})
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+
+		// The members of a nested group gain the roles of the group it is nested in.
+		sellers, err := svc.CreateGroup(ctx, "sellers")
+		require.NoError(t, err)
+		require.NoError(t, svc.AddGroupMembers(ctx, sellers.ID, []string{userSalesPerson}))
+		err = svc.NestGroup(ctx, group.ID, sellers.ID)
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+
+		fu, err := svc.GetForUser(context.WithValue(ctx, contextkey.CtxKeyUserID, userSalesPerson), nil)
+		require.NoError(t, err)
+		assert.Equal(t, permissions.Roles{roleSalesPerson, roleSalesAuditor}, fu.Roles, "the rejected grant is not made")
+	})
+
+	t.Run("Existing violations do not block unrelated grants", func(t *testing.T) {
+		require.NoError(t, svc.AssignUserRoles(ctx, userAdmin, []string{roleReadOnly.ID}))
+	})
+
+	t.Run("Dynamic rules limit the active roles", func(t *testing.T) {
+		_, err := svc.CreateSoDRule(ctx, "administer or browse", permissions.SoDDynamic, []string{roleAdmin.ID, roleReadOnly.ID}, nil)
+		require.NoError(t, err)
+		ctx := context.WithValue(ctx, contextkey.CtxKeyUserID, userAdmin)
+
+		fu, err := svc.GetForUser(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, fu.Roles, "neither role is active unless named")
+
+		fu, err = svc.GetForUser(contextkey.WithActiveRoles(ctx, []string{"admin"}), nil)
+		require.NoError(t, err)
+		assert.Equal(t, permissions.Roles{roleAdmin}, fu.Roles)
+		assert.True(t, fu.Allows("invoices:delete"))
+
+		_, err = svc.GetForUser(contextkey.WithActiveRoles(ctx, []string{"admin", "read only"}), nil)
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+	})
+
+	t.Run("Rules are validated", func(t *testing.T) {
+		_, err := svc.CreateSoDRule(ctx, "administer or sell", permissions.SoDStatic, []string{roleAdmin.ID, roleReadOnly.ID}, nil)
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+
+		_, err = svc.CreateSoDRule(ctx, "unknown", permissions.SoDStatic, []string{roleAdmin.ID, "0c5d7e9f-1a3b-4c5d-8e7f-9a1b3c5d7e90"}, nil)
+		assert.True(t, errors.Is(err, permissions.ErrNotFound), err)
+
+		_, err = svc.CreateSoDRule(ctx, "wildcard", permissions.SoDStatic, nil, []string{"invoices:*", "products:read"})
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+
+		_, err = svc.CreateSoDRule(ctx, "dynamic permissions", permissions.SoDDynamic, nil, []string{"invoices:read", "products:read"})
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+	})
+
+	t.Run("Deleting a rule allows its grants", func(t *testing.T) {
+		require.NoError(t, svc.DeleteSoDRule(ctx, rule.ID))
+		require.NoError(t, svc.AssignUserRoles(ctx, userAdmin, []string{roleSalesPerson.ID}))
+
+		rules, err := svc.GetSoDRules(ctx)
+		require.NoError(t, err)
+		var names []string
+		for _, r := range rules {
+			names = append(names, r.Name)
+		}
+		assert.Equal(t, []string{"administer or browse", "raise or remove invoices"}, names)
+	})
+}
@@ -0,0 +1,30 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+)
+
+// AssignUserRoles assigns the roles directly to the user, roles already assigned
+// without an expiry are ignored.
+func (s *Service) AssignUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	if err := ValidateUserID(userID); err != nil {
+		return fmt.Errorf("assign user roles: %w", err)
+	}
+	if err := s.repo.AddUserRoles(ctx, userID, roleIDs); err != nil {
+		return fmt.Errorf("assign user roles: %w", err)
+	}
+	return nil
+}
+
+// UnassignUserRoles removes the roles assigned directly to the user, the user
+// keeps any of them they hold through a group.
+func (s *Service) UnassignUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	if err := ValidateUserID(userID); err != nil {
+		return fmt.Errorf("unassign user roles: %w", err)
+	}
+	if err := s.repo.RemoveUserRoles(ctx, userID, roleIDs); err != nil {
+		return fmt.Errorf("unassign user roles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// whoCanBatchSize is the number of candidate users read at a time by WhoCan.
+	whoCanBatchSize = 100
+	// whoCanConcurrency is the number of candidate users WhoCan evaluates at once.
+	whoCanConcurrency = 8
+)
+
+// WhoCan returns the users who may perform the permission's action on the
+// resource, which is of the permission's resource type, in order of user ID.
+// Users are evaluated as GetForUser and DecideOn would, so role inheritance,
+// groups, extra and revoked permissions, and grants on the resource or its
+// ancestors all count. Users whose permission is conditional are included,
+// with the condition in their Decision. Every role a user holds counts, and
+// those who may only once a request activates a role which a dynamic SoDRule
+// otherwise keeps inactive are included, with the roles in Activate.
+//
+// The users are read and evaluated a batch at a time as the sequence is
+// iterated, so large Tenants can be streamed. An error ends the sequence.
+func (s *Service) WhoCan(ctx context.Context, permission, resourceID string) iter.Seq2[Grantee, error] {
+	return func(yield func(Grantee, error) bool) {
+		resource, err := s.validateWhoCan(ctx, permission, resourceID)
+		if err != nil {
+			yield(Grantee{}, fmt.Errorf("who can: %w", err))
+			return
+		}
+
+		after := ""
+		for {
+			userIDs, err := s.repo.GetCandidateUsers(ctx, permission, resource, after, whoCanBatchSize)
+			if err != nil {
+				yield(Grantee{}, fmt.Errorf("who can: get candidate users: %w", err))
+				return
+			}
+
+			grantees, err := s.whoCan(ctx, permission, resource, userIDs)
+			if err != nil {
+				yield(Grantee{}, fmt.Errorf("who can: %w", err))
+				return
+			}
+			for _, g := range grantees {
+				if g != nil && !yield(*g, nil) {
+					return
+				}
+			}
+
+			if len(userIDs) < whoCanBatchSize {
+				return
+			}
+			after = userIDs[len(userIDs)-1]
+		}
+	}
+}
+
+func (s *Service) validateWhoCan(ctx context.Context, permission, resourceID string) (Resource, error) {
+	tenantID, _ := contextkey.TenantID(ctx)
+	if err := ValidateTenantID(tenantID); err != nil {
+		return Resource{}, err
+	}
+	if err := ValidatePermissionName(permission); err != nil {
+		return Resource{}, err
+	}
+	p := Permission{Name: permission}
+	if p.IsWildcard() {
+		return Resource{}, Errorf(ErrInvalidInput, "permission %q must not be a wildcard", permission)
+	}
+	if err := uuid.Validate(resourceID); err != nil {
+		return Resource{}, Errorf(ErrInvalidInput, "resource ID %q is not a UUID", resourceID)
+	}
+
+	resource := Resource{ID: resourceID, Type: p.Resource()}
+	unknown, err := s.repo.GetUnknownResourceTypes(ctx, []string{resource.Type})
+	if err != nil {
+		return Resource{}, err
+	}
+	if len(unknown) > 0 {
+		return Resource{}, Errorf(ErrNotFound, "unknown resource types: %s", strings.Join(unknown, ", "))
+	}
+	return resource, nil
+}
+
+// whoCan evaluates the candidate users concurrently, returning the grantee of
+// each in the same order, nil for those who may not.
+func (s *Service) whoCan(ctx context.Context, permission string, resource Resource, userIDs []string) ([]*Grantee, error) {
+	grantees := make([]*Grantee, len(userIDs))
+
+	eg, ctxEg := errgroup.WithContext(ctx)
+	eg.SetLimit(whoCanConcurrency)
+	for i, userID := range userIDs {
+		eg.Go(func() error {
+			forUser, rules, err := s.getForUser(contextkey.WithUserID(ctxEg, userID), []string{resource.Type})
+			if err != nil {
+				return fmt.Errorf("user %s: %w", userID, err)
+			}
+			decision := forUser.DecideOn(permission, resource, nil)
+			if decision.Effect == EffectDeny {
+				return nil
+			}
+
+			// Every role the user holds counts, but when the roles a request
+			// has active by default do not allow it, the user may only in a
+			// request which activates another.
+			var activate Roles
+			byDefault := *forUser
+			if err := byDefault.activate(nil, rules); err != nil {
+				return fmt.Errorf("user %s: %w", userID, err)
+			}
+			if byDefault.DecideOn(permission, resource, nil).Effect == EffectDeny {
+				if activate = forUser.activations(permission, resource, rules, byDefault.Roles); len(activate) == 0 {
+					return nil
+				}
+			}
+
+			// Only the grants on this resource, and those for every resource, are relevant.
+			derivations := make(Derivations, 0)
+			for _, d := range explain(permission, forUser).Derivations {
+				if d.Kind != DerivationResource || d.Resource.Is(resource) {
+					derivations = append(derivations, d)
+				}
+			}
+			grantees[i] = &Grantee{UserID: userID, Decision: decision, Derivations: derivations, Activate: activate}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return grantees, nil
+}
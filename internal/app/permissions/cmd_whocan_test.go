@@ -0,0 +1,89 @@
+//go:build test
+// +build test
+
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoCan(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, contextkey.CtxKeyTenantID, TestTenantID)
+
+	svc, _ := NewTestEnv(ctx, t)
+
+	const invoice = "6b63b489-61cb-4087-8636-f10716bd724e"
+
+	t.Run("Roles and resource grants, without revoked users", func(t *testing.T) {
+		var grantees []permissions.Grantee
+		for g, err := range svc.WhoCan(ctx, "invoices:delete", invoice) {
+			require.NoError(t, err)
+			grantees = append(grantees, g)
+		}
+
+		// The Read Only user's extra "invoices:*" is revoked for delete.
+		userIDs := make([]string, len(grantees))
+		for i, g := range grantees {
+			userIDs[i] = g.UserID
+			assert.Equal(t, permissions.EffectAllow, g.Decision.Effect)
+		}
+		assert.Equal(t, []string{userAdmin, userSalesPerson, userSalesManager}, userIDs)
+
+		assert.Equal(t, permissions.Derivations{
+			{
+				Kind:     permissions.DerivationResource,
+				Grant:    permissions.Permission{Name: "invoices:delete", ID: "41c21275-b7d5-4031-b551-b5e293b85319"},
+				Resource: &permissions.Resource{ID: invoice, Type: "invoices"},
+			},
+		}, grantees[1].Derivations)
+		assert.Equal(t, permissions.DerivationRole, grantees[2].Derivations[0].Kind)
+	})
+
+	t.Run("Grants on other resources do not count", func(t *testing.T) {
+		for g, err := range svc.WhoCan(ctx, "invoices:delete", "568104df-6ff3-40be-b660-91e3160aa7e6") {
+			require.NoError(t, err)
+			assert.NotEqual(t, userSalesPerson, g.UserID)
+		}
+	})
+
+	t.Run("Grants inherited from an ancestor", func(t *testing.T) {
+		var userIDs []string
+		for g, err := range svc.WhoCan(ctx, "contacts:read", "5a7c9e1b-3d5f-4a7c-9e1b-3d5f7a9c1e28") {
+			require.NoError(t, err)
+			userIDs = append(userIDs, g.UserID)
+		}
+		assert.Contains(t, userIDs, "8e0a2c4e-6f1b-4d3a-b5c7-9d1f3b5d7e64", "the Account Manager can read the customer's contacts")
+	})
+
+	t.Run("Stops when the caller does", func(t *testing.T) {
+		var n int
+		for range svc.WhoCan(ctx, "invoices:delete", invoice) {
+			n++
+			break
+		}
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		for _, tc := range []struct {
+			permission, resourceID string
+			kind                   error
+		}{
+			{"invoices:*", invoice, permissions.ErrInvalidInput},
+			{"invoices:delete", "6b63", permissions.ErrInvalidInput},
+			{"stables:delete", invoice, permissions.ErrNotFound},
+		} {
+			for _, err := range svc.WhoCan(ctx, tc.permission, tc.resourceID) {
+				assert.True(t, errors.Is(err, tc.kind), err)
+			}
+		}
+	})
+}
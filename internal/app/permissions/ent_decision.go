@@ -0,0 +1,24 @@
+package permissions
+
+type Effect string
+
+const (
+	// EffectAllow means the user holds the permission.
+	EffectAllow Effect = "allow"
+	// EffectDeny means the user does not hold the permission.
+	EffectDeny Effect = "deny"
+	// EffectConditional means the user holds the permission only if the Decision's
+	// Condition holds, because the attributes it references were not supplied.
+	EffectConditional Effect = "conditional"
+)
+
+// Decision is the outcome of checking a permission for a user against the request's attributes.
+type Decision struct {
+	Permission string
+	Effect     Effect
+	// Condition is the expression which must hold for the permission to be allowed,
+	// combining every undecided grant and revocation. Only set for EffectConditional.
+	Condition string
+}
+
+type Decisions []Decision
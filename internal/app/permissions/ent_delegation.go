@@ -0,0 +1,161 @@
+package permissions
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxDelegationDuration is the longest a Delegation may last.
+const MaxDelegationDuration = 90 * 24 * time.Hour
+
+type Delegations []Delegation
+
+// Delegation lends some of the delegator's roles or permissions to the
+// delegate for a time window, as when a manager on leave hands their approval
+// rights to a colleague. The delegate holds what is delegated only while the
+// delegator does, see DelegatedPermission.
+type Delegation struct {
+	ID          string
+	DelegatorID string
+	DelegateID  string
+	// Roles and Permissions are what is delegated, a Delegation has either or both.
+	// Permissions are single permissions, not wildcards.
+	Roles       Roles
+	Permissions Permissions
+	Reason      string
+	// StartsAt and EndsAt are the window in which the Delegation applies, it ends at EndsAt.
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedAt time.Time
+}
+
+// ActiveAt reports whether the Delegation applies at the time.
+func (d Delegation) ActiveAt(t time.Time) bool {
+	return !t.Before(d.StartsAt) && t.Before(d.EndsAt)
+}
+
+type DelegatedPermissions []DelegatedPermission
+
+func (dps DelegatedPermissions) StringSlice() []string {
+	names := make([]string, len(dps))
+	for i, dp := range dps {
+		names[i] = dp.Permission.String()
+	}
+	return names
+}
+
+// DelegatedPermission is a permission a user holds through a Delegation. It is
+// only held while the delegator holds it, and, when it was delegated through a
+// role, while the delegator holds the role, so it lapses as soon as the
+// delegator loses it. Its Permission is never a wildcard, and its Condition
+// combines those of the delegated role's grant and of the delegator's Decision.
+type DelegatedPermission struct {
+	Permission   UserPermission
+	DelegationID string
+	DelegatorID  string
+	// Role is the name of the delegated role granting the permission, empty
+	// when the permission itself was delegated.
+	Role   string
+	EndsAt time.Time
+}
+
+// delegatedPermissions returns the permissions the Delegation grants while the
+// delegator is holder: each delegated permission the holder is allowed, and
+// each of the Tenant's permissions granted by a delegated role the holder
+// holds, or by the roles it inherits, which the holder is also allowed.
+func delegatedPermissions(d Delegation, holder *ForUser, tenantPermissions TenantPermissions) DelegatedPermissions {
+	var delegated DelegatedPermissions
+	add := func(p Permission, role, cond string) {
+		decision := holder.Decide(p.Name, nil)
+		switch decision.Effect {
+		case EffectDeny:
+			return
+		case EffectConditional:
+			cond = and(cond, decision.Condition)
+		}
+		dp := DelegatedPermission{
+			Permission:   UserPermission{ID: p.ID, Name: p.Name, Condition: cond},
+			DelegationID: d.ID,
+			DelegatorID:  d.DelegatorID,
+			Role:         role,
+			EndsAt:       d.EndsAt,
+		}
+		if !slices.Contains(delegated, dp) {
+			delegated = append(delegated, dp)
+		}
+	}
+
+	for _, p := range d.Permissions {
+		add(p, "", "")
+	}
+	held := holder.RoleAssignments.Roles()
+	for _, role := range d.Roles {
+		i := slices.IndexFunc(held, func(r Role) bool { return r.ID == role.ID })
+		if i < 0 {
+			continue
+		}
+		role = held[i]
+		for _, granting := range holder.RoleMap.inheritedBy(role) {
+			for _, grant := range holder.RoleMap[granting].Permissions {
+				for _, tp := range tenantPermissions {
+					if p := Permission(tp); !p.IsWildcard() && Permission(grant).Matches(p.Name) {
+						add(p, role.Name, grant.Condition)
+					}
+				}
+			}
+		}
+	}
+	return delegated
+}
+
+// inheritedBy returns the role and the roles it inherits, directly or indirectly.
+func (trm TenantRoleMap) inheritedBy(role Role) Roles {
+	roles := Roles{role}
+	for i := 0; i < len(roles); i++ {
+		for _, child := range trm[roles[i]].Inherits {
+			if !roles.contains(child) {
+				roles = append(roles, child)
+			}
+		}
+	}
+	return roles
+}
+
+// and returns a condition which holds when both do, either may be empty.
+func and(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return "(" + a + ") && (" + b + ")"
+}
+
+// validateDelegation checks the Delegation is of at least one role or single
+// permission, to someone other than the delegator, for a window which has not
+// ended and lasts no longer than MaxDelegationDuration.
+func validateDelegation(d Delegation, now time.Time) error {
+	switch {
+	case d.DelegatorID == d.DelegateID:
+		return Errorf(ErrInvalidInput, "a user cannot delegate to themselves")
+	case len(d.Roles) == 0 && len(d.Permissions) == 0:
+		return Errorf(ErrInvalidInput, "a delegation is of at least one role or permission")
+	case !d.EndsAt.After(d.StartsAt):
+		return Errorf(ErrInvalidInput, "the delegation must end after it starts")
+	case !d.EndsAt.After(now):
+		return Errorf(ErrInvalidInput, "the delegation must end in the future")
+	case d.EndsAt.Sub(d.StartsAt) > MaxDelegationDuration:
+		return Errorf(ErrInvalidInput, "a delegation lasts at most %d days", MaxDelegationDuration/(24*time.Hour))
+	}
+	for _, p := range d.Permissions {
+		if err := ValidatePermissionName(p.Name); err != nil {
+			return err
+		}
+		if strings.Contains(p.Name, Wildcard) {
+			return Errorf(ErrInvalidInput, "permission %q is a wildcard, only single permissions are delegated", p.Name)
+		}
+	}
+	return nil
+}
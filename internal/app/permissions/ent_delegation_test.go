@@ -0,0 +1,254 @@
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	manager   = "7c1e4f3a-2b5d-4e6f-8a9b-0c1d2e3f4a5b"
+	colleague = "1a2b3c4d-5e6f-4a8b-9c0d-1e2f3a4b5c6d"
+)
+
+var (
+	invoiceApprover = permissions.Role{Name: "invoice approver", ID: "b3f1c2d4-5e6a-4b7c-8d9e-0f1a2b3c4d5e"}
+	invoiceClerk    = permissions.Role{Name: "invoice clerk", ID: "c4a2d3e5-6f7b-4c8d-9e0f-1a2b3c4d5e6f"}
+	invoiceReader   = permissions.Role{Name: "invoice reader", ID: "d5b3e4f6-7a8c-4d9e-8f1a-2b3c4d5e6f7a"}
+)
+
+// delegationRepo holds the manager, who is an invoice approver inheriting
+// invoice reader, with invoices:read revoked and an extra invoices:delete, and
+// the colleague, who is an invoice clerk. The methods GetForUser and
+// CreateDelegation do not use are left nil.
+type delegationRepo struct {
+	permissions.ReaderWriter
+	assignments map[string]permissions.RoleAssignments
+	extra       map[string]permissions.UserExtraPermissions
+	delegations permissions.Delegations
+}
+
+func newDelegationRepo() *delegationRepo {
+	return &delegationRepo{
+		assignments: map[string]permissions.RoleAssignments{
+			manager: {
+				{Role: invoiceApprover, Source: permissions.RoleSourceDirect},
+				{Role: invoiceReader, Source: permissions.RoleSourceInherited, Via: invoiceApprover.Name},
+			},
+			colleague: {
+				{Role: invoiceClerk, Source: permissions.RoleSourceDirect},
+			},
+		},
+		extra: map[string]permissions.UserExtraPermissions{
+			manager: {{Name: "invoices:delete", Condition: `amount < 100`}},
+		},
+	}
+}
+
+func (r *delegationRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return permissions.TenantRoleMap{
+		invoiceApprover: {Permissions: permissions.TenantPermissions{{Name: "invoices:approve"}}, Inherits: permissions.Roles{invoiceReader}},
+		invoiceReader:   {Permissions: permissions.TenantPermissions{{Name: "invoices:*"}}},
+		invoiceClerk:    {Permissions: permissions.TenantPermissions{{Name: "invoices:create"}}},
+	}, nil
+}
+
+func (r *delegationRepo) GetTenantPermissions(context.Context, []string) (permissions.TenantPermissions, error) {
+	return permissions.TenantPermissions{
+		{Name: "invoices:*"},
+		{Name: "invoices:approve"},
+		{Name: "invoices:create"},
+		{Name: "invoices:delete"},
+		{Name: "invoices:read"},
+	}, nil
+}
+
+func (r *delegationRepo) GetUserPermissionsExtraAndRevoked(ctx context.Context, _ []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	userID, _ := contextkey.UserID(ctx)
+	var revoked permissions.UserRevokedPermissions
+	if userID == manager {
+		revoked = permissions.UserRevokedPermissions{{Name: "invoices:read"}}
+	}
+	return r.extra[userID], revoked, nil
+}
+
+func (r *delegationRepo) GetUserRoleAssignments(ctx context.Context) (permissions.RoleAssignments, error) {
+	userID, _ := contextkey.UserID(ctx)
+	return r.assignments[userID], nil
+}
+
+func (r *delegationRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (r *delegationRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return nil, nil
+}
+
+func (r *delegationRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return nil, nil
+}
+
+func (r *delegationRepo) GetUserDelegations(ctx context.Context) (permissions.Delegations, error) {
+	userID, _ := contextkey.UserID(ctx)
+	var delegations permissions.Delegations
+	for _, d := range r.delegations {
+		if d.DelegateID == userID {
+			delegations = append(delegations, d)
+		}
+	}
+	return delegations, nil
+}
+
+func (r *delegationRepo) GetDelegations(context.Context, string) (permissions.Delegations, error) {
+	return r.delegations, nil
+}
+
+func (r *delegationRepo) CreateDelegation(_ context.Context, d permissions.Delegation) error {
+	r.delegations = append(r.delegations, d)
+	return nil
+}
+
+func TestGetForUser_DelegatedPermissions(t *testing.T) {
+	ends := time.Now().Add(14 * 24 * time.Hour)
+	delegation := permissions.Delegation{
+		ID:          "e6c4f5a7-8b9d-4e0f-9a2b-3c4d5e6f7a8b",
+		DelegatorID: manager,
+		DelegateID:  colleague,
+		Roles:       permissions.Roles{invoiceApprover},
+		Permissions: permissions.Permissions{{Name: "invoices:delete"}},
+		EndsAt:      ends,
+	}
+	delegated := func(name, role, cond string) permissions.DelegatedPermission {
+		return permissions.DelegatedPermission{
+			Permission:   permissions.UserPermission{Name: name, Condition: cond},
+			DelegationID: delegation.ID,
+			DelegatorID:  manager,
+			Role:         role,
+			EndsAt:       ends,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *delegationRepo)
+		want   permissions.DelegatedPermissions
+	}{
+		{
+			name: "What the delegator holds, but not what is revoked from them",
+			want: permissions.DelegatedPermissions{
+				delegated("invoices:delete", "", ""),
+				delegated("invoices:approve", invoiceApprover.Name, ""),
+				delegated("invoices:create", invoiceApprover.Name, ""),
+				delegated("invoices:delete", invoiceApprover.Name, ""),
+			},
+		},
+		{
+			name: "A role the delegator loses lapses, what they hold on a condition is delegated on it",
+			change: func(r *delegationRepo) {
+				r.assignments[manager] = nil
+			},
+			want: permissions.DelegatedPermissions{
+				delegated("invoices:delete", "", `amount < 100`),
+			},
+		},
+		{
+			name: "A permission the delegator loses lapses",
+			change: func(r *delegationRepo) {
+				r.assignments[manager] = nil
+				r.extra[manager] = nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newDelegationRepo()
+			repo.delegations = permissions.Delegations{delegation}
+			if tt.change != nil {
+				tt.change(repo)
+			}
+			svc := permissions.NewService(repo)
+			ctx := contextkey.WithTenantID(context.Background(), "test")
+
+			forUser, err := svc.GetForUser(contextkey.WithUserID(ctx, colleague), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, forUser.DelegatedPermissions)
+			assert.Equal(t, permissions.Roles{invoiceClerk}, forUser.Roles)
+			assert.Equal(t, len(tt.want) > 1, forUser.Allows("invoices:approve"))
+			assert.False(t, forUser.Allows("invoices:read"))
+		})
+	}
+}
+
+func TestService_CreateDelegation(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	ends := time.Now().Add(14 * 24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		delegation permissions.Delegation
+		wantErr    error
+	}{
+		{
+			name:       "A role and a permission the delegator holds",
+			delegation: permissions.Delegation{Roles: permissions.Roles{invoiceReader}, Permissions: permissions.Permissions{{Name: "invoices:approve"}}},
+		},
+		{
+			name:       "A role the delegator does not hold",
+			delegation: permissions.Delegation{Roles: permissions.Roles{invoiceClerk}},
+			wantErr:    permissions.ErrForbidden,
+		},
+		{
+			name:       "A permission revoked from the delegator",
+			delegation: permissions.Delegation{Permissions: permissions.Permissions{{Name: "invoices:read"}}},
+			wantErr:    permissions.ErrForbidden,
+		},
+		{
+			name:       "A wildcard",
+			delegation: permissions.Delegation{Permissions: permissions.Permissions{{Name: "invoices:*"}}},
+			wantErr:    permissions.ErrInvalidInput,
+		},
+		{
+			name:       "To the delegator",
+			delegation: permissions.Delegation{DelegateID: manager, Roles: permissions.Roles{invoiceApprover}},
+			wantErr:    permissions.ErrInvalidInput,
+		},
+		{
+			name:       "Longer than the maximum",
+			delegation: permissions.Delegation{Roles: permissions.Roles{invoiceApprover}, EndsAt: time.Now().Add(permissions.MaxDelegationDuration + time.Hour)},
+			wantErr:    permissions.ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newDelegationRepo()
+			svc := permissions.NewService(repo)
+
+			d := tt.delegation
+			d.DelegatorID = manager
+			if d.DelegateID == "" {
+				d.DelegateID = colleague
+			}
+			if d.EndsAt.IsZero() {
+				d.EndsAt = ends
+			}
+			created, err := svc.CreateDelegation(ctx, d)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				assert.Empty(t, repo.delegations)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, manager, created.DelegatorID)
+			assert.Equal(t, colleague, created.DelegateID)
+			assert.Equal(t, tt.delegation.Roles, created.Roles)
+			assert.False(t, created.StartsAt.IsZero())
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package permissions
+
+type Verdict string
+
+const (
+	// VerdictAllowed means the user holds the permission for every resource.
+	VerdictAllowed Verdict = "allowed"
+	// VerdictResources means the user holds the permission only on the resources they have been granted.
+	VerdictResources Verdict = "resources"
+	// VerdictConditional means the user holds the permission for every resource only
+	// if the conditions on their grants hold, see ForUser.Decide.
+	VerdictConditional Verdict = "conditional"
+	// VerdictDenied means the user does not hold the permission.
+	VerdictDenied Verdict = "denied"
+)
+
+type DerivationKind string
+
+const (
+	// DerivationRole is a grant from a role the user holds, directly or through inheritance.
+	DerivationRole DerivationKind = "role"
+	// DerivationExtra is an extra permission granted to the user.
+	DerivationExtra DerivationKind = "extra"
+	// DerivationDelegated is a permission delegated to the user, see Delegation.
+	DerivationDelegated DerivationKind = "delegated"
+	// DerivationRevoked is a permission revoked from the user, overriding role and extra grants.
+	DerivationRevoked DerivationKind = "revoked"
+	// DerivationResource is a grant on a single resource.
+	DerivationResource DerivationKind = "resource"
+)
+
+// Derivation is one way in which a permission is granted to, or revoked from, a user.
+type Derivation struct {
+	Kind DerivationKind
+	// Grant is the stored permission which matched, this may be a wildcard.
+	// Its Condition is set when the grant is conditional.
+	Grant Permission
+	// RolePath is the chain of roles from the role assigned to the user to the
+	// role holding the grant. Only set for DerivationRole.
+	RolePath Roles
+	// Group is the name of the group the first role in the RolePath is
+	// assigned to, empty when the role is assigned to the user.
+	Group string
+	// DelegatorID is the user who delegated the grant, and DelegatedRole the
+	// delegated role granting it, if any. Only set for DerivationDelegated.
+	DelegatorID   string
+	DelegatedRole string
+	// Resource is the resource the grant applies to. Only set for DerivationResource.
+	Resource *Resource
+	// InheritedFrom is the ancestor of the Resource the permission was granted on,
+	// nil when it was granted on the Resource itself.
+	InheritedFrom *Resource
+}
+
+type Derivations []Derivation
+
+// Explanation describes why a user has, or lacks, a permission.
+type Explanation struct {
+	Permission  string
+	Verdict     Verdict
+	Derivations Derivations
+}
@@ -0,0 +1,16 @@
+package permissions
+
+// Grantee is a user who may perform an action on a resource, see Service.WhoCan.
+type Grantee struct {
+	UserID string
+	// Decision is EffectAllow, or EffectConditional with the condition on which the user may.
+	Decision Decision
+	// Derivations are the ways the permission is granted to, or revoked from,
+	// the user, for every resource or on the resource.
+	Derivations Derivations
+	// Activate are the roles, any one of which a request must activate for the
+	// user to be allowed, when a dynamic SoDRule keeps those they need
+	// inactive otherwise, see contextkey.WithActiveRoles. Empty when the roles
+	// active by default allow them.
+	Activate Roles
+}
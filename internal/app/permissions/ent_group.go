@@ -0,0 +1,14 @@
+package permissions
+
+type Groups []Group
+
+// Group is a group of users, a role assigned to a group is held by every
+// member of the group and of any group nested within it.
+type Group struct {
+	ID   string
+	Name string
+}
+
+func (g Group) String() string {
+	return g.Name
+}
@@ -1,8 +1,37 @@
 package permissions
 
+import "strings"
+
 type Resources []Resource
 
 type Resource struct {
 	ID   string
 	Type string
 }
+
+// Is reports whether r and other are the same resource, resource types are case insensitive.
+func (r Resource) Is(other Resource) bool {
+	return r.ID == other.ID && strings.EqualFold(r.Type, other.Type)
+}
+
+type ResourceGrants []ResourceGrant
+
+// ResourceGrant is a permission a user holds on a single resource.
+type ResourceGrant struct {
+	Resource   Resource
+	Permission Permission
+	// InheritedFrom is the ancestor of the resource the permission was granted on,
+	// nil when it was granted on the resource itself.
+	InheritedFrom *Resource
+}
+
+// Grants reports whether the grant allows the permission on its resource.
+// An inherited grant allows the same action on the resource as it does on the
+// ancestor, so "customers:read" on a customer allows "contacts:read" on its contacts.
+func (rg ResourceGrant) Grants(permission string) bool {
+	grant := rg.Permission
+	if rg.InheritedFrom != nil {
+		grant = Permission{Name: rg.Resource.Type + PermissionSeparator + grant.Action()}
+	}
+	return grant.Matches(permission)
+}
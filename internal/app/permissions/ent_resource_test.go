@@ -0,0 +1,45 @@
+package permissions_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForUser_AllowsOn(t *testing.T) {
+	var (
+		customer = permissions.Resource{ID: "d2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92", Type: "customers"}
+		contact  = permissions.Resource{ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71", Type: "contacts"}
+		other    = permissions.Resource{ID: "5a7c9e1b-3d5f-4a7c-9e1b-3d5f7a9c1e28", Type: "contacts"}
+		invoice  = permissions.Resource{ID: "6b63b489-61cb-4087-8636-f10716bd724e", Type: "invoices"}
+	)
+
+	fu := &permissions.ForUser{
+		ExtraPermissions: permissions.UserExtraPermissions{{Name: "products:read"}},
+		ResourceGrants: permissions.ResourceGrants{
+			{Resource: customer, Permission: permissions.Permission{Name: "customers:read"}},
+			{Resource: contact, Permission: permissions.Permission{Name: "customers:read"}, InheritedFrom: &customer},
+			{Resource: invoice, Permission: permissions.Permission{Name: "invoices:*"}},
+		},
+	}
+
+	tests := []struct {
+		permission string
+		resource   permissions.Resource
+		want       bool
+	}{
+		{permission: "customers:read", resource: customer, want: true},
+		{permission: "customers:update", resource: customer, want: false},
+		{permission: "contacts:read", resource: contact, want: true}, // Inherited from the customer.
+		{permission: "contacts:read", resource: permissions.Resource{ID: contact.ID, Type: "CONTACTS"}, want: true},
+		{permission: "contacts:update", resource: contact, want: false},
+		{permission: "contacts:read", resource: other, want: false},    // Not a contact of the customer.
+		{permission: "invoices:delete", resource: invoice, want: true}, // Wildcard grant.
+		{permission: "products:read", resource: permissions.Resource{ID: "75248bd5-73a2-4507-9ab3-5418abd33a3c", Type: "products"}, want: true},
+	}
+	for _, tt := range tests {
+		got := fu.AllowsOn(tt.permission, tt.resource)
+		assert.Equal(t, tt.want, got, "permission: %s, resource: %s %s", tt.permission, tt.resource.Type, tt.resource.ID)
+	}
+}
@@ -0,0 +1,164 @@
+package permissions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SoDEnforcement is when a separation of duties rule is enforced.
+type SoDEnforcement string
+
+const (
+	// SoDStatic rules are enforced when roles and permissions are granted, no
+	// user may hold two of the rule's members.
+	SoDStatic SoDEnforcement = "static"
+	// SoDDynamic rules are enforced per request, a user may hold the rule's
+	// roles but not have two of them active in the same request.
+	SoDDynamic SoDEnforcement = "dynamic"
+)
+
+type SoDRules []SoDRule
+
+// SoDRule is a separation of duties rule, a set of roles or of permissions of
+// which no user may hold two. Roles are held directly, through a group or by
+// inheritance, and permissions through any role, extra permission or wildcard
+// which grants them, even on a condition, unless unconditionally revoked.
+type SoDRule struct {
+	ID          string
+	Name        string
+	Enforcement SoDEnforcement
+	// Roles or Permissions are the rule's members, a rule has one or the other.
+	// Only rules of roles may be SoDDynamic.
+	Roles       Roles
+	Permissions []string
+}
+
+// Members returns the names of the rule's roles or permissions.
+func (r SoDRule) Members() []string {
+	if len(r.Roles) > 0 {
+		return r.Roles.StringSlice()
+	}
+	return r.Permissions
+}
+
+// SoDViolation is a user holding more than one member of a static rule.
+type SoDViolation struct {
+	RuleID   string
+	RuleName string
+	UserID   string
+	// Held are the names of the rule's members the user holds, in order.
+	Held []string
+}
+
+func (v SoDViolation) String() string {
+	return fmt.Sprintf("user %s holds %s, which rule %q separates", v.UserID, strings.Join(v.Held, " and "), v.RuleName)
+}
+
+// Equal reports whether the violations are of the same rule by the same user holding the same members.
+func (v SoDViolation) Equal(other SoDViolation) bool {
+	return v.RuleID == other.RuleID && v.UserID == other.UserID && slices.Equal(v.Held, other.Held)
+}
+
+// activate limits the user's roles to those active in the request, see
+// contextkey.WithActiveRoles. Named roles are active along with the roles they
+// inherit, and naming a role the user does not hold is ErrInvalidInput, or two
+// members of a dynamic rule ErrForbidden. Without names every role is active
+// but the members of a dynamic rule the user holds more than one of, and the
+// roles held only through them, which the request must name to use.
+func (fu *ForUser) activate(names []string, rules SoDRules) error {
+	held := fu.RoleAssignments.Roles()
+	var roots, excluded Roles
+	if len(names) > 0 {
+		for _, name := range names {
+			i := slices.IndexFunc(held, func(r Role) bool { return strings.EqualFold(r.Name, name) })
+			if i < 0 {
+				return Errorf(ErrInvalidInput, "role %q is not held", name)
+			}
+			roots = append(roots, held[i])
+		}
+	} else {
+		for _, ra := range fu.RoleAssignments {
+			if ra.Source != RoleSourceInherited {
+				roots = append(roots, ra.Role)
+			}
+		}
+		for _, rule := range rules {
+			if members := heldMembers(rule, held); rule.Enforcement == SoDDynamic && len(members) > 1 {
+				excluded = append(excluded, members...)
+			}
+		}
+	}
+
+	active := fu.RoleAssignments.inheritedBy(roots, excluded)
+	if len(names) > 0 {
+		for _, rule := range rules {
+			if members := heldMembers(rule, active); rule.Enforcement == SoDDynamic && len(members) > 1 {
+				return Errorf(ErrForbidden, "roles %s cannot be active in the same request, see rule %q",
+					strings.Join(members.StringSlice(), " and "), rule.Name)
+			}
+		}
+	}
+	if len(active) == len(held) {
+		return nil
+	}
+
+	var assignments RoleAssignments
+	for _, ra := range fu.RoleAssignments {
+		if active.contains(ra.Role) {
+			assignments = append(assignments, ra)
+		}
+	}
+	fu.RoleAssignments = assignments
+	fu.Roles = assignments.Roles()
+	return nil
+}
+
+// activations returns the roles the user holds which are not active, those
+// of which naming one in the request allows the permission on the resource.
+func (fu *ForUser) activations(permission string, resource Resource, rules SoDRules, active Roles) Roles {
+	var roles Roles
+	for _, role := range fu.RoleAssignments.Roles() {
+		if active.contains(role) {
+			continue
+		}
+		named := *fu
+		if err := named.activate([]string{role.Name}, rules); err != nil {
+			continue // It activates two members of a dynamic rule by inheritance.
+		}
+		if named.DecideOn(permission, resource, nil).Effect != EffectDeny {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// inheritedBy returns the roles and those they inherit, leaving out the excluded
+// roles and the roles inherited only through them.
+func (ras RoleAssignments) inheritedBy(roots, excluded Roles) Roles {
+	var roles Roles
+	for _, role := range roots {
+		if !excluded.contains(role) && !roles.contains(role) {
+			roles = append(roles, role)
+		}
+	}
+	for i := 0; i < len(roles); i++ {
+		for _, ra := range ras {
+			if ra.Source == RoleSourceInherited && ra.Via == roles[i].Name && !excluded.contains(ra.Role) && !roles.contains(ra.Role) {
+				roles = append(roles, ra.Role)
+			}
+		}
+	}
+	return roles
+}
+
+// heldMembers returns the rule's roles among those held.
+func heldMembers(rule SoDRule, held Roles) Roles {
+	var members Roles
+	for _, role := range rule.Roles {
+		if held.contains(role) {
+			members = append(members, role)
+		}
+	}
+	return members
+}
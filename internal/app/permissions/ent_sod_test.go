@@ -0,0 +1,168 @@
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	viewer   = permissions.Role{Name: "viewer", ID: "1"}
+	creator  = permissions.Role{Name: "invoice creator", ID: "2"}
+	approver = permissions.Role{Name: "invoice approver", ID: "3"}
+	auditor  = permissions.Role{Name: "auditor", ID: "4"}
+)
+
+// sodRepo returns a user holding a viewer, creator and approver, who inherits
+// auditor, where creator and approver are separated dynamically. The methods
+// GetForUser does not use are left nil.
+type sodRepo struct {
+	permissions.ReaderWriter
+}
+
+func (sodRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return permissions.TenantRoleMap{
+		viewer:   {},
+		creator:  {},
+		approver: {Inherits: permissions.Roles{auditor}},
+		auditor:  {},
+	}, nil
+}
+
+func (sodRepo) GetUserPermissionsExtraAndRevoked(context.Context, []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	return nil, nil, nil
+}
+
+func (sodRepo) GetUserRoleAssignments(context.Context) (permissions.RoleAssignments, error) {
+	return permissions.RoleAssignments{
+		{Role: viewer, Source: permissions.RoleSourceDirect},
+		{Role: creator, Source: permissions.RoleSourceDirect},
+		{Role: approver, Source: permissions.RoleSourceGroup, Via: "finance"},
+		{Role: auditor, Source: permissions.RoleSourceInherited, Via: approver.Name},
+	}, nil
+}
+
+func (sodRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (sodRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return nil, nil
+}
+
+func (sodRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return permissions.SoDRules{
+		{ID: "r1", Name: "invoices", Enforcement: permissions.SoDDynamic, Roles: permissions.Roles{creator, approver}},
+		{ID: "r2", Name: "viewing", Enforcement: permissions.SoDStatic, Roles: permissions.Roles{viewer, auditor}},
+	}, nil
+}
+
+func (sodRepo) GetUserDelegations(context.Context) (permissions.Delegations, error) {
+	return nil, nil
+}
+
+func TestGetForUser_ActiveRoles(t *testing.T) {
+	svc := permissions.NewService(sodRepo{})
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	ctx = contextkey.WithUserID(ctx, "652f4d18-dd3d-40c0-874e-cbe3566abccf")
+
+	tests := []struct {
+		name    string
+		active  []string
+		want    permissions.Roles
+		wantErr error
+	}{
+		{
+			name: "Roles a dynamic rule separates are inactive unless named",
+			want: permissions.Roles{viewer},
+		},
+		{
+			name:   "A named role is active with the roles it inherits",
+			active: []string{"Invoice Approver"},
+			want:   permissions.Roles{approver, auditor},
+		},
+		{
+			name:   "Static rules do not limit activation",
+			active: []string{"viewer", "auditor"},
+			want:   permissions.Roles{viewer, auditor},
+		},
+		{
+			name:    "Two roles a dynamic rule separates",
+			active:  []string{"invoice creator", "invoice approver"},
+			wantErr: permissions.ErrForbidden,
+		},
+		{
+			name:    "A role which is not held",
+			active:  []string{"admin"},
+			wantErr: permissions.ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forUser, err := svc.GetForUser(contextkey.WithActiveRoles(ctx, tt.active), nil)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, forUser.Roles)
+			assert.Equal(t, tt.want, forUser.RoleAssignments.Roles())
+		})
+	}
+}
+
+// sodWhoCanRepo is sodRepo with permissions on its roles, and its user as the only candidate.
+type sodWhoCanRepo struct {
+	sodRepo
+}
+
+func (sodWhoCanRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return permissions.TenantRoleMap{
+		viewer:   {Permissions: permissions.TenantPermissions{{Name: "invoices:list"}}},
+		creator:  {Permissions: permissions.TenantPermissions{{Name: "invoices:create"}}},
+		approver: {Permissions: permissions.TenantPermissions{{Name: "invoices:approve"}}, Inherits: permissions.Roles{auditor}},
+		auditor:  {Permissions: permissions.TenantPermissions{{Name: "invoices:read"}}},
+	}, nil
+}
+
+func (sodWhoCanRepo) GetUnknownResourceTypes(context.Context, []string) ([]string, error) {
+	return nil, nil
+}
+
+func (sodWhoCanRepo) GetCandidateUsers(_ context.Context, _ string, _ permissions.Resource, after string, _ int) ([]string, error) {
+	if after != "" {
+		return nil, nil
+	}
+	return []string{"652f4d18-dd3d-40c0-874e-cbe3566abccf"}, nil
+}
+
+func TestWhoCan_ActiveRoles(t *testing.T) {
+	svc := permissions.NewService(sodWhoCanRepo{})
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+
+	tests := []struct {
+		permission string
+		want       permissions.Roles
+	}{
+		{permission: "invoices:list"},
+		{permission: "invoices:approve", want: permissions.Roles{approver}},
+		{permission: "invoices:read", want: permissions.Roles{approver, auditor}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.permission, func(t *testing.T) {
+			var grantees []permissions.Grantee
+			for g, err := range svc.WhoCan(ctx, tt.permission, "6b63b489-61cb-4087-8636-f10716bd724e") {
+				require.NoError(t, err)
+				grantees = append(grantees, g)
+			}
+			require.Len(t, grantees, 1, "the user holds a role which allows it")
+			assert.Equal(t, permissions.EffectAllow, grantees[0].Decision.Effect)
+			assert.Equal(t, tt.want, grantees[0].Activate)
+		})
+	}
+}
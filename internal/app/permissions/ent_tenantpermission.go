@@ -10,6 +10,19 @@ func (tps TenantPermissions) StringSlice() []string {
 	return names
 }
 
+// SplitWildcards separates the concrete permissions from the wildcard grants.
+func (tps TenantPermissions) SplitWildcards() (concrete TenantPermissions, wildcards TenantPermissions) {
+	concrete = make(TenantPermissions, 0, len(tps))
+	for _, tp := range tps {
+		if Permission(tp).IsWildcard() {
+			wildcards = append(wildcards, tp)
+			continue
+		}
+		concrete = append(concrete, tp)
+	}
+	return concrete, wildcards
+}
+
 type TenantPermission Permission
 
 func (tp TenantPermission) String() string {
@@ -0,0 +1,67 @@
+package permissions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Version is a hash of the role map's roles, their permissions, conditions and
+// inherited roles. It is the same for equal role maps, whatever order they were read in.
+func (trm TenantRoleMap) Version() string {
+	var lines []string
+	for role, mapped := range trm {
+		lines = append(lines, fmt.Sprintf("role\x00%s\x00%s", role.ID, role.Name))
+		for _, p := range mapped.Permissions {
+			lines = append(lines, fmt.Sprintf("role_permission\x00%s\x00%s\x00%s", role.ID, p.Name, p.Condition))
+		}
+		for _, child := range mapped.Inherits {
+			lines = append(lines, fmt.Sprintf("role_inherit\x00%s\x00%s", role.ID, child.ID))
+		}
+	}
+	return version(lines)
+}
+
+// AssignmentVersion is a hash of the user's role assignments, extra, revoked and
+// delegated permissions, resources and resource grants, everything in ForUser
+// but the RoleMap. It is the same for equal assignment sets, whatever order they
+// were read in.
+func (fu *ForUser) AssignmentVersion() string {
+	var lines []string
+	for _, ra := range fu.RoleAssignments {
+		lines = append(lines, fmt.Sprintf("assignment\x00%s\x00%s\x00%s", ra.Role.ID, ra.Source, ra.Via))
+	}
+	for _, p := range fu.ExtraPermissions {
+		lines = append(lines, fmt.Sprintf("extra\x00%s\x00%s", p.Name, p.Condition))
+	}
+	for _, p := range fu.RevokedPermissions {
+		lines = append(lines, fmt.Sprintf("revoked\x00%s\x00%s", p.Name, p.Condition))
+	}
+	for _, dp := range fu.DelegatedPermissions {
+		lines = append(lines, fmt.Sprintf("delegated\x00%s\x00%s\x00%s\x00%s\x00%s",
+			dp.Permission.Name, dp.Permission.Condition, dp.DelegationID, dp.Role, dp.EndsAt.UTC().Format(time.RFC3339)))
+	}
+	for _, r := range fu.Resources {
+		lines = append(lines, fmt.Sprintf("resource\x00%s\x00%s", r.Type, r.ID))
+	}
+	for _, rg := range fu.ResourceGrants {
+		var from Resource
+		if rg.InheritedFrom != nil {
+			from = *rg.InheritedFrom
+		}
+		lines = append(lines, fmt.Sprintf("resource_grant\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s",
+			rg.Resource.Type, rg.Resource.ID, rg.Permission.Name, rg.Permission.Condition, from.Type, from.ID))
+	}
+	return version(lines)
+}
+
+func version(lines []string) string {
+	slices.Sort(lines)
+	h := sha256.New()
+	for _, line := range lines {
+		fmt.Fprintln(h, line)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
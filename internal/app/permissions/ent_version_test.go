@@ -0,0 +1,48 @@
+package permissions_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRoleMap_Version(t *testing.T) {
+	clerk := permissions.Role{Name: "clerk", ID: "1"}
+	manager := permissions.Role{Name: "manager", ID: "2"}
+	trm := permissions.TenantRoleMap{
+		clerk:   {Permissions: permissions.TenantPermissions{{Name: "invoices:read"}, {Name: "invoices:create"}}},
+		manager: {Permissions: permissions.TenantPermissions{{Name: "invoices:approve"}}, Inherits: permissions.Roles{clerk}},
+	}
+	reordered := permissions.TenantRoleMap{
+		manager: {Permissions: permissions.TenantPermissions{{Name: "invoices:approve"}}, Inherits: permissions.Roles{clerk}},
+		clerk:   {Permissions: permissions.TenantPermissions{{Name: "invoices:create"}, {Name: "invoices:read"}}},
+	}
+	assert.Equal(t, trm.Version(), reordered.Version())
+	assert.Len(t, trm.Version(), 64)
+
+	conditional := permissions.TenantRoleMap{
+		clerk:   {Permissions: permissions.TenantPermissions{{Name: "invoices:read"}, {Name: "invoices:create", Condition: "invoice.amount < 100"}}},
+		manager: {Permissions: permissions.TenantPermissions{{Name: "invoices:approve"}}, Inherits: permissions.Roles{clerk}},
+	}
+	assert.NotEqual(t, trm.Version(), conditional.Version())
+
+	assert.NotEqual(t, trm.Version(), permissions.TenantRoleMap{}.Version())
+}
+
+func TestForUser_AssignmentVersion(t *testing.T) {
+	clerk := permissions.Role{Name: "clerk", ID: "1"}
+	fu := &permissions.ForUser{
+		RoleAssignments:  permissions.RoleAssignments{{Role: clerk, Source: permissions.RoleSourceDirect}},
+		ExtraPermissions: permissions.UserExtraPermissions{{Name: "invoices:export"}},
+	}
+	version := fu.AssignmentVersion()
+
+	// The role map is not part of the assignment version.
+	fu.RoleMap = permissions.TenantRoleMap{clerk: {}}
+	assert.Equal(t, version, fu.AssignmentVersion())
+
+	// An extra permission moving to revoked is a change.
+	fu.ExtraPermissions, fu.RevokedPermissions = nil, permissions.UserRevokedPermissions{{Name: "invoices:export"}}
+	assert.NotEqual(t, version, fu.AssignmentVersion())
+}
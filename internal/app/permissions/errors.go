@@ -0,0 +1,73 @@
+package permissions
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of error callers can act on, test for them with errors.Is.
+// Any other error is unexpected, and its detail is not shown to callers.
+var (
+	// ErrNotFound is a Tenant, user or resource type which does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput is a malformed request, such as a user ID which is not a UUID.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrForbidden is a request the caller may not make.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnavailable is a failure the caller may retry, such as the database being unreachable.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is an error of one of the kinds, with a message which may be shown to callers.
+type Error struct {
+	// Kind is ErrNotFound, ErrInvalidInput, ErrForbidden or ErrUnavailable.
+	Kind error
+	// Message describes the error without internal detail.
+	Message string
+	// Err is the cause, if any.
+	Err error
+}
+
+// Errorf returns an Error of the kind without a cause, whose message is formatted as fmt.Sprintf does.
+func Errorf(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// WrapError returns an Error of the kind with the message, caused by err.
+func WrapError(kind error, err error, message string) error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the kind and the cause, so errors.Is matches either.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Kind returns the kind of the error, or nil when it is not one of the kinds.
+func Kind(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrInvalidInput, ErrForbidden, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+// Message returns the message of the Error in err's chain, ok is false when there is none.
+func Message(err error) (message string, ok bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message, true
+	}
+	return "", false
+}
@@ -0,0 +1,44 @@
+package permissions_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("get for user: %w", permissions.WrapError(permissions.ErrUnavailable, cause, "database unavailable"))
+
+	assert.ErrorIs(t, err, permissions.ErrUnavailable)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, permissions.ErrUnavailable, permissions.Kind(err))
+	message, ok := permissions.Message(err)
+	assert.True(t, ok)
+	assert.Equal(t, "database unavailable", message)
+	assert.Equal(t, "get for user: database unavailable: connection refused", err.Error())
+
+	assert.Nil(t, permissions.Kind(cause))
+	_, ok = permissions.Message(cause)
+	assert.False(t, ok)
+}
+
+func TestValidateIdentity(t *testing.T) {
+	assert.NoError(t, permissions.ValidateTenantID("test_tenant"))
+	for _, id := range []string{"", "test tenant", "test;drop", "a123456789012345678901234567890123456789012345678901234567890123"} {
+		assert.ErrorIs(t, permissions.ValidateTenantID(id), permissions.ErrInvalidInput, id)
+	}
+
+	assert.NoError(t, permissions.ValidateUserID("652f4d18-dd3d-40c0-874e-cbe3566abccf"))
+	for _, id := range []string{"", "u1", "652f4d18-dd3d-40c0-874e-cbe3566abccg"} {
+		assert.ErrorIs(t, permissions.ValidateUserID(id), permissions.ErrInvalidInput, id)
+	}
+
+	assert.NoError(t, permissions.ValidateResourceType("invoices"))
+	for _, name := range []string{"", "*", "invoices:read", "in voices"} {
+		assert.ErrorIs(t, permissions.ValidateResourceType(name), permissions.ErrInvalidInput, name)
+	}
+}
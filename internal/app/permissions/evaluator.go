@@ -0,0 +1,257 @@
+package permissions
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+)
+
+// Allows reports whether the user holds the named permission.
+// Permissions granted by the user's roles, extra and delegated permissions are
+// combined, then any revoked permission removes the grant. Wildcard grants and
+// revocations match every permission they cover. Without attributes a
+// conditional grant is not held and a conditional revocation applies, see Decide.
+func (fu *ForUser) Allows(permission string) bool {
+	return fu.Decide(permission, nil).Effect == EffectAllow
+}
+
+// AllowsOn reports whether the user holds the named permission on the resource,
+// either for every resource, see Allows, or through a grant on the resource or
+// on one of its ancestors.
+func (fu *ForUser) AllowsOn(permission string, resource Resource) bool {
+	return fu.DecideOn(permission, resource, nil).Effect == EffectAllow
+}
+
+// Decide checks the named permission against the request's attributes, in the
+// same way as Allows. Grants and revocations with a condition apply when it
+// holds. When a condition references an attribute which was not supplied the
+// Decision may be conditional on it.
+func (fu *ForUser) Decide(permission string, attrs condition.Attributes) Decision {
+	var d decider
+	if fu == nil {
+		return d.decision(permission)
+	}
+	for _, rp := range fu.RevokedPermissions {
+		if Permission(rp).Matches(permission) {
+			d.revoke(rp.Condition, attrs)
+		}
+	}
+	for _, ep := range fu.ExtraPermissions {
+		if Permission(ep).Matches(permission) {
+			d.grant(ep.Condition, attrs)
+		}
+	}
+	for _, dp := range fu.DelegatedPermissions {
+		if Permission(dp.Permission).Matches(permission) {
+			d.grant(dp.Permission.Condition, attrs)
+		}
+	}
+	for _, role := range fu.Roles {
+		for _, tp := range fu.RoleMap[role].Permissions {
+			if Permission(tp).Matches(permission) {
+				d.grant(tp.Condition, attrs)
+			}
+		}
+	}
+	return d.decision(permission)
+}
+
+// DecideOn checks the named permission on the resource against the request's
+// attributes, in the same way as AllowsOn.
+func (fu *ForUser) DecideOn(permission string, resource Resource, attrs condition.Attributes) Decision {
+	decision := fu.Decide(permission, attrs)
+	if fu == nil || decision.Effect == EffectAllow {
+		return decision
+	}
+
+	var d decider
+	for _, rg := range fu.ResourceGrants {
+		if rg.Resource.Is(resource) && rg.Grants(permission) {
+			d.grant(rg.Permission.Condition, attrs)
+		}
+	}
+	onResource := d.decision(permission)
+
+	switch {
+	case onResource.Effect == EffectAllow || decision.Effect == EffectDeny:
+		return onResource
+	case onResource.Effect == EffectConditional:
+		decision.Condition = "(" + decision.Condition + ") || (" + onResource.Condition + ")"
+	}
+	return decision
+}
+
+// Resolve evaluates the conditions on the user's grants and revocations against
+// the attributes. In the returned copy those whose condition holds are
+// unconditional, those whose condition does not hold are removed, and those
+// whose condition references a missing attribute keep it.
+func (fu *ForUser) Resolve(attrs condition.Attributes) *ForUser {
+	if fu == nil {
+		return nil
+	}
+	resolved := *fu
+
+	resolved.ExtraPermissions = make(UserExtraPermissions, 0, len(fu.ExtraPermissions))
+	for _, ep := range fu.ExtraPermissions {
+		if o := evaluate(ep.Condition, attrs, outcomeFails); o != outcomeFails {
+			ep.Condition = o.remaining(ep.Condition)
+			resolved.ExtraPermissions = append(resolved.ExtraPermissions, ep)
+		}
+	}
+
+	resolved.DelegatedPermissions = make(DelegatedPermissions, 0, len(fu.DelegatedPermissions))
+	for _, dp := range fu.DelegatedPermissions {
+		if o := evaluate(dp.Permission.Condition, attrs, outcomeFails); o != outcomeFails {
+			dp.Permission.Condition = o.remaining(dp.Permission.Condition)
+			resolved.DelegatedPermissions = append(resolved.DelegatedPermissions, dp)
+		}
+	}
+
+	resolved.RevokedPermissions = make(UserRevokedPermissions, 0, len(fu.RevokedPermissions))
+	for _, rp := range fu.RevokedPermissions {
+		if o := evaluate(rp.Condition, attrs, outcomeHolds); o != outcomeFails {
+			rp.Condition = o.remaining(rp.Condition)
+			resolved.RevokedPermissions = append(resolved.RevokedPermissions, rp)
+		}
+	}
+
+	resolved.ResourceGrants = make(ResourceGrants, 0, len(fu.ResourceGrants))
+	for _, rg := range fu.ResourceGrants {
+		if o := evaluate(rg.Permission.Condition, attrs, outcomeFails); o != outcomeFails {
+			rg.Permission.Condition = o.remaining(rg.Permission.Condition)
+			resolved.ResourceGrants = append(resolved.ResourceGrants, rg)
+		}
+	}
+
+	resolved.RoleMap = make(TenantRoleMap, len(fu.RoleMap))
+	for role, mapped := range fu.RoleMap {
+		tps := make(TenantPermissions, 0, len(mapped.Permissions))
+		for _, tp := range mapped.Permissions {
+			if o := evaluate(tp.Condition, attrs, outcomeFails); o != outcomeFails {
+				tp.Condition = o.remaining(tp.Condition)
+				tps = append(tps, tp)
+			}
+		}
+		resolved.RoleMap[role] = TenantMappedRole{Permissions: tps, Inherits: mapped.Inherits}
+	}
+
+	return &resolved
+}
+
+// EffectivePermissions expands the user's grants against the tenant's
+// permissions, returning every concrete permission the user is allowed.
+func (fu *ForUser) EffectivePermissions(tenantPermissions TenantPermissions) Permissions {
+	effective := make(Permissions, 0)
+	for _, tp := range tenantPermissions {
+		p := Permission(tp)
+		if p.IsWildcard() {
+			continue
+		}
+		if fu.Allows(p.Name) {
+			effective = append(effective, p)
+		}
+	}
+	return effective
+}
+
+type outcome int
+
+const (
+	outcomeHolds outcome = iota
+	outcomeFails
+	// outcomeUnknown means the condition references an attribute which was not supplied.
+	outcomeUnknown
+)
+
+// remaining returns the condition left to be satisfied after the outcome.
+func (o outcome) remaining(cond string) string {
+	if o == outcomeUnknown {
+		return cond
+	}
+	return ""
+}
+
+// evaluate evaluates the condition against the attributes, an empty condition
+// always holds. A condition which is invalid, or cannot be evaluated against
+// the attributes, has the outcome onError, so a broken condition never widens access.
+func evaluate(cond string, attrs condition.Attributes, onError outcome) outcome {
+	if cond == "" {
+		return outcomeHolds
+	}
+	expr, err := condition.Parse(cond)
+	if err != nil {
+		return onError
+	}
+	ok, err := expr.Eval(attrs)
+	switch {
+	case errors.Is(err, condition.ErrMissingAttribute):
+		return outcomeUnknown
+	case err != nil:
+		return onError
+	case ok:
+		return outcomeHolds
+	}
+	return outcomeFails
+}
+
+// decider combines the grants and revocations matching a permission into a Decision.
+type decider struct {
+	granted bool
+	revoked bool
+	// grants and revokes are the conditions which could not be evaluated.
+	grants  []string
+	revokes []string
+}
+
+func (d *decider) grant(cond string, attrs condition.Attributes) {
+	switch evaluate(cond, attrs, outcomeFails) {
+	case outcomeHolds:
+		d.granted = true
+	case outcomeUnknown:
+		d.grants = appendUnique(d.grants, cond)
+	}
+}
+
+func (d *decider) revoke(cond string, attrs condition.Attributes) {
+	switch evaluate(cond, attrs, outcomeHolds) {
+	case outcomeHolds:
+		d.revoked = true
+	case outcomeUnknown:
+		d.revokes = appendUnique(d.revokes, cond)
+	}
+}
+
+func (d *decider) decision(permission string) Decision {
+	if d.revoked || (!d.granted && len(d.grants) == 0) {
+		return Decision{Permission: permission, Effect: EffectDeny}
+	}
+
+	var parts []string
+	if !d.granted {
+		grants := d.grants[0]
+		if len(d.grants) > 1 {
+			grants = "(" + strings.Join(d.grants, ") || (") + ")"
+		}
+		if len(d.revokes) > 0 {
+			grants = "(" + grants + ")"
+		}
+		parts = append(parts, grants)
+	}
+	for _, r := range d.revokes {
+		parts = append(parts, "!("+r+")")
+	}
+	if len(parts) == 0 {
+		return Decision{Permission: permission, Effect: EffectAllow}
+	}
+	return Decision{Permission: permission, Effect: EffectConditional, Condition: strings.Join(parts, " && ")}
+}
+
+func appendUnique(conds []string, cond string) []string {
+	for _, c := range conds {
+		if c == cond {
+			return conds
+		}
+	}
+	return append(conds, cond)
+}
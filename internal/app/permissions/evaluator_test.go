@@ -0,0 +1,143 @@
+package permissions_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForUser_Decide(t *testing.T) {
+	clerk := permissions.Role{Name: "clerk", ID: "1"}
+	fu := &permissions.ForUser{
+		Roles: permissions.Roles{clerk},
+		RoleMap: permissions.TenantRoleMap{
+			clerk: {Permissions: permissions.TenantPermissions{
+				{Name: "invoices:read"},
+				{Name: "invoices:approve", Condition: "invoice.amount < 10000"},
+				{Name: "invoices:delete", Condition: "invoice.amount <"}, // Invalid, never applies.
+			}},
+		},
+		ExtraPermissions: permissions.UserExtraPermissions{
+			{Name: "invoices:export", Condition: "request.hour >= 9 && request.hour < 17"},
+			{Name: "invoices:approve", Condition: `user.region == "north"`},
+		},
+		RevokedPermissions: permissions.UserRevokedPermissions{
+			{Name: "invoices:read", Condition: "invoice.archived"},
+		},
+	}
+
+	tests := []struct {
+		permission string
+		attrs      condition.Attributes
+		want       permissions.Decision
+	}{
+		{
+			permission: "invoices:read",
+			attrs:      condition.Attributes{"invoice": map[string]any{"archived": false}},
+			want:       permissions.Decision{Permission: "invoices:read", Effect: permissions.EffectAllow},
+		},
+		{
+			permission: "invoices:read",
+			attrs:      condition.Attributes{"invoice": map[string]any{"archived": true}},
+			want:       permissions.Decision{Permission: "invoices:read", Effect: permissions.EffectDeny},
+		},
+		{
+			permission: "invoices:read",
+			want:       permissions.Decision{Permission: "invoices:read", Effect: permissions.EffectConditional, Condition: "!(invoice.archived)"},
+		},
+		{
+			permission: "invoices:export",
+			attrs:      condition.Attributes{"request": map[string]any{"hour": 10}},
+			want:       permissions.Decision{Permission: "invoices:export", Effect: permissions.EffectAllow},
+		},
+		{
+			permission: "invoices:export",
+			attrs:      condition.Attributes{"request": map[string]any{"hour": 20}},
+			want:       permissions.Decision{Permission: "invoices:export", Effect: permissions.EffectDeny},
+		},
+		{
+			permission: "invoices:approve",
+			attrs:      condition.Attributes{"invoice.amount": 50000},
+			want:       permissions.Decision{Permission: "invoices:approve", Effect: permissions.EffectConditional, Condition: `user.region == "north"`},
+		},
+		{
+			permission: "invoices:approve",
+			want: permissions.Decision{
+				Permission: "invoices:approve",
+				Effect:     permissions.EffectConditional,
+				Condition:  `(user.region == "north") || (invoice.amount < 10000)`,
+			},
+		},
+		{
+			permission: "invoices:delete",
+			attrs:      condition.Attributes{"invoice.amount": 1},
+			want:       permissions.Decision{Permission: "invoices:delete", Effect: permissions.EffectDeny},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, fu.Decide(tt.permission, tt.attrs), "permission: %s, attributes: %v", tt.permission, tt.attrs)
+	}
+
+	// Without attributes conditional grants are not held, and conditional revocations apply.
+	assert.False(t, fu.Allows("invoices:read"))
+	assert.False(t, fu.Allows("invoices:export"))
+}
+
+func TestForUser_DecideOn(t *testing.T) {
+	contact := permissions.Resource{ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71", Type: "contacts"}
+	fu := &permissions.ForUser{
+		ExtraPermissions: permissions.UserExtraPermissions{{Name: "contacts:update", Condition: "request.hour < 17"}},
+		ResourceGrants: permissions.ResourceGrants{
+			{Resource: contact, Permission: permissions.Permission{Name: "contacts:update", Condition: `user.region == "north"`}},
+		},
+	}
+
+	assert.Equal(t, permissions.Decision{
+		Permission: "contacts:update",
+		Effect:     permissions.EffectConditional,
+		Condition:  `(request.hour < 17) || (user.region == "north")`,
+	}, fu.DecideOn("contacts:update", contact, nil))
+
+	d := fu.DecideOn("contacts:update", contact, condition.Attributes{"request.hour": 20, "user.region": "north"})
+	assert.Equal(t, permissions.EffectAllow, d.Effect)
+
+	d = fu.DecideOn("contacts:update", contact, condition.Attributes{"request.hour": 20, "user.region": "south"})
+	assert.Equal(t, permissions.EffectDeny, d.Effect)
+}
+
+func TestForUser_Resolve(t *testing.T) {
+	clerk := permissions.Role{Name: "clerk", ID: "1"}
+	fu := &permissions.ForUser{
+		Roles: permissions.Roles{clerk},
+		RoleMap: permissions.TenantRoleMap{
+			clerk: {Permissions: permissions.TenantPermissions{
+				{Name: "invoices:read"},
+				{Name: "invoices:approve", Condition: "invoice.amount < 10000"},
+			}},
+		},
+		ExtraPermissions: permissions.UserExtraPermissions{
+			{Name: "invoices:export", Condition: "request.hour < 17"},
+			{Name: "invoices:create", Condition: `user.region == "north"`},
+		},
+		RevokedPermissions: permissions.UserRevokedPermissions{
+			{Name: "invoices:read", Condition: "invoice.archived"},
+		},
+	}
+
+	resolved := fu.Resolve(condition.Attributes{"request.hour": 10, "invoice.amount": 50000})
+
+	assert.Equal(t, permissions.UserExtraPermissions{
+		{Name: "invoices:export"},
+		{Name: "invoices:create", Condition: `user.region == "north"`},
+	}, resolved.ExtraPermissions)
+	assert.Equal(t, permissions.UserRevokedPermissions{
+		{Name: "invoices:read", Condition: "invoice.archived"},
+	}, resolved.RevokedPermissions)
+	assert.Equal(t, permissions.TenantPermissions{{Name: "invoices:read"}}, resolved.RoleMap[clerk].Permissions)
+
+	// The original is unchanged.
+	assert.Equal(t, "request.hour < 17", fu.ExtraPermissions[0].Condition)
+	assert.Len(t, fu.RoleMap[clerk].Permissions, 2)
+}
@@ -7,13 +7,61 @@ type Reader interface {
 	GetUserPermissions(ctx context.Context, resources []string) (UserPermissions, error)
 	GetUserPermissionsExtraAndRevoked(ctx context.Context, resources []string) (UserExtraPermissions, UserRevokedPermissions, error)
 	GetUserResources(ctx context.Context, resources []string) (Resources, error)
+	GetUserResourceGrants(ctx context.Context, resources []string) (ResourceGrants, error)
 	GetUserRoles(ctx context.Context) (Roles, error)
+	GetUserRoleAssignments(ctx context.Context) (RoleAssignments, error)
 	GetTenantRoles(ctx context.Context) (Roles, error)
 	GetTenantRoleMap(ctx context.Context, resources []string) (TenantRoleMap, error)
+	GetTenantRoleAssignments(ctx context.Context) (TenantRoleAssignments, error)
+	// GetUnknownResourceTypes returns the names which are not resource types of the Tenant, compared case-insensitively.
+	GetUnknownResourceTypes(ctx context.Context, names []string) ([]string, error)
+	// GetCandidateUsers returns the IDs of the users who may hold the permission on
+	// the resource, those holding a role granting it, or with an extra permission or
+	// resource grant matching it, or delegated such a role or the permission, in
+	// order and at most limit of them after the ID.
+	// Revocations and conditions are not considered, so each must be evaluated.
+	GetCandidateUsers(ctx context.Context, permission string, resource Resource, after string, limit int) ([]string, error)
+	// GetSoDRules returns the Tenant's separation of duties rules, by name, with their roles' names.
+	GetSoDRules(ctx context.Context) (SoDRules, error)
+	// GetSoDViolations returns the users holding more than one member of a static rule, by rule then user.
+	GetSoDViolations(ctx context.Context) ([]SoDViolation, error)
+	// GetUserDelegations returns the Delegations to the user in the context whose window is open now.
+	GetUserDelegations(ctx context.Context) (Delegations, error)
+	// GetDelegations returns the Delegations by or to the user which have not
+	// ended, every user's when userID is empty, by when they start.
+	GetDelegations(ctx context.Context, userID string) (Delegations, error)
 }
 
+// Writer changes the Tenant's groups, resources and role assignments. A write
+// which grants roles, such as AddUserRoles, AddGroupMembers, AddGroupRoles and
+// NestGroup, is ErrForbidden when it would leave a user holding members of a
+// static SoDRule they did not before.
 type Writer interface {
-	// Nothing yet.
+	CreateGroup(ctx context.Context, group Group) error
+	DeleteGroup(ctx context.Context, groupID string) error
+	AddGroupMembers(ctx context.Context, groupID string, userIDs []string) error
+	RemoveGroupMembers(ctx context.Context, groupID string, userIDs []string) error
+	AddGroupRoles(ctx context.Context, groupID string, roleIDs []string) error
+	RemoveGroupRoles(ctx context.Context, groupID string, roleIDs []string) error
+	NestGroup(ctx context.Context, parentGroupID, childGroupID string) error
+	UnnestGroup(ctx context.Context, parentGroupID, childGroupID string) error
+	AddResourceTypeChild(ctx context.Context, parentType, childType string) error
+	RemoveResourceTypeChild(ctx context.Context, parentType, childType string) error
+	AddResourceChildren(ctx context.Context, parent Resource, children Resources) error
+	RemoveResourceChildren(ctx context.Context, parent Resource, children Resources) error
+	// AddUserRoles assigns the roles to the user, a role which does not exist is ErrNotFound.
+	AddUserRoles(ctx context.Context, userID string, roleIDs []string) error
+	RemoveUserRoles(ctx context.Context, userID string, roleIDs []string) error
+	// CreateSoDRule creates the rule, a role which does not exist is ErrNotFound
+	// and a name already in use ErrInvalidInput.
+	CreateSoDRule(ctx context.Context, rule SoDRule) error
+	DeleteSoDRule(ctx context.Context, ruleID string) error
+	// CreateDelegation creates the Delegation of its roles, by ID, and
+	// permissions, by name, a role or permission which does not exist is ErrNotFound.
+	// A Delegation which would leave the delegate holding members of a static
+	// SoDRule they did not before is ErrForbidden.
+	CreateDelegation(ctx context.Context, delegation Delegation) error
+	DeleteDelegation(ctx context.Context, delegationID string) error
 }
 
 type ReaderWriter interface {
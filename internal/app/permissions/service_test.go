@@ -8,14 +8,13 @@ import (
 	"os"
 	"testing"
 
-	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
 	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
 	"github.com/Equineregister/user-permissions-service/internal/pkg/application"
-	"github.com/Equineregister/user-permissions-service/pkg/testdatabase"
 )
 
 const (
-	TestTenantID = "test_tenant"
+	TestTenantID = postgrestest.TenantID
 )
 
 func NewTestEnv(ctx context.Context, t *testing.T) (*permissions.Service, permissions.ReaderWriter) {
@@ -24,13 +23,7 @@ func NewTestEnv(ctx context.Context, t *testing.T) (*permissions.Service, permis
 	os.Setenv("LOG_LEVEL", "debug")
 	application.InitLogger()
 
-	db, err := testdatabase.NewTestDatabase(ctx, postgres.Migrations, postgres.Tenants)
-	if err != nil {
-		t.Fatalf("failed to create new test database: %s", err.Error())
-	}
-	tp := postgres.NewTenantPoolFromSuppliedPool(ctx, TestTenantID, db.DB)
-
-	repo := postgres.NewPermissionsRepoWithTenantPool(tp)
+	_, repo := postgrestest.NewTenantRepo(t)
 	service := permissions.NewService(repo)
 
 	return service, repo
@@ -1,11 +1,84 @@
 package permissions
 
+import "strings"
+
+const (
+	// PermissionSeparator separates the resource and action parts of a permission name.
+	PermissionSeparator = ":"
+	// Wildcard matches any resource or action when used as a whole part of a permission name.
+	Wildcard = "*"
+)
+
 type Permissions []Permission
+
+func (ps Permissions) StringSlice() []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.String()
+	}
+	return names
+}
+
 type Permission struct {
 	Name string
 	ID   string
+	// Condition is an optional expression, see package condition, which must
+	// hold against the request's attributes for the grant to apply.
+	Condition string
 }
 
 func (p Permission) String() string {
 	return p.Name
 }
+
+// Resource returns the resource part of the permission name, e.g. "invoices" for "invoices:create".
+func (p Permission) Resource() string {
+	resource, _, _ := strings.Cut(p.Name, PermissionSeparator)
+	return resource
+}
+
+// Action returns the action part of the permission name, e.g. "create" for "invoices:create".
+func (p Permission) Action() string {
+	_, action, _ := strings.Cut(p.Name, PermissionSeparator)
+	return action
+}
+
+// IsWildcard reports whether the permission is a wildcard grant such as "invoices:*" or "*:read".
+func (p Permission) IsWildcard() bool {
+	return p.Resource() == Wildcard || p.Action() == Wildcard
+}
+
+// Matches reports whether the permission grants the named permission.
+// A wildcard part matches any value, other parts are compared case-insensitively.
+func (p Permission) Matches(name string) bool {
+	resource, action, ok := strings.Cut(name, PermissionSeparator)
+	if !ok {
+		return false
+	}
+	return matchPart(p.Resource(), resource) && matchPart(p.Action(), action)
+}
+
+func matchPart(pattern, value string) bool {
+	return pattern == Wildcard || strings.EqualFold(pattern, value)
+}
+
+// ValidatePermissionName checks the name is of the form "resource:action",
+// where either part may be the Wildcard but may not otherwise contain it.
+// The error is ErrInvalidInput.
+func ValidatePermissionName(name string) error {
+	resource, action, ok := strings.Cut(name, PermissionSeparator)
+	if !ok {
+		return Errorf(ErrInvalidInput, "permission %q is not of the form resource:action", name)
+	}
+	for _, part := range []string{resource, action} {
+		switch {
+		case part == "":
+			return Errorf(ErrInvalidInput, "permission %q has an empty part", name)
+		case part == Wildcard:
+			continue
+		case strings.ContainsAny(part, Wildcard+PermissionSeparator+" "):
+			return Errorf(ErrInvalidInput, "permission %q has an invalid part %q", name, part)
+		}
+	}
+	return nil
+}
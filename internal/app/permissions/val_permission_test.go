@@ -0,0 +1,63 @@
+package permissions_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermission_Matches(t *testing.T) {
+	tests := []struct {
+		grant string
+		name  string
+		want  bool
+	}{
+		{grant: "invoices:create", name: "invoices:create", want: true},
+		{grant: "invoices:create", name: "INVOICES:Create", want: true},
+		{grant: "invoices:create", name: "invoices:read", want: false},
+		{grant: "invoices:*", name: "invoices:read", want: true},
+		{grant: "invoices:*", name: "products:read", want: false},
+		{grant: "*:read", name: "products:read", want: true},
+		{grant: "*:read", name: "products:delete", want: false},
+		{grant: "*:*", name: "products:delete", want: true},
+		{grant: "*:*", name: "products", want: false},
+	}
+	for _, tt := range tests {
+		got := permissions.Permission{Name: tt.grant}.Matches(tt.name)
+		assert.Equal(t, tt.want, got, "grant: %s, name: %s", tt.grant, tt.name)
+	}
+}
+
+func TestValidatePermissionName(t *testing.T) {
+	for _, name := range []string{"invoices:create", "invoices:*", "*:read", "*:*"} {
+		assert.NoError(t, permissions.ValidatePermissionName(name), name)
+	}
+	for _, name := range []string{"", "invoices", "invoices:", ":read", "inv*:read", "invoices:re ad", "a:b:c"} {
+		assert.Error(t, permissions.ValidatePermissionName(name), name)
+	}
+}
+
+func TestForUser_EffectivePermissions(t *testing.T) {
+	reader := permissions.Role{Name: "reader", ID: "1"}
+	fu := &permissions.ForUser{
+		Roles: permissions.Roles{reader},
+		RoleMap: permissions.TenantRoleMap{
+			reader: {Permissions: permissions.TenantPermissions{{Name: "*:read"}}},
+		},
+		ExtraPermissions:   permissions.UserExtraPermissions{{Name: "invoices:*"}},
+		RevokedPermissions: permissions.UserRevokedPermissions{{Name: "invoices:delete"}},
+	}
+	tenantPermissions := permissions.TenantPermissions{
+		{Name: "*:read"},
+		{Name: "invoices:create"},
+		{Name: "invoices:delete"},
+		{Name: "invoices:read"},
+		{Name: "products:create"},
+		{Name: "products:read"},
+	}
+
+	got := fu.EffectivePermissions(tenantPermissions)
+
+	assert.Equal(t, []string{"invoices:create", "invoices:read", "products:read"}, got.StringSlice())
+}
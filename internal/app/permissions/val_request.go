@@ -0,0 +1,61 @@
+package permissions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/google/uuid"
+)
+
+// maxTenantIDLength is the longest Tenant ID, which names the Tenant's database.
+const maxTenantIDLength = 63
+
+// ValidateTenantID checks the Tenant ID is non-empty, at most 63 characters,
+// and only letters, digits, '_' and '-'.
+func ValidateTenantID(tenantID string) error {
+	if tenantID == "" {
+		return Errorf(ErrInvalidInput, "tenant ID is required")
+	}
+	if len(tenantID) > maxTenantIDLength || strings.IndexFunc(tenantID, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) >= 0 {
+		return Errorf(ErrInvalidInput, "tenant ID %q is not valid", tenantID)
+	}
+	return nil
+}
+
+// ValidateUserID checks the user ID is a UUID.
+func ValidateUserID(userID string) error {
+	if userID == "" {
+		return Errorf(ErrInvalidInput, "user ID is required")
+	}
+	if err := uuid.Validate(userID); err != nil {
+		return Errorf(ErrInvalidInput, "user ID %q is not a UUID", userID)
+	}
+	return nil
+}
+
+// ValidateResourceType checks the resource type name is non-empty and without
+// the Wildcard, PermissionSeparator or spaces.
+func ValidateResourceType(name string) error {
+	if name == "" || strings.ContainsAny(name, Wildcard+PermissionSeparator+" ") {
+		return Errorf(ErrInvalidInput, "resource type %q is not valid", name)
+	}
+	return nil
+}
+
+// ValidateTenant checks the Tenant in the context, before it is used in queries.
+func ValidateTenant(ctx context.Context) error {
+	tenantID, _ := contextkey.TenantID(ctx)
+	return ValidateTenantID(tenantID)
+}
+
+// validateIdentity checks the Tenant and user in the context, before they are used in queries.
+func validateIdentity(ctx context.Context) error {
+	if err := ValidateTenant(ctx); err != nil {
+		return err
+	}
+	userID, _ := contextkey.UserID(ctx)
+	return ValidateUserID(userID)
+}
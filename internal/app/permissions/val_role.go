@@ -18,6 +18,15 @@ func (r Roles) StringSlice() []string {
 	return names
 }
 
+func (r Roles) contains(role Role) bool {
+	for _, rr := range r {
+		if rr.ID == role.ID {
+			return true
+		}
+	}
+	return false
+}
+
 type Role struct {
 	ID   string
 	Name string
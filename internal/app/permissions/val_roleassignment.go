@@ -0,0 +1,39 @@
+package permissions
+
+type RoleSource string
+
+const (
+	// RoleSourceDirect is a role assigned to the user.
+	RoleSourceDirect RoleSource = "direct"
+	// RoleSourceGroup is a role assigned to a group the user is a member of.
+	RoleSourceGroup RoleSource = "group"
+	// RoleSourceInherited is a role inherited from another role the user holds.
+	RoleSourceInherited RoleSource = "inherited"
+)
+
+type RoleAssignments []RoleAssignment
+
+// Roles returns the unique roles held, in the order they were assigned.
+func (ras RoleAssignments) Roles() Roles {
+	var roles Roles
+	for _, ra := range ras {
+		if roles.contains(ra.Role) {
+			continue
+		}
+		roles = append(roles, ra.Role)
+	}
+	return roles
+}
+
+// RoleAssignment is a role held by a user and how the user came to hold it.
+// A user can hold the same role from more than one source.
+type RoleAssignment struct {
+	Role   Role
+	Source RoleSource
+	// Via is the name of the group for RoleSourceGroup, or of the
+	// inheriting role for RoleSourceInherited.
+	Via string
+}
+
+// TenantRoleAssignments are the roles assigned to each user in the Tenant, keyed by user ID.
+type TenantRoleAssignments map[string]RoleAssignments
@@ -0,0 +1,22 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+)
+
+// Apply makes exactly the changes in the plan to the Tenant in the context, in one transaction.
+// ErrPlanStale is returned, and nothing changed, if the Tenant's model no longer
+// has the fingerprint the plan was made against.
+func (s *Service) Apply(ctx context.Context, plan *Plan) error {
+	if plan.Fingerprint == "" {
+		return fmt.Errorf("apply: plan has no fingerprint")
+	}
+	if plan.Empty() {
+		return nil
+	}
+	if err := s.repo.ApplyPlan(ctx, plan); err != nil {
+		return fmt.Errorf("apply: %w", err)
+	}
+	return nil
+}
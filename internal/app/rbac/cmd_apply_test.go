@@ -0,0 +1,61 @@
+//go:build test
+// +build test
+
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	desired, err := svc.Export(ctx, true)
+	require.NoError(t, err)
+	require.NotEmpty(t, desired.Assignments)
+
+	// Remove the first role, and give the first user a new one.
+	removed := desired.Roles[0].Name
+	desired.Roles = append(desired.Roles[1:], rbac.Role{Name: "stable hand", Permissions: []string{desired.Permissions[0].Name}})
+	for i, r := range desired.Roles {
+		var inherits []string
+		for _, child := range r.Inherits {
+			if child != removed {
+				inherits = append(inherits, child)
+			}
+		}
+		desired.Roles[i].Inherits = inherits
+	}
+	for i, a := range desired.Assignments {
+		var roles []string
+		for _, role := range a.Roles {
+			if role != removed {
+				roles = append(roles, role)
+			}
+		}
+		desired.Assignments[i].Roles = roles
+	}
+	desired.Assignments[0].Roles = append(desired.Assignments[0].Roles, "stable hand")
+
+	plan, err := svc.Plan(ctx, desired)
+	require.NoError(t, err)
+	require.False(t, plan.Empty())
+
+	require.NoError(t, svc.Apply(ctx, plan))
+
+	after, err := svc.Export(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, desired.Fingerprint(), after.Fingerprint())
+
+	again, err := svc.Plan(ctx, desired)
+	require.NoError(t, err)
+	assert.True(t, again.Empty())
+
+	// The Tenant has changed since the plan was made.
+	err = svc.Apply(ctx, plan)
+	assert.ErrorIs(t, err, rbac.ErrPlanStale)
+}
@@ -0,0 +1,19 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+)
+
+// Export returns the model of the Tenant in the context, with its assignments if withAssignments is true.
+func (s *Service) Export(ctx context.Context, withAssignments bool) (*Model, error) {
+	model, err := s.repo.GetModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	if !withAssignments {
+		model.Assignments = nil
+	}
+	model.Sort()
+	return model, nil
+}
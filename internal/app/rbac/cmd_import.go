@@ -0,0 +1,55 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/validation"
+)
+
+// Import applies the desired model to the Tenant in the context, matching on name.
+//
+// Missing resource types, permissions and roles are created. Each permission's
+// enabled state is set, and each role's permissions and inherited roles are set
+// to exactly those in the desired model. Anything not in the desired model is
+// left alone, so importing the same model again changes nothing.
+//
+// Assignments are not imported, use Plan and Apply to manage them.
+// The import is rejected if the Tenant's model would be invalid afterwards.
+func (s *Service) Import(ctx context.Context, desired *Model) error {
+	if desired.Assignments != nil {
+		return fmt.Errorf("import: assignments are not imported, use plan and apply")
+	}
+	if err := desired.ValidateNames(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	current, err := s.repo.GetModel(ctx)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	merged := current.Merge(desired)
+	if err := merged.ValidateReferences(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	if report := validation.Validate(merged.Snapshot()); report.Has(validation.SeverityError) {
+		return fmt.Errorf("import: %w", reportError(report))
+	}
+
+	if err := s.repo.ImportModel(ctx, desired); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	return nil
+}
+
+func reportError(report *validation.Report) error {
+	var messages []string
+	for _, f := range report.Findings {
+		if f.Severity.AtLeast(validation.SeverityError) {
+			messages = append(messages, f.Message)
+		}
+	}
+	return fmt.Errorf("model is invalid: %s", strings.Join(messages, "; "))
+}
@@ -0,0 +1,134 @@
+//go:build test
+// +build test
+
+package rbac_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (context.Context, *rbac.Service) {
+	t.Helper()
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	return ctx, rbac.NewService(repo)
+}
+
+func TestImport_ExportIsIdempotent(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	exported, err := svc.Export(ctx, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, exported.Roles)
+
+	require.NoError(t, svc.Import(ctx, exported))
+
+	again, err := svc.Export(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, exported, again)
+}
+
+func TestImport(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	before, err := svc.Export(ctx, false)
+	require.NoError(t, err)
+
+	err = svc.Import(ctx, &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{Name: "stables"}},
+		Permissions:   []rbac.Permission{{Name: "stables:read", Enabled: true}},
+		Roles: []rbac.Role{
+			{Name: "stable hand", Permissions: []string{"stables:read"}},
+		},
+	})
+	require.NoError(t, err)
+
+	after, err := svc.Export(ctx, false)
+	require.NoError(t, err)
+	assert.Len(t, after.ResourceTypes, len(before.ResourceTypes)+1)
+	assert.Contains(t, after.Permissions, rbac.Permission{Name: "stables:read", Enabled: true})
+	assert.Contains(t, after.Roles, rbac.Role{Name: "stable hand", Permissions: []string{"stables:read"}})
+}
+
+func TestImport_ConditionsRoundTrip(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	err := svc.Import(ctx, &rbac.Model{
+		Permissions: []rbac.Permission{{Name: "invoices:approve", Enabled: true}},
+		Roles: []rbac.Role{{
+			Name:        "invoice approver",
+			Permissions: []string{"invoices:approve"},
+			Conditions:  map[string]string{"invoices:approve": "invoice.amount < 10000"},
+		}},
+	})
+	require.NoError(t, err)
+
+	exported, err := svc.Export(ctx, false)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, rbacdoc.Encode(&buf, rbacdoc.FormatYAML, rbacdoc.FromModel(exported)))
+	doc, err := rbacdoc.Decode(&buf, rbacdoc.FormatYAML)
+	require.NoError(t, err)
+
+	// The document is applied to another Tenant, its grants keep their conditions.
+	otherCtx, other := newTestService(t)
+	plan, err := other.Plan(otherCtx, doc.Model())
+	require.NoError(t, err)
+	assert.Contains(t, plan.Changes, rbac.Change{
+		Action:    rbac.ActionAdd,
+		Kind:      rbac.ChangeKindRolePermission,
+		Subject:   "invoice approver",
+		Object:    "invoices:approve",
+		Condition: "invoice.amount < 10000",
+	})
+	require.NoError(t, other.Apply(otherCtx, plan))
+
+	applied, err := other.Export(otherCtx, false)
+	require.NoError(t, err)
+	assert.Equal(t, exported, applied)
+	plan, err = other.Plan(otherCtx, exported)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty())
+
+	require.NoError(t, other.Import(otherCtx, doc.Model()))
+	imported, err := other.Export(otherCtx, false)
+	require.NoError(t, err)
+	assert.Equal(t, exported, imported)
+}
+
+func TestImport_Invalid(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	tests := []struct {
+		name    string
+		model   *rbac.Model
+		wantErr string
+	}{
+		{
+			name:    "unknown permission",
+			model:   &rbac.Model{Roles: []rbac.Role{{Name: "clerk", Permissions: []string{"stables:muck-out"}}}},
+			wantErr: `grants unknown permission "stables:muck-out"`,
+		},
+		{
+			name: "cycle",
+			model: &rbac.Model{Roles: []rbac.Role{
+				{Name: "a", Inherits: []string{"b"}},
+				{Name: "b", Inherits: []string{"a"}},
+			}},
+			wantErr: "model is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.Import(ctx, tt.model)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/validation"
+)
+
+// Plan returns the changes needed to make the Tenant in the context match the desired model.
+//
+// Unlike Import, the desired model is the whole of the Tenant's model: resource
+// types, permissions, roles and edges not in it are removed. Assignments are
+// only planned if the desired model has them, otherwise only assignments of
+// removed roles are removed.
+func (s *Service) Plan(ctx context.Context, desired *Model) (*Plan, error) {
+	if err := desired.ValidateNames(); err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	if err := desired.ValidateReferences(); err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	if report := validation.Validate(desired.Snapshot()); report.Has(validation.SeverityError) {
+		return nil, fmt.Errorf("plan: %w", reportError(report))
+	}
+
+	current, err := s.repo.GetModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	grants, err := s.repo.GetUserGrants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+
+	return plan(current, desired, grants), nil
+}
+
+func plan(current, desired *Model, grants map[string]UserGrants) *Plan {
+	after := &Model{
+		ResourceTypes: desired.ResourceTypes,
+		Permissions:   desired.Permissions,
+		Roles:         desired.Roles,
+		Assignments:   desired.Assignments,
+	}
+	if after.Assignments == nil {
+		after.Assignments = keepRoles(current.Assignments, desired.Roles)
+	}
+	after.Sort()
+
+	return &Plan{
+		Fingerprint: current.Fingerprint(),
+		Changes:     diff(current, after),
+		Impacts:     impacts(current, after, grants),
+	}
+}
+
+// keepRoles returns the assignments with only the roles that are in roles.
+func keepRoles(assignments []Assignment, roles []Role) []Assignment {
+	names := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		names[r.Name] = true
+	}
+	kept := make([]Assignment, 0, len(assignments))
+	for _, a := range assignments {
+		k := Assignment{UserID: a.UserID}
+		for _, r := range a.Roles {
+			if names[r] {
+				k.Roles = append(k.Roles, r)
+			}
+		}
+		kept = append(kept, k)
+	}
+	return kept
+}
+
+// diff returns the changes that turn current into desired, ordered so that
+// nothing is referenced before it is added or after it is removed.
+func diff(current, desired *Model) []Change {
+	var changes []Change
+	add := func(action Action, kind ChangeKind, subject, object string) {
+		changes = append(changes, Change{Action: action, Kind: kind, Subject: subject, Object: object})
+	}
+
+	currentRoles := rolesByName(current.Roles)
+	desiredRoles := rolesByName(desired.Roles)
+	currentAssignments := assignmentsByUser(current.Assignments)
+	desiredAssignments := assignmentsByUser(desired.Assignments)
+
+	// Edges and assignments go first, so that nothing removed is still referenced.
+	for _, user := range sortedKeys(currentAssignments) {
+		removed, _ := difference(currentAssignments[user], desiredAssignments[user])
+		for _, role := range removed {
+			add(ActionRemove, ChangeKindAssignment, user, role)
+		}
+	}
+	for _, r := range current.Roles {
+		removed, _ := difference(r.Inherits, desiredRoles[r.Name].Inherits)
+		for _, child := range removed {
+			add(ActionRemove, ChangeKindRoleInherit, r.Name, child)
+		}
+	}
+	for _, r := range current.Roles {
+		removed, _ := difference(r.Permissions, desiredRoles[r.Name].Permissions)
+		for _, p := range removed {
+			add(ActionRemove, ChangeKindRolePermission, r.Name, p)
+		}
+	}
+	removedRoles, addedRoles := difference(roleNames(current.Roles), roleNames(desired.Roles))
+	for _, name := range removedRoles {
+		add(ActionRemove, ChangeKindRole, name, "")
+	}
+
+	desiredPermissions := make(map[string]Permission, len(desired.Permissions))
+	for _, p := range desired.Permissions {
+		desiredPermissions[p.Name] = p
+	}
+	currentPermissions := make(map[string]Permission, len(current.Permissions))
+	for _, p := range current.Permissions {
+		currentPermissions[p.Name] = p
+		if _, ok := desiredPermissions[p.Name]; !ok {
+			add(ActionRemove, ChangeKindPermission, p.Name, "")
+		}
+	}
+
+	removedTypes, addedTypes := difference(resourceTypeNames(current.ResourceTypes), resourceTypeNames(desired.ResourceTypes))
+	for _, name := range removedTypes {
+		add(ActionRemove, ChangeKindResourceType, name, "")
+	}
+	for _, rt := range desired.ResourceTypes {
+		if slices.Contains(addedTypes, rt.Name) {
+			changes = append(changes, Change{Action: ActionAdd, Kind: ChangeKindResourceType, Subject: rt.Name, ResourceTypeID: rt.ID})
+		}
+	}
+
+	for _, p := range desired.Permissions {
+		cp, ok := currentPermissions[p.Name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Action: ActionAdd, Kind: ChangeKindPermission, Subject: p.Name, Enabled: p.Enabled})
+		case cp.Enabled != p.Enabled:
+			changes = append(changes, Change{Action: ActionChange, Kind: ChangeKindPermission, Subject: p.Name, Enabled: p.Enabled})
+		}
+	}
+
+	for _, name := range addedRoles {
+		add(ActionAdd, ChangeKindRole, name, "")
+	}
+	for _, r := range desired.Roles {
+		cr := currentRoles[r.Name]
+		for _, p := range r.Permissions {
+			switch {
+			case !slices.Contains(cr.Permissions, p):
+				changes = append(changes, Change{Action: ActionAdd, Kind: ChangeKindRolePermission, Subject: r.Name, Object: p, Condition: r.Conditions[p]})
+			case cr.Conditions[p] != r.Conditions[p]:
+				changes = append(changes, Change{Action: ActionChange, Kind: ChangeKindRolePermission, Subject: r.Name, Object: p, Condition: r.Conditions[p]})
+			}
+		}
+	}
+	for _, r := range desired.Roles {
+		_, added := difference(currentRoles[r.Name].Inherits, r.Inherits)
+		for _, child := range added {
+			add(ActionAdd, ChangeKindRoleInherit, r.Name, child)
+		}
+	}
+	for _, user := range sortedKeys(desiredAssignments) {
+		_, added := difference(currentAssignments[user], desiredAssignments[user])
+		for _, role := range added {
+			add(ActionAdd, ChangeKindAssignment, user, role)
+		}
+	}
+
+	return changes
+}
+
+// impacts counts the users that gain or lose each effective permission going from current to desired.
+func impacts(current, desired *Model, grants map[string]UserGrants) []Impact {
+	before := newEvaluator(current)
+	after := newEvaluator(desired)
+	currentAssignments := assignmentsByUser(current.Assignments)
+	desiredAssignments := assignmentsByUser(desired.Assignments)
+
+	users := make(map[string]bool)
+	for user := range currentAssignments {
+		users[user] = true
+	}
+	for user := range desiredAssignments {
+		users[user] = true
+	}
+	for user := range grants {
+		users[user] = true
+	}
+
+	counts := make(map[string]*Impact)
+	count := func(permission string) *Impact {
+		if counts[permission] == nil {
+			counts[permission] = &Impact{Permission: permission}
+		}
+		return counts[permission]
+	}
+	for user := range users {
+		had := before.effective(currentAssignments[user], grants[user])
+		has := after.effective(desiredAssignments[user], grants[user])
+		for p := range has {
+			if !had[p] {
+				count(p).Gained++
+			}
+		}
+		for p := range had {
+			if !has[p] {
+				count(p).Lost++
+			}
+		}
+	}
+
+	result := make([]Impact, 0, len(counts))
+	for _, i := range counts {
+		result = append(result, *i)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Permission < result[j].Permission })
+	return result
+}
+
+// evaluator works out users' effective permissions under a model.
+type evaluator struct {
+	snapshot    validation.Snapshot
+	roles       map[string]Role
+	permissions map[string]bool
+}
+
+func newEvaluator(m *Model) *evaluator {
+	e := &evaluator{
+		snapshot:    m.Snapshot(),
+		roles:       rolesByName(m.Roles),
+		permissions: make(map[string]bool, len(m.Permissions)),
+	}
+	for _, p := range m.Permissions {
+		e.permissions[p.Name] = true
+	}
+	return e
+}
+
+// effective returns the names of the permissions a user with the direct roles and grants is allowed.
+// Roles and permissions not in the model are ignored, as they are removed along with it.
+func (e *evaluator) effective(direct []string, grants UserGrants) map[string]bool {
+	fu := &permissions.ForUser{RoleMap: e.snapshot.RoleMap}
+
+	seen := make(map[string]bool)
+	queue := append(append([]string{}, direct...), grants.GroupRoles...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		role, ok := e.roles[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		fu.Roles = append(fu.Roles, permissions.Role{ID: name, Name: name})
+		queue = append(queue, role.Inherits...)
+	}
+	for _, p := range grants.Extra {
+		if e.permissions[p] {
+			fu.ExtraPermissions = append(fu.ExtraPermissions, permissions.UserPermission{ID: p, Name: p})
+		}
+	}
+	for _, p := range grants.Revoked {
+		if e.permissions[p] {
+			fu.RevokedPermissions = append(fu.RevokedPermissions, permissions.UserPermission{ID: p, Name: p})
+		}
+	}
+
+	effective := make(map[string]bool)
+	for _, p := range fu.EffectivePermissions(e.snapshot.TenantPermissions) {
+		effective[p.Name] = true
+	}
+	return effective
+}
+
+func rolesByName(roles []Role) map[string]Role {
+	byName := make(map[string]Role, len(roles))
+	for _, r := range roles {
+		byName[r.Name] = r
+	}
+	return byName
+}
+
+func roleNames(roles []Role) []string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func resourceTypeNames(resourceTypes []ResourceType) []string {
+	names := make([]string, len(resourceTypes))
+	for i, rt := range resourceTypes {
+		names[i] = rt.Name
+	}
+	return names
+}
+
+func assignmentsByUser(assignments []Assignment) map[string][]string {
+	byUser := make(map[string][]string, len(assignments))
+	for _, a := range assignments {
+		byUser[a.UserID] = append(byUser[a.UserID], a.Roles...)
+	}
+	return byUser
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// difference returns the names only in a, and the names only in b, in the order they appear.
+func difference(a, b []string) (onlyA, onlyB []string) {
+	inA := make(map[string]bool, len(a))
+	for _, name := range a {
+		inA[name] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, name := range b {
+		inB[name] = true
+	}
+	for _, name := range a {
+		if !inB[name] {
+			onlyA = append(onlyA, name)
+		}
+	}
+	for _, name := range b {
+		if !inA[name] {
+			onlyB = append(onlyB, name)
+		}
+	}
+	return onlyA, onlyB
+}
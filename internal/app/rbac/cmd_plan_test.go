@@ -0,0 +1,150 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userAlice = "0f9a4c52-3b1e-4d8a-9c6f-2e7b5d1a8c34"
+	userBob   = "6d2b8e71-4a9c-4f3e-8b5d-1c7a9e3f2b60"
+)
+
+type fakeRepo struct {
+	rbac.ReaderWriter
+	model  *rbac.Model
+	grants map[string]rbac.UserGrants
+}
+
+func (f *fakeRepo) GetModel(context.Context) (*rbac.Model, error) {
+	return f.model, nil
+}
+
+func (f *fakeRepo) GetUserGrants(context.Context) (map[string]rbac.UserGrants, error) {
+	return f.grants, nil
+}
+
+func TestPlan(t *testing.T) {
+	current := &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}},
+		Permissions: []rbac.Permission{
+			{Name: "invoices:delete", Enabled: true},
+			{Name: "invoices:export", Enabled: true},
+			{Name: "invoices:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "clerk", Permissions: []string{"invoices:read"}},
+			{Name: "exporter", Permissions: []string{"invoices:export"}},
+			{Name: "manager", Permissions: []string{"invoices:delete"}, Inherits: []string{"clerk"}},
+		},
+		Assignments: []rbac.Assignment{
+			{UserID: userAlice, Roles: []string{"manager"}},
+			{UserID: userBob, Roles: []string{"clerk", "exporter"}},
+		},
+	}
+	grants := map[string]rbac.UserGrants{
+		userBob: {Revoked: []string{"invoices:read"}},
+	}
+	desired := &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}, {ID: 2, Name: "horses"}},
+		Permissions: []rbac.Permission{
+			{Name: "horses:read", Enabled: true},
+			{Name: "invoices:delete", Enabled: false},
+			{Name: "invoices:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "clerk", Permissions: []string{"invoices:read", "horses:read"}},
+			{Name: "manager", Permissions: []string{"invoices:delete"}, Inherits: []string{"clerk"}},
+		},
+	}
+
+	repo := &fakeRepo{model: current, grants: grants}
+	fingerprint := current.Fingerprint()
+
+	plan, err := rbac.NewService(repo).Plan(context.Background(), desired)
+	require.NoError(t, err)
+
+	assert.Equal(t, fingerprint, plan.Fingerprint)
+	assert.Equal(t, []rbac.Change{
+		{Action: rbac.ActionRemove, Kind: rbac.ChangeKindAssignment, Subject: userBob, Object: "exporter"},
+		{Action: rbac.ActionRemove, Kind: rbac.ChangeKindRolePermission, Subject: "exporter", Object: "invoices:export"},
+		{Action: rbac.ActionRemove, Kind: rbac.ChangeKindRole, Subject: "exporter"},
+		{Action: rbac.ActionRemove, Kind: rbac.ChangeKindPermission, Subject: "invoices:export"},
+		{Action: rbac.ActionAdd, Kind: rbac.ChangeKindResourceType, Subject: "horses", ResourceTypeID: 2},
+		{Action: rbac.ActionAdd, Kind: rbac.ChangeKindPermission, Subject: "horses:read", Enabled: true},
+		{Action: rbac.ActionChange, Kind: rbac.ChangeKindPermission, Subject: "invoices:delete", Enabled: false},
+		{Action: rbac.ActionAdd, Kind: rbac.ChangeKindRolePermission, Subject: "clerk", Object: "horses:read"},
+	}, plan.Changes)
+	assert.Equal(t, []rbac.Impact{
+		{Permission: "horses:read", Gained: 2},
+		{Permission: "invoices:delete", Lost: 1},
+		{Permission: "invoices:export", Lost: 1},
+	}, plan.Impacts)
+}
+
+func TestPlan_Assignments(t *testing.T) {
+	current := &rbac.Model{
+		Permissions: []rbac.Permission{{Name: "invoices:read", Enabled: true}},
+		Roles:       []rbac.Role{{Name: "clerk", Permissions: []string{"invoices:read"}}},
+		Assignments: []rbac.Assignment{{UserID: userAlice, Roles: []string{"clerk"}}},
+	}
+	desired := &rbac.Model{
+		Permissions: current.Permissions,
+		Roles:       current.Roles,
+		Assignments: []rbac.Assignment{{UserID: userBob, Roles: []string{"clerk"}}},
+	}
+
+	plan, err := rbac.NewService(&fakeRepo{model: current}).Plan(context.Background(), desired)
+	require.NoError(t, err)
+
+	assert.Equal(t, []rbac.Change{
+		{Action: rbac.ActionRemove, Kind: rbac.ChangeKindAssignment, Subject: userAlice, Object: "clerk"},
+		{Action: rbac.ActionAdd, Kind: rbac.ChangeKindAssignment, Subject: userBob, Object: "clerk"},
+	}, plan.Changes)
+	assert.Equal(t, []rbac.Impact{{Permission: "invoices:read", Gained: 1, Lost: 1}}, plan.Impacts)
+}
+
+func TestPlan_Conditions(t *testing.T) {
+	current := &rbac.Model{
+		Permissions: []rbac.Permission{
+			{Name: "invoices:approve", Enabled: true},
+			{Name: "invoices:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "approver", Permissions: []string{"invoices:approve"}},
+			{Name: "clerk", Permissions: []string{"invoices:read"}, Conditions: map[string]string{"invoices:read": "request.hour >= 9"}},
+		},
+	}
+	desired := &rbac.Model{
+		Permissions: current.Permissions,
+		Roles: []rbac.Role{
+			{Name: "approver", Permissions: []string{"invoices:approve"}, Conditions: map[string]string{"invoices:approve": "invoice.amount < 10000"}},
+			{Name: "clerk", Permissions: []string{"invoices:read"}},
+			{Name: "manager", Permissions: []string{"invoices:read"}, Conditions: map[string]string{"invoices:read": "user.region == \"north\""}},
+		},
+	}
+	assert.NotEqual(t, current.Fingerprint(), (&rbac.Model{Permissions: current.Permissions, Roles: desired.Roles[:2]}).Fingerprint())
+
+	plan, err := rbac.NewService(&fakeRepo{model: current}).Plan(context.Background(), desired)
+	require.NoError(t, err)
+
+	assert.Equal(t, []rbac.Change{
+		{Action: rbac.ActionAdd, Kind: rbac.ChangeKindRole, Subject: "manager"},
+		{Action: rbac.ActionChange, Kind: rbac.ChangeKindRolePermission, Subject: "approver", Object: "invoices:approve", Condition: "invoice.amount < 10000"},
+		{Action: rbac.ActionChange, Kind: rbac.ChangeKindRolePermission, Subject: "clerk", Object: "invoices:read"},
+		{Action: rbac.ActionAdd, Kind: rbac.ChangeKindRolePermission, Subject: "manager", Object: "invoices:read", Condition: `user.region == "north"`},
+	}, plan.Changes)
+}
+
+func TestPlan_Invalid(t *testing.T) {
+	desired := &rbac.Model{
+		Roles: []rbac.Role{{Name: "clerk", Permissions: []string{"invoices:read"}}},
+	}
+
+	_, err := rbac.NewService(&fakeRepo{model: &rbac.Model{}}).Plan(context.Background(), desired)
+	assert.ErrorContains(t, err, `grants unknown permission "invoices:read"`)
+}
@@ -0,0 +1,287 @@
+package rbac
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/validation"
+)
+
+// Model is a Tenant's role based access control model, keyed by name rather than ID
+// so that it can be kept in version control and applied to any Tenant.
+type Model struct {
+	ResourceTypes []ResourceType
+	Permissions   []Permission
+	Roles         []Role
+	// Assignments are the roles assigned directly to users. A nil Assignments
+	// means the model does not manage assignments, leaving them as they are.
+	Assignments []Assignment
+}
+
+type ResourceType struct {
+	// ID is optional when importing, a new resource type is given the next free ID.
+	ID   int64
+	Name string
+}
+
+type Permission struct {
+	Name string
+	// Enabled is whether the permission is active for the Tenant, i.e. in tenant_permissions.
+	Enabled bool
+}
+
+type Role struct {
+	Name string
+	// Permissions are the names of the permissions granted to the role.
+	Permissions []string
+	// Conditions are the conditions of the role's conditional grants, keyed by
+	// permission name. A permission without one is granted unconditionally.
+	Conditions map[string]string
+	// Inherits are the names of the roles this role inherits permissions from.
+	Inherits []string
+}
+
+type Assignment struct {
+	UserID string
+	// Roles are the names of the roles assigned to the user.
+	Roles []string
+}
+
+// Sort orders every list in the model by name, so that exported models are stable.
+func (m *Model) Sort() {
+	sort.Slice(m.ResourceTypes, func(i, j int) bool { return m.ResourceTypes[i].Name < m.ResourceTypes[j].Name })
+	sort.Slice(m.Permissions, func(i, j int) bool { return m.Permissions[i].Name < m.Permissions[j].Name })
+	sort.Slice(m.Roles, func(i, j int) bool { return m.Roles[i].Name < m.Roles[j].Name })
+	sort.Slice(m.Assignments, func(i, j int) bool { return m.Assignments[i].UserID < m.Assignments[j].UserID })
+	for _, r := range m.Roles {
+		sort.Strings(r.Permissions)
+		sort.Strings(r.Inherits)
+	}
+	for _, a := range m.Assignments {
+		sort.Strings(a.Roles)
+	}
+}
+
+// Fingerprint identifies the content of the model, two models with the same
+// content have the same fingerprint. The model is sorted first.
+func (m *Model) Fingerprint() string {
+	m.Sort()
+	h := sha256.New()
+	for _, rt := range m.ResourceTypes {
+		fmt.Fprintf(h, "resource_type\x00%d\x00%s\n", rt.ID, rt.Name)
+	}
+	for _, p := range m.Permissions {
+		fmt.Fprintf(h, "permission\x00%s\x00%t\n", p.Name, p.Enabled)
+	}
+	for _, r := range m.Roles {
+		fmt.Fprintf(h, "role\x00%s\n", r.Name)
+		for _, p := range r.Permissions {
+			fmt.Fprintf(h, "role_permission\x00%s\x00%s\n", r.Name, p)
+			if c := r.Conditions[p]; c != "" {
+				fmt.Fprintf(h, "role_permission_condition\x00%s\x00%s\x00%s\n", r.Name, p, c)
+			}
+		}
+		for _, child := range r.Inherits {
+			fmt.Fprintf(h, "role_inherit\x00%s\x00%s\n", r.Name, child)
+		}
+	}
+	for _, a := range m.Assignments {
+		for _, role := range a.Roles {
+			fmt.Fprintf(h, "assignment\x00%s\x00%s\n", a.UserID, role)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ValidateNames checks that names are well formed and not repeated.
+func (m *Model) ValidateNames() error {
+	var errs []error
+
+	resourceTypes := make(map[string]bool)
+	for _, rt := range m.ResourceTypes {
+		if rt.Name == "" {
+			errs = append(errs, fmt.Errorf("resource type with ID %d has no name", rt.ID))
+		}
+		if resourceTypes[rt.Name] {
+			errs = append(errs, fmt.Errorf("resource type %q is repeated", rt.Name))
+		}
+		resourceTypes[rt.Name] = true
+	}
+
+	perms := make(map[string]bool)
+	for _, p := range m.Permissions {
+		if err := permissions.ValidatePermissionName(p.Name); err != nil {
+			errs = append(errs, err)
+		}
+		if perms[p.Name] {
+			errs = append(errs, fmt.Errorf("permission %q is repeated", p.Name))
+		}
+		perms[p.Name] = true
+	}
+
+	roles := make(map[string]bool)
+	for _, r := range m.Roles {
+		if r.Name == "" {
+			errs = append(errs, errors.New("role with no name"))
+		}
+		if roles[r.Name] {
+			errs = append(errs, fmt.Errorf("role %q is repeated", r.Name))
+		}
+		roles[r.Name] = true
+	}
+
+	users := make(map[string]bool)
+	for _, a := range m.Assignments {
+		if err := uuid.Validate(a.UserID); err != nil {
+			errs = append(errs, fmt.Errorf("assignment user ID %q is not a UUID", a.UserID))
+		}
+		if users[a.UserID] {
+			errs = append(errs, fmt.Errorf("assignments for user %q are repeated", a.UserID))
+		}
+		users[a.UserID] = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateReferences checks that every permission and role referenced by a role
+// is in the model, and that a role's conditions are on permissions it grants.
+func (m *Model) ValidateReferences() error {
+	var errs []error
+
+	perms := make(map[string]bool)
+	for _, p := range m.Permissions {
+		perms[p.Name] = true
+	}
+	roles := make(map[string]bool)
+	for _, r := range m.Roles {
+		roles[r.Name] = true
+	}
+
+	for _, r := range m.Roles {
+		for _, p := range r.Permissions {
+			if !perms[p] {
+				errs = append(errs, fmt.Errorf("role %q grants unknown permission %q", r.Name, p))
+			}
+		}
+		for _, p := range sortedConditions(r.Conditions) {
+			if !slices.Contains(r.Permissions, p) {
+				errs = append(errs, fmt.Errorf("role %q has a condition on permission %q it does not grant", r.Name, p))
+			}
+		}
+		for _, child := range r.Inherits {
+			if !roles[child] {
+				errs = append(errs, fmt.Errorf("role %q inherits unknown role %q", r.Name, child))
+			}
+		}
+	}
+	for _, a := range m.Assignments {
+		for _, role := range a.Roles {
+			if !roles[role] {
+				errs = append(errs, fmt.Errorf("user %q is assigned unknown role %q", a.UserID, role))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Merge returns the model that results from importing the desired model over m.
+// Resource types, permissions and roles in desired replace those of the same
+// name in m, anything not in desired is kept. The assignments of m are kept.
+func (m *Model) Merge(desired *Model) *Model {
+	merged := &Model{Assignments: m.Assignments}
+
+	resourceTypes := make(map[string]bool)
+	for _, rt := range desired.ResourceTypes {
+		resourceTypes[rt.Name] = true
+	}
+	for _, rt := range m.ResourceTypes {
+		if !resourceTypes[rt.Name] {
+			merged.ResourceTypes = append(merged.ResourceTypes, rt)
+		}
+	}
+	merged.ResourceTypes = append(merged.ResourceTypes, desired.ResourceTypes...)
+
+	perms := make(map[string]bool)
+	for _, p := range desired.Permissions {
+		perms[p.Name] = true
+	}
+	for _, p := range m.Permissions {
+		if !perms[p.Name] {
+			merged.Permissions = append(merged.Permissions, p)
+		}
+	}
+	merged.Permissions = append(merged.Permissions, desired.Permissions...)
+
+	roles := make(map[string]bool)
+	for _, r := range desired.Roles {
+		roles[r.Name] = true
+	}
+	for _, r := range m.Roles {
+		if !roles[r.Name] {
+			merged.Roles = append(merged.Roles, r)
+		}
+	}
+	merged.Roles = append(merged.Roles, desired.Roles...)
+
+	merged.Sort()
+	return merged
+}
+
+// Snapshot returns the model as a validation.Snapshot. Only direct assignments
+// are in the model, so roles held through groups are not accounted for.
+func (m *Model) Snapshot() validation.Snapshot {
+	// Roles are identified by name within a model.
+	role := func(name string) permissions.Role {
+		return permissions.Role{ID: name, Name: name}
+	}
+
+	s := validation.Snapshot{
+		RoleMap: make(permissions.TenantRoleMap, len(m.Roles)),
+	}
+	for _, p := range m.Permissions {
+		if p.Enabled {
+			s.TenantPermissions = append(s.TenantPermissions, permissions.TenantPermission{ID: p.Name, Name: p.Name})
+		}
+	}
+	for _, r := range m.Roles {
+		mapped := permissions.TenantMappedRole{}
+		for _, p := range r.Permissions {
+			mapped.Permissions = append(mapped.Permissions, permissions.TenantPermission{ID: p, Name: p, Condition: r.Conditions[p]})
+		}
+		for _, child := range r.Inherits {
+			mapped.Inherits = append(mapped.Inherits, role(child))
+		}
+		s.RoleMap[role(r.Name)] = mapped
+	}
+	if m.Assignments != nil {
+		s.RoleAssignments = make(permissions.TenantRoleAssignments, len(m.Assignments))
+		for _, a := range m.Assignments {
+			for _, r := range a.Roles {
+				s.RoleAssignments[a.UserID] = append(s.RoleAssignments[a.UserID], permissions.RoleAssignment{
+					Role:   role(r),
+					Source: permissions.RoleSourceDirect,
+				})
+			}
+		}
+	}
+	return s
+}
+
+// sortedConditions returns the permission names of the conditions, in order.
+func sortedConditions(conditions map[string]string) []string {
+	names := make([]string, 0, len(conditions))
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
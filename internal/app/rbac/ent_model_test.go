@@ -0,0 +1,84 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_ValidateNames(t *testing.T) {
+	model := &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}, {ID: 2, Name: "invoices"}},
+		Permissions:   []rbac.Permission{{Name: "invoices:read"}, {Name: "invoices"}, {Name: "invoices:read"}},
+		Roles:         []rbac.Role{{Name: "clerk"}, {Name: ""}, {Name: "clerk"}},
+	}
+
+	err := model.ValidateNames()
+	assert.ErrorContains(t, err, `resource type "invoices" is repeated`)
+	assert.ErrorContains(t, err, `permission "invoices:read" is repeated`)
+	assert.ErrorContains(t, err, `"invoices"`)
+	assert.ErrorContains(t, err, "role with no name")
+	assert.ErrorContains(t, err, `role "clerk" is repeated`)
+
+	assert.NoError(t, (&rbac.Model{}).ValidateNames())
+}
+
+func TestModel_Merge(t *testing.T) {
+	current := &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}},
+		Permissions: []rbac.Permission{
+			{Name: "invoices:read", Enabled: true},
+			{Name: "invoices:delete", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "clerk", Permissions: []string{"invoices:read"}},
+			{Name: "manager", Permissions: []string{"invoices:delete"}, Inherits: []string{"clerk"}},
+		},
+	}
+	desired := &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{Name: "horses"}},
+		Permissions: []rbac.Permission{
+			{Name: "invoices:delete", Enabled: false},
+			{Name: "horses:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "manager", Permissions: []string{"horses:read"}, Inherits: []string{"clerk"}},
+		},
+	}
+
+	merged := current.Merge(desired)
+
+	assert.Equal(t, &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{Name: "horses"}, {ID: 1, Name: "invoices"}},
+		Permissions: []rbac.Permission{
+			{Name: "horses:read", Enabled: true},
+			{Name: "invoices:delete", Enabled: false},
+			{Name: "invoices:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "clerk", Permissions: []string{"invoices:read"}},
+			{Name: "manager", Permissions: []string{"horses:read"}, Inherits: []string{"clerk"}},
+		},
+	}, merged)
+	assert.NoError(t, merged.ValidateReferences())
+}
+
+func TestModel_ValidateReferences(t *testing.T) {
+	model := &rbac.Model{
+		Permissions: []rbac.Permission{{Name: "invoices:read"}},
+		Roles: []rbac.Role{
+			{
+				Name:        "clerk",
+				Permissions: []string{"invoices:read", "invoices:write"},
+				Conditions:  map[string]string{"invoices:delete": "invoice.amount < 100"},
+				Inherits:    []string{"auditor"},
+			},
+		},
+	}
+
+	err := model.ValidateReferences()
+	assert.ErrorContains(t, err, `role "clerk" grants unknown permission "invoices:write"`)
+	assert.ErrorContains(t, err, `role "clerk" has a condition on permission "invoices:delete" it does not grant`)
+	assert.ErrorContains(t, err, `role "clerk" inherits unknown role "auditor"`)
+}
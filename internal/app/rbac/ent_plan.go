@@ -0,0 +1,68 @@
+package rbac
+
+import "errors"
+
+// ErrPlanStale is returned when applying a plan to a Tenant that has changed since the plan was made.
+var ErrPlanStale = errors.New("tenant has changed since the plan was made")
+
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionChange Action = "change"
+	ActionRemove Action = "remove"
+)
+
+type ChangeKind string
+
+const (
+	ChangeKindResourceType ChangeKind = "resource_type"
+	ChangeKindPermission   ChangeKind = "permission"
+	ChangeKindRole         ChangeKind = "role"
+	// ChangeKindRolePermission is an edge from a role to a permission it grants,
+	// it is changed when the grant's condition is.
+	ChangeKindRolePermission ChangeKind = "role_permission"
+	// ChangeKindRoleInherit is an edge from a role to a role it inherits.
+	ChangeKindRoleInherit ChangeKind = "role_inherit"
+	// ChangeKindAssignment is a role assigned directly to a user.
+	ChangeKindAssignment ChangeKind = "assignment"
+)
+
+// Change is a single step of a plan.
+type Change struct {
+	Action Action
+	Kind   ChangeKind
+	// Subject is the name of the resource type, permission or role, the role of
+	// an edge, or the user ID of an assignment.
+	Subject string
+	// Object is the permission or inherited role of an edge, or the role of an assignment.
+	Object string
+	// Enabled is the state of a permission that is added or changed.
+	Enabled bool
+	// Condition is that of a role's grant of a permission that is added or
+	// changed, empty for an unconditional grant.
+	Condition string
+	// ResourceTypeID is the ID of a resource type that is added, zero for the next free ID.
+	ResourceTypeID int64
+}
+
+// Impact is how many users gain or lose an effective permission.
+type Impact struct {
+	Permission string
+	Gained     int
+	Lost       int
+}
+
+// Plan is the changes needed to make a Tenant match a desired model.
+type Plan struct {
+	// Fingerprint is of the Tenant's model when the plan was made, see Model.Fingerprint.
+	Fingerprint string
+	// Changes are in the order they are applied.
+	Changes []Change
+	Impacts []Impact
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
@@ -0,0 +1,10 @@
+package rbac
+
+// UserGrants are what a user holds besides the roles assigned directly to them.
+type UserGrants struct {
+	// GroupRoles are the names of the roles held through groups.
+	GroupRoles []string
+	// Extra and Revoked are the names of the user's extra and revoked permissions.
+	Extra   []string
+	Revoked []string
+}
@@ -0,0 +1,23 @@
+package rbac
+
+import "context"
+
+type Reader interface {
+	// GetModel returns the Tenant's model, including its assignments.
+	GetModel(ctx context.Context) (*Model, error)
+	// GetUserGrants returns the grants of every user that has any, keyed by user ID.
+	GetUserGrants(ctx context.Context) (map[string]UserGrants, error)
+}
+
+type Writer interface {
+	// ImportModel applies the model to the Tenant, see Service.Import.
+	ImportModel(ctx context.Context, model *Model) error
+	// ApplyPlan makes the plan's changes if the Tenant's model still has the
+	// plan's fingerprint, otherwise it returns ErrPlanStale.
+	ApplyPlan(ctx context.Context, plan *Plan) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
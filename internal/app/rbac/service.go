@@ -0,0 +1,10 @@
+package rbac
+
+type Service struct {
+	repo ReaderWriter
+}
+
+// NewService creates a new RBAC model service
+func NewService(repo ReaderWriter) *Service {
+	return &Service{repo: repo}
+}
@@ -0,0 +1,21 @@
+package relations
+
+import (
+	"context"
+	"fmt"
+)
+
+// Check reports whether the subject has the relation to the object, either
+// directly or because it is in a userset which has it, at any depth.
+// e.g. with "document:readme#viewer@folder:reports#viewer" and
+// "folder:reports#viewer@user:<id>", the user is a viewer of the document.
+func (s *Service) Check(ctx context.Context, userset Userset, subject Subject) (bool, error) {
+	if err := (Tuple{Object: userset.Object, Relation: userset.Relation, Subject: subject}).Validate(); err != nil {
+		return false, fmt.Errorf("check: %w", err)
+	}
+	ok, err := s.repo.CheckTuple(ctx, userset, subject)
+	if err != nil {
+		return false, fmt.Errorf("check: %w", err)
+	}
+	return ok, nil
+}
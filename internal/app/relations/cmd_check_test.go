@@ -0,0 +1,185 @@
+//go:build test
+// +build test
+
+package relations_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/imports"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/relations"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	userAdmin        = "032fb302-4aee-4a68-b426-0c6faf12081e"
+	userSalesManager = "652f4d18-dd3d-40c0-874e-cbe3566abccf"
+	userSalesPerson  = "2133479c-35a8-4a49-a682-2952d4772ecc"
+	userReadOnly     = "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42"
+
+	roleSalesAuditor = "da244750-f014-415c-b7b9-43ead3d8fa25"
+)
+
+func newTestService(t *testing.T) (context.Context, *relations.Service) {
+	t.Helper()
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	return ctx, relations.NewService(repo)
+}
+
+func mustUserset(t *testing.T, s string) relations.Userset {
+	t.Helper()
+	u, err := relations.ParseUserset(s)
+	require.NoError(t, err)
+	return u
+}
+
+func mustSubject(t *testing.T, s string) relations.Subject {
+	t.Helper()
+	sub, err := relations.ParseSubject(s)
+	require.NoError(t, err)
+	return sub
+}
+
+func TestCheck(t *testing.T) {
+	ctx, svc := newTestService(t)
+
+	t.Run("Role membership is projected", func(t *testing.T) {
+		auditors := mustUserset(t, "role:"+roleSalesAuditor+"#member")
+		for userID, want := range map[string]bool{
+			userSalesPerson:  true, // Inherited from Sales Person.
+			userSalesManager: true, // Inherited from Sales Manager, through Sales Person.
+			userReadOnly:     true, // Through the North Sales Team, nested in the Sales Team.
+			userAdmin:        false,
+		} {
+			ok, err := svc.Check(ctx, auditors, mustSubject(t, "user:"+userID))
+			require.NoError(t, err)
+			assert.Equal(t, want, ok, "user ID: %s", userID)
+		}
+	})
+
+	t.Run("User resources are projected", func(t *testing.T) {
+		ok, err := svc.Check(ctx, mustUserset(t, "invoices:6b63b489-61cb-4087-8636-f10716bd724e#delete"), mustSubject(t, "user:"+userSalesPerson))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		tuples, err := svc.Read(ctx, relations.Filter{Namespace: "invoices", ObjectID: "6b63b489-61cb-4087-8636-f10716bd724e"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"invoices:6b63b489-61cb-4087-8636-f10716bd724e#delete@user:" + userSalesPerson}, tuples.StringSlice())
+	})
+
+	t.Run("Written tuples are followed through usersets", func(t *testing.T) {
+		tuples := mustTuples(t,
+			"document:readme#viewer@folder:reports#viewer",
+			"folder:reports#viewer@user:"+userAdmin,
+			"folder:reports#viewer@role:"+roleSalesAuditor+"#member",
+		)
+		require.NoError(t, svc.Write(ctx, tuples))
+		// Writing again leaves the tuples as they are.
+		require.NoError(t, svc.Write(ctx, tuples))
+
+		readme := mustUserset(t, "document:readme#viewer")
+		for _, userID := range []string{userAdmin, userReadOnly} {
+			ok, err := svc.Check(ctx, readme, mustSubject(t, "user:"+userID))
+			require.NoError(t, err)
+			assert.True(t, ok, "user ID: %s", userID)
+		}
+
+		tree, err := svc.Expand(ctx, readme)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []relations.Subject{
+			mustSubject(t, "user:"+userAdmin),
+			mustSubject(t, "user:"+userSalesPerson),
+			mustSubject(t, "user:"+userSalesManager),
+			mustSubject(t, "user:"+userReadOnly),
+		}, tree.Leaves())
+
+		require.NoError(t, svc.Delete(ctx, tuples[1:2]))
+		ok, err := svc.Check(ctx, readme, mustSubject(t, "user:"+userAdmin))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Projected namespaces cannot be written", func(t *testing.T) {
+		err := svc.Write(ctx, mustTuples(t, "role:"+roleSalesAuditor+"#member@user:"+userAdmin))
+		assert.Error(t, err)
+	})
+}
+
+func TestCheck_ExpiredGrantsAreNotProjected(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := relations.NewService(repo)
+	const invoice = "6b63b489-61cb-4087-8636-f10716bd724e"
+
+	expired := time.Now().Add(-time.Hour)
+	_, err := repo.ImportAssignments(ctx, []imports.Assignment{
+		{Line: 2, UserID: userAdmin, Role: "sales auditor", ExpiresAt: &expired},
+		{Line: 3, UserID: userAdmin, Role: "sales auditor", Resource: &permissions.Resource{Type: "invoices", ID: invoice}, Permissions: []string{"invoices:read"}, ExpiresAt: &expired},
+	})
+	require.NoError(t, err)
+
+	for _, userset := range []string{"role:" + roleSalesAuditor + "#member", "invoices:" + invoice + "#read"} {
+		ok, err := svc.Check(ctx, mustUserset(t, userset), mustSubject(t, "user:"+userAdmin))
+		require.NoError(t, err)
+		assert.False(t, ok, "userset: %s", userset)
+	}
+}
+
+func TestCheck_AgreesWithAllowsOn(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := relations.NewService(repo)
+	const (
+		userAccountManager = "8e0a2c4e-6f1b-4d3a-b5c7-9d1f3b5d7e64"
+		invoice1           = "6b63b489-61cb-4087-8636-f10716bd724e"
+		invoice2           = "568104df-6ff3-40be-b660-91e3160aa7e6"
+	)
+	var (
+		customer = permissions.Resource{ID: "d2f4a6c8-0e1b-4d3f-a5c7-9e1b3d5f7a92", Type: "customers"}
+		contact  = permissions.Resource{ID: "1e3a5c7e-9b0d-4f2a-8c4e-6a8c0e2b4d71", Type: "contacts"}
+	)
+
+	// The Account Manager has no roles, so AllowsOn only counts their grants on resources, as Check does.
+	_, err := repo.ImportAssignments(ctx, []imports.Assignment{
+		{Line: 2, UserID: userAccountManager, Role: "sales auditor", Resource: &permissions.Resource{Type: "invoices", ID: invoice1}, Permissions: []string{"invoices:*"}},
+		{Line: 3, UserID: userAccountManager, Role: "sales auditor", Resource: &permissions.Resource{Type: "invoices", ID: invoice2}, Permissions: []string{"customers:read"}},
+	})
+	require.NoError(t, err)
+
+	fu, err := permissions.NewService(repo).GetForUser(contextkey.WithUserID(ctx, userAccountManager), nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		resource permissions.Resource
+		action   string
+		want     bool
+	}{
+		{resource: permissions.Resource{Type: "invoices", ID: invoice1}, action: "read", want: true},   // Every action is granted.
+		{resource: permissions.Resource{Type: "invoices", ID: invoice1}, action: "delete", want: true}, // Every action is granted.
+		{resource: permissions.Resource{Type: "invoices", ID: invoice2}, action: "read", want: false},  // The permission is on customers.
+		{resource: customer, action: "read", want: true},
+		{resource: contact, action: "read", want: true}, // Inherited from the customer.
+		{resource: contact, action: "delete", want: false},
+	} {
+		userset := mustUserset(t, tc.resource.Type+":"+tc.resource.ID+"#"+tc.action)
+		ok, err := svc.Check(ctx, userset, mustSubject(t, "user:"+userAccountManager))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, ok, "userset: %s", userset)
+		assert.Equal(t, fu.AllowsOn(tc.resource.Type+":"+tc.action, tc.resource), ok, "userset: %s", userset)
+	}
+
+	t.Run("Resource type namespaces cannot be written", func(t *testing.T) {
+		err := svc.Write(ctx, mustTuples(t, "invoices:"+invoice2+"#read@user:"+userAccountManager))
+		assert.ErrorIs(t, err, permissions.ErrInvalidInput)
+
+		err = svc.Delete(ctx, mustTuples(t, "customers:"+customer.ID+"#read@user:"+userAccountManager))
+		assert.ErrorIs(t, err, permissions.ErrInvalidInput)
+		ok, err := svc.Check(ctx, mustUserset(t, "customers:"+customer.ID+"#read"), mustSubject(t, "user:"+userAccountManager))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
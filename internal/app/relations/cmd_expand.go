@@ -0,0 +1,22 @@
+package relations
+
+import (
+	"context"
+	"fmt"
+)
+
+// Expand returns the tree of every subject with the relation to the object,
+// following the usersets related to it.
+func (s *Service) Expand(ctx context.Context, userset Userset) (*Tree, error) {
+	if err := userset.Object.Validate(); err != nil {
+		return nil, fmt.Errorf("expand: %w", err)
+	}
+	if err := validateName("relation", userset.Relation); err != nil {
+		return nil, fmt.Errorf("expand: %w", err)
+	}
+	tuples, err := s.repo.ExpandTuples(ctx, userset)
+	if err != nil {
+		return nil, fmt.Errorf("expand: %w", err)
+	}
+	return newTree(userset, tuples), nil
+}
@@ -0,0 +1,129 @@
+package relations_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/relations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	relations.ReaderWriter
+	tuples relations.Tuples
+}
+
+func (f *fakeRepo) ExpandTuples(context.Context, relations.Userset) (relations.Tuples, error) {
+	return f.tuples, nil
+}
+
+func mustTuples(t *testing.T, strs ...string) relations.Tuples {
+	t.Helper()
+	tuples := make(relations.Tuples, len(strs))
+	for i, s := range strs {
+		tuple, err := relations.ParseTuple(s)
+		require.NoError(t, err)
+		tuples[i] = tuple
+	}
+	return tuples
+}
+
+func TestExpand(t *testing.T) {
+	repo := &fakeRepo{tuples: mustTuples(t,
+		"document:readme#viewer@user:alice",
+		"document:readme#viewer@folder:reports#viewer",
+		"folder:reports#viewer@user:bob",
+		"folder:reports#viewer@user:alice",
+		"folder:reports#viewer@document:readme#viewer", // A cycle back to the document.
+	)}
+
+	userset, err := relations.ParseUserset("document:readme#viewer")
+	require.NoError(t, err)
+
+	tree, err := relations.NewService(repo).Expand(context.Background(), userset)
+	require.NoError(t, err)
+
+	folder, err := relations.ParseUserset("folder:reports#viewer")
+	require.NoError(t, err)
+	alice, _ := relations.ParseSubject("user:alice")
+	bob, _ := relations.ParseSubject("user:bob")
+
+	assert.Equal(t, &relations.Tree{
+		Userset:  userset,
+		Subjects: []relations.Subject{alice},
+		Children: []*relations.Tree{
+			{
+				Userset:  folder,
+				Subjects: []relations.Subject{bob, alice},
+				Children: []*relations.Tree{{Userset: userset, Cycle: true}},
+			},
+		},
+	}, tree)
+	assert.Equal(t, []relations.Subject{alice, bob}, tree.Leaves())
+}
+
+func TestExpand_Diamonds(t *testing.T) {
+	// Each level's two usersets both include the next level's, so there are
+	// 2^levels paths to the last, which is expanded once.
+	const levels = 20
+	var strs []string
+	for i := 0; i < levels; i++ {
+		for _, side := range []string{"a", "b"} {
+			strs = append(strs,
+				fmt.Sprintf("group:%d%s#member@group:%da#member", i, side, i+1),
+				fmt.Sprintf("group:%d%s#member@group:%db#member", i, side, i+1),
+			)
+		}
+	}
+	strs = append(strs, fmt.Sprintf("group:%da#member@user:alice", levels))
+	repo := &fakeRepo{tuples: mustTuples(t, strs...)}
+
+	userset, err := relations.ParseUserset("group:0a#member")
+	require.NoError(t, err)
+	tree, err := relations.NewService(repo).Expand(context.Background(), userset)
+	require.NoError(t, err)
+
+	var count func(t *relations.Tree) int
+	count = func(t *relations.Tree) int {
+		n := 1
+		for _, child := range t.Children {
+			n += count(child)
+		}
+		return n
+	}
+	assert.LessOrEqual(t, count(tree), 4*levels+1, "each userset is expanded once")
+	alice, _ := relations.ParseSubject("user:alice")
+	assert.Equal(t, []relations.Subject{alice}, tree.Leaves())
+}
+
+func TestWrite_Projected(t *testing.T) {
+	err := relations.NewService(&fakeRepo{}).Write(context.Background(), mustTuples(t,
+		"role:da244750-f014-415c-b7b9-43ead3d8fa25#member@user:alice",
+	))
+	assert.ErrorContains(t, err, "projected from the RBAC tables")
+}
+
+func TestWrite_RelationAny(t *testing.T) {
+	err := relations.NewService(&fakeRepo{}).Write(context.Background(), mustTuples(t,
+		"document:readme#*@user:alice",
+	))
+	assert.ErrorContains(t, err, "only projected")
+}
+
+func TestExpand_RelationAny(t *testing.T) {
+	repo := &fakeRepo{tuples: mustTuples(t,
+		"invoices:1#read@user:alice",
+		"invoices:1#*@user:bob", // Every action on the invoice.
+	)}
+
+	userset, err := relations.ParseUserset("invoices:1#read")
+	require.NoError(t, err)
+	tree, err := relations.NewService(repo).Expand(context.Background(), userset)
+	require.NoError(t, err)
+
+	alice, _ := relations.ParseSubject("user:alice")
+	bob, _ := relations.ParseSubject("user:bob")
+	assert.Equal(t, []relations.Subject{alice, bob}, tree.Leaves())
+}
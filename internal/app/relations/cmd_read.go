@@ -0,0 +1,18 @@
+package relations
+
+import (
+	"context"
+	"fmt"
+)
+
+// Read returns the tuples matching the filter, both stored and projected.
+func (s *Service) Read(ctx context.Context, filter Filter) (Tuples, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	tuples, err := s.repo.ReadTuples(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return tuples, nil
+}
@@ -0,0 +1,45 @@
+package relations
+
+import (
+	"context"
+	"fmt"
+)
+
+// Write stores the tuples, all or none of them. Tuples in projected namespaces
+// cannot be written, see Projected.
+func (s *Service) Write(ctx context.Context, tuples Tuples) error {
+	if err := validateWritable(tuples); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := s.repo.WriteTuples(ctx, tuples); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the stored tuples, all or none of them. Tuples in projected
+// namespaces cannot be deleted, see Projected.
+func (s *Service) Delete(ctx context.Context, tuples Tuples) error {
+	if err := validateWritable(tuples); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	if err := s.repo.DeleteTuples(ctx, tuples); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+func validateWritable(tuples Tuples) error {
+	for _, t := range tuples {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if Projected(t.Object.Namespace) {
+			return fmt.Errorf("tuple %s is in the %s namespace, which is projected from the RBAC tables", t, t.Object.Namespace)
+		}
+		if t.Relation == RelationAny {
+			return fmt.Errorf("tuple %s has the relation %s, which is only projected, for grants of every action", t, RelationAny)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package relations
+
+import "fmt"
+
+// Filter selects tuples, empty fields match any value.
+type Filter struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	// Subject, when set, matches tuples whose subject is exactly the subject.
+	Subject *Subject
+}
+
+// Validate checks the filter is narrow enough to be answered from an index,
+// by a namespace or a subject.
+func (f Filter) Validate() error {
+	if f.Namespace == "" && f.Subject == nil {
+		return fmt.Errorf("filter needs a namespace or a subject")
+	}
+	if f.ObjectID != "" && f.Namespace == "" {
+		return fmt.Errorf("filter needs a namespace for an object ID")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package relations
+
+// Tree is the expansion of a Userset, every subject with the relation to the object.
+type Tree struct {
+	Userset Userset
+	// Subjects are the single subjects related directly to the object.
+	Subjects []Subject
+	// Children are the expansions of the usersets related to the object, whose
+	// subjects are also related to it.
+	Children []*Tree
+	// Cycle is set when the Userset is already being expanded further up the
+	// tree, its subjects are not repeated.
+	Cycle bool
+	// Repeat is set when the Userset is expanded elsewhere in the tree, reached
+	// along another path, its subjects are not repeated either. A userset is
+	// thus expanded once, however many paths lead to it.
+	Repeat bool
+}
+
+// Leaves returns every single subject in the tree, without repeats, in the order they are found.
+func (t *Tree) Leaves() []Subject {
+	var leaves []Subject
+	seen := make(map[Subject]bool)
+	var walk func(t *Tree)
+	walk = func(t *Tree) {
+		for _, s := range t.Subjects {
+			if !seen[s] {
+				seen[s] = true
+				leaves = append(leaves, s)
+			}
+		}
+		for _, child := range t.Children {
+			walk(child)
+		}
+	}
+	walk(t)
+	return leaves
+}
+
+// newTree builds the tree for the userset from tuples, which must include every
+// tuple reachable from the userset. A tuple with RelationAny relates its subject
+// to the object by every relation.
+func newTree(userset Userset, tuples Tuples) *Tree {
+	byUserset := make(map[Userset]Tuples)
+	for _, t := range tuples {
+		byUserset[t.Userset()] = append(byUserset[t.Userset()], t)
+	}
+
+	onPath := make(map[Userset]bool)
+	expanded := make(map[Userset]bool)
+	var build func(u Userset) *Tree
+	build = func(u Userset) *Tree {
+		tree := &Tree{Userset: u}
+		switch {
+		case onPath[u]:
+			tree.Cycle = true
+			return tree
+		case expanded[u]:
+			tree.Repeat = true
+			return tree
+		}
+		onPath[u] = true
+		expanded[u] = true
+		defer delete(onPath, u)
+
+		related := byUserset[u]
+		if u.Relation != RelationAny {
+			// Subjects with every relation to the object have this one too.
+			related = append(related[:len(related):len(related)], byUserset[Userset{Object: u.Object, Relation: RelationAny}]...)
+		}
+		for _, t := range related {
+			if t.Subject.IsUserset() {
+				tree.Children = append(tree.Children, build(t.Subject.Userset()))
+				continue
+			}
+			tree.Subjects = append(tree.Subjects, t.Subject)
+		}
+		return tree
+	}
+	return build(userset)
+}
@@ -0,0 +1,206 @@
+package relations
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// NamespaceUser is the namespace of users, the subjects relations are usually checked for.
+	NamespaceUser = "user"
+	// NamespaceRole is the namespace of roles, projected from the role tables.
+	// Each role has a "member" relation, held by the users assigned the role,
+	// directly, through a group, or through a role which inherits it.
+	NamespaceRole = "role"
+	// NamespaceGroup is the namespace of groups, projected from the group tables.
+	// Each group has a "member" relation, held by the group's users and the members of its nested groups.
+	NamespaceGroup = "group"
+	// RelationMember is the relation projected for role and group membership.
+	RelationMember = "member"
+	// RelationAny is the relation projected for a grant of every action on a
+	// resource, e.g. "invoices:*". It holds every relation of the resource.
+	RelationAny = "*"
+)
+
+// Projected reports whether the namespace's tuples are projected from the RBAC
+// tables. They are changed through the RBAC model, not written as tuples.
+//
+// A User's grants on single resources, in user_resources, are also projected,
+// as AllowsOn reads them. The object is the resource, in the namespace of its
+// resource type, and the relation is the action of the granted permission,
+// e.g. "customers:<id>#read@user:<id>". A grant also relates the user to the
+// resource's descendants by the same action. Resource type namespaces are
+// therefore projected too, which only the repository can tell, so it rejects
+// writes and deletes in them.
+func Projected(namespace string) bool {
+	return namespace == NamespaceRole || namespace == NamespaceGroup
+}
+
+// Object is an object in a namespace, written "namespace:id", e.g. "document:readme".
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+// Userset is the set of subjects with a relation to an object, written "namespace:id#relation".
+type Userset struct {
+	Object   Object
+	Relation string
+}
+
+func (u Userset) String() string {
+	return u.Object.String() + "#" + u.Relation
+}
+
+// Subject is either a single subject, written "namespace:id", e.g. "user:<id>",
+// or every subject in a Userset, written "namespace:id#relation", e.g. "folder:reports#viewer".
+type Subject struct {
+	Object Object
+	// Relation is empty for a single subject.
+	Relation string
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Object.String()
+	}
+	return s.Userset().String()
+}
+
+// IsUserset reports whether the subject is a Userset rather than a single subject.
+func (s Subject) IsUserset() bool {
+	return s.Relation != ""
+}
+
+// Userset returns the subject as a Userset.
+func (s Subject) Userset() Userset {
+	return Userset{Object: s.Object, Relation: s.Relation}
+}
+
+type Tuples []Tuple
+
+func (ts Tuples) StringSlice() []string {
+	strs := make([]string, len(ts))
+	for i, t := range ts {
+		strs[i] = t.String()
+	}
+	return strs
+}
+
+// Tuple relates a subject to an object, written "namespace:id#relation@subject",
+// e.g. "document:readme#editor@user:<id>" or "document:readme#viewer@folder:reports#viewer".
+type Tuple struct {
+	Object   Object
+	Relation string
+	Subject  Subject
+}
+
+func (t Tuple) String() string {
+	return t.Userset().String() + "@" + t.Subject.String()
+}
+
+// Userset returns the tuple's object and relation.
+func (t Tuple) Userset() Userset {
+	return Userset{Object: t.Object, Relation: t.Relation}
+}
+
+// ParseTuple parses a tuple written "namespace:id#relation@subject".
+func ParseTuple(s string) (Tuple, error) {
+	userset, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, fmt.Errorf("tuple %q is not of the form namespace:id#relation@subject", s)
+	}
+	u, err := ParseUserset(userset)
+	if err != nil {
+		return Tuple{}, fmt.Errorf("tuple %q: %w", s, err)
+	}
+	sub, err := ParseSubject(subject)
+	if err != nil {
+		return Tuple{}, fmt.Errorf("tuple %q: %w", s, err)
+	}
+	return Tuple{Object: u.Object, Relation: u.Relation, Subject: sub}, nil
+}
+
+// ParseUserset parses a userset written "namespace:id#relation".
+func ParseUserset(s string) (Userset, error) {
+	object, relation, ok := strings.Cut(s, "#")
+	if !ok {
+		return Userset{}, fmt.Errorf("userset %q is not of the form namespace:id#relation", s)
+	}
+	o, err := ParseObject(object)
+	if err != nil {
+		return Userset{}, err
+	}
+	if err := validateName("relation", relation); err != nil {
+		return Userset{}, err
+	}
+	return Userset{Object: o, Relation: relation}, nil
+}
+
+// ParseSubject parses a subject written "namespace:id" or "namespace:id#relation".
+func ParseSubject(s string) (Subject, error) {
+	if strings.Contains(s, "#") {
+		u, err := ParseUserset(s)
+		if err != nil {
+			return Subject{}, err
+		}
+		return Subject{Object: u.Object, Relation: u.Relation}, nil
+	}
+	o, err := ParseObject(s)
+	if err != nil {
+		return Subject{}, err
+	}
+	return Subject{Object: o}, nil
+}
+
+// ParseObject parses an object written "namespace:id".
+func ParseObject(s string) (Object, error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok {
+		return Object{}, fmt.Errorf("object %q is not of the form namespace:id", s)
+	}
+	o := Object{Namespace: namespace, ID: id}
+	if err := o.Validate(); err != nil {
+		return Object{}, err
+	}
+	return o, nil
+}
+
+// Validate checks the object's namespace and ID are non-empty and free of the separators.
+func (o Object) Validate() error {
+	if err := validateName("namespace", o.Namespace); err != nil {
+		return err
+	}
+	if o.ID == "" || strings.ContainsAny(o.ID, "#@ ") {
+		return fmt.Errorf("object ID %q must be non-empty, without '#', '@' or spaces", o.ID)
+	}
+	return nil
+}
+
+// Validate checks every part of the tuple.
+func (t Tuple) Validate() error {
+	if err := t.Object.Validate(); err != nil {
+		return err
+	}
+	if err := validateName("relation", t.Relation); err != nil {
+		return err
+	}
+	if err := t.Subject.Object.Validate(); err != nil {
+		return err
+	}
+	if t.Subject.IsUserset() {
+		return validateName("relation", t.Subject.Relation)
+	}
+	return nil
+}
+
+func validateName(kind, name string) error {
+	if name == "" || strings.ContainsAny(name, ":#@ ") {
+		return fmt.Errorf("%s %q must be non-empty, without ':', '#', '@' or spaces", kind, name)
+	}
+	return nil
+}
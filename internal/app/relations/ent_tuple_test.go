@@ -0,0 +1,61 @@
+package relations_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/relations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTuple(t *testing.T) {
+	tests := []struct {
+		s    string
+		want relations.Tuple
+	}{
+		{
+			s: "document:readme#editor@user:6d2b8e71-4a9c-4f3e-8b5d-1c7a9e3f2b60",
+			want: relations.Tuple{
+				Object:   relations.Object{Namespace: "document", ID: "readme"},
+				Relation: "editor",
+				Subject:  relations.Subject{Object: relations.Object{Namespace: "user", ID: "6d2b8e71-4a9c-4f3e-8b5d-1c7a9e3f2b60"}},
+			},
+		},
+		{
+			s: "document:readme#viewer@folder:reports#viewer",
+			want: relations.Tuple{
+				Object:   relations.Object{Namespace: "document", ID: "readme"},
+				Relation: "viewer",
+				Subject:  relations.Subject{Object: relations.Object{Namespace: "folder", ID: "reports"}, Relation: "viewer"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := relations.ParseTuple(tt.s)
+		require.NoError(t, err, tt.s)
+		assert.Equal(t, tt.want, got)
+		assert.Equal(t, tt.s, got.String())
+	}
+
+	for _, s := range []string{
+		"",
+		"document:readme#editor",
+		"document:readme@user:1",
+		"document#editor@user:1",
+		":readme#editor@user:1",
+		"document:#editor@user:1",
+		"document:readme#@user:1",
+		"document:readme#editor@user",
+		"document:readme#editor@folder:reports#",
+		"document:read me#editor@user:1",
+	} {
+		_, err := relations.ParseTuple(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestProjected(t *testing.T) {
+	assert.True(t, relations.Projected(relations.NamespaceRole))
+	assert.True(t, relations.Projected(relations.NamespaceGroup))
+	assert.False(t, relations.Projected("document"))
+}
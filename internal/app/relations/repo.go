@@ -0,0 +1,24 @@
+package relations
+
+import "context"
+
+type Reader interface {
+	// ReadTuples returns the tuples, stored and projected, matching the filter.
+	ReadTuples(ctx context.Context, filter Filter) (Tuples, error)
+	// CheckTuple reports whether the subject is in the userset, directly or through other usersets.
+	CheckTuple(ctx context.Context, userset Userset, subject Subject) (bool, error)
+	// ExpandTuples returns every tuple, stored and projected, reachable from the userset.
+	ExpandTuples(ctx context.Context, userset Userset) (Tuples, error)
+}
+
+type Writer interface {
+	// WriteTuples stores the tuples, tuples which are already stored are left as they are.
+	WriteTuples(ctx context.Context, tuples Tuples) error
+	// DeleteTuples deletes the stored tuples, tuples which are not stored are ignored.
+	DeleteTuples(ctx context.Context, tuples Tuples) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
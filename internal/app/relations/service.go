@@ -0,0 +1,10 @@
+package relations
+
+type Service struct {
+	repo ReaderWriter
+}
+
+// NewService creates a new relationship tuple service
+func NewService(repo ReaderWriter) *Service {
+	return &Service{repo: repo}
+}
@@ -0,0 +1,86 @@
+package reviews
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+// CloseCampaign applies the campaign's revoke decisions and closes it, the
+// user in the context, if any, closes it. A direct assignment is revoked by
+// unassigning the role, and one through a group by removing the user from the
+// group, the other roles they lose with it are recorded on the item. Items
+// still pending are kept.
+//
+// Each revocation is recorded as it is applied, so should closing fail part
+// way it can be retried without repeating them.
+func (s *Service) CloseCampaign(ctx context.Context, campaignID string) (*Report, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("close campaign: %w", err)
+	}
+	campaign, err := s.repo.GetCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("close campaign: %w", err)
+	}
+	if campaign.Status == StatusClosed {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "close campaign: campaign %s is already closed", campaignID)
+	}
+
+	items, err := s.repo.GetItems(ctx, campaignID, "")
+	if err != nil {
+		return nil, fmt.Errorf("close campaign: %w", err)
+	}
+	for _, item := range items {
+		if item.Decision != DecisionRevoke || item.AppliedAt != nil {
+			continue
+		}
+		alsoRevoked, err := s.revoke(ctx, item)
+		if err != nil {
+			return nil, fmt.Errorf("close campaign: revoke item %s: %w", item.ID, err)
+		}
+		if err := s.repo.SetApplied(ctx, item.ID, alsoRevoked, s.now().UTC()); err != nil {
+			return nil, fmt.Errorf("close campaign: %w", err)
+		}
+	}
+
+	closedBy, _ := contextkey.UserID(ctx)
+	if err := s.repo.CloseCampaign(ctx, campaignID, closedBy, s.now().UTC()); err != nil {
+		return nil, fmt.Errorf("close campaign: %w", err)
+	}
+	return s.Report(ctx, campaignID)
+}
+
+// revoke applies the item's revoke decision, and returns the names of the
+// other roles the user lost with it: those the group granted them, or which
+// those inherit, and which they do not hold some other way.
+func (s *Service) revoke(ctx context.Context, item Item) ([]string, error) {
+	if item.Source != permissions.RoleSourceGroup {
+		return nil, s.permissions.UnassignUserRoles(ctx, item.UserID, []string{item.Role.ID})
+	}
+
+	userCtx := contextkey.WithUserID(ctx, item.UserID)
+	before, err := s.permissions.GetRoleAssignments(userCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.permissions.RemoveGroupMembers(ctx, item.Group.ID, []string{item.UserID}); err != nil {
+		return nil, err
+	}
+	after, err := s.permissions.GetRoleAssignments(userCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	held := after.Roles()
+	var lost []string
+	for _, role := range before.Roles() {
+		if role.ID != item.Role.ID && !slices.ContainsFunc(held, func(r permissions.Role) bool { return r.ID == role.ID }) {
+			lost = append(lost, role.Name)
+		}
+	}
+	slices.Sort(lost)
+	return lost, nil
+}
@@ -0,0 +1,112 @@
+//go:build test
+// +build test
+
+package reviews_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/reviews"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (context.Context, *reviews.Service, *permissions.Service) {
+	t.Helper()
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	perms := permissions.NewService(repo)
+	return ctx, reviews.NewService(repo, perms), perms
+}
+
+func TestCampaign(t *testing.T) {
+	ctx, svc, perms := newTestService(t)
+	const (
+		userAdmin       = "032fb302-4aee-4a68-b426-0c6faf12081e"
+		userSalesPerson = "2133479c-35a8-4a49-a682-2952d4772ecc"
+		userReadOnly    = "e5a7c9b1-2d4f-4a6c-8e0b-1f3d5a7c9e42"
+		reviewer1       = "7a1c3e5f-9b2d-4f6a-8c0e-2a4c6e8a0c11"
+		reviewer2       = "8b2d4f6a-0c3e-4a7b-9d1f-3b5d7f9b1d22"
+	)
+
+	campaign, err := svc.CreateCampaign(ctx, "Q3 review", "", []string{reviewer1, reviewer2})
+	require.NoError(t, err)
+
+	items, err := svc.GetItems(ctx, campaign.ID, "")
+	require.NoError(t, err)
+	require.Len(t, items, 5, "four direct assignments and one through a nested group")
+
+	find := func(userID, role string) reviews.Item {
+		for _, item := range items {
+			if item.UserID == userID && item.Role.Name == role {
+				return item
+			}
+		}
+		t.Fatalf("no item for %s %s", userID, role)
+		return reviews.Item{}
+	}
+	decide := func(item reviews.Item, decision reviews.Decision) error {
+		ctx := contextkey.WithUserID(ctx, item.ReviewerID)
+		return svc.Decide(ctx, campaign.ID, item.ID, decision, "quarterly review")
+	}
+
+	salesPerson := find(userSalesPerson, "sales person")
+	auditor := find(userReadOnly, "sales auditor")
+	require.NotNil(t, auditor.Group)
+	assert.Equal(t, "north sales team", auditor.Group.Name, "the group the user is a member of")
+
+	// The group also grants a role the campaign did not snapshot, which the user loses with the group.
+	const roleSalesPerson = "123e4567-e89b-12d3-a456-426614174000"
+	require.NoError(t, perms.AssignGroupRoles(ctx, auditor.Group.ID, []string{roleSalesPerson}))
+
+	require.NoError(t, decide(salesPerson, reviews.DecisionRevoke))
+	require.NoError(t, decide(auditor, reviews.DecisionRevoke))
+	require.NoError(t, decide(find(userAdmin, "admin"), reviews.DecisionKeep))
+
+	t.Run("Only the item's reviewer decides", func(t *testing.T) {
+		other := reviewer1
+		if salesPerson.ReviewerID == reviewer1 {
+			other = reviewer2
+		}
+		err := svc.Decide(contextkey.WithUserID(ctx, other), campaign.ID, salesPerson.ID, reviews.DecisionKeep, "")
+		assert.True(t, errors.Is(err, permissions.ErrForbidden), err)
+	})
+
+	report, err := svc.CloseCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, reviews.StatusClosed, report.Campaign.Status)
+	assert.Equal(t, reviews.Summary{Total: 5, Kept: 1, Revoked: 2, Pending: 2, Applied: 2}, report.Summary())
+
+	t.Run("The other roles lost with a group are reported", func(t *testing.T) {
+		for _, item := range report.Items {
+			switch item.ID {
+			case auditor.ID:
+				assert.Equal(t, []string{"sales person"}, item.AlsoRevoked)
+			case salesPerson.ID:
+				assert.Empty(t, item.AlsoRevoked, "a direct assignment is revoked alone")
+			}
+		}
+	})
+
+	t.Run("Revocations are applied", func(t *testing.T) {
+		assignments, err := perms.GetRoleAssignments(contextkey.WithUserID(ctx, userSalesPerson))
+		require.NoError(t, err)
+		assert.Empty(t, assignments)
+
+		assignments, err = perms.GetRoleAssignments(contextkey.WithUserID(ctx, userReadOnly))
+		require.NoError(t, err)
+		assert.Equal(t, permissions.Roles{{ID: "9a3c1e5f-6b2d-4e8a-a1f7-3c5b7d9e0f24", Name: "read only"}}, assignments.Roles())
+	})
+
+	t.Run("A closed campaign cannot change", func(t *testing.T) {
+		err := decide(salesPerson, reviews.DecisionKeep)
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+
+		_, err = svc.CloseCampaign(ctx, campaign.ID)
+		assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+	})
+}
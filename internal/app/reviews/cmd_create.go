@@ -0,0 +1,94 @@
+package reviews
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/google/uuid"
+)
+
+// CreateCampaign opens a campaign reviewing the role assignments of the role,
+// or of every role when roleID is empty, as they are now. Each user's
+// assignments are reviewed by one of the reviewers, taking turns, but never by
+// the user themselves. The user in the context, if any, is the creator.
+func (s *Service) CreateCampaign(ctx context.Context, name, roleID string, reviewerIDs []string) (*Campaign, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("create campaign: %w", err)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "create campaign: name is required")
+	}
+	if roleID != "" {
+		if err := uuid.Validate(roleID); err != nil {
+			return nil, permissions.Errorf(permissions.ErrInvalidInput, "create campaign: role ID %q is not a UUID", roleID)
+		}
+	}
+	if len(reviewerIDs) == 0 {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "create campaign: a reviewer is required")
+	}
+	for _, id := range reviewerIDs {
+		if err := permissions.ValidateUserID(id); err != nil {
+			return nil, fmt.Errorf("create campaign: reviewer: %w", err)
+		}
+	}
+	reviewerIDs = slices.Compact(slices.Sorted(slices.Values(reviewerIDs)))
+
+	items, err := s.repo.GetReviewableAssignments(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("create campaign: get reviewable assignments: %w", err)
+	}
+	if err := assignReviewers(items, reviewerIDs); err != nil {
+		return nil, fmt.Errorf("create campaign: %w", err)
+	}
+
+	createdBy, _ := contextkey.UserID(ctx)
+	campaign := Campaign{
+		ID:          uuid.NewString(),
+		Name:        name,
+		RoleID:      roleID,
+		ReviewerIDs: reviewerIDs,
+		Status:      StatusOpen,
+		CreatedBy:   createdBy,
+		CreatedAt:   s.now().UTC(),
+	}
+	for i := range items {
+		items[i].ID = uuid.NewString()
+		items[i].Decision = DecisionPending
+	}
+	if err := s.repo.CreateCampaign(ctx, campaign, items); err != nil {
+		return nil, fmt.Errorf("create campaign: %w", err)
+	}
+	return &campaign, nil
+}
+
+// assignReviewers gives each user's items to the next of the reviewers, in
+// turn, skipping the user themselves. It is ErrInvalidInput when a user's only
+// possible reviewer is themselves.
+func assignReviewers(items []Item, reviewerIDs []string) error {
+	byUser := make(map[string]string)
+	next := 0
+	for i := range items {
+		userID := items[i].UserID
+		reviewerID, ok := byUser[userID]
+		if !ok {
+			for range reviewerIDs {
+				reviewerID = reviewerIDs[next%len(reviewerIDs)]
+				next++
+				if reviewerID != userID {
+					break
+				}
+			}
+			if reviewerID == userID {
+				return permissions.Errorf(permissions.ErrInvalidInput, "user %s is the only reviewer and cannot review their own access", userID)
+			}
+			byUser[userID] = reviewerID
+		}
+		items[i].ReviewerID = reviewerID
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package reviews_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/reviews"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	alice = "0c5d7e9f-1a3b-4c5d-8e7f-9a1b3c5d7e90"
+	bob   = "1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01"
+	carol = "2133479c-35a8-4a49-a682-2952d4772ecc"
+)
+
+var (
+	clerk   = permissions.Role{ID: "550e8400-e29b-41d4-a716-446655440000", Name: "clerk"}
+	auditor = permissions.Role{ID: "da244750-f014-415c-b7b9-43ead3d8fa25", Name: "auditor"}
+)
+
+// stubRepo returns its assignments, and keeps the campaign created, the other methods are left nil.
+type stubRepo struct {
+	reviews.ReaderWriter
+	assignments []reviews.Item
+	items       []reviews.Item
+}
+
+func (r *stubRepo) GetReviewableAssignments(context.Context, string) ([]reviews.Item, error) {
+	return r.assignments, nil
+}
+
+func (r *stubRepo) CreateCampaign(_ context.Context, _ reviews.Campaign, items []reviews.Item) error {
+	r.items = items
+	return nil
+}
+
+func TestCreateCampaign_AssignsReviewers(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{assignments: []reviews.Item{
+		{UserID: alice, Role: clerk, Source: permissions.RoleSourceDirect},
+		{UserID: alice, Role: auditor, Source: permissions.RoleSourceDirect},
+		{UserID: bob, Role: clerk, Source: permissions.RoleSourceDirect},
+		{UserID: carol, Role: clerk, Source: permissions.RoleSourceGroup, Group: &permissions.Group{ID: "3f6b1d2c-8e4a-4c7b-9a1e-5d2c7b8e9f10", Name: "clerks"}},
+	}}
+
+	campaign, err := reviews.NewService(repo, nil).CreateCampaign(ctx, "Q3 review", "", []string{bob, alice, alice})
+	require.NoError(t, err)
+	assert.Equal(t, reviews.StatusOpen, campaign.Status)
+	assert.Equal(t, []string{alice, bob}, campaign.ReviewerIDs, "reviewers are sorted and unique")
+
+	reviewers := make(map[string]string)
+	for _, item := range repo.items {
+		assert.NotEmpty(t, item.ID)
+		assert.Equal(t, reviews.DecisionPending, item.Decision)
+		assert.NotEqual(t, item.UserID, item.ReviewerID, "no one reviews their own access")
+		if r, ok := reviewers[item.UserID]; ok {
+			assert.Equal(t, r, item.ReviewerID, "a user's items have one reviewer")
+		}
+		reviewers[item.UserID] = item.ReviewerID
+	}
+	assert.Equal(t, map[string]string{alice: bob, bob: alice, carol: bob}, reviewers)
+}
+
+func TestCreateCampaign_InvalidInput(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{assignments: []reviews.Item{{UserID: alice, Role: clerk, Source: permissions.RoleSourceDirect}}}
+	svc := reviews.NewService(repo, nil)
+
+	for name, tc := range map[string]struct {
+		name, roleID string
+		reviewerIDs  []string
+	}{
+		"no name":                   {roleID: clerk.ID, reviewerIDs: []string{bob}},
+		"role ID not a UUID":        {name: "review", roleID: "clerk", reviewerIDs: []string{bob}},
+		"no reviewers":              {name: "review"},
+		"reviewer not a UUID":       {name: "review", reviewerIDs: []string{"bob"}},
+		"only reviewer is the user": {name: "review", reviewerIDs: []string{alice}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.CreateCampaign(ctx, tc.name, tc.roleID, tc.reviewerIDs)
+			assert.True(t, errors.Is(err, permissions.ErrInvalidInput), err)
+		})
+	}
+}
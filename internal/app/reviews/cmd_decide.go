@@ -0,0 +1,62 @@
+package reviews
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+)
+
+// Decide records the decision of the reviewer, the user in the context, on
+// an item of an open campaign. Only the item's reviewer may decide on it, and
+// may change their decision until the campaign is closed.
+func (s *Service) Decide(ctx context.Context, campaignID, itemID string, decision Decision, comment string) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("decide: %w", err)
+	}
+	reviewerID, _ := contextkey.UserID(ctx)
+	if err := permissions.ValidateUserID(reviewerID); err != nil {
+		return fmt.Errorf("decide: reviewer: %w", err)
+	}
+	if decision != DecisionKeep && decision != DecisionRevoke {
+		return permissions.Errorf(permissions.ErrInvalidInput, "decide: decision %q is not %q or %q", decision, DecisionKeep, DecisionRevoke)
+	}
+
+	item, err := s.repo.GetItem(ctx, campaignID, itemID)
+	if err != nil {
+		return fmt.Errorf("decide: %w", err)
+	}
+	if item.ReviewerID != reviewerID {
+		return permissions.Errorf(permissions.ErrForbidden, "decide: item %s is reviewed by another reviewer", itemID)
+	}
+
+	if err := s.repo.SetDecision(ctx, campaignID, itemID, decision, comment, s.now().UTC()); err != nil {
+		return fmt.Errorf("decide: %w", err)
+	}
+	return nil
+}
+
+// GetItems returns the items of the campaign, those of the reviewer when reviewerID is not empty.
+func (s *Service) GetItems(ctx context.Context, campaignID, reviewerID string) ([]Item, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get items: %w", err)
+	}
+	items, err := s.repo.GetItems(ctx, campaignID, reviewerID)
+	if err != nil {
+		return nil, fmt.Errorf("get items: %w", err)
+	}
+	return items, nil
+}
+
+// GetCampaigns returns the Tenant's campaigns, most recent first.
+func (s *Service) GetCampaigns(ctx context.Context) ([]Campaign, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get campaigns: %w", err)
+	}
+	campaigns, err := s.repo.GetCampaigns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get campaigns: %w", err)
+	}
+	return campaigns, nil
+}
@@ -0,0 +1,24 @@
+package reviews
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Report returns the evidence report of the campaign, which may still be open.
+func (s *Service) Report(ctx context.Context, campaignID string) (*Report, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	campaign, err := s.repo.GetCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	items, err := s.repo.GetItems(ctx, campaignID, "")
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	return &Report{Campaign: *campaign, Items: items, GeneratedAt: s.now().UTC()}, nil
+}
@@ -0,0 +1,61 @@
+package reviews
+
+import (
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+type Status string
+
+const (
+	StatusOpen   Status = "open"
+	StatusClosed Status = "closed"
+)
+
+// Campaign is a review of the role assignments of the Tenant, or of one role.
+type Campaign struct {
+	ID   string
+	Name string
+	// RoleID is the role reviewed, empty when every role is.
+	RoleID      string
+	ReviewerIDs []string
+	Status      Status
+	CreatedBy   string
+	CreatedAt   time.Time
+	// ClosedBy and ClosedAt are set once the campaign is closed.
+	ClosedBy string
+	ClosedAt *time.Time
+}
+
+type Decision string
+
+const (
+	// DecisionPending is an item not yet reviewed, the assignment is kept when the campaign closes.
+	DecisionPending Decision = "pending"
+	DecisionKeep    Decision = "keep"
+	// DecisionRevoke removes the assignment when the campaign closes.
+	DecisionRevoke Decision = "revoke"
+)
+
+// Item is a role assignment under review, as it was when the campaign was created.
+type Item struct {
+	ID     string
+	UserID string
+	Role   permissions.Role
+	// Source is RoleSourceDirect or RoleSourceGroup, the role is held through
+	// the Group when RoleSourceGroup, and revoking it removes the user from the Group.
+	Source permissions.RoleSource
+	Group  *permissions.Group
+	// ReviewerID is the reviewer who decides on the item.
+	ReviewerID string
+	Decision   Decision
+	Comment    string
+	DecidedAt  *time.Time
+	// AppliedAt is when a revoke decision was applied, on closing the campaign.
+	AppliedAt *time.Time
+	// AlsoRevoked are the names of the other roles the user lost when the
+	// decision was applied, by removing them from the Group, which the
+	// reviewer did not decide on.
+	AlsoRevoked []string
+}
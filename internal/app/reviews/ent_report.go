@@ -0,0 +1,81 @@
+package reviews
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+)
+
+// Report is the evidence of a campaign, every item with its decision.
+type Report struct {
+	Campaign    Campaign
+	Items       []Item
+	GeneratedAt time.Time
+}
+
+// Summary counts the report's items by decision, Applied counts the revocations made.
+type Summary struct {
+	Total   int
+	Kept    int
+	Revoked int
+	Pending int
+	Applied int
+}
+
+func (r *Report) Summary() Summary {
+	s := Summary{Total: len(r.Items)}
+	for _, item := range r.Items {
+		switch item.Decision {
+		case DecisionKeep:
+			s.Kept++
+		case DecisionRevoke:
+			s.Revoked++
+		default:
+			s.Pending++
+		}
+		if item.AppliedAt != nil {
+			s.Applied++
+		}
+	}
+	return s
+}
+
+// reportColumns are the columns of the report written by WriteCSV.
+var reportColumns = []string{
+	"campaign_id", "campaign_name", "campaign_status", "item_id", "user_id", "role_id", "role_name",
+	"source", "group_id", "group_name", "reviewer_id", "decision", "comment", "decided_at", "applied_at", "also_revoked",
+}
+
+// WriteCSV writes the report as CSV, a header and then a row for each item.
+// Times are RFC 3339, in UTC, and empty when not set. The roles also revoked
+// are separated by semicolons.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportColumns); err != nil {
+		return err
+	}
+	for _, item := range r.Items {
+		var groupID, groupName string
+		if item.Group != nil {
+			groupID, groupName = item.Group.ID, item.Group.Name
+		}
+		err := cw.Write([]string{
+			r.Campaign.ID, r.Campaign.Name, string(r.Campaign.Status), item.ID, item.UserID, item.Role.ID, item.Role.Name,
+			string(item.Source), groupID, groupName, item.ReviewerID, string(item.Decision), item.Comment,
+			formatTime(item.DecidedAt), formatTime(item.AppliedAt), strings.Join(item.AlsoRevoked, ";"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
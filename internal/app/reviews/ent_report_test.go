@@ -0,0 +1,37 @@
+package reviews_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/reviews"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	decided := time.Date(2026, 9, 30, 14, 5, 0, 0, time.FixedZone("BST", 3600))
+	applied := decided.Add(time.Hour)
+	report := &reviews.Report{
+		Campaign: reviews.Campaign{ID: "c1", Name: "Q3, all roles", Status: reviews.StatusClosed},
+		Items: []reviews.Item{
+			{ID: "i1", UserID: alice, Role: clerk, Source: permissions.RoleSourceDirect, ReviewerID: bob, Decision: reviews.DecisionRevoke, Comment: "left the team", DecidedAt: &decided, AppliedAt: &applied},
+			{ID: "i2", UserID: carol, Role: clerk, Source: permissions.RoleSourceGroup, Group: &permissions.Group{ID: "g1", Name: "clerks"}, ReviewerID: bob, Decision: reviews.DecisionKeep, DecidedAt: &decided},
+			{ID: "i3", UserID: bob, Role: auditor, Source: permissions.RoleSourceDirect, ReviewerID: alice, Decision: reviews.DecisionPending},
+			{ID: "i4", UserID: carol, Role: auditor, Source: permissions.RoleSourceGroup, Group: &permissions.Group{ID: "g1", Name: "clerks"}, ReviewerID: bob, Decision: reviews.DecisionRevoke, DecidedAt: &decided, AppliedAt: &applied, AlsoRevoked: []string{"approver", "clerk"}},
+		},
+	}
+
+	assert.Equal(t, reviews.Summary{Total: 4, Kept: 1, Revoked: 2, Pending: 1, Applied: 2}, report.Summary())
+
+	var b strings.Builder
+	require.NoError(t, report.WriteCSV(&b))
+	assert.Equal(t, `campaign_id,campaign_name,campaign_status,item_id,user_id,role_id,role_name,source,group_id,group_name,reviewer_id,decision,comment,decided_at,applied_at,also_revoked
+c1,"Q3, all roles",closed,i1,`+alice+`,`+clerk.ID+`,clerk,direct,,,`+bob+`,revoke,left the team,2026-09-30T13:05:00Z,2026-09-30T14:05:00Z,
+c1,"Q3, all roles",closed,i2,`+carol+`,`+clerk.ID+`,clerk,group,g1,clerks,`+bob+`,keep,,2026-09-30T13:05:00Z,,
+c1,"Q3, all roles",closed,i3,`+bob+`,`+auditor.ID+`,auditor,direct,,,`+alice+`,pending,,,,
+c1,"Q3, all roles",closed,i4,`+carol+`,`+auditor.ID+`,auditor,group,g1,clerks,`+bob+`,revoke,,2026-09-30T13:05:00Z,2026-09-30T14:05:00Z,approver;clerk
+`, b.String())
+}
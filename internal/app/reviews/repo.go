@@ -0,0 +1,38 @@
+package reviews
+
+import (
+	"context"
+	"time"
+)
+
+type Reader interface {
+	// GetReviewableAssignments returns the roles assigned to users, directly or
+	// through a group they are a member of, of the role or of every role when
+	// roleID is empty. The Items have only their assignment set.
+	GetReviewableAssignments(ctx context.Context, roleID string) ([]Item, error)
+	// GetCampaign returns the campaign, ErrNotFound when there is no such campaign.
+	GetCampaign(ctx context.Context, campaignID string) (*Campaign, error)
+	// GetCampaigns returns the Tenant's campaigns, most recent first.
+	GetCampaigns(ctx context.Context) ([]Campaign, error)
+	// GetItem returns the campaign's item, ErrNotFound when there is no such item.
+	GetItem(ctx context.Context, campaignID, itemID string) (*Item, error)
+	// GetItems returns the campaign's items, those of the reviewer when reviewerID is not empty.
+	GetItems(ctx context.Context, campaignID, reviewerID string) ([]Item, error)
+}
+
+type Writer interface {
+	CreateCampaign(ctx context.Context, campaign Campaign, items []Item) error
+	// SetDecision records the decision on an item of an open campaign, it is
+	// ErrNotFound when there is no such item and ErrInvalidInput when the campaign is closed.
+	SetDecision(ctx context.Context, campaignID, itemID string, decision Decision, comment string, at time.Time) error
+	// SetApplied records that the item's revoke decision was applied, and the
+	// other roles the user lost with it.
+	SetApplied(ctx context.Context, itemID string, alsoRevoked []string, at time.Time) error
+	// CloseCampaign closes the campaign, it is ErrInvalidInput when it is already closed.
+	CloseCampaign(ctx context.Context, campaignID, closedBy string, at time.Time) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
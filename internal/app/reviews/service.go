@@ -0,0 +1,27 @@
+// Package reviews runs access review campaigns, periodic reviews of who holds
+// which role. A campaign snapshots the role assignments of the Tenant, or of a
+// role, and shares them out between its reviewers, who decide to keep or revoke
+// each. Closing the campaign revokes those decided so through the permissions
+// service, and the campaign's Report is the evidence of the review.
+package reviews
+
+import (
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+type Service struct {
+	repo        ReaderWriter
+	permissions *permissions.Service
+	now         func() time.Time
+}
+
+// NewService creates a review service, revocations are made through the permissions service.
+func NewService(repo ReaderWriter, permissions *permissions.Service) *Service {
+	return &Service{
+		repo:        repo,
+		permissions: permissions,
+		now:         time.Now,
+	}
+}
@@ -0,0 +1,137 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/google/uuid"
+)
+
+// ListGroups returns the page of the Tenant's groups, by displayName, which match the query.
+func (s *Service) ListGroups(ctx context.Context, q Query) (*List[Group], error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	groups, err := s.repo.GetSCIMGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	matched, err := match(groups, q.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	return page(matched, q), nil
+}
+
+// GetGroup returns the group, ErrNotFound when there is no such group.
+func (s *Service) GetGroup(ctx context.Context, groupID string) (*Group, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	group, err := s.getGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	return group, nil
+}
+
+// CreateGroup creates the group, assigning its ID, mapped to the role or group
+// of the same name as the Tenant's GroupTarget is. Its members are assigned the role, or
+// made members of the group.
+func (s *Service) CreateGroup(ctx context.Context, group Group) (*Group, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+	if err := group.validate(); err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+	target, err := s.repo.GetSCIMGroupTarget(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+	group.ID = uuid.NewString()
+	group.Target = target
+	group.Members = uniqueMembers(group.Members)
+	group.Created = s.now()
+	group.LastModified = group.Created
+	if err := s.repo.CreateSCIMGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+	return s.GetGroup(ctx, group.ID)
+}
+
+// ReplaceGroup replaces the group's attributes and members, the role or group it is mapped to is kept.
+func (s *Service) ReplaceGroup(ctx context.Context, groupID string, group Group) (*Group, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("replace group: %w", err)
+	}
+	current, err := s.getGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("replace group: %w", err)
+	}
+	if err := s.updateGroup(ctx, current, group); err != nil {
+		return nil, fmt.Errorf("replace group: %w", err)
+	}
+	return s.GetGroup(ctx, groupID)
+}
+
+// PatchGroup applies the operations to the group and its members.
+func (s *Service) PatchGroup(ctx context.Context, groupID string, ops []Operation) (*Group, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("patch group: %w", err)
+	}
+	current, err := s.getGroup(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("patch group: %w", err)
+	}
+	group := *current
+	group.Members = append([]string(nil), current.Members...)
+	if err := group.patch(ops); err != nil {
+		return nil, fmt.Errorf("patch group: %w", err)
+	}
+	if err := s.updateGroup(ctx, current, group); err != nil {
+		return nil, fmt.Errorf("patch group: %w", err)
+	}
+	return s.GetGroup(ctx, groupID)
+}
+
+// DeleteGroup deletes the group, its members lose the role or group it is mapped to.
+func (s *Service) DeleteGroup(ctx context.Context, groupID string) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	if uuid.Validate(groupID) != nil {
+		return fmt.Errorf("delete group: %w", groupNotFound(groupID))
+	}
+	if err := s.repo.DeleteSCIMGroup(ctx, groupID); err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	return nil
+}
+
+// getGroup returns the group, an ID which is not a UUID is ErrNotFound as any other unknown group is.
+func (s *Service) getGroup(ctx context.Context, groupID string) (*Group, error) {
+	if uuid.Validate(groupID) != nil {
+		return nil, groupNotFound(groupID)
+	}
+	return s.repo.GetSCIMGroup(ctx, groupID)
+}
+
+// updateGroup saves the group as the current group updated, adding and removing the members which differ.
+func (s *Service) updateGroup(ctx context.Context, current *Group, group Group) error {
+	if err := group.validate(); err != nil {
+		return err
+	}
+	group.ID = current.ID
+	group.Target, group.TargetID, group.TargetName = current.Target, current.TargetID, current.TargetName
+	group.Created = current.Created
+	group.LastModified = s.now()
+	group.Members = uniqueMembers(group.Members)
+	added, removed := group.diff(current.Members)
+	return s.repo.UpdateSCIMGroup(ctx, group, added, removed)
+}
+
+func groupNotFound(groupID string) error {
+	return permissions.Errorf(permissions.ErrNotFound, "group %s not found", groupID)
+}
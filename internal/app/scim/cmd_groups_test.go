@@ -0,0 +1,79 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ada = "0c5d7e9f-1a3b-4c5d-8e7f-9a1b3c5d7e90"
+	bob = "1d6e8f0a-2b4c-4d6e-9f8a-0b2c4d6e8f01"
+	cy  = "2133479c-35a8-4a49-a682-2952d4772ecc"
+)
+
+func TestCreateGroup_Target(t *testing.T) {
+	ctx := newContext()
+	repo := &memRepo{}
+	svc := scim.NewService(repo)
+
+	group, err := svc.CreateGroup(ctx, scim.Group{DisplayName: "sales", Members: []string{ada, ada, bob}})
+	require.NoError(t, err)
+	assert.Equal(t, scim.TargetGroup, group.Target, "groups are mapped to groups until the Tenant says otherwise")
+	assert.Equal(t, []string{ada, bob}, group.Members, "repeated members are dropped")
+
+	require.NoError(t, svc.SetGroupTarget(ctx, scim.TargetRole))
+	group, err = svc.CreateGroup(ctx, scim.Group{DisplayName: "sales manager"})
+	require.NoError(t, err)
+	assert.Equal(t, scim.TargetRole, group.Target)
+
+	_, err = svc.CreateGroup(ctx, scim.Group{DisplayName: "x", Members: []string{"not-a-user"}})
+	assert.ErrorIs(t, err, scim.ErrInvalidValue)
+	_, err = svc.CreateGroup(ctx, scim.Group{})
+	assert.ErrorIs(t, err, permissions.ErrInvalidInput)
+	assert.ErrorIs(t, svc.SetGroupTarget(ctx, "team"), scim.ErrInvalidValue)
+}
+
+func TestPatchGroup_Members(t *testing.T) {
+	ctx := newContext()
+	repo := &memRepo{}
+	svc := scim.NewService(repo)
+	group, err := svc.CreateGroup(ctx, scim.Group{DisplayName: "sales", Members: []string{ada}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		ops  []scim.Operation
+		want []string
+	}{
+		{"add", []scim.Operation{{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + bob + `"},{"value":"` + ada + `"}]`)}}, []string{ada, bob}},
+		{"remove by filter", []scim.Operation{{Op: "remove", Path: `members[value eq "` + ada + `"]`}}, []string{bob}},
+		{"add without a path", []scim.Operation{{Op: "Add", Value: json.RawMessage(`{"members":[{"value":"` + cy + `"}]}`)}}, []string{bob, cy}},
+		{"remove listed", []scim.Operation{{Op: "Remove", Path: "members", Value: json.RawMessage(`[{"value":"` + cy + `"}]`)}}, []string{bob}},
+		{"replace", []scim.Operation{{Op: "replace", Path: "members", Value: json.RawMessage(`[{"value":"` + ada + `"},{"value":"` + cy + `"}]`)}}, []string{ada, cy}},
+		{"remove all", []scim.Operation{{Op: "remove", Path: "members"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := svc.PatchGroup(ctx, group.ID, tt.ops)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, patched.Members)
+		})
+	}
+
+	patched, err := svc.PatchGroup(ctx, group.ID, []scim.Operation{{Op: "replace", Value: json.RawMessage(`{"displayName":"sales team","externalId":"g1"}`)}})
+	require.NoError(t, err)
+	assert.Equal(t, "sales team", patched.DisplayName)
+	assert.Equal(t, "g1", patched.ExternalID)
+
+	_, err = svc.PatchGroup(ctx, group.ID, []scim.Operation{{Op: "add", Path: `members[value eq "x"]`, Value: json.RawMessage(`[]`)}})
+	assert.ErrorIs(t, err, scim.ErrInvalidPath)
+
+	list, err := svc.ListGroups(ctx, scim.Query{Filter: `displayName eq "Sales Team"`, Count: scim.MaxResults})
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.TotalResults)
+}
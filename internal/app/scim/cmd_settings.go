@@ -0,0 +1,34 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// GetGroupTarget returns what the Tenant's new groups are mapped to.
+func (s *Service) GetGroupTarget(ctx context.Context) (Target, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return "", fmt.Errorf("get group target: %w", err)
+	}
+	target, err := s.repo.GetSCIMGroupTarget(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get group target: %w", err)
+	}
+	return target, nil
+}
+
+// SetGroupTarget sets what the Tenant's new groups are mapped to, existing groups keep their mapping.
+func (s *Service) SetGroupTarget(ctx context.Context, target Target) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("set group target: %w", err)
+	}
+	if _, err := ParseTarget(string(target)); err != nil {
+		return fmt.Errorf("set group target: %w", err)
+	}
+	if err := s.repo.SetSCIMGroupTarget(ctx, target); err != nil {
+		return fmt.Errorf("set group target: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/google/uuid"
+)
+
+// ListUsers returns the page of the Tenant's users, by userName, which match the query.
+func (s *Service) ListUsers(ctx context.Context, q Query) (*List[User], error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	users, err := s.repo.GetSCIMUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	matched, err := match(users, q.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	return page(matched, q), nil
+}
+
+// GetUser returns the user, ErrNotFound when there is no such user.
+func (s *Service) GetUser(ctx context.Context, userID string) (*User, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateUser creates the user, assigning its ID: the externalId when it is a
+// UUID, linking the user to the one of that ID who may already hold grants, or
+// else a new one.
+func (s *Service) CreateUser(ctx context.Context, user User) (*User, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	if err := user.validate(); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	user.ID = newUserID(user.ExternalID)
+	user.Created = s.now()
+	user.LastModified = user.Created
+	if err := s.repo.CreateSCIMUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	return &user, nil
+}
+
+// ReplaceUser replaces the user's attributes. A user made inactive loses all their grants.
+func (s *Service) ReplaceUser(ctx context.Context, userID string, user User) (*User, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("replace user: %w", err)
+	}
+	current, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("replace user: %w", err)
+	}
+	updated, err := s.updateUser(ctx, current, user)
+	if err != nil {
+		return nil, fmt.Errorf("replace user: %w", err)
+	}
+	return updated, nil
+}
+
+// PatchUser applies the operations to the user. A user made inactive loses all their grants.
+func (s *Service) PatchUser(ctx context.Context, userID string, ops []Operation) (*User, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("patch user: %w", err)
+	}
+	current, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("patch user: %w", err)
+	}
+	user := *current
+	if err := user.patch(ops); err != nil {
+		return nil, fmt.Errorf("patch user: %w", err)
+	}
+	updated, err := s.updateUser(ctx, current, user)
+	if err != nil {
+		return nil, fmt.Errorf("patch user: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteUser deletes the user, who loses all their grants.
+func (s *Service) DeleteUser(ctx context.Context, userID string) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if uuid.Validate(userID) != nil {
+		return fmt.Errorf("delete user: %w", userNotFound(userID))
+	}
+	if err := s.repo.DeleteSCIMUser(ctx, userID); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	return nil
+}
+
+// getUser returns the user, an ID which is not a UUID is ErrNotFound as any other unknown user is.
+func (s *Service) getUser(ctx context.Context, userID string) (*User, error) {
+	if uuid.Validate(userID) != nil {
+		return nil, userNotFound(userID)
+	}
+	return s.repo.GetSCIMUser(ctx, userID)
+}
+
+// updateUser saves the user as the current user updated, revoking their grants when they are made inactive.
+func (s *Service) updateUser(ctx context.Context, current *User, user User) (*User, error) {
+	if err := user.validate(); err != nil {
+		return nil, err
+	}
+	user.ID = current.ID
+	user.Created = current.Created
+	user.LastModified = s.now()
+	if err := s.repo.UpdateSCIMUser(ctx, user, current.Active && !user.Active); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// newUserID returns the externalId as a user ID when it is a UUID, or a new one.
+func newUserID(externalID string) string {
+	if id, err := uuid.Parse(externalID); err == nil {
+		return id.String()
+	}
+	return uuid.NewString()
+}
+
+func userNotFound(userID string) error {
+	return permissions.Errorf(permissions.ErrNotFound, "user %s not found", userID)
+}
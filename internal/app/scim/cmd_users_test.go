@@ -0,0 +1,260 @@
+package scim_test
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memRepo keeps users and groups in memory, recording the users whose grants were revoked.
+type memRepo struct {
+	users   []scim.User
+	groups  []scim.Group
+	target  scim.Target
+	revoked []string
+}
+
+func (r *memRepo) GetSCIMUsers(context.Context) ([]scim.User, error) {
+	return slices.Clone(r.users), nil
+}
+
+func (r *memRepo) GetSCIMUser(_ context.Context, userID string) (*scim.User, error) {
+	for _, u := range r.users {
+		if u.ID == userID {
+			return &u, nil
+		}
+	}
+	return nil, permissions.Errorf(permissions.ErrNotFound, "user %s not found", userID)
+}
+
+func (r *memRepo) GetSCIMGroups(context.Context) ([]scim.Group, error) {
+	return slices.Clone(r.groups), nil
+}
+
+func (r *memRepo) GetSCIMGroup(_ context.Context, groupID string) (*scim.Group, error) {
+	for _, g := range r.groups {
+		if g.ID == groupID {
+			g.Members = slices.Clone(g.Members)
+			return &g, nil
+		}
+	}
+	return nil, permissions.Errorf(permissions.ErrNotFound, "group %s not found", groupID)
+}
+
+func (r *memRepo) GetSCIMGroupTarget(context.Context) (scim.Target, error) {
+	return cmp.Or(r.target, scim.TargetGroup), nil
+}
+
+func (r *memRepo) CreateSCIMUser(_ context.Context, user scim.User) error {
+	for _, u := range r.users {
+		if u.ID == user.ID {
+			return scim.Errorf(scim.ErrUniqueness, "externalId %q is already in use", user.ExternalID)
+		}
+		if strings.EqualFold(u.UserName, user.UserName) {
+			return scim.Errorf(scim.ErrUniqueness, "userName %q is already in use", user.UserName)
+		}
+	}
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *memRepo) UpdateSCIMUser(_ context.Context, user scim.User, revokeGrants bool) error {
+	i := slices.IndexFunc(r.users, func(u scim.User) bool { return u.ID == user.ID })
+	r.users[i] = user
+	if revokeGrants {
+		r.revoked = append(r.revoked, user.ID)
+	}
+	return nil
+}
+
+func (r *memRepo) DeleteSCIMUser(_ context.Context, userID string) error {
+	r.users = slices.DeleteFunc(r.users, func(u scim.User) bool { return u.ID == userID })
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+func (r *memRepo) CreateSCIMGroup(_ context.Context, group scim.Group) error {
+	group.TargetName = group.DisplayName
+	r.groups = append(r.groups, group)
+	return nil
+}
+
+func (r *memRepo) UpdateSCIMGroup(_ context.Context, group scim.Group, added, removed []string) error {
+	i := slices.IndexFunc(r.groups, func(g scim.Group) bool { return g.ID == group.ID })
+	members := slices.DeleteFunc(r.groups[i].Members, func(m string) bool { return slices.Contains(removed, m) })
+	group.Members = append(members, added...)
+	r.groups[i] = group
+	return nil
+}
+
+func (r *memRepo) DeleteSCIMGroup(_ context.Context, groupID string) error {
+	r.groups = slices.DeleteFunc(r.groups, func(g scim.Group) bool { return g.ID == groupID })
+	return nil
+}
+
+func (r *memRepo) SetSCIMGroupTarget(_ context.Context, target scim.Target) error {
+	r.target = target
+	return nil
+}
+
+func newContext() context.Context {
+	return contextkey.WithTenantID(context.Background(), "test")
+}
+
+func TestListUsers_Filter(t *testing.T) {
+	ctx := newContext()
+	svc := scim.NewService(&memRepo{})
+	for _, u := range []scim.User{
+		{UserName: "ada@example.com", GivenName: "Ada", Email: "ada@example.com", ExternalID: "A1", Active: true},
+		{UserName: "bob@example.com", GivenName: "Bob", Email: "bob@example.org", ExternalID: "b2", Active: false},
+		{UserName: "cy@example.com", DisplayName: "Cy", Active: true},
+	} {
+		_, err := svc.CreateUser(ctx, u)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{`userName eq "ADA@example.com"`, []string{"ada@example.com"}},
+		{`externalId eq "a1"`, nil},
+		{`externalId eq "A1"`, []string{"ada@example.com"}},
+		{`emails co "example.org"`, []string{"bob@example.com"}},
+		{`emails[value ew ".org"]`, []string{"bob@example.com"}},
+		{`name.givenName pr and active eq true`, []string{"ada@example.com"}},
+		{`not (active eq true) or displayName sw "c"`, []string{"bob@example.com", "cy@example.com"}},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "b"`, []string{"bob@example.com"}},
+		{`userName ne "cy@example.com"`, []string{"ada@example.com", "bob@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			list, err := svc.ListUsers(ctx, scim.Query{Filter: tt.filter, Count: scim.MaxResults})
+			require.NoError(t, err)
+			var got []string
+			for _, u := range list.Resources {
+				got = append(got, u.UserName)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, len(tt.want), list.TotalResults)
+		})
+	}
+
+	nested := strings.Repeat("(", 33) + `userName eq "a"` + strings.Repeat(")", 33)
+	for _, filter := range []string{`userName`, `userName eq`, `userName xx "a"`, `(userName eq "a"`, `userName eq "a`, `userName eq bob`, nested} {
+		_, err := svc.ListUsers(ctx, scim.Query{Filter: filter})
+		assert.ErrorIs(t, err, scim.ErrInvalidFilter, filter)
+		assert.ErrorIs(t, err, permissions.ErrInvalidInput, filter)
+	}
+}
+
+func TestListUsers_Page(t *testing.T) {
+	ctx := newContext()
+	svc := scim.NewService(&memRepo{})
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := svc.CreateUser(ctx, scim.User{UserName: name})
+		require.NoError(t, err)
+	}
+
+	list, err := svc.ListUsers(ctx, scim.Query{StartIndex: 2, Count: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	require.Len(t, list.Resources, 1)
+	assert.Equal(t, "b", list.Resources[0].UserName)
+
+	list, err = svc.ListUsers(ctx, scim.Query{StartIndex: 5, Count: 10})
+	require.NoError(t, err)
+	assert.Empty(t, list.Resources)
+}
+
+func TestPatchUser(t *testing.T) {
+	ctx := newContext()
+	repo := &memRepo{}
+	svc := scim.NewService(repo)
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "ada", Email: "ada@example.com", Active: true})
+	require.NoError(t, err)
+
+	patched, err := svc.PatchUser(ctx, user.ID, []scim.Operation{
+		{Op: "Replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"ada@example.org"`)},
+		{Op: "add", Value: json.RawMessage(`{"name.givenName": "Ada", "displayName": "Ada L", "title": "ignored"}`)},
+		{Op: "remove", Path: "displayName"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.org", patched.Email)
+	assert.Equal(t, "Ada", patched.GivenName)
+	assert.Empty(t, patched.DisplayName)
+	assert.True(t, patched.Active)
+	assert.Empty(t, repo.revoked)
+
+	// Some identity providers send booleans as strings.
+	patched, err = svc.PatchUser(ctx, user.ID, []scim.Operation{{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)}})
+	require.NoError(t, err)
+	assert.False(t, patched.Active)
+	assert.Equal(t, []string{user.ID}, repo.revoked, "deactivation revokes the user's grants")
+
+	_, err = svc.PatchUser(ctx, user.ID, []scim.Operation{{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}})
+	require.NoError(t, err)
+	assert.Len(t, repo.revoked, 1, "an inactive user has nothing to revoke")
+
+	for _, ops := range [][]scim.Operation{
+		{{Op: "remove", Path: "userName"}},
+		{{Op: "remove"}},
+		{{Op: "move", Path: "userName", Value: json.RawMessage(`"x"`)}},
+		{{Op: "replace", Path: "id", Value: json.RawMessage(`"x"`)}},
+		{{Op: "replace", Path: "emails[type eq]", Value: json.RawMessage(`"x"`)}},
+		{{Op: "replace", Path: "active", Value: json.RawMessage(`"maybe"`)}},
+	} {
+		_, err := svc.PatchUser(ctx, user.ID, ops)
+		assert.ErrorIs(t, err, permissions.ErrInvalidInput, ops[0].Path)
+	}
+}
+
+func TestReplaceAndDeleteUser(t *testing.T) {
+	ctx := newContext()
+	repo := &memRepo{}
+	svc := scim.NewService(repo)
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "ada", Active: true})
+	require.NoError(t, err)
+	_, err = svc.CreateUser(ctx, scim.User{UserName: "ADA"})
+	assert.ErrorIs(t, err, scim.ErrUniqueness)
+
+	replaced, err := svc.ReplaceUser(ctx, user.ID, scim.User{UserName: "ada", FamilyName: "Lovelace"})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, replaced.ID)
+	assert.Equal(t, user.Created, replaced.Created)
+	assert.Equal(t, []string{user.ID}, repo.revoked)
+
+	_, err = svc.GetUser(ctx, "not-a-uuid")
+	assert.ErrorIs(t, err, permissions.ErrNotFound)
+
+	require.NoError(t, svc.DeleteUser(ctx, user.ID))
+	_, err = svc.GetUser(ctx, user.ID)
+	assert.ErrorIs(t, err, permissions.ErrNotFound)
+}
+
+func TestCreateUser_ExternalID(t *testing.T) {
+	ctx := newContext()
+	svc := scim.NewService(&memRepo{})
+
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "ada", ExternalID: "2133479C-35A8-4A49-A682-2952D4772ECC"})
+	require.NoError(t, err)
+	assert.Equal(t, "2133479c-35a8-4a49-a682-2952d4772ecc", user.ID, "a UUID externalId is the user ID")
+	_, err = svc.CreateUser(ctx, scim.User{UserName: "bob", ExternalID: "2133479c-35a8-4a49-a682-2952d4772ecc"})
+	assert.ErrorIs(t, err, scim.ErrUniqueness)
+
+	user, err = svc.CreateUser(ctx, scim.User{UserName: "carol", ExternalID: "C3"})
+	require.NoError(t, err)
+	assert.NotEqual(t, "C3", user.ID)
+	assert.NoError(t, uuid.Validate(user.ID))
+}
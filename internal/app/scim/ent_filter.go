@@ -0,0 +1,381 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// attributes are a resource's attribute values by their lower-cased path, such
+// as "name.givenname". A multi-valued attribute has a value for each.
+type attributes map[string][]string
+
+// add adds the non-empty values of the attribute.
+func (a attributes) add(path string, values ...string) {
+	for _, v := range values {
+		if v != "" {
+			a[path] = append(a[path], v)
+		}
+	}
+}
+
+// get returns the values of the attribute, those of its "value" sub-attribute
+// for a complex attribute, such as emails.
+func (a attributes) get(path string) []string {
+	if values, ok := a[path]; ok {
+		return values
+	}
+	return a[path+".value"]
+}
+
+// sub returns the sub-attributes of the complex attribute, by their own names.
+func (a attributes) sub(path string) attributes {
+	sub := make(attributes)
+	for p, values := range a {
+		if name, ok := strings.CutPrefix(p, path+"."); ok {
+			sub[name] = values
+		}
+	}
+	return sub
+}
+
+// caseExact are the attributes whose values are compared case-sensitively, others are not.
+var caseExact = map[string]bool{"id": true, "externalid": true}
+
+// maxFilterDepth is the maximum nesting of a filter's parentheses and value
+// paths, it bounds the recursion of the parser.
+const maxFilterDepth = 32
+
+// Filter is a parsed SCIM filter, see RFC 7644 section 3.4.2.2.
+type Filter interface {
+	match(a attributes) bool
+}
+
+// compareOperators are the operators comparing an attribute with a value.
+var compareOperators = map[string]bool{"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f logicalFilter) match(a attributes) bool {
+	if f.and {
+		return f.left.match(a) && f.right.match(a)
+	}
+	return f.left.match(a) || f.right.match(a)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f notFilter) match(a attributes) bool {
+	return !f.filter.match(a)
+}
+
+// valuePathFilter matches a complex attribute with a value whose sub-attributes match the filter, as in emails[type eq "work"].
+type valuePathFilter struct {
+	path   string
+	filter Filter
+}
+
+func (f valuePathFilter) match(a attributes) bool {
+	return f.filter.match(a.sub(f.path))
+}
+
+// compareFilter compares an attribute with a value, or tests it is present when op is "pr".
+type compareFilter struct {
+	path  string
+	op    string
+	value string
+}
+
+func (f compareFilter) match(a attributes) bool {
+	values := a.get(f.path)
+	switch f.op {
+	case "pr":
+		return len(values) > 0
+	case "ne":
+		return !compareFilter{path: f.path, op: "eq", value: f.value}.match(a)
+	}
+	want := f.value
+	if !caseExact[f.path] {
+		want = strings.ToLower(want)
+	}
+	for _, v := range values {
+		if !caseExact[f.path] {
+			v = strings.ToLower(v)
+		}
+		if compare(v, f.op, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(v, op, want string) bool {
+	switch op {
+	case "eq":
+		return v == want
+	case "co":
+		return strings.Contains(v, want)
+	case "sw":
+		return strings.HasPrefix(v, want)
+	case "ew":
+		return strings.HasSuffix(v, want)
+	case "gt":
+		return v > want
+	case "ge":
+		return v >= want
+	case "lt":
+		return v < want
+	case "le":
+		return v <= want
+	}
+	return false
+}
+
+// ParseFilter parses the filter, of the attribute operators eq, ne, co, sw,
+// ew, gt, ge, lt, le and pr, the logical operators and, or and not, grouping
+// in parentheses and value paths such as emails[type eq "work"]. A filter which
+// cannot be parsed, or is nested more than maxFilterDepth deep, is ErrInvalidFilter.
+func ParseFilter(s string) (Filter, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, Errorf(ErrInvalidFilter, "unexpected %q in filter", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+// tokenize splits the filter into parentheses, brackets, quoted strings, kept
+// quoted, and words, such as attribute paths, operators and literals.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, s[i:i+1])
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, Errorf(ErrInvalidFilter, "unterminated string in filter")
+			}
+			tokens = append(tokens, s[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t()[]\"", s[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, Errorf(ErrInvalidFilter, "filter is empty")
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+	// depth is the nesting of the filter being parsed, each or is one deeper.
+	depth int
+}
+
+func (p *filterParser) peek(word string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], word)
+}
+
+func (p *filterParser) expect(word string) error {
+	if !p.peek(word) {
+		return Errorf(ErrInvalidFilter, "expected %q in filter", word)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) next() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", Errorf(ErrInvalidFilter, "filter ends unexpectedly")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) or() (Filter, error) {
+	if p.depth++; p.depth > maxFilterDepth {
+		return nil, Errorf(ErrInvalidFilter, "filter is nested more than %d deep", maxFilterDepth)
+	}
+	defer func() { p.depth-- }()
+	left, err := p.and()
+	for err == nil && p.peek("or") {
+		p.pos++
+		var right Filter
+		if right, err = p.and(); err == nil {
+			left = logicalFilter{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) and() (Filter, error) {
+	left, err := p.unary()
+	for err == nil && p.peek("and") {
+		p.pos++
+		var right Filter
+		if right, err = p.unary(); err == nil {
+			left = logicalFilter{and: true, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) unary() (Filter, error) {
+	if p.peek("not") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.group()
+		return notFilter{filter: f}, err
+	}
+	if p.peek("(") {
+		p.pos++
+		return p.group()
+	}
+	return p.attribute()
+}
+
+// group parses the filter within parentheses, the opening one already read.
+func (p *filterParser) group() (Filter, error) {
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	return f, p.expect(")")
+}
+
+func (p *filterParser) attribute() (Filter, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(token, `()[]"`) {
+		return nil, Errorf(ErrInvalidFilter, "expected an attribute in filter, not %q", token)
+	}
+	path := attributePath(token)
+
+	if p.peek("[") {
+		p.pos++
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return valuePathFilter{path: path, filter: f}, p.expect("]")
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op = strings.ToLower(op)
+	if op == "pr" {
+		return compareFilter{path: path, op: op}, nil
+	}
+	if !compareOperators[op] {
+		return nil, Errorf(ErrInvalidFilter, "unknown operator %q in filter", op)
+	}
+	token, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := literal(token)
+	if err != nil {
+		return nil, err
+	}
+	return compareFilter{path: path, op: op, value: value}, nil
+}
+
+// attributePath returns the lower-cased path of the attribute, without the
+// schema URN it may be qualified with, as in
+// urn:ietf:params:scim:schemas:core:2.0:User:userName.
+func attributePath(s string) string {
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.ToLower(s)
+}
+
+// literal returns the value of the filter's quoted string, boolean, null or number.
+func literal(token string) (string, error) {
+	if strings.HasPrefix(token, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(token), &s); err != nil {
+			return "", Errorf(ErrInvalidFilter, "string %s in filter is not valid", token)
+		}
+		return s, nil
+	}
+	switch lower := strings.ToLower(token); {
+	case lower == "true" || lower == "false" || lower == "null":
+		return lower, nil
+	case isNumber(token):
+		return token, nil
+	}
+	return "", Errorf(ErrInvalidFilter, "expected a value in filter, not %q", token)
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func boolString(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+// timeString returns the time as the meta attributes show it, empty for the zero time.
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// match returns the resources whose attributes the filter matches, all of them when it is empty.
+func match[T interface{ attributes() attributes }](resources []T, filter string) ([]T, error) {
+	if strings.TrimSpace(filter) == "" {
+		return resources, nil
+	}
+	f, err := ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]T, 0, len(resources))
+	for _, r := range resources {
+		if f.match(r.attributes()) {
+			matched = append(matched, r)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,102 @@
+package scim
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Target is what a group is mapped to.
+type Target string
+
+const (
+	// TargetRole maps a group to the role of the same name, its members are the users assigned the role directly.
+	TargetRole Target = "role"
+	// TargetGroup maps a group to the group of the same name, created when there is none.
+	TargetGroup Target = "group"
+)
+
+// ParseTarget returns the Target named, ErrInvalidInput when it is neither.
+func ParseTarget(s string) (Target, error) {
+	switch t := Target(strings.ToLower(s)); t {
+	case TargetRole, TargetGroup:
+		return t, nil
+	}
+	return "", Errorf(ErrInvalidValue, "group target %q is neither %q nor %q", s, TargetRole, TargetGroup)
+}
+
+// Group is a group provisioned by the Tenant's identity provider.
+type Group struct {
+	ID          string
+	DisplayName string
+	ExternalID  string
+	// Target is what the group is mapped to, and TargetID and TargetName the role or group.
+	Target     Target
+	TargetID   string
+	TargetName string
+	// Members are the IDs of the group's users.
+	Members      []string
+	Created      time.Time
+	LastModified time.Time
+}
+
+func (g Group) validate() error {
+	if strings.TrimSpace(g.DisplayName) == "" {
+		return Errorf(ErrInvalidValue, "displayName is required")
+	}
+	for _, m := range g.Members {
+		if uuid.Validate(m) != nil {
+			return Errorf(ErrInvalidValue, "member %q is not a user", m)
+		}
+	}
+	return nil
+}
+
+// attributes returns the group's attributes as filters see them.
+func (g Group) attributes() attributes {
+	a := make(attributes)
+	a.add("id", g.ID)
+	a.add("displayname", g.DisplayName)
+	a.add("externalid", g.ExternalID)
+	a.add("members.value", g.Members...)
+	a.add("meta.created", timeString(g.Created))
+	a.add("meta.lastmodified", timeString(g.LastModified))
+	return a
+}
+
+// diff returns the members of the group not in from, and those of from not in the group.
+func (g Group) diff(from []string) (added, removed []string) {
+	for _, m := range g.Members {
+		if !containsFold(from, m) {
+			added = append(added, m)
+		}
+	}
+	for _, m := range from {
+		if !containsFold(g.Members, m) {
+			removed = append(removed, m)
+		}
+	}
+	return added, removed
+}
+
+// uniqueMembers returns the members without those repeated.
+func uniqueMembers(members []string) []string {
+	unique := make([]string, 0, len(members))
+	for _, m := range members {
+		if !containsFold(unique, m) {
+			unique = append(unique, m)
+		}
+	}
+	return unique
+}
+
+// containsFold reports whether the IDs contain the ID, compared case-insensitively as UUIDs are.
+func containsFold(ids []string, id string) bool {
+	for _, v := range ids {
+		if strings.EqualFold(v, id) {
+			return true
+		}
+	}
+	return false
+}
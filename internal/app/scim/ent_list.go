@@ -0,0 +1,31 @@
+package scim
+
+// MaxResults is the most resources a List returns.
+const MaxResults = 200
+
+// Query selects the resources a List returns.
+type Query struct {
+	// Filter is a SCIM filter, see ParseFilter, every resource matches when it is empty.
+	Filter string
+	// StartIndex is the 1-based index of the first resource returned.
+	StartIndex int
+	// Count is the most resources returned, at most MaxResults.
+	Count int
+}
+
+// List is a page of the resources matching a Query.
+type List[T any] struct {
+	// TotalResults is how many resources match, of which Resources are those from StartIndex.
+	TotalResults int
+	StartIndex   int
+	Resources    []T
+}
+
+// page returns the resources the query's StartIndex and Count select.
+func page[T any](resources []T, q Query) *List[T] {
+	start := max(q.StartIndex, 1)
+	count := min(max(q.Count, 0), MaxResults)
+	from := min(start-1, len(resources))
+	to := min(from+count, len(resources))
+	return &List[T]{TotalResults: len(resources), StartIndex: start, Resources: resources[from:to]}
+}
@@ -0,0 +1,299 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Operation is an operation of a SCIM PATCH request, see RFC 7644 section 3.5.2.
+type Operation struct {
+	// Op is "add", "replace" or "remove", in any case.
+	Op string
+	// Path is the attribute changed, the attributes of Value when it is empty.
+	Path string
+	// Value is the JSON value, absent for "remove".
+	Value json.RawMessage
+}
+
+// path is an operation's parsed Path, as in emails[type eq "work"].value.
+type path struct {
+	// attribute is the lower-cased attribute, without its schema URN.
+	attribute string
+	// filter selects the values of a multi-valued attribute, nil for all of them.
+	filter Filter
+	// sub is the lower-cased sub-attribute of those values, if any.
+	sub string
+}
+
+func parsePath(s string) (path, error) {
+	i := strings.IndexByte(s, '[')
+	if i < 0 {
+		return path{attribute: attributePath(s)}, nil
+	}
+	j := strings.LastIndexByte(s, ']')
+	if j < i {
+		return path{}, Errorf(ErrInvalidPath, "path %q is not valid", s)
+	}
+	filter, err := ParseFilter(s[i+1 : j])
+	if err != nil {
+		return path{}, Errorf(ErrInvalidPath, "path %q is not valid: %s", s, messageOf(err))
+	}
+	p := path{attribute: attributePath(s[:i]), filter: filter}
+	if rest := s[j+1:]; rest != "" {
+		sub, ok := strings.CutPrefix(rest, ".")
+		if !ok || sub == "" {
+			return path{}, Errorf(ErrInvalidPath, "path %q is not valid", s)
+		}
+		p.sub = strings.ToLower(sub)
+	}
+	return p, nil
+}
+
+// operations checks each operation's Op, and calls fn with its lower-cased op
+// and the attributes it changes: that of its Path, or each of its Value's.
+func operations(ops []Operation, fn func(op string, p path, value json.RawMessage) error) error {
+	if len(ops) == 0 {
+		return Errorf(ErrInvalidValue, "no operations")
+	}
+	for _, o := range ops {
+		op := strings.ToLower(o.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return Errorf(ErrInvalidValue, "operation %q is not add, replace or remove", o.Op)
+		}
+		if o.Path != "" {
+			p, err := parsePath(o.Path)
+			if err != nil {
+				return err
+			}
+			if err := fn(op, p, o.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if op == "remove" {
+			return Errorf(ErrNoTarget, "remove requires a path")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(o.Value, &values); err != nil {
+			return Errorf(ErrInvalidValue, "the value of an operation without a path must be an object")
+		}
+		for name, value := range values {
+			if err := fn(op, path{attribute: attributePath(name)}, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// patch applies the operations to the user. Attributes the service does not keep are ignored.
+func (u *User) patch(ops []Operation) error {
+	return operations(ops, func(op string, p path, value json.RawMessage) error {
+		if op == "remove" {
+			value = nil
+		}
+		return u.set(p, value)
+	})
+}
+
+// set sets the user's attribute at the path to the value, or clears it when the value is nil.
+func (u *User) set(p path, value json.RawMessage) error {
+	var err error
+	switch p.attribute {
+	case "id", "meta":
+		return Errorf(ErrMutability, "%s cannot be changed", p.attribute)
+	case "username":
+		if err = decodeString(value, &u.UserName); err == nil && u.UserName == "" {
+			return Errorf(ErrInvalidValue, "userName is required")
+		}
+	case "externalid":
+		err = decodeString(value, &u.ExternalID)
+	case "displayname":
+		err = decodeString(value, &u.DisplayName)
+	case "name.givenname":
+		err = decodeString(value, &u.GivenName)
+	case "name.familyname":
+		err = decodeString(value, &u.FamilyName)
+	case "name":
+		var name struct {
+			GivenName  *string `json:"givenName"`
+			FamilyName *string `json:"familyName"`
+		}
+		if value == nil {
+			u.GivenName, u.FamilyName = "", ""
+			return nil
+		}
+		if err = json.Unmarshal(value, &name); err != nil {
+			return Errorf(ErrInvalidValue, "name must be an object")
+		}
+		if name.GivenName != nil {
+			u.GivenName = *name.GivenName
+		}
+		if name.FamilyName != nil {
+			u.FamilyName = *name.FamilyName
+		}
+	case "emails", "emails.value":
+		// Only the primary email is kept, so a filter selects it whichever it names.
+		if p.filter != nil || p.attribute == "emails.value" {
+			if p.sub != "" && p.sub != "value" {
+				return nil
+			}
+			err = decodeString(value, &u.Email)
+			break
+		}
+		err = decodeEmails(value, &u.Email)
+	case "active":
+		if value == nil {
+			return Errorf(ErrInvalidValue, "active cannot be removed")
+		}
+		err = decodeBool(value, &u.Active)
+	}
+	if err != nil {
+		return Errorf(ErrInvalidValue, "%s: %s", p.attribute, messageOf(err))
+	}
+	return nil
+}
+
+// patch applies the operations to the group and its Members. Attributes the service does not keep are ignored.
+func (g *Group) patch(ops []Operation) error {
+	return operations(ops, func(op string, p path, value json.RawMessage) error {
+		switch p.attribute {
+		case "id", "meta":
+			return Errorf(ErrMutability, "%s cannot be changed", p.attribute)
+		case "displayname":
+			if op == "remove" {
+				return Errorf(ErrInvalidValue, "displayName is required")
+			}
+			if err := decodeString(value, &g.DisplayName); err != nil {
+				return Errorf(ErrInvalidValue, "displayName: %s", messageOf(err))
+			}
+		case "externalid":
+			if op == "remove" {
+				value = nil
+			}
+			if err := decodeString(value, &g.ExternalID); err != nil {
+				return Errorf(ErrInvalidValue, "externalId: %s", messageOf(err))
+			}
+		case "members":
+			return g.patchMembers(op, p, value)
+		}
+		return nil
+	})
+}
+
+func (g *Group) patchMembers(op string, p path, value json.RawMessage) error {
+	if p.filter != nil {
+		if op != "remove" {
+			return Errorf(ErrInvalidPath, "only remove may filter members")
+		}
+		g.Members = removeMembers(g.Members, func(m string) bool {
+			return p.filter.match(attributes{"value": {m}})
+		})
+		return nil
+	}
+
+	all := value == nil || string(value) == "null"
+	var members []string
+	if !all {
+		var values []struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(value, &values); err != nil {
+			return Errorf(ErrInvalidValue, "members must be an array of objects with a value")
+		}
+		for _, v := range values {
+			if v.Value == "" {
+				return Errorf(ErrInvalidValue, "a member's value is required")
+			}
+			members = append(members, v.Value)
+		}
+	}
+
+	switch op {
+	case "add":
+		for _, m := range members {
+			if !containsFold(g.Members, m) {
+				g.Members = append(g.Members, m)
+			}
+		}
+	case "replace":
+		g.Members = members
+	case "remove":
+		// Without a value every member is removed, with one only those it lists.
+		g.Members = removeMembers(g.Members, func(m string) bool {
+			return all || containsFold(members, m)
+		})
+	}
+	return nil
+}
+
+func removeMembers(members []string, remove func(string) bool) []string {
+	kept := make([]string, 0, len(members))
+	for _, m := range members {
+		if !remove(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// decodeString decodes the JSON string into s, a nil or null value clears it.
+func decodeString(value json.RawMessage, s *string) error {
+	if value == nil || string(value) == "null" {
+		*s = ""
+		return nil
+	}
+	return json.Unmarshal(value, s)
+}
+
+// decodeBool decodes the JSON boolean into b, or a string "true" or "false" in
+// any case, as some identity providers send.
+func decodeBool(value json.RawMessage, b *bool) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			*b = true
+			return nil
+		case "false":
+			*b = false
+			return nil
+		}
+	}
+	return json.Unmarshal(value, b)
+}
+
+// decodeEmails decodes the value of the emails attribute into the primary email, or the first when none is.
+func decodeEmails(value json.RawMessage, email *string) error {
+	if value == nil || string(value) == "null" {
+		*email = ""
+		return nil
+	}
+	var emails []struct {
+		Value   string `json:"value"`
+		Primary bool   `json:"primary"`
+	}
+	if err := json.Unmarshal(value, &emails); err != nil {
+		return err
+	}
+	*email = ""
+	for i, e := range emails {
+		if i == 0 || e.Primary {
+			*email = e.Value
+		}
+		if e.Primary {
+			break
+		}
+	}
+	return nil
+}
+
+// messageOf returns the message of the error without its cause.
+func messageOf(err error) string {
+	if message, ok := permissions.Message(err); ok {
+		return message
+	}
+	return err.Error()
+}
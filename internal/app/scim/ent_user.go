@@ -0,0 +1,47 @@
+package scim
+
+import (
+	"strings"
+	"time"
+)
+
+// User is a user provisioned by the Tenant's identity provider. Its ID is the
+// user ID of the permissions service, assigned when the user is created, see
+// Service.CreateUser.
+type User struct {
+	ID          string
+	UserName    string
+	ExternalID  string
+	DisplayName string
+	GivenName   string
+	FamilyName  string
+	// Email is the user's primary email address.
+	Email string
+	// Active is false for a deactivated user, who holds no grants.
+	Active       bool
+	Created      time.Time
+	LastModified time.Time
+}
+
+func (u User) validate() error {
+	if strings.TrimSpace(u.UserName) == "" {
+		return Errorf(ErrInvalidValue, "userName is required")
+	}
+	return nil
+}
+
+// attributes returns the user's attributes as filters see them.
+func (u User) attributes() attributes {
+	a := make(attributes)
+	a.add("id", u.ID)
+	a.add("username", u.UserName)
+	a.add("externalid", u.ExternalID)
+	a.add("displayname", u.DisplayName)
+	a.add("name.givenname", u.GivenName)
+	a.add("name.familyname", u.FamilyName)
+	a.add("emails.value", u.Email)
+	a.add("active", boolString(u.Active))
+	a.add("meta.created", timeString(u.Created))
+	a.add("meta.lastmodified", timeString(u.LastModified))
+	return a
+}
@@ -0,0 +1,34 @@
+package scim
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// The SCIM error types, see RFC 7644 section 3.12. Each is the cause of a
+// permissions error of the kind ErrInvalidInput, so errors.Is matches either.
+var (
+	ErrInvalidFilter = errors.New("invalidFilter")
+	ErrInvalidPath   = errors.New("invalidPath")
+	ErrInvalidValue  = errors.New("invalidValue")
+	ErrMutability    = errors.New("mutability")
+	ErrNoTarget      = errors.New("noTarget")
+	ErrUniqueness    = errors.New("uniqueness")
+)
+
+// Errorf returns an error of the SCIM error type, whose message is formatted as fmt.Sprintf does.
+func Errorf(scimType error, format string, args ...any) error {
+	return permissions.WrapError(permissions.ErrInvalidInput, scimType, fmt.Sprintf(format, args...))
+}
+
+// Type returns the SCIM error type of the error, or nil when it is not one of them.
+func Type(err error) error {
+	for _, t := range []error{ErrInvalidFilter, ErrInvalidPath, ErrInvalidValue, ErrMutability, ErrNoTarget, ErrUniqueness} {
+		if errors.Is(err, t) {
+			return t
+		}
+	}
+	return nil
+}
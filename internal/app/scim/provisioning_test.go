@@ -0,0 +1,122 @@
+//go:build test
+// +build test
+
+package scim_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/scim"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roleNames returns the names of the roles the user holds.
+func roleNames(t *testing.T, ctx context.Context, repo *postgres.PermissionsRepo, userID string) []string {
+	t.Helper()
+	roles, err := repo.GetUserRoles(contextkey.WithUserID(ctx, userID))
+	require.NoError(t, err)
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestProvisioning_RoleTarget(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := scim.NewService(repo)
+	require.NoError(t, svc.SetGroupTarget(ctx, scim.TargetRole))
+
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "ada@example.com", Active: true})
+	require.NoError(t, err)
+	_, err = svc.CreateUser(ctx, scim.User{UserName: "Ada@Example.com"})
+	assert.ErrorIs(t, err, scim.ErrUniqueness)
+
+	group, err := svc.CreateGroup(ctx, scim.Group{DisplayName: "sales person", Members: []string{user.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, scim.TargetRole, group.Target)
+	assert.Equal(t, []string{user.ID}, group.Members)
+	assert.Contains(t, roleNames(t, ctx, repo, user.ID), "sales person")
+
+	_, err = svc.CreateGroup(ctx, scim.Group{DisplayName: "no such role"})
+	assert.ErrorIs(t, err, scim.ErrInvalidValue)
+	_, err = svc.CreateGroup(ctx, scim.Group{DisplayName: "sales person"})
+	assert.ErrorIs(t, err, scim.ErrUniqueness)
+
+	group, err = svc.PatchGroup(ctx, group.ID, []scim.Operation{{Op: "remove", Path: `members[value eq "` + user.ID + `"]`}})
+	require.NoError(t, err)
+	assert.Empty(t, group.Members)
+	assert.NotContains(t, roleNames(t, ctx, repo, user.ID), "sales person")
+
+	_, err = svc.PatchGroup(ctx, group.ID, []scim.Operation{{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + user.ID + `"}]`)}})
+	require.NoError(t, err)
+	_, err = svc.PatchUser(ctx, user.ID, []scim.Operation{{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}})
+	require.NoError(t, err)
+	assert.Empty(t, roleNames(t, ctx, repo, user.ID), "deactivation removes every grant")
+
+	group, err = svc.PatchGroup(ctx, group.ID, []scim.Operation{{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + user.ID + `"}]`)}})
+	require.NoError(t, err)
+	assert.Empty(t, group.Members, "an inactive user is not made a member")
+}
+
+func TestProvisioning_GroupTarget(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := scim.NewService(repo)
+
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "bob@example.com", Active: true})
+	require.NoError(t, err)
+	group, err := svc.CreateGroup(ctx, scim.Group{DisplayName: "field sales", Members: []string{user.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, scim.TargetGroup, group.Target)
+	assert.Equal(t, "field sales", group.TargetName)
+	assert.Equal(t, []string{user.ID}, group.Members)
+
+	require.NoError(t, svc.DeleteGroup(ctx, group.ID))
+	groups, err := svc.ListGroups(ctx, scim.Query{Count: scim.MaxResults})
+	require.NoError(t, err)
+	assert.Zero(t, groups.TotalResults)
+
+	require.NoError(t, svc.DeleteUser(ctx, user.ID))
+	_, err = svc.GetUser(ctx, user.ID)
+	assert.Error(t, err)
+}
+
+func TestProvisioning_ExistingUser(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := scim.NewService(repo)
+	const userSalesPerson = "2133479c-35a8-4a49-a682-2952d4772ecc"
+	require.Contains(t, roleNames(t, ctx, repo, userSalesPerson), "sales person")
+
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "sales@example.com", ExternalID: userSalesPerson, Active: true})
+	require.NoError(t, err)
+	assert.Equal(t, userSalesPerson, user.ID)
+
+	_, err = svc.PatchUser(ctx, user.ID, []scim.Operation{{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}})
+	require.NoError(t, err)
+	assert.Empty(t, roleNames(t, ctx, repo, userSalesPerson), "deactivation removes the grants the user already had")
+	resources, err := repo.GetUserResources(contextkey.WithUserID(ctx, userSalesPerson), nil)
+	require.NoError(t, err)
+	assert.Empty(t, resources)
+}
+
+func TestProvisioning_RevocationsAreKept(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	svc := scim.NewService(repo)
+	const userSalesManager = "652f4d18-dd3d-40c0-874e-cbe3566abccf"
+
+	user, err := svc.CreateUser(ctx, scim.User{UserName: "manager@example.com", ExternalID: userSalesManager, Active: true})
+	require.NoError(t, err)
+	_, err = svc.PatchUser(ctx, user.ID, []scim.Operation{{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}})
+	require.NoError(t, err)
+
+	extra, revoked, err := repo.GetUserPermissionsExtraAndRevoked(contextkey.WithUserID(ctx, userSalesManager), nil)
+	require.NoError(t, err)
+	assert.Empty(t, extra, "deactivation removes extra permissions")
+	assert.NotEmpty(t, revoked, "deactivation keeps revoked permissions, so reactivation does not give them back")
+}
@@ -0,0 +1,46 @@
+package scim
+
+import "context"
+
+type Reader interface {
+	// GetUsers returns the Tenant's users, by userName.
+	GetSCIMUsers(ctx context.Context) ([]User, error)
+	// GetUser returns the user, ErrNotFound when there is no such user.
+	GetSCIMUser(ctx context.Context, userID string) (*User, error)
+	// GetGroups returns the Tenant's groups with their members, by displayName.
+	GetSCIMGroups(ctx context.Context) ([]Group, error)
+	// GetGroup returns the group with its members, ErrNotFound when there is no such group.
+	GetSCIMGroup(ctx context.Context, groupID string) (*Group, error)
+	// GetGroupTarget returns what the Tenant's new groups are mapped to, TargetGroup when it has not been set.
+	GetSCIMGroupTarget(ctx context.Context) (Target, error)
+}
+
+// Writer changes the Tenant's users and groups. A user or group which does not
+// exist is ErrNotFound, and a userName or displayName already in use, compared
+// case-insensitively, is ErrUniqueness.
+type Writer interface {
+	CreateSCIMUser(ctx context.Context, user User) error
+	// UpdateUser replaces the user's attributes, and removes all their grants when revokeGrants is set.
+	UpdateSCIMUser(ctx context.Context, user User, revokeGrants bool) error
+	// DeleteUser deletes the user and removes all their grants.
+	DeleteSCIMUser(ctx context.Context, userID string) error
+	// CreateGroup creates the group mapped to its Target named as the group is:
+	// the role, which must exist, or the group, which is created when there is
+	// none. A role or group already mapped is ErrUniqueness. The Members are then
+	// added as UpdateGroup adds them.
+	CreateSCIMGroup(ctx context.Context, group Group) error
+	// UpdateGroup replaces the group's displayName and externalId, and adds and
+	// removes the members. A member who is not a user is ErrInvalidValue, and an
+	// inactive user is left out. Adding members is ErrForbidden when it would
+	// violate a separation of duties rule.
+	UpdateSCIMGroup(ctx context.Context, group Group, added, removed []string) error
+	// DeleteGroup deletes the group, its members lose the role or group it is
+	// mapped to, which is itself kept.
+	DeleteSCIMGroup(ctx context.Context, groupID string) error
+	SetSCIMGroupTarget(ctx context.Context, target Target) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
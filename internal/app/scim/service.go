@@ -0,0 +1,24 @@
+// Package scim provisions the Tenant's users and groups from its identity
+// provider over SCIM 2.0, see RFC 7643 and RFC 7644. A SCIM group is mapped to
+// a role, whose members are the users assigned it directly, or to a group of
+// the permissions service, as the Tenant's GroupTarget is when it is created.
+// Changes to a group's members are changes to those assignments, and a user who
+// is deactivated or deleted loses all their grants.
+//
+// The service keeps the attributes it needs of users and groups, and ignores
+// any others it is sent.
+package scim
+
+import "time"
+
+type Service struct {
+	repo ReaderWriter
+	now  func() time.Time
+}
+
+func NewService(repo ReaderWriter) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
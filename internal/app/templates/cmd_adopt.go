@@ -0,0 +1,142 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+)
+
+// Adopt makes the role adopt the template, the role is named after the template
+// when role is empty and created if the Tenant has no such role. An existing
+// role loses none of its permissions, those beyond the template's become its
+// extensions. The report is of the sync granting the role the template's
+// permissions, which is only planned when dryRun is true.
+func (s *Service) Adopt(ctx context.Context, template, role string, dryRun bool) (*SyncReport, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("adopt: %w", err)
+	}
+	t, ok := s.catalogue.Get(template)
+	if !ok {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "adopt: template %q is not in the catalogue", template)
+	}
+	role = strings.TrimSpace(role)
+	if role == "" {
+		role = t.Name
+	}
+
+	adoptions, err := s.repo.GetAdoptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("adopt: get adoptions: %w", err)
+	}
+	if i := slices.IndexFunc(adoptions, func(a Adoption) bool { return a.Role == role }); i >= 0 {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "adopt: role %q already adopts template %q", role, adoptions[i].Template)
+	}
+
+	model, err := s.rbac.Export(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("adopt: %w", err)
+	}
+	adoption := Adoption{Role: role, Template: t.Name, AdoptedAt: s.now().UTC()}
+	if i := slices.IndexFunc(model.Roles, func(r rbac.Role) bool { return r.Name == role }); i >= 0 {
+		adoption.Extensions = without(model.Roles[i].Permissions, t.Permissions)
+	}
+
+	report, err := s.sync(ctx, model, []Adoption{adoption}, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("adopt: %w", err)
+	}
+	return report, nil
+}
+
+// Extend grants the adopting role the permissions beyond its template's, they
+// are kept through every sync until the role is detached. The report is of
+// the sync granting them, which is only planned when dryRun is true.
+func (s *Service) Extend(ctx context.Context, role string, permissionNames []string, dryRun bool) (*SyncReport, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("extend: %w", err)
+	}
+	if len(permissionNames) == 0 {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "extend: a permission is required")
+	}
+	for _, p := range permissionNames {
+		if err := permissions.ValidatePermissionName(p); err != nil {
+			return nil, fmt.Errorf("extend: %w", err)
+		}
+	}
+
+	adoption, err := s.getAdoption(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("extend: %w", err)
+	}
+	t, ok := s.catalogue.Get(adoption.Template)
+	if !ok {
+		return nil, permissions.Errorf(permissions.ErrInvalidInput, "extend: template %q of role %q is no longer in the catalogue", adoption.Template, role)
+	}
+	adoption.Extensions = without(sortedUnique(append(adoption.Extensions, permissionNames...)), t.Permissions)
+
+	model, err := s.rbac.Export(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("extend: %w", err)
+	}
+	report, err := s.sync(ctx, model, []Adoption{*adoption}, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("extend: %w", err)
+	}
+	return report, nil
+}
+
+// Detach ends the role's adoption of its template, leaving the role with the
+// permissions it has for the Tenant to manage.
+func (s *Service) Detach(ctx context.Context, role string) error {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return fmt.Errorf("detach: %w", err)
+	}
+	if err := s.repo.DeleteAdoption(ctx, role); err != nil {
+		return fmt.Errorf("detach: %w", err)
+	}
+	return nil
+}
+
+// GetAdoptions returns the Tenant's roles adopting a template, ordered by role name.
+func (s *Service) GetAdoptions(ctx context.Context) ([]Adoption, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("get adoptions: %w", err)
+	}
+	adoptions, err := s.repo.GetAdoptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get adoptions: %w", err)
+	}
+	return adoptions, nil
+}
+
+// Catalogue returns the templates Tenants may adopt.
+func (s *Service) Catalogue() Catalogue {
+	return s.catalogue
+}
+
+func (s *Service) getAdoption(ctx context.Context, role string) (*Adoption, error) {
+	adoptions, err := s.repo.GetAdoptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get adoptions: %w", err)
+	}
+	i := slices.IndexFunc(adoptions, func(a Adoption) bool { return a.Role == role })
+	if i < 0 {
+		return nil, permissions.Errorf(permissions.ErrNotFound, "role %q adopts no template", role)
+	}
+	return &adoptions[i], nil
+}
+
+// without returns the names not in excluded, in order.
+func without(names, excluded []string) []string {
+	var kept []string
+	for _, name := range names {
+		if !slices.Contains(excluded, name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
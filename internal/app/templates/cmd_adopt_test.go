@@ -0,0 +1,78 @@
+//go:build test
+// +build test
+
+package templates_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres/postgrestest"
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/internal/app/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptSyncDetach(t *testing.T) {
+	ctx, repo := postgrestest.NewTenantRepo(t)
+	standard := templates.Catalogue{
+		{Name: "administrator", Permissions: []string{"*:*"}},
+		{Name: "read only", Permissions: []string{"*:read"}},
+	}
+	svc := templates.NewService(repo, rbac.NewService(repo), standard)
+
+	report, err := svc.Adopt(ctx, "administrator", "admin", false)
+	require.NoError(t, err)
+	require.Len(t, report.Roles, 1)
+	assert.Equal(t, []string{"*:*"}, report.Roles[0].Added)
+	assert.Empty(t, report.Roles[0].Removed, "the role's own permissions are kept")
+
+	report, err = svc.Adopt(ctx, "read only", "", false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Plan.Changes, "the role already matches the template")
+
+	adoptions, err := svc.GetAdoptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, adoptions, 2)
+	assert.Equal(t, "admin", adoptions[0].Role)
+	assert.Len(t, adoptions[0].Extensions, 6)
+
+	// The catalogue changes, and the adopting roles follow it.
+	changed := templates.Catalogue{
+		standard[0],
+		{Name: "read only", Permissions: []string{"*:read", "invoices:*"}},
+	}
+	svc = templates.NewService(repo, rbac.NewService(repo), changed)
+
+	report, err = svc.Sync(ctx, true)
+	require.NoError(t, err)
+	assert.False(t, report.Applied)
+	require.Len(t, report.Roles, 2)
+	assert.False(t, report.Roles[0].Changed(), "the administrator template is unchanged")
+	assert.Equal(t, []string{"invoices:*"}, report.Roles[1].Added)
+
+	report, err = svc.Sync(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+
+	adoptions, err = svc.GetAdoptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, changed[1].Version(), adoptions[1].Version)
+
+	report, err = svc.Sync(ctx, true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Plan.Changes, "a second sync changes nothing")
+
+	require.NoError(t, svc.Detach(ctx, "admin"))
+	err = svc.Detach(ctx, "admin")
+	assert.ErrorIs(t, err, permissions.ErrNotFound)
+
+	model, err := rbac.NewService(repo).Export(ctx, false)
+	require.NoError(t, err)
+	for _, r := range model.Roles {
+		if r.Name == "admin" {
+			assert.Contains(t, r.Permissions, "*:*", "a detached role keeps its permissions")
+		}
+	}
+}
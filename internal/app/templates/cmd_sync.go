@@ -0,0 +1,117 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+)
+
+// Sync grants every adopting role exactly its template's permissions and its
+// extensions, propagating the changes made to the catalogue since the last
+// sync. Permissions new to the Tenant are added enabled, while those the
+// Tenant has disabled are left so. The changes are planned against the whole
+// model and applied in one transaction, unless dryRun is true.
+func (s *Service) Sync(ctx context.Context, dryRun bool) (*SyncReport, error) {
+	if err := permissions.ValidateTenant(ctx); err != nil {
+		return nil, fmt.Errorf("sync: %w", err)
+	}
+	adoptions, err := s.repo.GetAdoptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sync: get adoptions: %w", err)
+	}
+	model, err := s.rbac.Export(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("sync: %w", err)
+	}
+	report, err := s.sync(ctx, model, adoptions, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("sync: %w", err)
+	}
+	return report, nil
+}
+
+// sync plans the changes making the adoptions' roles in the current model
+// match their templates, and unless dryRun applies them and saves the adoptions.
+func (s *Service) sync(ctx context.Context, current *rbac.Model, adoptions []Adoption, dryRun bool) (*SyncReport, error) {
+	desired := &rbac.Model{
+		ResourceTypes: current.ResourceTypes,
+		Permissions:   slices.Clone(current.Permissions),
+		Roles:         slices.Clone(current.Roles),
+	}
+
+	report := &SyncReport{}
+	var synced []Adoption
+	for _, a := range adoptions {
+		t, ok := s.catalogue.Get(a.Template)
+		if !ok {
+			report.Missing = append(report.Missing, a)
+			continue
+		}
+
+		want := a.Permissions(t)
+		i := slices.IndexFunc(desired.Roles, func(r rbac.Role) bool { return r.Name == a.Role })
+		if i < 0 {
+			desired.Roles = append(desired.Roles, rbac.Role{Name: a.Role})
+			i = len(desired.Roles) - 1
+		}
+		have := desired.Roles[i].Permissions
+		desired.Roles[i].Permissions = want
+		desired.Roles[i].Conditions = keepConditions(desired.Roles[i].Conditions, want)
+		for _, p := range want {
+			if !slices.ContainsFunc(desired.Permissions, func(dp rbac.Permission) bool { return dp.Name == p }) {
+				desired.Permissions = append(desired.Permissions, rbac.Permission{Name: p, Enabled: true})
+			}
+		}
+
+		report.Roles = append(report.Roles, RoleDiff{
+			Role:        a.Role,
+			Template:    t.Name,
+			FromVersion: a.Version,
+			ToVersion:   t.Version(),
+			Added:       without(want, have),
+			Removed:     without(have, want),
+		})
+		a.Version = t.Version()
+		synced = append(synced, a)
+	}
+
+	plan, err := s.rbac.Plan(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+	report.Plan = plan
+	if dryRun {
+		return report, nil
+	}
+
+	if err := s.rbac.Apply(ctx, plan); err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	for _, a := range synced {
+		a.SyncedAt = now
+		if err := s.repo.SaveAdoption(ctx, a); err != nil {
+			return nil, fmt.Errorf("save adoption of role %q: %w", a.Role, err)
+		}
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// keepConditions returns the conditions of the granted permissions, a grant
+// the sync removes takes its condition with it. conditions is not modified.
+func keepConditions(conditions map[string]string, granted []string) map[string]string {
+	var kept map[string]string
+	for _, name := range granted {
+		if c, ok := conditions[name]; ok {
+			if kept == nil {
+				kept = make(map[string]string)
+			}
+			kept[name] = c
+		}
+	}
+	return kept
+}
@@ -0,0 +1,154 @@
+package templates_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/internal/app/templates"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var catalogue = templates.Catalogue{
+	{Name: "administrator", Permissions: []string{"*:*"}},
+	{Name: "clerk", Permissions: []string{"invoices:create", "invoices:read"}},
+}
+
+// stubRepo keeps the adoptions saved, in memory.
+type stubRepo struct {
+	adoptions []templates.Adoption
+}
+
+func (r *stubRepo) GetAdoptions(context.Context) ([]templates.Adoption, error) {
+	return r.adoptions, nil
+}
+
+func (r *stubRepo) SaveAdoption(_ context.Context, adoption templates.Adoption) error {
+	for i, a := range r.adoptions {
+		if a.Role == adoption.Role {
+			r.adoptions[i] = adoption
+			return nil
+		}
+	}
+	r.adoptions = append(r.adoptions, adoption)
+	return nil
+}
+
+func (r *stubRepo) DeleteAdoption(context.Context, string) error {
+	return nil
+}
+
+// stubModel returns a copy of its model, as Export clears the assignments of
+// the model it is given, and keeps the plan applied.
+type stubModel struct {
+	rbac.ReaderWriter
+	model   *rbac.Model
+	applied *rbac.Plan
+}
+
+func (m *stubModel) GetModel(context.Context) (*rbac.Model, error) {
+	model := *m.model
+	return &model, nil
+}
+
+func (m *stubModel) GetUserGrants(context.Context) (map[string]rbac.UserGrants, error) {
+	return nil, nil
+}
+
+func (m *stubModel) ApplyPlan(_ context.Context, plan *rbac.Plan) error {
+	m.applied = plan
+	return nil
+}
+
+func newModel() *rbac.Model {
+	return &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}},
+		Permissions: []rbac.Permission{
+			{Name: "invoices:delete", Enabled: true},
+			{Name: "invoices:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "sales clerk", Permissions: []string{"invoices:delete", "invoices:read"}},
+		},
+		Assignments: []rbac.Assignment{{UserID: "0f9a4c52-3b1e-4d8a-9c6f-2e7b5d1a8c34", Roles: []string{"sales clerk"}}},
+	}
+}
+
+func TestAdopt_KeepsExistingPermissionsAsExtensions(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{}
+	model := &stubModel{model: newModel()}
+	svc := templates.NewService(repo, rbac.NewService(model), catalogue)
+
+	report, err := svc.Adopt(ctx, "clerk", "sales clerk", false)
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	require.Len(t, report.Roles, 1)
+	assert.Equal(t, templates.RoleDiff{
+		Role:        "sales clerk",
+		Template:    "clerk",
+		FromVersion: "",
+		ToVersion:   catalogue[1].Version(),
+		Added:       []string{"invoices:create"},
+	}, report.Roles[0])
+
+	require.NotNil(t, model.applied)
+	assert.Contains(t, model.applied.Changes, rbac.Change{Action: rbac.ActionAdd, Kind: rbac.ChangeKindPermission, Subject: "invoices:create", Enabled: true})
+	assert.Contains(t, model.applied.Changes, rbac.Change{Action: rbac.ActionAdd, Kind: rbac.ChangeKindRolePermission, Subject: "sales clerk", Object: "invoices:create"})
+	assert.Equal(t, []rbac.Impact{{Permission: "invoices:create", Gained: 1}}, model.applied.Impacts)
+
+	require.Len(t, repo.adoptions, 1)
+	assert.Equal(t, []string{"invoices:delete"}, repo.adoptions[0].Extensions)
+	assert.Equal(t, catalogue[1].Version(), repo.adoptions[0].Version)
+	assert.False(t, repo.adoptions[0].SyncedAt.IsZero())
+
+	_, err = svc.Adopt(ctx, "clerk", "sales clerk", false)
+	assert.ErrorIs(t, err, permissions.ErrInvalidInput, "a role adopts one template")
+}
+
+func TestSync_PropagatesTemplateChanges(t *testing.T) {
+	ctx := contextkey.WithTenantID(context.Background(), "test")
+	repo := &stubRepo{adoptions: []templates.Adoption{
+		{Role: "sales clerk", Template: "clerk", Version: "old", Extensions: []string{"invoices:export"}},
+		{Role: "retired", Template: "archivist", Version: "old"},
+	}}
+	current := newModel()
+	current.Permissions = append(current.Permissions, rbac.Permission{Name: "invoices:export", Enabled: false})
+	current.Roles[0].Permissions = []string{"invoices:delete", "invoices:export", "invoices:read"}
+	model := &stubModel{model: current}
+	svc := templates.NewService(repo, rbac.NewService(model), catalogue)
+
+	report, err := svc.Sync(ctx, true)
+	require.NoError(t, err)
+	assert.False(t, report.Applied)
+	assert.Nil(t, model.applied, "a dry run applies nothing")
+	assert.Equal(t, "old", repo.adoptions[0].Version, "a dry run saves nothing")
+
+	require.Len(t, report.Roles, 1)
+	diff := report.Roles[0]
+	assert.True(t, diff.Changed())
+	assert.Equal(t, []string{"invoices:create"}, diff.Added)
+	assert.Equal(t, []string{"invoices:delete"}, diff.Removed)
+	require.Len(t, report.Missing, 1)
+	assert.Equal(t, "archivist", report.Missing[0].Template)
+	assert.NotContains(t, report.Plan.Changes, rbac.Change{Action: rbac.ActionChange, Kind: rbac.ChangeKindPermission, Subject: "invoices:export", Enabled: true},
+		"a permission the Tenant disabled stays disabled")
+
+	report, err = svc.Sync(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.Equal(t, catalogue[1].Version(), repo.adoptions[0].Version)
+	assert.Equal(t, "old", repo.adoptions[1].Version, "an adoption of a missing template is left as it is")
+}
+
+func TestTemplate_Version(t *testing.T) {
+	a := templates.Template{Name: "clerk", Permissions: []string{"invoices:read", "invoices:create"}}
+	b := templates.Template{Name: "clerk", Description: "Clerks.", Permissions: []string{"invoices:create", "invoices:read", "invoices:read"}}
+	c := templates.Template{Name: "clerk", Permissions: []string{"invoices:read"}}
+
+	assert.Equal(t, a.Version(), b.Version(), "order, repetition and description do not matter")
+	assert.NotEqual(t, a.Version(), c.Version())
+}
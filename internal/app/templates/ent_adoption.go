@@ -0,0 +1,57 @@
+package templates
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+)
+
+// Adoption is a Tenant's role adopting a template.
+type Adoption struct {
+	// Role is the name of the adopting role.
+	Role     string
+	Template string
+	// Version is of the template when the role was last synced, see Template.Version.
+	Version string
+	// Extensions are the permissions the Tenant grants the role beyond the template's.
+	Extensions []string
+	AdoptedAt  time.Time
+	SyncedAt   time.Time
+}
+
+// Permissions returns the names of the permissions granted to the role, the
+// template's and the extensions, in order.
+func (a Adoption) Permissions(t Template) []string {
+	return sortedUnique(append(slices.Clone(t.Permissions), a.Extensions...))
+}
+
+// RoleDiff is how a sync changes an adopting role.
+type RoleDiff struct {
+	Role     string
+	Template string
+	// FromVersion is the template's version the role was last synced with, empty for a new adoption.
+	FromVersion string
+	ToVersion   string
+	// Added and Removed are the names of the permissions granted to and taken from the role.
+	Added   []string
+	Removed []string
+}
+
+// Changed reports whether the sync changes the role's permissions or template version.
+func (d RoleDiff) Changed() bool {
+	return d.FromVersion != d.ToVersion || len(d.Added) > 0 || len(d.Removed) > 0
+}
+
+// SyncReport is the difference a sync makes to the Tenant.
+type SyncReport struct {
+	Roles []RoleDiff
+	// Missing are the adoptions of templates no longer in the catalogue, their
+	// roles are left as they are until detached.
+	Missing []Adoption
+	// Plan makes the changes to the Tenant's model, its Impacts are the users
+	// gaining and losing each permission.
+	Plan *rbac.Plan
+	// Applied is false for a dry run.
+	Applied bool
+}
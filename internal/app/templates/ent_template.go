@@ -0,0 +1,66 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+)
+
+// Template is a standard role of the catalogue.
+type Template struct {
+	Name        string
+	Description string
+	// Permissions are the names of the permissions granted to the roles adopting the template.
+	Permissions []string
+}
+
+// Version identifies the template's permissions, it changes whenever they do.
+func (t Template) Version() string {
+	h := sha256.Sum256([]byte(strings.Join(sortedUnique(t.Permissions), "\n")))
+	return hex.EncodeToString(h[:6])
+}
+
+// Catalogue is the templates Tenants may adopt.
+type Catalogue []Template
+
+// Get returns the template with the name.
+func (c Catalogue) Get(name string) (Template, bool) {
+	i := slices.IndexFunc(c, func(t Template) bool { return t.Name == name })
+	if i < 0 {
+		return Template{}, false
+	}
+	return c[i], true
+}
+
+// Validate checks every template has a name of its own and valid permission names.
+func (c Catalogue) Validate() error {
+	var errs []error
+	names := make(map[string]bool, len(c))
+	for _, t := range c {
+		if strings.TrimSpace(t.Name) == "" {
+			errs = append(errs, errors.New("template with no name"))
+		}
+		if names[t.Name] {
+			errs = append(errs, fmt.Errorf("template %q is repeated", t.Name))
+		}
+		names[t.Name] = true
+		if len(t.Permissions) == 0 {
+			errs = append(errs, fmt.Errorf("template %q grants no permissions", t.Name))
+		}
+		for _, p := range t.Permissions {
+			if err := permissions.ValidatePermissionName(p); err != nil {
+				errs = append(errs, fmt.Errorf("template %q: %w", t.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sortedUnique(names []string) []string {
+	return slices.Compact(slices.Sorted(slices.Values(names)))
+}
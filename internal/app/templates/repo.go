@@ -0,0 +1,22 @@
+package templates
+
+import "context"
+
+type Reader interface {
+	// GetAdoptions returns the Tenant's roles adopting a template, ordered by role name.
+	GetAdoptions(ctx context.Context) ([]Adoption, error)
+}
+
+type Writer interface {
+	// SaveAdoption creates or replaces the adoption of the role, it is
+	// ErrNotFound when the Tenant has no such role.
+	SaveAdoption(ctx context.Context, adoption Adoption) error
+	// DeleteAdoption deletes the adoption of the role, it is ErrNotFound when
+	// the role adopts no template.
+	DeleteAdoption(ctx context.Context, role string) error
+}
+
+type ReaderWriter interface {
+	Reader
+	Writer
+}
@@ -0,0 +1,30 @@
+// Package templates keeps Tenants' standard roles in step with a shared
+// catalogue of role templates. A Tenant adopts a template as one of its roles,
+// which is then granted the template's permissions and any the Tenant extends
+// it with, and nothing else. Sync propagates changes to the catalogue to every
+// adopting role through an RBAC plan, reporting the difference. Detaching a
+// role leaves it as it is, to be managed by the Tenant alone.
+package templates
+
+import (
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+)
+
+type Service struct {
+	repo      ReaderWriter
+	rbac      *rbac.Service
+	catalogue Catalogue
+	now       func() time.Time
+}
+
+// NewService creates a template service for the catalogue, roles are changed through the RBAC service.
+func NewService(repo ReaderWriter, rbac *rbac.Service, catalogue Catalogue) *Service {
+	return &Service{
+		repo:      repo,
+		rbac:      rbac,
+		catalogue: catalogue,
+		now:       time.Now,
+	}
+}
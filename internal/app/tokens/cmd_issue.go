@@ -0,0 +1,98 @@
+package tokens
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+	"github.com/google/uuid"
+)
+
+// Issue returns a signed token holding the user's effective permissions on the
+// resources, or all resources when empty. Conditions are evaluated against the
+// attributes, those which cannot be are carried in the token.
+// The Tenant and user are read from the context.
+func (s *Service) Issue(ctx context.Context, resources []string, attrs condition.Attributes) (string, *permtoken.Claims, error) {
+	tenantID, ok := contextkey.TenantID(ctx)
+	if !ok {
+		return "", nil, fmt.Errorf("tenant ID not in context")
+	}
+	userID, ok := contextkey.UserID(ctx)
+	if !ok {
+		return "", nil, fmt.Errorf("user ID not in context")
+	}
+
+	forUser, err := s.permissions.GetForUser(ctx, resources)
+	if err != nil {
+		return "", nil, fmt.Errorf("get for user: %w", err)
+	}
+	tenantPermissions, err := s.repo.GetTenantPermissions(ctx, resources)
+	if err != nil {
+		return "", nil, fmt.Errorf("get tenant permissions: %w", err)
+	}
+
+	now := s.now()
+	claims := newClaims(forUser.Resolve(attrs), tenantPermissions)
+	claims.Issuer = s.issuer
+	claims.Subject = userID
+	claims.ID = uuid.NewString()
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = now.Add(s.ttl).Unix()
+	claims.TenantID = tenantID
+
+	token, err := permtoken.Sign(*claims, s.keys.SigningKey())
+	if err != nil {
+		return "", nil, fmt.Errorf("sign: %w", err)
+	}
+	return token, claims, nil
+}
+
+// JWKS returns the public keys tokens are verified with.
+func (s *Service) JWKS() permtoken.JWKS {
+	return s.keys.JWKS()
+}
+
+// newClaims are the user's roles, the concrete tenant permissions they hold,
+// and their resource grants. forUser must already be resolved, so the only
+// conditions left are those which could not be evaluated.
+func newClaims(forUser *permissions.ForUser, tenantPermissions permissions.TenantPermissions) *permtoken.Claims {
+	claims := &permtoken.Claims{
+		Roles:       forUser.Roles.StringSlice(),
+		Permissions: []string{},
+	}
+	for _, tp := range tenantPermissions {
+		p := permissions.Permission(tp)
+		if p.IsWildcard() {
+			continue
+		}
+		d := forUser.Decide(p.Name, nil)
+		switch d.Effect {
+		case permissions.EffectAllow:
+			claims.Permissions = append(claims.Permissions, p.Name)
+		case permissions.EffectConditional:
+			claims.ConditionalPermissions = append(claims.ConditionalPermissions, permtoken.ConditionalPermission{
+				Permission: p.Name,
+				Condition:  d.Condition,
+			})
+		}
+	}
+
+	for _, rg := range forUser.ResourceGrants {
+		// An inherited grant allows the same action on the resource as on its ancestor.
+		permission := rg.Permission.Name
+		if rg.InheritedFrom != nil {
+			permission = rg.Resource.Type + permissions.PermissionSeparator + rg.Permission.Action()
+		}
+		claims.ResourceGrants = append(claims.ResourceGrants, permtoken.ResourceGrant{
+			ResourceType: rg.Resource.Type,
+			ResourceID:   rg.Resource.ID,
+			Permission:   permission,
+			Condition:    rg.Permission.Condition,
+		})
+	}
+	return claims
+}
@@ -0,0 +1,104 @@
+package tokens_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/tokens"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepo is a clerk with a conditional extra permission and a grant inherited
+// by a customer's contact, the methods Issue does not use are left nil.
+type stubRepo struct {
+	permissions.ReaderWriter
+}
+
+var clerk = permissions.Role{Name: "clerk", ID: "1"}
+
+func (stubRepo) GetTenantPermissions(context.Context, []string) (permissions.TenantPermissions, error) {
+	return permissions.TenantPermissions{
+		{Name: "invoices:read"}, {Name: "invoices:approve"}, {Name: "invoices:export"}, {Name: "invoices:delete"}, {Name: "invoices:*"},
+	}, nil
+}
+
+func (stubRepo) GetTenantRoleMap(context.Context, []string) (permissions.TenantRoleMap, error) {
+	return permissions.TenantRoleMap{clerk: {Permissions: permissions.TenantPermissions{
+		{Name: "invoices:read"},
+		{Name: "invoices:approve", Condition: "invoice.amount < 10000"},
+	}}}, nil
+}
+
+func (stubRepo) GetUserPermissionsExtraAndRevoked(context.Context, []string) (permissions.UserExtraPermissions, permissions.UserRevokedPermissions, error) {
+	return permissions.UserExtraPermissions{{Name: "invoices:export", Condition: "request.hour < 17"}}, nil, nil
+}
+
+func (stubRepo) GetUserRoleAssignments(context.Context) (permissions.RoleAssignments, error) {
+	return permissions.RoleAssignments{{Role: clerk, Source: permissions.RoleSourceDirect}}, nil
+}
+
+func (stubRepo) GetUserResources(context.Context, []string) (permissions.Resources, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetUserResourceGrants(context.Context, []string) (permissions.ResourceGrants, error) {
+	return permissions.ResourceGrants{{
+		Resource:      permissions.Resource{ID: "k1", Type: "contacts"},
+		Permission:    permissions.Permission{Name: "customers:read"},
+		InheritedFrom: &permissions.Resource{ID: "c1", Type: "customers"},
+	}, {
+		Resource:   permissions.Resource{ID: "i1", Type: "invoices"},
+		Permission: permissions.Permission{Name: "invoices:*"},
+	}}, nil
+}
+
+func (stubRepo) GetSoDRules(context.Context) (permissions.SoDRules, error) {
+	return nil, nil
+}
+
+func (stubRepo) GetUserDelegations(context.Context) (permissions.Delegations, error) {
+	return nil, nil
+}
+
+func TestService_Issue(t *testing.T) {
+	key, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	svc := tokens.NewService(stubRepo{}, permtoken.KeySet{key}, "", 2*time.Hour)
+
+	ctx := context.WithValue(context.Background(), contextkey.CtxKeyTenantID, "test")
+	ctx = context.WithValue(ctx, contextkey.CtxKeyUserID, "652f4d18-dd3d-40c0-874e-cbe3566abccf")
+	token, issued, err := svc.Issue(ctx, nil, condition.Attributes{"request.hour": 10})
+	require.NoError(t, err)
+
+	claims, err := permtoken.NewVerifier(svc.JWKS(), tokens.DefaultIssuer, 0).Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, issued, claims)
+
+	assert.Equal(t, "test", claims.TenantID)
+	assert.Equal(t, "652f4d18-dd3d-40c0-874e-cbe3566abccf", claims.UserID())
+	assert.Equal(t, []string{"clerk"}, claims.Roles)
+	assert.Equal(t, []string{"invoices:read", "invoices:export"}, claims.Permissions)
+	assert.Equal(t, []permtoken.ConditionalPermission{{Permission: "invoices:approve", Condition: "invoice.amount < 10000"}}, claims.ConditionalPermissions)
+	assert.True(t, claims.AllowsOn("contacts:read", "contacts", "k1"))
+	assert.True(t, claims.AllowsOn("invoices:delete", "invoices", "i1"), "a wildcard grant on the resource allows any action")
+	assert.False(t, claims.AllowsOn("invoices:delete", "invoices", "i2"))
+
+	// The TTL is capped.
+	assert.Equal(t, tokens.MaxTTL, time.Duration(claims.ExpiresAt-claims.IssuedAt)*time.Second)
+}
+
+func TestService_IssueNeedsUser(t *testing.T) {
+	key, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	svc := tokens.NewService(stubRepo{}, permtoken.KeySet{key}, "", 0)
+
+	ctx := context.WithValue(context.Background(), contextkey.CtxKeyTenantID, "test")
+	_, _, err = svc.Issue(ctx, nil, nil)
+	assert.Error(t, err)
+}
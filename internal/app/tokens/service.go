@@ -0,0 +1,45 @@
+package tokens
+
+import (
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+)
+
+const (
+	// DefaultIssuer is the issuer of tokens when none is configured.
+	DefaultIssuer = "user-permissions-service"
+	// DefaultTTL is how long tokens last when no TTL is configured.
+	DefaultTTL = 5 * time.Minute
+	// MaxTTL is the longest a token may last, permission changes take up to this long to reach token holders.
+	MaxTTL = time.Hour
+)
+
+type Service struct {
+	repo        permissions.ReaderWriter
+	permissions *permissions.Service
+	keys        permtoken.KeySet
+	issuer      string
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// NewService creates a token service signing with keys. An empty issuer or zero
+// ttl take the defaults, and the ttl is capped at MaxTTL.
+func NewService(repo permissions.ReaderWriter, keys permtoken.KeySet, issuer string, ttl time.Duration) *Service {
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Service{
+		repo:        repo,
+		permissions: permissions.NewService(repo),
+		keys:        keys,
+		issuer:      issuer,
+		ttl:         min(ttl, MaxTTL),
+		now:         time.Now,
+	}
+}
@@ -0,0 +1,88 @@
+package validation
+
+import "sort"
+
+type Severity string
+
+const (
+	// SeverityError is a problem which changes, or breaks, the permissions users are given.
+	SeverityError Severity = "error"
+	// SeverityWarning is something which is likely a mistake, but is harmless to users.
+	SeverityWarning Severity = "warning"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether s is as, or more, severe than other.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+type Check string
+
+const (
+	// CheckRoleCycle is a cycle in the role hierarchy, e.g. a inherits b, b inherits a.
+	CheckRoleCycle Check = "role_cycle"
+	// CheckDuplicateRoleName is more than one role with the same name.
+	CheckDuplicateRoleName Check = "duplicate_role_name"
+	// CheckUnusedRole is a role no user holds, directly, through a group or through inheritance.
+	CheckUnusedRole Check = "unused_role"
+	// CheckUnusedPermission is a tenant permission which no role grants.
+	CheckUnusedPermission Check = "unused_permission"
+	// CheckInvalidCondition is a role's conditional grant whose condition cannot be parsed,
+	// the grant never applies.
+	CheckInvalidCondition Check = "invalid_condition"
+)
+
+// Finding is a single problem found in a Tenant's role graph.
+type Finding struct {
+	Check    Check
+	Severity Severity
+	Message  string
+	// Subjects are the names of the roles or permissions involved.
+	Subjects []string
+}
+
+type Findings []Finding
+
+// Report is the result of validating a Tenant's role graph.
+type Report struct {
+	Findings Findings
+}
+
+// Has reports whether the report has any finding at least as severe as the severity.
+func (r *Report) Has(severity Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(severity) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+// sort orders the findings by severity, most severe first, then by check and message.
+func (r *Report) sort() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity.rank() > b.Severity.rank()
+		}
+		if a.Check != b.Check {
+			return a.Check < b.Check
+		}
+		return a.Message < b.Message
+	})
+}
@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"golang.org/x/sync/errgroup"
+)
+
+type Service struct {
+	repo permissions.Reader
+}
+
+// NewService creates a new validation service
+func NewService(repo permissions.Reader) *Service {
+	return &Service{repo: repo}
+}
+
+// Validate loads the permission model of the Tenant in the context and validates it.
+func (s *Service) Validate(ctx context.Context) (*Report, error) {
+	var snapshot Snapshot
+
+	eg, ctxEg := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		var err error
+		snapshot.RoleMap, err = s.repo.GetTenantRoleMap(ctxEg, nil)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		snapshot.TenantPermissions, err = s.repo.GetTenantPermissions(ctxEg, nil)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		snapshot.RoleAssignments, err = s.repo.GetTenantRoleAssignments(ctxEg)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
+	}
+
+	return Validate(snapshot), nil
+}
@@ -0,0 +1,54 @@
+//go:build test
+// +build test
+
+package validation_test
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/adapters/secondary/postgres"
+	"github.com/Equineregister/user-permissions-service/internal/app/validation"
+	"github.com/Equineregister/user-permissions-service/internal/pkg/contextkey"
+	"github.com/Equineregister/user-permissions-service/pkg/migrations"
+	"github.com/Equineregister/user-permissions-service/pkg/testdatabase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tenantsDir = "migrations/tenants"
+
+// TestValidate_Tenants applies every Tenant's data population scripts to a new
+// database and fails if the resulting role graph has any errors.
+func TestValidate_Tenants(t *testing.T) {
+	entries, err := fs.ReadDir(postgres.Migrations, tenantsDir)
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		tenantID := entry.Name()
+
+		t.Run(tenantID, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), contextkey.CtxKeyTenantID, tenantID)
+
+			db, err := testdatabase.NewTestDatabase(ctx, postgres.Migrations, nil)
+			require.NoError(t, err)
+			defer db.TearDown()
+
+			err = migrations.LoadTenantData(ctx, db.DB, postgres.Migrations, tenantsDir+"/"+tenantID)
+			require.NoError(t, err)
+
+			tp := postgres.NewTenantPoolFromSuppliedPool(ctx, tenantID, db.DB)
+			report, err := validation.NewService(postgres.NewPermissionsRepoWithTenantPool(tp)).Validate(ctx)
+			require.NoError(t, err)
+
+			for _, f := range report.Findings {
+				t.Logf("%s %s: %s", f.Severity, f.Check, f.Message)
+			}
+			assert.False(t, report.Has(validation.SeverityError), "tenant %s has validation errors", tenantID)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+)
+
+// Snapshot is the part of a Tenant's permission model that is validated.
+type Snapshot struct {
+	RoleMap           permissions.TenantRoleMap
+	TenantPermissions permissions.TenantPermissions
+	// RoleAssignments are the roles assigned to each user, directly or through a group, keyed by user ID.
+	RoleAssignments permissions.TenantRoleAssignments
+}
+
+// Validate checks the snapshot for every class of problem, returning the findings most severe first.
+func Validate(s Snapshot) *Report {
+	r := &Report{}
+
+	checkRoleCycles(r, s.RoleMap)
+	checkDuplicateRoleNames(r, s.RoleMap)
+	checkUnusedRoles(r, s.RoleMap, s.RoleAssignments)
+	checkUnusedPermissions(r, s.RoleMap, s.TenantPermissions)
+	checkConditions(r, s.RoleMap)
+
+	r.sort()
+	return r
+}
+
+// sortedRoles returns the roles in the map in a stable order, so findings are reproducible.
+func sortedRoles(roleMap permissions.TenantRoleMap) permissions.Roles {
+	roles := make(permissions.Roles, 0, len(roleMap))
+	for role := range roleMap {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].Name != roles[j].Name {
+			return roles[i].Name < roles[j].Name
+		}
+		return roles[i].ID < roles[j].ID
+	})
+	return roles
+}
+
+func checkRoleCycles(r *Report, roleMap permissions.TenantRoleMap) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+
+	var path permissions.Roles
+	var visit func(role permissions.Role)
+	visit = func(role permissions.Role) {
+		state[role.ID] = visiting
+		path = append(path, role)
+
+		for _, child := range roleMap[role].Inherits {
+			switch state[child.ID] {
+			case unvisited:
+				visit(child)
+			case visiting:
+				// The path from the child back to itself is a cycle.
+				var cycle []string
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i].ID == child.ID {
+						cycle = append(path[i:].StringSlice(), child.Name)
+						break
+					}
+				}
+				r.add(Finding{
+					Check:    CheckRoleCycle,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("role hierarchy cycle: %s", strings.Join(cycle, " -> ")),
+					Subjects: cycle,
+				})
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[role.ID] = visited
+	}
+
+	for _, role := range sortedRoles(roleMap) {
+		if state[role.ID] == unvisited {
+			visit(role)
+		}
+	}
+}
+
+func checkDuplicateRoleNames(r *Report, roleMap permissions.TenantRoleMap) {
+	ids := make(map[string][]string)
+	for _, role := range sortedRoles(roleMap) {
+		ids[role.Name] = append(ids[role.Name], role.ID)
+	}
+	for _, role := range sortedRoles(roleMap) {
+		roleIDs := ids[role.Name]
+		if len(roleIDs) < 2 || roleIDs[0] != role.ID {
+			continue
+		}
+		r.add(Finding{
+			Check:    CheckDuplicateRoleName,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("role name %q is used by %d roles: %s", role.Name, len(roleIDs), strings.Join(roleIDs, ", ")),
+			Subjects: []string{role.Name},
+		})
+	}
+}
+
+func checkUnusedRoles(r *Report, roleMap permissions.TenantRoleMap, assignments permissions.TenantRoleAssignments) {
+	held := make(map[string]bool)
+	var hold func(role permissions.Role)
+	hold = func(role permissions.Role) {
+		if held[role.ID] {
+			return
+		}
+		held[role.ID] = true
+		for _, child := range roleMap[role].Inherits {
+			hold(child)
+		}
+	}
+	for _, ras := range assignments {
+		for _, ra := range ras {
+			hold(ra.Role)
+		}
+	}
+
+	for _, role := range sortedRoles(roleMap) {
+		if held[role.ID] {
+			continue
+		}
+		r.add(Finding{
+			Check:    CheckUnusedRole,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("role %q is not held by any user, group or inheriting role", role.Name),
+			Subjects: []string{role.Name},
+		})
+	}
+}
+
+func checkUnusedPermissions(r *Report, roleMap permissions.TenantRoleMap, tenantPermissions permissions.TenantPermissions) {
+	var grants []permissions.Permission
+	for _, mapped := range roleMap {
+		for _, tp := range mapped.Permissions {
+			grants = append(grants, permissions.Permission(tp))
+		}
+	}
+
+	for _, tp := range tenantPermissions {
+		granted := false
+		for _, grant := range grants {
+			if grant.Matches(tp.Name) {
+				granted = true
+				break
+			}
+		}
+		if granted {
+			continue
+		}
+		r.add(Finding{
+			Check:    CheckUnusedPermission,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("permission %q is not granted to any role", tp.Name),
+			Subjects: []string{tp.Name},
+		})
+	}
+}
+
+func checkConditions(r *Report, roleMap permissions.TenantRoleMap) {
+	for _, role := range sortedRoles(roleMap) {
+		for _, tp := range roleMap[role].Permissions {
+			if tp.Condition == "" {
+				continue
+			}
+			if err := condition.Validate(tp.Condition); err != nil {
+				r.add(Finding{
+					Check:    CheckInvalidCondition,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("role %q grants %q on an invalid condition: %v", role.Name, tp.Name, err),
+					Subjects: []string{role.Name, tp.Name},
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/permissions"
+	"github.com/Equineregister/user-permissions-service/internal/app/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	var (
+		manager   = permissions.Role{Name: "manager", ID: "1"}
+		clerk     = permissions.Role{Name: "clerk", ID: "2"}
+		auditor   = permissions.Role{Name: "auditor", ID: "3"}
+		clerkCopy = permissions.Role{Name: "clerk", ID: "4"}
+		unused    = permissions.Role{Name: "unused", ID: "5"}
+	)
+
+	snapshot := validation.Snapshot{
+		RoleMap: permissions.TenantRoleMap{
+			manager: {
+				Permissions: permissions.TenantPermissions{{Name: "invoices:delete"}},
+				Inherits:    permissions.Roles{clerk},
+			},
+			clerk: {
+				Permissions: permissions.TenantPermissions{{Name: "invoices:create", Condition: "invoice.amount <"}},
+				Inherits:    permissions.Roles{auditor},
+			},
+			auditor: {
+				Permissions: permissions.TenantPermissions{{Name: "*:read"}},
+				Inherits:    permissions.Roles{manager},
+			},
+			clerkCopy: {},
+			unused:    {},
+		},
+		TenantPermissions: permissions.TenantPermissions{
+			{Name: "invoices:create"},
+			{Name: "invoices:delete"},
+			{Name: "invoices:read"},
+			{Name: "products:disable"},
+		},
+		RoleAssignments: permissions.TenantRoleAssignments{
+			"user-1": {{Role: manager, Source: permissions.RoleSourceDirect}},
+			"user-2": {{Role: clerkCopy, Source: permissions.RoleSourceGroup, Via: "clerks"}},
+		},
+	}
+
+	report := validation.Validate(snapshot)
+
+	assert.Equal(t, validation.Findings{
+		{
+			Check:    validation.CheckDuplicateRoleName,
+			Severity: validation.SeverityError,
+			Message:  `role name "clerk" is used by 2 roles: 2, 4`,
+			Subjects: []string{"clerk"},
+		},
+		{
+			Check:    validation.CheckInvalidCondition,
+			Severity: validation.SeverityError,
+			Message:  `role "clerk" grants "invoices:create" on an invalid condition: unexpected end of condition at offset 16`,
+			Subjects: []string{"clerk", "invoices:create"},
+		},
+		{
+			Check:    validation.CheckRoleCycle,
+			Severity: validation.SeverityError,
+			Message:  "role hierarchy cycle: auditor -> manager -> clerk -> auditor",
+			Subjects: []string{"auditor", "manager", "clerk", "auditor"},
+		},
+		{
+			Check:    validation.CheckUnusedPermission,
+			Severity: validation.SeverityWarning,
+			Message:  `permission "products:disable" is not granted to any role`,
+			Subjects: []string{"products:disable"},
+		},
+		{
+			Check:    validation.CheckUnusedRole,
+			Severity: validation.SeverityWarning,
+			Message:  `role "unused" is not held by any user, group or inheriting role`,
+			Subjects: []string{"unused"},
+		},
+	}, report.Findings)
+	assert.True(t, report.Has(validation.SeverityError))
+}
+
+func TestValidate_Clean(t *testing.T) {
+	admin := permissions.Role{Name: "admin", ID: "1"}
+
+	report := validation.Validate(validation.Snapshot{
+		RoleMap: permissions.TenantRoleMap{
+			admin: {Permissions: permissions.TenantPermissions{{Name: "*:*"}}},
+		},
+		TenantPermissions: permissions.TenantPermissions{{Name: "invoices:create"}, {Name: "*:*"}},
+		RoleAssignments: permissions.TenantRoleAssignments{
+			"user-1": {{Role: admin, Source: permissions.RoleSourceDirect}},
+		},
+	})
+
+	assert.Empty(t, report.Findings)
+	assert.False(t, report.Has(validation.SeverityWarning))
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// EventConfig configures the Lambda's serving of API Gateway and Function URL events.
+type EventConfig struct {
+	TenantClaim    string
+	UserClaim      string
+	AllowedOrigins []string
+}
+
+// LoadEventConfig reads the event configuration from the env, each is optional:
+//
+//	AUTH_TENANT_CLAIM     the authorizer claim holding the Tenant ID
+//	AUTH_USER_CLAIM       the authorizer claim holding the user ID
+//	CORS_ALLOWED_ORIGINS  comma separated origins browsers may call from, or "*"
+func LoadEventConfig() EventConfig {
+	var origins []string
+	for _, o := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return EventConfig{
+		TenantClaim:    os.Getenv("AUTH_TENANT_CLAIM"),
+		UserClaim:      os.Getenv("AUTH_USER_CLAIM"),
+		AllowedOrigins: origins,
+	}
+}
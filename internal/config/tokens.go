@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+)
+
+// TokenConfig configures the issuing of signed permission tokens.
+type TokenConfig struct {
+	Keys   permtoken.KeySet
+	Issuer string
+	TTL    time.Duration
+}
+
+// LoadTokenConfig reads the token configuration from the env:
+//
+//	PERMTOKEN_KEYS         the signing keys, a permtoken.KeySet as JSON
+//	PERMTOKEN_ISSUER       the issuer of tokens, optional
+//	PERMTOKEN_TTL_SECONDS  how long tokens last, optional
+//
+// It returns nil when PERMTOKEN_KEYS is not set, tokens are then not issued.
+func LoadTokenConfig() (*TokenConfig, error) {
+	raw := os.Getenv("PERMTOKEN_KEYS")
+	if raw == "" {
+		return nil, nil
+	}
+	keys, err := permtoken.ParseKeySet([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("PERMTOKEN_KEYS: %w", err)
+	}
+	return &TokenConfig{
+		Keys:   keys,
+		Issuer: os.Getenv("PERMTOKEN_ISSUER"),
+		TTL:    time.Duration(getEnvAsIntOrDefault("PERMTOKEN_TTL_SECONDS", 0)) * time.Second,
+	}, nil
+}
@@ -7,6 +7,7 @@ type CtxKey int
 const (
 	CtxKeyUserID CtxKey = iota
 	CtxKeyTenantID
+	CtxKeyActiveRoles
 )
 
 func TenantID(ctx context.Context) (string, bool) {
@@ -24,3 +25,25 @@ func UserID(ctx context.Context) (string, bool) {
 	}
 	return "", false
 }
+
+// WithTenantID returns a copy of ctx holding the Tenant ID.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, CtxKeyTenantID, tenantID)
+}
+
+// WithUserID returns a copy of ctx holding the user ID.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, CtxKeyUserID, userID)
+}
+
+// ActiveRoles returns the names of the roles active in the request, ok is false when none were named.
+func ActiveRoles(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(CtxKeyActiveRoles).([]string)
+	return roles, ok && len(roles) > 0
+}
+
+// WithActiveRoles returns a copy of ctx naming the roles active in the request,
+// the user's permissions are those of these roles alone.
+func WithActiveRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, CtxKeyActiveRoles, roles)
+}
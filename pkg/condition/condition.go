@@ -0,0 +1,84 @@
+// Package condition implements the expression language used for conditions on grants.
+//
+// A condition is evaluated against the attributes supplied with a request, e.g.
+//
+//	request.hour >= 9 && request.hour < 17 && user.region in ["north", "south"]
+//
+// The language only has literals (numbers, strings, true and false, and lists),
+// attribute references, comparisons (==, !=, <, <=, >, >= and in), the boolean
+// operators &&, || and ! and parentheses. There are no function calls, loops or
+// assignments, so evaluating a condition always terminates and has no side effects.
+package condition
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxLength is the maximum length of a condition in bytes.
+	MaxLength = 1024
+	// maxDepth is the maximum nesting of a condition, it bounds the recursion of the parser.
+	maxDepth = 32
+)
+
+// ErrMissingAttribute is returned by Eval when a condition references an attribute
+// which is not supplied, the condition can be neither satisfied nor refuted.
+var ErrMissingAttribute = errors.New("missing attribute")
+
+// Attributes are the values conditions are evaluated against. Nested maps are
+// referenced with dotted names, so "invoice.amount" is the "amount" of the "invoice"
+// map, unless "invoice.amount" is itself a key. Values are numbers, strings, bools,
+// lists of those, or nested maps, as decoded from JSON.
+type Attributes map[string]any
+
+// Expr is a parsed condition.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses the condition.
+func Parse(src string) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("condition is longer than %d bytes", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Validate reports whether the condition can be parsed.
+func Validate(src string) error {
+	_, err := Parse(src)
+	return err
+}
+
+// String returns the condition as it was written.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the condition against the attributes. The error wraps
+// ErrMissingAttribute when a referenced attribute is not supplied.
+func (e *Expr) Eval(attrs Attributes) (bool, error) {
+	v, err := e.root.eval(attrs)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition is a %s, not a bool", typeName(v))
+	}
+	return b, nil
+}
@@ -0,0 +1,93 @@
+package condition_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/pkg/condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr_Eval(t *testing.T) {
+	attrs := condition.Attributes{
+		"request":        map[string]any{"hour": float64(10)},
+		"user":           map[string]any{"region": "north", "teams": []any{"sales", "support"}},
+		"invoice.amount": 2500,
+		"approved":       true,
+	}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{src: `request.hour >= 9 && request.hour < 17`, want: true},
+		{src: `request.hour >= 12`, want: false},
+		{src: `user.region == "north"`, want: true},
+		{src: `user.region != 'north'`, want: false},
+		{src: `user.region in ["north", "south"]`, want: true},
+		{src: `"support" in user.teams`, want: true},
+		{src: `invoice.amount <= 10000 && approved`, want: true},
+		{src: `!approved || invoice.amount < 1000`, want: false},
+		{src: `!(invoice.amount > -1)`, want: false},
+		{src: `user.region == 1`, want: false},
+		{src: `"09:00" < "17:30"`, want: true},
+		// The missing attribute does not matter when the other side is decisive.
+		{src: `approved || missing == 1`, want: true},
+		{src: `missing == 1 && !approved`, want: false},
+	}
+	for _, tt := range tests {
+		expr, err := condition.Parse(tt.src)
+		require.NoError(t, err, tt.src)
+		got, err := expr.Eval(attrs)
+		require.NoError(t, err, tt.src)
+		assert.Equal(t, tt.want, got, tt.src)
+		assert.Equal(t, tt.src, expr.String())
+	}
+}
+
+func TestExpr_Eval_Errors(t *testing.T) {
+	attrs := condition.Attributes{"hour": 10, "region": "north"}
+
+	for _, src := range []string{`hour > 9 && region == missing`, `other.hour < 1`, `!missing`} {
+		expr, err := condition.Parse(src)
+		require.NoError(t, err, src)
+		_, err = expr.Eval(attrs)
+		assert.ErrorIs(t, err, condition.ErrMissingAttribute, src)
+	}
+
+	for _, src := range []string{`hour`, `hour < "10"`, `region in "north"`, `!region`, `true < false`} {
+		expr, err := condition.Parse(src)
+		require.NoError(t, err, src)
+		_, err = expr.Eval(attrs)
+		require.Error(t, err, src)
+		assert.NotErrorIs(t, err, condition.ErrMissingAttribute, src)
+	}
+
+	expr, err := condition.Parse(`hour < "10"`)
+	require.NoError(t, err)
+	_, err = expr.Eval(attrs)
+	assert.EqualError(t, err, "cannot compare a number with a string", "the error names the right operand's type")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`hour >`,
+		`hour > 9 &&`,
+		`(hour > 9`,
+		`hour > 9)`,
+		`hour = 9`,
+		`region in ["north"`,
+		`region == "north`,
+		`user..region == "north"`,
+		`user. == 1`,
+		`len(region) > 1`,
+		`hour > 9 hour < 17`,
+		`1.2.3 == 1`,
+		strings.Repeat("(", 40) + "true" + strings.Repeat(")", 40),
+		strings.Repeat("!", 40) + "true",
+		strings.Repeat("a", condition.MaxLength+1),
+	} {
+		assert.Error(t, condition.Validate(src), src)
+	}
+}
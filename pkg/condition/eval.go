@@ -0,0 +1,296 @@
+package condition
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type node interface {
+	eval(attrs Attributes) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(Attributes) (any, error) {
+	return n.value, nil
+}
+
+type attrNode struct {
+	name string
+}
+
+func (n attrNode) eval(attrs Attributes) (any, error) {
+	v, ok := lookup(attrs, n.name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAttribute, n.name)
+	}
+	v, err := normalise(v)
+	if err != nil {
+		return nil, fmt.Errorf("attribute %s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n listNode) eval(attrs Attributes) (any, error) {
+	list := make([]any, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(attrs)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(attrs Attributes) (any, error) {
+	b, err := evalBool(n.operand, attrs)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+// andNode is false when either side is false, even when the other side
+// references a missing attribute.
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(attrs Attributes) (any, error) {
+	return evalLogical(n.left, n.right, attrs, false)
+}
+
+// orNode is true when either side is true, even when the other side
+// references a missing attribute.
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(attrs Attributes) (any, error) {
+	return evalLogical(n.left, n.right, attrs, true)
+}
+
+// evalLogical evaluates both sides, returning decisive if either side is decisive,
+// otherwise the first error or !decisive.
+func evalLogical(left, right node, attrs Attributes, decisive bool) (any, error) {
+	l, lerr := evalBool(left, attrs)
+	if lerr == nil && l == decisive {
+		return decisive, nil
+	}
+	if lerr != nil && !errors.Is(lerr, ErrMissingAttribute) {
+		return nil, lerr
+	}
+	r, rerr := evalBool(right, attrs)
+	if rerr == nil && r == decisive {
+		return decisive, nil
+	}
+	if lerr != nil {
+		return nil, lerr
+	}
+	if rerr != nil {
+		return nil, rerr
+	}
+	return !decisive, nil
+}
+
+func evalBool(n node, attrs Attributes) (bool, error) {
+	v, err := n.eval(attrs)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool, found a %s", typeName(v))
+	}
+	return b, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(attrs Attributes) (any, error) {
+	l, err := n.left.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		list, ok := r.([]any)
+		if !ok {
+			return nil, fmt.Errorf("in expects a list, found a %s", typeName(r))
+		}
+		for _, item := range list {
+			if equal(l, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	var c int
+	switch l := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare a number with a %s", typeName(r))
+		}
+		switch {
+		case l < rv:
+			c = -1
+		case l > rv:
+			c = 1
+		}
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare a string with a %s", typeName(r))
+		}
+		c = strings.Compare(l, rv)
+	default:
+		return nil, fmt.Errorf("cannot order a %s", typeName(l))
+	}
+
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// equal reports whether two normalised values are equal, values of different types are never equal.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		return false
+	}
+	if _, ok := b.([]any); ok {
+		return false
+	}
+	if _, ok := b.(map[string]any); ok {
+		return false
+	}
+	return a == b
+}
+
+// lookup finds the named attribute, either as a key or by following the dotted
+// name through nested maps.
+func lookup(attrs Attributes, name string) (any, bool) {
+	if v, ok := attrs[name]; ok {
+		return v, true
+	}
+	head, rest, found := strings.Cut(name, ".")
+	if !found {
+		return nil, false
+	}
+	switch nested := attrs[head].(type) {
+	case map[string]any:
+		return lookup(nested, rest)
+	case Attributes:
+		return lookup(nested, rest)
+	}
+	return nil, false
+}
+
+// normalise converts the value to one of the types conditions are evaluated with:
+// float64, string, bool, []any or map[string]any.
+func normalise(v any) (any, error) {
+	switch v := v.(type) {
+	case float64, string, bool, map[string]any:
+		return v, nil
+	case Attributes:
+		return map[string]any(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case []string:
+		list := make([]any, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list, nil
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			n, err := normalise(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = n
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,139 @@
+package condition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of condition"
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators are matched longest first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!"}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenLBracket, text: "[", pos: i})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenRBracket, text: "]", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			t, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i += n
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			num, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", src[i:j], i)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:j], num: num, pos: i})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && (isIdentPart(src[j]) || src[j] == '.') {
+				j++
+			}
+			name := src[i:j]
+			if strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+				return nil, fmt.Errorf("invalid attribute %q at offset %d", name, i)
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: name, pos: i})
+			i = j
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// lexString lexes a single or double quoted string starting at src[start],
+// returning the token and the number of bytes consumed. A backslash escapes the
+// following character.
+func lexString(src string, start int) (token, int, error) {
+	quote := src[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if i+1 == len(src) {
+				return token{}, 0, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			sb.WriteByte(src[i])
+		case quote:
+			return token{kind: tokenString, text: sb.String(), pos: start}, i + 1 - start, nil
+		default:
+			sb.WriteByte(src[i])
+		}
+	}
+	return token{}, 0, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,168 @@
+package condition
+
+import "fmt"
+
+// The grammar, from lowest to highest precedence:
+//
+//	or      = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | compare
+//	compare = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" ) operand ]
+//	operand = number | string | "true" | "false" | attribute | list | "(" or ")"
+//	list    = "[" [ operand { "," operand } ] "]"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokenOp && t.text == op
+}
+
+func (p *parser) parseOr(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("condition is nested more than %d deep", maxDepth)
+	}
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot(depth int) (node, error) {
+	if p.isOp("!") {
+		p.next()
+		if depth+1 > maxDepth {
+			return nil, fmt.Errorf("condition is nested more than %d deep", maxDepth)
+		}
+		operand, err := p.parseNot(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseCompare(depth)
+}
+
+func (p *parser) parseCompare(depth int) (node, error) {
+	left, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	switch {
+	case t.kind == tokenOp && isComparison(t.text):
+	case t.kind == tokenIdent && t.text == "in":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{op: t.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand(depth int) (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return literalNode{value: t.num}, nil
+	case tokenString:
+		return literalNode{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+		}
+		return attrNode{name: t.text}, nil
+	case tokenLParen:
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected \")\" at offset %d, found %s", closing.pos, closing)
+		}
+		return inner, nil
+	case tokenLBracket:
+		return p.parseList(depth + 1)
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
+
+func (p *parser) parseList(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("condition is nested more than %d deep", maxDepth)
+	}
+	var items []node
+	if p.peek().kind == tokenRBracket {
+		p.next()
+		return listNode{items: items}, nil
+	}
+	for {
+		item, err := p.parseOperand(depth)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		switch t := p.next(); t.kind {
+		case tokenComma:
+		case tokenRBracket:
+			return listNode{items: items}, nil
+		default:
+			return nil, fmt.Errorf("expected \",\" or \"]\" at offset %d, found %s", t.pos, t)
+		}
+	}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+// Package manifest is the file format of the manifest a consuming service
+// publishes of its resource types and actions, as read by "userperms register".
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/manifests"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the only manifest version currently understood.
+const Version = 1
+
+type Document struct {
+	Version       int            `json:"version" yaml:"version"`
+	Service       string         `json:"service" yaml:"service"`
+	ResourceTypes []ResourceType `json:"resourceTypes" yaml:"resourceTypes"`
+}
+
+type ResourceType struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Actions     []Action `json:"actions" yaml:"actions"`
+}
+
+type Action struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// DefaultRoles are granted the action's permission when it is first registered.
+	DefaultRoles []string `json:"defaultRoles,omitempty" yaml:"defaultRoles,omitempty"`
+}
+
+// Decode reads a manifest, rejecting unknown fields and versions. The formats
+// are those of rbacdoc, see rbacdoc.FormatFromPath.
+func Decode(r io.Reader, format rbacdoc.Format) (*Document, error) {
+	var doc Document
+	switch format {
+	case rbacdoc.FormatYAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+	case rbacdoc.FormatJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", doc.Version, Version)
+	}
+	return &doc, nil
+}
+
+// Manifest returns the manifest the document describes.
+func (d *Document) Manifest() *manifests.Manifest {
+	m := &manifests.Manifest{Service: d.Service}
+	for _, rt := range d.ResourceTypes {
+		mrt := manifests.ResourceType{Name: rt.Name, Description: rt.Description}
+		for _, a := range rt.Actions {
+			mrt.Actions = append(mrt.Actions, manifests.Action{Name: a.Name, Description: a.Description, DefaultRoles: a.DefaultRoles})
+		}
+		m.ResourceTypes = append(m.ResourceTypes, mrt)
+	}
+	return m
+}
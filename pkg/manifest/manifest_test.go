@@ -0,0 +1,60 @@
+package manifest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/manifests"
+	"github.com/Equineregister/user-permissions-service/pkg/manifest"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifestYAML = `version: 1
+service: invoicing
+resourceTypes:
+  - name: invoices
+    description: Bills sent to customers.
+    actions:
+      - name: create
+        description: Create an invoice.
+        defaultRoles: [sales person]
+      - name: read
+`
+
+func TestDecode(t *testing.T) {
+	doc, err := manifest.Decode(strings.NewReader(manifestYAML), rbacdoc.FormatYAML)
+	require.NoError(t, err)
+
+	assert.Equal(t, &manifests.Manifest{
+		Service: "invoicing",
+		ResourceTypes: []manifests.ResourceType{{
+			Name:        "invoices",
+			Description: "Bills sent to customers.",
+			Actions: []manifests.Action{
+				{Name: "create", Description: "Create an invoice.", DefaultRoles: []string{"sales person"}},
+				{Name: "read"},
+			},
+		}},
+	}, doc.Manifest())
+}
+
+func TestDecode_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format rbacdoc.Format
+		want   string
+	}{
+		{name: "unknown yaml field", input: "version: 1\nservice: a\nroles: []\n", format: rbacdoc.FormatYAML, want: "field roles not found"},
+		{name: "unknown json field", input: `{"version": 1, "service": "a", "roles": []}`, format: rbacdoc.FormatJSON, want: `unknown field "roles"`},
+		{name: "unknown version", input: "version: 2\nservice: a\n", format: rbacdoc.FormatYAML, want: "unsupported version 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manifest.Decode(strings.NewReader(tt.input), tt.format)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
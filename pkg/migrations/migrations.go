@@ -29,9 +29,8 @@ const insertMigration = `
 `
 
 const (
-	migrationsDir      = "migrations"
-	tenantsDir         = "migrations/tenants/test"
-	testTenantFilename = "0001-foundation.sql"
+	migrationsDir = "migrations"
+	tenantsDir    = "migrations/tenants/test"
 )
 
 func Migrate(ctx context.Context, db *pgxpool.Pool, fsys fs.FS) error {
@@ -133,6 +132,11 @@ func filterFiles(files []fs.DirEntry) []fs.DirEntry {
 }
 
 func LoadTestTenantData(ctx context.Context, db *pgxpool.Pool, fsys fs.FS) error {
+	return LoadTenantData(ctx, db, fsys, tenantsDir)
+}
+
+// LoadTenantData applies every data population script in the Tenant's directory, in order.
+func LoadTenantData(ctx context.Context, db *pgxpool.Pool, fsys fs.FS, dir string) error {
 	tx, err := db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
@@ -144,18 +148,26 @@ func LoadTestTenantData(ctx context.Context, db *pgxpool.Pool, fsys fs.FS) error
 		}
 	}()
 
-	bytes, err := fs.ReadFile(fsys, fmt.Sprintf("%s/%s", tenantsDir, testTenantFilename))
+	files, err := fs.ReadDir(fsys, dir)
 	if err != nil {
-		return fmt.Errorf("read tenants file (%s): %w", testTenantFilename, err)
-	}
-	query := string(bytes)
-	if len(strings.TrimSpace(query)) == 0 {
-		return fmt.Errorf("empty query in file: %s", testTenantFilename)
+		return fmt.Errorf("read tenants dir (%s): %w", dir, err)
 	}
 
-	_, err = tx.Exec(ctx, query)
-	if err != nil {
-		return fmt.Errorf("exec query for file: %s caused: %w", testTenantFilename, err)
+	// Data population scripts are applied in order, as they are for a real Tenant.
+	for _, file := range filterFiles(files) {
+		bytes, err := fs.ReadFile(fsys, fmt.Sprintf("%s/%s", dir, file.Name()))
+		if err != nil {
+			return fmt.Errorf("read tenants file (%s): %w", file.Name(), err)
+		}
+		query := string(bytes)
+		if len(strings.TrimSpace(query)) == 0 {
+			return fmt.Errorf("empty query in file: %s", file.Name())
+		}
+
+		_, err = tx.Exec(ctx, query)
+		if err != nil {
+			return fmt.Errorf("exec query for file: %s caused: %w", file.Name(), err)
+		}
 	}
 
 	err = tx.Commit(ctx)
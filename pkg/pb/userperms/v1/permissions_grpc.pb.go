@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: userperms/v1/permissions.proto
+
+package userpermsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PermissionsService_GetForUser_FullMethodName      = "/userperms.v1.PermissionsService/GetForUser"
+	PermissionsService_Check_FullMethodName           = "/userperms.v1.PermissionsService/Check"
+	PermissionsService_BatchCheck_FullMethodName      = "/userperms.v1.PermissionsService/BatchCheck"
+	PermissionsService_ListPermissions_FullMethodName = "/userperms.v1.PermissionsService/ListPermissions"
+	PermissionsService_ListRoles_FullMethodName       = "/userperms.v1.PermissionsService/ListRoles"
+)
+
+// PermissionsServiceClient is the client API for PermissionsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PermissionsService looks up and checks a user's permissions in a Tenant.
+//
+// Every call identifies the Tenant and user with the "x-tenant-id" and
+// "x-user-id" metadata. Calls without a deadline are given a default one.
+type PermissionsServiceClient interface {
+	// GetForUser returns the user's roles, grants and the Tenant's role graph,
+	// the same as the Lambda's response.
+	GetForUser(ctx context.Context, in *GetForUserRequest, opts ...grpc.CallOption) (*GetForUserResponse, error)
+	// Check decides whether the user holds a permission, optionally on a single resource.
+	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// BatchCheck decides several permissions at once, against the same attributes.
+	BatchCheck(ctx context.Context, in *BatchCheckRequest, opts ...grpc.CallOption) (*BatchCheckResponse, error)
+	// ListPermissions lists the concrete permissions the user holds, unconditionally or conditionally.
+	ListPermissions(ctx context.Context, in *ListPermissionsRequest, opts ...grpc.CallOption) (*ListPermissionsResponse, error)
+	// ListRoles lists the roles the user holds and where each came from.
+	ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error)
+}
+
+type permissionsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPermissionsServiceClient(cc grpc.ClientConnInterface) PermissionsServiceClient {
+	return &permissionsServiceClient{cc}
+}
+
+func (c *permissionsServiceClient) GetForUser(ctx context.Context, in *GetForUserRequest, opts ...grpc.CallOption) (*GetForUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetForUserResponse)
+	err := c.cc.Invoke(ctx, PermissionsService_GetForUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionsServiceClient) Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, PermissionsService_Check_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionsServiceClient) BatchCheck(ctx context.Context, in *BatchCheckRequest, opts ...grpc.CallOption) (*BatchCheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCheckResponse)
+	err := c.cc.Invoke(ctx, PermissionsService_BatchCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionsServiceClient) ListPermissions(ctx context.Context, in *ListPermissionsRequest, opts ...grpc.CallOption) (*ListPermissionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPermissionsResponse)
+	err := c.cc.Invoke(ctx, PermissionsService_ListPermissions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionsServiceClient) ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRolesResponse)
+	err := c.cc.Invoke(ctx, PermissionsService_ListRoles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PermissionsServiceServer is the server API for PermissionsService service.
+// All implementations must embed UnimplementedPermissionsServiceServer
+// for forward compatibility.
+//
+// PermissionsService looks up and checks a user's permissions in a Tenant.
+//
+// Every call identifies the Tenant and user with the "x-tenant-id" and
+// "x-user-id" metadata. Calls without a deadline are given a default one.
+type PermissionsServiceServer interface {
+	// GetForUser returns the user's roles, grants and the Tenant's role graph,
+	// the same as the Lambda's response.
+	GetForUser(context.Context, *GetForUserRequest) (*GetForUserResponse, error)
+	// Check decides whether the user holds a permission, optionally on a single resource.
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	// BatchCheck decides several permissions at once, against the same attributes.
+	BatchCheck(context.Context, *BatchCheckRequest) (*BatchCheckResponse, error)
+	// ListPermissions lists the concrete permissions the user holds, unconditionally or conditionally.
+	ListPermissions(context.Context, *ListPermissionsRequest) (*ListPermissionsResponse, error)
+	// ListRoles lists the roles the user holds and where each came from.
+	ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error)
+	mustEmbedUnimplementedPermissionsServiceServer()
+}
+
+// UnimplementedPermissionsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPermissionsServiceServer struct{}
+
+func (UnimplementedPermissionsServiceServer) GetForUser(context.Context, *GetForUserRequest) (*GetForUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForUser not implemented")
+}
+func (UnimplementedPermissionsServiceServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedPermissionsServiceServer) BatchCheck(context.Context, *BatchCheckRequest) (*BatchCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCheck not implemented")
+}
+func (UnimplementedPermissionsServiceServer) ListPermissions(context.Context, *ListPermissionsRequest) (*ListPermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPermissions not implemented")
+}
+func (UnimplementedPermissionsServiceServer) ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRoles not implemented")
+}
+func (UnimplementedPermissionsServiceServer) mustEmbedUnimplementedPermissionsServiceServer() {}
+func (UnimplementedPermissionsServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafePermissionsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PermissionsServiceServer will
+// result in compilation errors.
+type UnsafePermissionsServiceServer interface {
+	mustEmbedUnimplementedPermissionsServiceServer()
+}
+
+func RegisterPermissionsServiceServer(s grpc.ServiceRegistrar, srv PermissionsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPermissionsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PermissionsService_ServiceDesc, srv)
+}
+
+func _PermissionsService_GetForUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetForUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServiceServer).GetForUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PermissionsService_GetForUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServiceServer).GetForUser(ctx, req.(*GetForUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PermissionsService_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServiceServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PermissionsService_Check_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServiceServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PermissionsService_BatchCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServiceServer).BatchCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PermissionsService_BatchCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServiceServer).BatchCheck(ctx, req.(*BatchCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PermissionsService_ListPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServiceServer).ListPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PermissionsService_ListPermissions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServiceServer).ListPermissions(ctx, req.(*ListPermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PermissionsService_ListRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRolesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServiceServer).ListRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PermissionsService_ListRoles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServiceServer).ListRoles(ctx, req.(*ListRolesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PermissionsService_ServiceDesc is the grpc.ServiceDesc for PermissionsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PermissionsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "userperms.v1.PermissionsService",
+	HandlerType: (*PermissionsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetForUser",
+			Handler:    _PermissionsService_GetForUser_Handler,
+		},
+		{
+			MethodName: "Check",
+			Handler:    _PermissionsService_Check_Handler,
+		},
+		{
+			MethodName: "BatchCheck",
+			Handler:    _PermissionsService_BatchCheck_Handler,
+		},
+		{
+			MethodName: "ListPermissions",
+			Handler:    _PermissionsService_ListPermissions_Handler,
+		},
+		{
+			MethodName: "ListRoles",
+			Handler:    _PermissionsService_ListRoles_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "userperms/v1/permissions.proto",
+}
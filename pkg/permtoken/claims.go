@@ -0,0 +1,102 @@
+// Package permtoken issues and verifies signed permission tokens, short lived
+// JWTs holding a user's effective permissions, so that services can authorise
+// requests offline without calling the user permissions service.
+//
+// Tokens are signed with Ed25519 (JWS alg "EdDSA"). The public keys are
+// published as a JWKS, and a Verifier checks tokens against it.
+//
+// Keys are rotated by adding the new key to the KeySet after the current
+// signing key, so that it is published before it signs; promoting it to the
+// front once verifiers have refreshed their JWKS; and removing the old key once
+// the tokens it signed have expired.
+package permtoken
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// wildcard is a part of a permission, "resource:action", which matches any value.
+const wildcard = "*"
+
+// Claims are the contents of a permission token.
+type Claims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf"`
+	ExpiresAt int64  `json:"exp"`
+
+	TenantID string   `json:"tenantId"`
+	Roles    []string `json:"roles"`
+	// Permissions are the concrete permissions the user holds unconditionally.
+	Permissions []string `json:"permissions"`
+	// ConditionalPermissions are held only if their condition holds against the
+	// request's attributes, see package condition.
+	ConditionalPermissions []ConditionalPermission `json:"conditionalPermissions,omitempty"`
+	ResourceGrants         []ResourceGrant         `json:"resourceGrants,omitempty"`
+}
+
+type ConditionalPermission struct {
+	Permission string `json:"permission"`
+	Condition  string `json:"condition"`
+}
+
+// ResourceGrant is a permission held on a single resource.
+type ResourceGrant struct {
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceId"`
+	// Permission may have a wildcard part, e.g. "invoices:*".
+	Permission string `json:"permission"`
+	// Condition is set when the grant applies only if it holds.
+	Condition string `json:"condition,omitempty"`
+}
+
+// UserID is the user the token was issued for.
+func (c *Claims) UserID() string {
+	return c.Subject
+}
+
+// Expiry is when the token expires.
+func (c *Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// Allows reports whether the user holds the permission unconditionally.
+// Permissions are matched as the service matches them, case-insensitively.
+func (c *Claims) Allows(permission string) bool {
+	return slices.ContainsFunc(c.Permissions, func(p string) bool {
+		return matches(p, permission)
+	})
+}
+
+// AllowsOn reports whether the user holds the permission unconditionally, either
+// outright or on the single resource. A wildcard part of a resource grant
+// matches any value, and resource types are case-insensitive.
+func (c *Claims) AllowsOn(permission, resourceType, resourceID string) bool {
+	if c.Allows(permission) {
+		return true
+	}
+	return slices.ContainsFunc(c.ResourceGrants, func(rg ResourceGrant) bool {
+		return rg.Condition == "" && matches(rg.Permission, permission) && strings.EqualFold(rg.ResourceType, resourceType) && rg.ResourceID == resourceID
+	})
+}
+
+// matches reports whether the granted permission grants the named one.
+func matches(granted, name string) bool {
+	grantedResource, grantedAction, ok := strings.Cut(granted, ":")
+	if !ok {
+		return false
+	}
+	resource, action, ok := strings.Cut(name, ":")
+	if !ok {
+		return false
+	}
+	return matchPart(grantedResource, resource) && matchPart(grantedAction, action)
+}
+
+func matchPart(pattern, value string) bool {
+	return pattern == wildcard || strings.EqualFold(pattern, value)
+}
@@ -0,0 +1,76 @@
+package permtoken
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// JWKS is a JSON Web Key Set (RFC 7517) of Ed25519 public keys (RFC 8037).
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+func NewJWK(id string, public ed25519.PublicKey) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(public),
+		KeyID:     id,
+		Algorithm: algorithm,
+		Use:       "sig",
+	}
+}
+
+// PublicKey returns the JWK's key, which must be an Ed25519 signing key.
+func (k JWK) PublicKey() (ed25519.PublicKey, error) {
+	if k.KeyType != "OKP" || k.Curve != "Ed25519" {
+		return nil, fmt.Errorf("key %q is %s %s, not OKP Ed25519", k.KeyID, k.KeyType, k.Curve)
+	}
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key %q is not for signatures", k.KeyID)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", k.KeyID, err)
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("key %q must be %d bytes", k.KeyID, ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(x), nil
+}
+
+// FetchJWKS gets the JWKS published at the URL.
+func FetchJWKS(ctx context.Context, client *http.Client, url string) (JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return JWKS{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return JWKS{}, fmt.Errorf("fetch jwks: %s", resp.Status)
+	}
+	var jwks JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&jwks); err != nil {
+		return JWKS{}, fmt.Errorf("fetch jwks: %w", err)
+	}
+	return jwks, nil
+}
@@ -0,0 +1,139 @@
+package permtoken
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const algorithm = "EdDSA"
+
+var (
+	// ErrMalformed is a token which is not a JWT signed with EdDSA.
+	ErrMalformed = errors.New("malformed token")
+	// ErrUnknownKey is a token signed with a key not in the Verifier's JWKS.
+	// The JWKS should be refreshed, the key may be new.
+	ErrUnknownKey = errors.New("token signed with unknown key")
+	// ErrSignature is a token whose signature does not verify.
+	ErrSignature = errors.New("invalid token signature")
+	// ErrExpired is a token which has expired, or is not valid yet.
+	ErrExpired = errors.New("token expired or not yet valid")
+	// ErrIssuer is a token from another issuer.
+	ErrIssuer = errors.New("token from unexpected issuer")
+)
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Sign encodes the claims as a JWT signed with the key.
+func Sign(claims Claims, key Key) (string, error) {
+	h, err := json.Marshal(header{Algorithm: algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", fmt.Errorf("marshal header: %w", err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	sig := ed25519.Sign(key.PrivateKey, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verifier checks tokens against a JWKS.
+type Verifier struct {
+	mu     sync.RWMutex
+	keys   map[string]ed25519.PublicKey
+	issuer string
+	leeway time.Duration
+	now    func() time.Time
+}
+
+// NewVerifier returns a Verifier for tokens from the issuer, signed with a key in
+// the JWKS. Keys which are not Ed25519 signing keys are ignored. The leeway
+// allows for clock skew when checking the token's times.
+func NewVerifier(jwks JWKS, issuer string, leeway time.Duration) *Verifier {
+	v := &Verifier{issuer: issuer, leeway: leeway, now: time.Now}
+	v.SetJWKS(jwks)
+	return v
+}
+
+// SetJWKS replaces the Verifier's keys, such as after refreshing the JWKS to pick up a new key.
+func (v *Verifier) SetJWKS(jwks JWKS) {
+	keys := make(map[string]ed25519.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Algorithm != "" && k.Algorithm != algorithm {
+			continue
+		}
+		if public, err := k.PublicKey(); err == nil {
+			keys[k.KeyID] = public
+		}
+	}
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+}
+
+// Verify checks the token's signature, issuer and times, and returns its claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodePart(parts[0], &h); err != nil {
+		return nil, err
+	}
+	// Only EdDSA is accepted, whatever else the header claims.
+	if h.Algorithm != algorithm {
+		return nil, fmt.Errorf("%w: alg %q", ErrMalformed, h.Algorithm)
+	}
+
+	v.mu.RLock()
+	public, ok := v.keys[h.KeyID]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, h.KeyID)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !ed25519.Verify(public, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodePart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("%w: %q", ErrIssuer, claims.Issuer)
+	}
+	now := v.now()
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(v.leeway)) || now.Before(time.Unix(claims.NotBefore, 0).Add(-v.leeway)) {
+		return nil, ErrExpired
+	}
+	return &claims, nil
+}
+
+func decodePart(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package permtoken
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Key is an Ed25519 signing key and the ID it is published under.
+type Key struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+}
+
+// GenerateKey creates a new signing key with a random ID.
+func GenerateKey() (Key, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, fmt.Errorf("generate key: %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, fmt.Errorf("generate key ID: %w", err)
+	}
+	return Key{ID: hex.EncodeToString(id), PrivateKey: private}, nil
+}
+
+// PublicKey returns the key's public half.
+func (k Key) PublicKey() ed25519.PublicKey {
+	return k.PrivateKey.Public().(ed25519.PublicKey)
+}
+
+// keyJSON is a Key as stored, the private key is its base64url seed.
+type keyJSON struct {
+	ID   string `json:"kid"`
+	Seed string `json:"seed"`
+}
+
+func (k Key) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keyJSON{ID: k.ID, Seed: base64.RawURLEncoding.EncodeToString(k.PrivateKey.Seed())})
+}
+
+func (k *Key) UnmarshalJSON(b []byte) error {
+	var kj keyJSON
+	if err := json.Unmarshal(b, &kj); err != nil {
+		return err
+	}
+	seed, err := base64.RawURLEncoding.DecodeString(kj.Seed)
+	if err != nil {
+		return fmt.Errorf("key %q seed: %w", kj.ID, err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("key %q seed must be %d bytes", kj.ID, ed25519.SeedSize)
+	}
+	if kj.ID == "" {
+		return errors.New("key ID is required")
+	}
+	*k = Key{ID: kj.ID, PrivateKey: ed25519.NewKeyFromSeed(seed)}
+	return nil
+}
+
+// KeySet is the signing keys, the first signs new tokens and every key is published in the JWKS.
+// It is stored as a JSON array of {"kid": <ID>, "seed": <base64url Ed25519 seed>}.
+type KeySet []Key
+
+// ParseKeySet reads a KeySet from JSON.
+func ParseKeySet(b []byte) (KeySet, error) {
+	var ks KeySet
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return nil, fmt.Errorf("parse key set: %w", err)
+	}
+	if len(ks) == 0 {
+		return nil, errors.New("parse key set: no keys")
+	}
+	seen := make(map[string]bool)
+	for _, k := range ks {
+		if seen[k.ID] {
+			return nil, fmt.Errorf("parse key set: key %q repeated", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return ks, nil
+}
+
+// SigningKey is the key new tokens are signed with.
+func (ks KeySet) SigningKey() Key {
+	return ks[0]
+}
+
+// JWKS returns the public keys to publish.
+func (ks KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, len(ks))}
+	for i, k := range ks {
+		jwks.Keys[i] = NewJWK(k.ID, k.PublicKey())
+	}
+	return jwks
+}
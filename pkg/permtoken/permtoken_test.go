@@ -0,0 +1,159 @@
+package permtoken_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Equineregister/user-permissions-service/pkg/permtoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const issuer = "user-permissions-service"
+
+func testClaims(now time.Time) permtoken.Claims {
+	return permtoken.Claims{
+		Issuer:      issuer,
+		Subject:     "u1",
+		ID:          "1",
+		IssuedAt:    now.Unix(),
+		NotBefore:   now.Unix(),
+		ExpiresAt:   now.Add(5 * time.Minute).Unix(),
+		TenantID:    "test",
+		Roles:       []string{"clerk"},
+		Permissions: []string{"invoices:read"},
+		ResourceGrants: []permtoken.ResourceGrant{
+			{ResourceType: "customers", ResourceID: "c1", Permission: "customers:update"},
+		},
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	key, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	keys := permtoken.KeySet{key}
+
+	token, err := permtoken.Sign(testClaims(time.Now()), keys.SigningKey())
+	require.NoError(t, err)
+
+	claims, err := permtoken.NewVerifier(keys.JWKS(), issuer, time.Second).Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", claims.UserID())
+	assert.True(t, claims.Allows("invoices:read"))
+	assert.False(t, claims.Allows("invoices:delete"))
+	assert.True(t, claims.AllowsOn("customers:update", "customers", "c1"))
+	assert.False(t, claims.AllowsOn("customers:update", "customers", "c2"))
+}
+
+func TestClaims_AllowsMatchesAsTheService(t *testing.T) {
+	claims := permtoken.Claims{
+		Permissions: []string{"invoices:read"},
+		ResourceGrants: []permtoken.ResourceGrant{
+			{ResourceType: "invoices", ResourceID: "i1", Permission: "invoices:*"},
+			{ResourceType: "Customers", ResourceID: "c1", Permission: "*:update"},
+			{ResourceType: "invoices", ResourceID: "i2", Permission: "invoices:delete", Condition: "invoice.amount < 100"},
+		},
+	}
+
+	assert.True(t, claims.Allows("Invoices:READ"))
+	assert.False(t, claims.Allows("invoices"))
+	assert.True(t, claims.AllowsOn("invoices:approve", "invoices", "i1"))
+	assert.True(t, claims.AllowsOn("INVOICES:Approve", "Invoices", "i1"))
+	assert.False(t, claims.AllowsOn("invoices:approve", "invoices", "i2"))
+	assert.True(t, claims.AllowsOn("customers:update", "customers", "c1"))
+	assert.False(t, claims.AllowsOn("customers:delete", "customers", "c1"))
+	assert.False(t, claims.AllowsOn("invoices:delete", "invoices", "i2"), "a conditional grant is not held unconditionally")
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	key, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	other, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	verifier := permtoken.NewVerifier(permtoken.KeySet{key}.JWKS(), issuer, time.Second)
+
+	sign := func(c permtoken.Claims, k permtoken.Key) string {
+		token, err := permtoken.Sign(c, k)
+		require.NoError(t, err)
+		return token
+	}
+	valid := sign(testClaims(time.Now()), key)
+	parts := strings.Split(valid, ".")
+
+	wrongIssuer := testClaims(time.Now())
+	wrongIssuer.Issuer = "someone-else"
+
+	tamperedClaims := testClaims(time.Now())
+	tamperedClaims.Permissions = append(tamperedClaims.Permissions, "invoices:delete")
+	b, err := json.Marshal(tamperedClaims)
+	require.NoError(t, err)
+
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT","kid":"`+key.ID+`"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "expired", token: sign(testClaims(time.Now().Add(-time.Hour)), key), want: permtoken.ErrExpired},
+		{name: "not yet valid", token: sign(testClaims(time.Now().Add(time.Hour)), key), want: permtoken.ErrExpired},
+		{name: "unknown key", token: sign(testClaims(time.Now()), other), want: permtoken.ErrUnknownKey},
+		{name: "wrong issuer", token: sign(wrongIssuer, key), want: permtoken.ErrIssuer},
+		{name: "tampered", token: parts[0] + "." + base64.RawURLEncoding.EncodeToString(b) + "." + parts[2], want: permtoken.ErrSignature},
+		{name: "alg none", token: unsigned, want: permtoken.ErrMalformed},
+		{name: "not a JWT", token: "abc", want: permtoken.ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(tt.token)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	next, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+
+	// The next key is published after the signing key, then promoted.
+	published := permtoken.KeySet{old, next}
+	rotated := permtoken.KeySet{next, old}
+	verifier := permtoken.NewVerifier(published.JWKS(), issuer, 0)
+
+	before, err := permtoken.Sign(testClaims(time.Now()), published.SigningKey())
+	require.NoError(t, err)
+	after, err := permtoken.Sign(testClaims(time.Now()), rotated.SigningKey())
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(before)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(after)
+	assert.NoError(t, err)
+
+	// Once the old key is retired its tokens are rejected.
+	verifier.SetJWKS(permtoken.KeySet{next}.JWKS())
+	_, err = verifier.Verify(before)
+	assert.ErrorIs(t, err, permtoken.ErrUnknownKey)
+}
+
+func TestParseKeySet(t *testing.T) {
+	key, err := permtoken.GenerateKey()
+	require.NoError(t, err)
+	b, err := json.Marshal(permtoken.KeySet{key})
+	require.NoError(t, err)
+
+	keys, err := permtoken.ParseKeySet(b)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, keys.SigningKey().ID)
+	assert.Equal(t, key.PublicKey(), keys.SigningKey().PublicKey())
+
+	_, err = permtoken.ParseKeySet([]byte(`[]`))
+	assert.Error(t, err)
+	_, err = permtoken.ParseKeySet(append(append([]byte(`[`), b[1:len(b)-1]...), append([]byte(`,`), b[1:]...)...))
+	assert.ErrorContains(t, err, "repeated")
+}
@@ -25,3 +25,19 @@ func Connect(ctx context.Context, port int, dbHost, dbUser, dbPass, dbName strin
 
 	return conn, dbAddr, nil
 }
+
+// ConnectDSN connects to the database described by the DSN. Settings missing
+// from the DSN, such as the password, are taken from the standard PG* environment variables.
+func ConnectDSN(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	conn, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ping context: %w", err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,168 @@
+// Package rbacdoc is the file format of a Tenant's declarative RBAC model,
+// as written by "userperms export" and read by "userperms import" and "userperms plan",
+// and of the plans made by "userperms plan" for "userperms apply".
+package rbacdoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the only document version currently understood.
+const Version = 1
+
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// FormatFromPath returns the format of a file from its extension.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unknown format for %s, expected .yaml, .yml or .json", path)
+	}
+}
+
+type Document struct {
+	Version       int            `json:"version" yaml:"version"`
+	ResourceTypes []ResourceType `json:"resourceTypes" yaml:"resourceTypes"`
+	Permissions   []Permission   `json:"permissions" yaml:"permissions"`
+	Roles         []Role         `json:"roles" yaml:"roles"`
+	// Assignments are only managed by plan and apply, and only when present.
+	Assignments []Assignment `json:"assignments,omitempty" yaml:"assignments,omitempty"`
+}
+
+type ResourceType struct {
+	ID   int64  `json:"id,omitempty" yaml:"id,omitempty"`
+	Name string `json:"name" yaml:"name"`
+}
+
+type Permission struct {
+	Name string `json:"name" yaml:"name"`
+	// Disabled permissions exist but are not active for the Tenant.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+type Role struct {
+	Name        string   `json:"name" yaml:"name"`
+	Permissions []string `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	// Conditions are those of the role's conditional grants, keyed by permission name.
+	Conditions map[string]string `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Inherits   []string          `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+}
+
+type Assignment struct {
+	UserID string   `json:"userId" yaml:"userId"`
+	Roles  []string `json:"roles" yaml:"roles"`
+}
+
+// Decode reads a document, rejecting unknown fields and versions.
+func Decode(r io.Reader, format Format) (*Document, error) {
+	var doc Document
+	switch format {
+	case FormatYAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+	case FormatJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	if doc.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", doc.Version, Version)
+	}
+	return &doc, nil
+}
+
+// Encode writes the document in the given format.
+func Encode(w io.Writer, format Format, doc *Document) error {
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// FromModel creates a Document from a model.
+func FromModel(m *rbac.Model) *Document {
+	doc := &Document{
+		Version:       Version,
+		ResourceTypes: make([]ResourceType, 0, len(m.ResourceTypes)),
+		Permissions:   make([]Permission, 0, len(m.Permissions)),
+		Roles:         make([]Role, 0, len(m.Roles)),
+	}
+	for _, rt := range m.ResourceTypes {
+		doc.ResourceTypes = append(doc.ResourceTypes, ResourceType{ID: rt.ID, Name: rt.Name})
+	}
+	for _, p := range m.Permissions {
+		doc.Permissions = append(doc.Permissions, Permission{Name: p.Name, Disabled: !p.Enabled})
+	}
+	for _, r := range m.Roles {
+		doc.Roles = append(doc.Roles, Role{Name: r.Name, Permissions: r.Permissions, Conditions: r.Conditions, Inherits: r.Inherits})
+	}
+	for _, a := range m.Assignments {
+		doc.Assignments = append(doc.Assignments, Assignment{UserID: a.UserID, Roles: a.Roles})
+	}
+	return doc
+}
+
+// Model returns the model the document describes.
+func (d *Document) Model() *rbac.Model {
+	m := &rbac.Model{}
+	for _, rt := range d.ResourceTypes {
+		m.ResourceTypes = append(m.ResourceTypes, rbac.ResourceType{ID: rt.ID, Name: rt.Name})
+	}
+	for _, p := range d.Permissions {
+		m.Permissions = append(m.Permissions, rbac.Permission{Name: p.Name, Enabled: !p.Disabled})
+	}
+	for _, r := range d.Roles {
+		m.Roles = append(m.Roles, rbac.Role{Name: r.Name, Permissions: r.Permissions, Conditions: r.Conditions, Inherits: r.Inherits})
+	}
+	if d.Assignments != nil {
+		m.Assignments = make([]rbac.Assignment, 0, len(d.Assignments))
+		for _, a := range d.Assignments {
+			m.Assignments = append(m.Assignments, rbac.Assignment{UserID: a.UserID, Roles: a.Roles})
+		}
+	}
+	return m
+}
@@ -0,0 +1,89 @@
+package rbacdoc_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+	"github.com/Equineregister/user-permissions-service/pkg/rbacdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	model := &rbac.Model{
+		ResourceTypes: []rbac.ResourceType{{ID: 1, Name: "invoices"}},
+		Permissions: []rbac.Permission{
+			{Name: "invoices:delete", Enabled: false},
+			{Name: "invoices:read", Enabled: true},
+		},
+		Roles: []rbac.Role{
+			{Name: "clerk", Permissions: []string{"invoices:read"}},
+			{
+				Name:        "manager",
+				Permissions: []string{"invoices:delete"},
+				Conditions:  map[string]string{"invoices:delete": "invoice.amount < 10000"},
+				Inherits:    []string{"clerk"},
+			},
+		},
+	}
+
+	for _, format := range []rbacdoc.Format{rbacdoc.FormatYAML, rbacdoc.FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, rbacdoc.Encode(&buf, format, rbacdoc.FromModel(model)))
+
+			doc, err := rbacdoc.Decode(&buf, format)
+			require.NoError(t, err)
+			assert.Equal(t, model, doc.Model())
+		})
+	}
+}
+
+func TestDecode_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  rbacdoc.Format
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "unknown yaml field",
+			format:  rbacdoc.FormatYAML,
+			input:   "version: 1\nroles:\n  - name: clerk\n    permision: [invoices:read]\n",
+			wantErr: "permision",
+		},
+		{
+			name:    "unknown json field",
+			format:  rbacdoc.FormatJSON,
+			input:   `{"version": 1, "role": []}`,
+			wantErr: "role",
+		},
+		{
+			name:    "missing version",
+			format:  rbacdoc.FormatYAML,
+			input:   "roles: []\n",
+			wantErr: "unsupported version 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rbacdoc.Decode(strings.NewReader(tt.input), tt.format)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	f, err := rbacdoc.FormatFromPath("tenant.YML")
+	require.NoError(t, err)
+	assert.Equal(t, rbacdoc.FormatYAML, f)
+
+	f, err = rbacdoc.FormatFromPath("tenant.json")
+	require.NoError(t, err)
+	assert.Equal(t, rbacdoc.FormatJSON, f)
+
+	_, err = rbacdoc.FormatFromPath("tenant.toml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package rbacdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/rbac"
+)
+
+// Plan is a saved rbac.Plan, always JSON.
+type Plan struct {
+	Version     int      `json:"version"`
+	Fingerprint string   `json:"fingerprint"`
+	Changes     []Change `json:"changes"`
+	Impacts     []Impact `json:"impacts"`
+}
+
+type Change struct {
+	Action         string `json:"action"`
+	Kind           string `json:"kind"`
+	Subject        string `json:"subject"`
+	Object         string `json:"object,omitempty"`
+	Enabled        bool   `json:"enabled,omitempty"`
+	Condition      string `json:"condition,omitempty"`
+	ResourceTypeID int64  `json:"resourceTypeId,omitempty"`
+}
+
+type Impact struct {
+	Permission string `json:"permission"`
+	Gained     int    `json:"gained"`
+	Lost       int    `json:"lost"`
+}
+
+// EncodePlan writes the plan as JSON.
+func EncodePlan(w io.Writer, plan *rbac.Plan) error {
+	p := Plan{
+		Version:     Version,
+		Fingerprint: plan.Fingerprint,
+		Changes:     make([]Change, 0, len(plan.Changes)),
+		Impacts:     make([]Impact, 0, len(plan.Impacts)),
+	}
+	for _, c := range plan.Changes {
+		p.Changes = append(p.Changes, Change{
+			Action:         string(c.Action),
+			Kind:           string(c.Kind),
+			Subject:        c.Subject,
+			Object:         c.Object,
+			Enabled:        c.Enabled,
+			Condition:      c.Condition,
+			ResourceTypeID: c.ResourceTypeID,
+		})
+	}
+	for _, i := range plan.Impacts {
+		p.Impacts = append(p.Impacts, Impact(i))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("encode plan: %w", err)
+	}
+	return nil
+}
+
+// DecodePlan reads a plan written by EncodePlan.
+func DecodePlan(r io.Reader) (*rbac.Plan, error) {
+	var p Plan
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("decode plan: %w", err)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", p.Version, Version)
+	}
+
+	plan := &rbac.Plan{Fingerprint: p.Fingerprint}
+	for _, c := range p.Changes {
+		plan.Changes = append(plan.Changes, rbac.Change{
+			Action:         rbac.Action(c.Action),
+			Kind:           rbac.ChangeKind(c.Kind),
+			Subject:        c.Subject,
+			Object:         c.Object,
+			Enabled:        c.Enabled,
+			Condition:      c.Condition,
+			ResourceTypeID: c.ResourceTypeID,
+		})
+	}
+	for _, i := range p.Impacts {
+		plan.Impacts = append(plan.Impacts, rbac.Impact(i))
+	}
+	return plan, nil
+}
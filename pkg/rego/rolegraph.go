@@ -6,7 +6,13 @@ import (
 
 type Role struct {
 	Permissions []string `json:"permissions"`
-	Inherits    []string `json:"inherits"`
+	// Wildcards are grants such as "invoices:*" or "*:read", kept apart from
+	// the concrete permissions so policies can match them with glob.match.
+	Wildcards []string `json:"wildcards,omitempty"`
+	// Conditions maps each conditional grant, which is in neither Permissions nor
+	// Wildcards, to the condition which must hold for it to apply.
+	Conditions map[string]string `json:"conditions,omitempty"`
+	Inherits   []string          `json:"inherits"`
 }
 
 type RoleGraph map[string]Role
@@ -16,10 +22,27 @@ func NewRoleGraph(tenantRoleMap permissions.TenantRoleMap) RoleGraph {
 	rg := make(RoleGraph)
 
 	for role, mappedRole := range tenantRoleMap {
-		rg[role.Name] = Role{
-			Permissions: mappedRole.Permissions.StringSlice(),
+		unconditional := make(permissions.TenantPermissions, 0, len(mappedRole.Permissions))
+		conditions := make(map[string]string)
+		for _, tp := range mappedRole.Permissions {
+			if tp.Condition != "" {
+				conditions[tp.Name] = tp.Condition
+				continue
+			}
+			unconditional = append(unconditional, tp)
+		}
+		concrete, wildcards := unconditional.SplitWildcards()
+		r := Role{
+			Permissions: concrete.StringSlice(),
 			Inherits:    mappedRole.Inherits.StringSlice(),
 		}
+		if len(wildcards) > 0 {
+			r.Wildcards = wildcards.StringSlice()
+		}
+		if len(conditions) > 0 {
+			r.Conditions = conditions
+		}
+		rg[role.Name] = r
 	}
 
 	return rg
@@ -0,0 +1,79 @@
+// Package roletemplates is the file format of the role template catalogue,
+// one YAML file per template, and the standard catalogue embedded in
+// "userperms", which "userperms template -catalogue" replaces with a directory.
+package roletemplates
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/Equineregister/user-permissions-service/internal/app/templates"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the only template version currently understood.
+const Version = 1
+
+//go:embed catalogue/*.yaml
+var standard embed.FS
+
+type Template struct {
+	Version     int      `yaml:"version"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Permissions []string `yaml:"permissions"`
+}
+
+// Decode reads a template, rejecting unknown fields and versions.
+func Decode(r io.Reader) (*Template, error) {
+	var t Template
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("decode yaml: %w", err)
+	}
+	if t.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", t.Version, Version)
+	}
+	return &t, nil
+}
+
+// Load reads the catalogue of the .yaml files at the root of fsys, in name order.
+func Load(fsys fs.FS) (templates.Catalogue, error) {
+	names, err := fs.Glob(fsys, "*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("load catalogue: %w", err)
+	}
+	catalogue := make(templates.Catalogue, 0, len(names))
+	for _, name := range names {
+		t, err := readTemplate(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("load catalogue: %s: %w", name, err)
+		}
+		catalogue = append(catalogue, templates.Template{Name: t.Name, Description: t.Description, Permissions: t.Permissions})
+	}
+	if err := catalogue.Validate(); err != nil {
+		return nil, fmt.Errorf("load catalogue: %w", err)
+	}
+	return catalogue, nil
+}
+
+// Standard returns the catalogue embedded in the binary.
+func Standard() (templates.Catalogue, error) {
+	sub, err := fs.Sub(standard, "catalogue")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+func readTemplate(fsys fs.FS, name string) (*Template, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
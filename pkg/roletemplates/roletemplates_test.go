@@ -0,0 +1,60 @@
+package roletemplates_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Equineregister/user-permissions-service/pkg/roletemplates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandard(t *testing.T) {
+	catalogue, err := roletemplates.Standard()
+	require.NoError(t, err)
+
+	admin, ok := catalogue.Get("administrator")
+	require.True(t, ok)
+	assert.Equal(t, []string{"*:*"}, admin.Permissions)
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"clerk.yaml":  {Data: []byte("version: 1\nname: clerk\npermissions: [invoices:read, invoices:create]\n")},
+		"README.md":   {Data: []byte("not a template")},
+		"viewer.yaml": {Data: []byte("version: 1\nname: viewer\ndescription: Reads.\npermissions: [\"*:read\"]\n")},
+	}
+
+	catalogue, err := roletemplates.Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, catalogue, 2)
+	assert.Equal(t, "clerk", catalogue[0].Name)
+	assert.Equal(t, "Reads.", catalogue[1].Description)
+}
+
+func TestLoad_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "unknown field", data: "version: 1\nname: clerk\nroles: [a]\npermissions: [invoices:read]\n", want: "field roles not found"},
+		{name: "unknown version", data: "version: 2\nname: clerk\npermissions: [invoices:read]\n", want: "unsupported version 2"},
+		{name: "invalid permission", data: "version: 1\nname: clerk\npermissions: [invoices]\n", want: "not of the form resource:action"},
+		{name: "no permissions", data: "version: 1\nname: clerk\n", want: "grants no permissions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := roletemplates.Load(fstest.MapFS{"clerk.yaml": {Data: []byte(tt.data)}})
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), tt.want), err.Error())
+		})
+	}
+}
+
+func TestLoad_RejectsRepeatedName(t *testing.T) {
+	data := []byte("version: 1\nname: clerk\npermissions: [invoices:read]\n")
+	_, err := roletemplates.Load(fstest.MapFS{"a.yaml": {Data: data}, "b.yaml": {Data: data}})
+	assert.ErrorContains(t, err, `template "clerk" is repeated`)
+}